@@ -15,6 +15,22 @@ type Config struct {
 	DbName     string `yaml:"db-name"`
 	DbUser     string `yaml:"db-user"`
 	DbPassword string `yaml:"db-password"`
+
+	AuthSecret     string `yaml:"auth-secret"`      // hmac key for access tokens
+	AuthAccessTTL  string `yaml:"auth-access-ttl"`  // e.g. 15m
+	AuthRefreshTTL string `yaml:"auth-refresh-ttl"` // e.g. 720h
+
+	TelegramBotToken      string `yaml:"telegram-bot-token"`      // signs telegram login data
+	TelegramAuthMaxAge    string `yaml:"telegram-auth-max-age"`   // e.g. 24h
+	TelegramAutoProvision bool   `yaml:"telegram-auto-provision"` // create account for unknown tg_id
+
+	TrashRetention string  `yaml:"trash-retention"` // deleted records kept before purge, e.g. 720h
+	AdminAccounts  []int64 `yaml:"admin-accounts"`  // ids of accounts allowed to purge trash
+
+	SchedulerInterval string `yaml:"scheduler-interval"` // how often jobs run, e.g. 1m, 0s disables
+	SchedulerState    string `yaml:"scheduler-state"`    // file keeping last runs of jobs, empty keeps them in memory
+
+	TrustedProxies []string `yaml:"trusted-proxies"` // ips or cidrs whose X-Forwarded-For is believed
 }
 
 var AppConf Config
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+)
+
+type contextKey struct{}
+
+// put authenticated account into request context
+func WithAccount(ctx context.Context, account *models.Account) context.Context {
+	return context.WithValue(ctx, contextKey{}, account)
+}
+
+// authenticated account from request context
+func AccountFromContext(ctx context.Context) (*models.Account, bool) {
+	account, ok := ctx.Value(contextKey{}).(*models.Account)
+	return account, ok && account != nil
+}
+
+type Claims struct {
+	Sub int64  `json:"sub"` // account id
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+	Typ string `json:"typ"` // access
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// sign claims as HS256 JWT
+func SignToken(secret []byte, claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + sign(secret, unsigned), nil
+}
+
+// verify HS256 JWT signature and expiration
+func ParseToken(secret []byte, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed token header")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil || header.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm")
+	}
+
+	expected := sign(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+
+	if now.Unix() >= claims.Exp {
+		return nil, errors.New("token expired")
+	}
+
+	return claims, nil
+}
+
+// random secret with readable prefix, e.g. af_3f9c...
+func GenerateSecret(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+// secrets are stored only as sha256
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func sign(secret []byte, data string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegram user confirmed by a valid signature
+type TelegramUser struct {
+	Id        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+}
+
+func (t *TelegramUser) DisplayName() string {
+	if t.Username != "" {
+		return t.Username
+	}
+	return strings.TrimSpace(t.FirstName + " " + t.LastName)
+}
+
+// Login Widget data: secret key is sha256(bot token)
+func VerifyTelegramLogin(botToken string, fields map[string]string, now time.Time, maxAge time.Duration) (*TelegramUser, error) {
+	secret := sha256.Sum256([]byte(botToken))
+	if err := verifyTelegramFields(secret[:], fields, now, maxAge); err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(fields["id"], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid telegram id")
+	}
+
+	return &TelegramUser{
+		Id:        id,
+		FirstName: fields["first_name"],
+		LastName:  fields["last_name"],
+		Username:  fields["username"],
+	}, nil
+}
+
+// WebApp initData query string: secret key is hmac("WebAppData", bot token)
+func VerifyTelegramWebApp(botToken string, initData string, now time.Time, maxAge time.Duration) (*TelegramUser, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, errors.New("malformed init data")
+	}
+
+	fields := make(map[string]string, len(values))
+	for key := range values {
+		fields[key] = values.Get(key)
+	}
+
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(botToken))
+	if err := verifyTelegramFields(mac.Sum(nil), fields, now, maxAge); err != nil {
+		return nil, err
+	}
+
+	user := &TelegramUser{}
+	if err := json.Unmarshal([]byte(fields["user"]), user); err != nil || user.Id == 0 {
+		return nil, errors.New("invalid telegram user")
+	}
+	return user, nil
+}
+
+// data check string is "key=value" of all fields but hash, sorted and joined by \n
+func TelegramDataCheckString(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+fields[key])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hex hmac of data check string, used to verify and to sign test data
+func TelegramHash(secret []byte, fields map[string]string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(TelegramDataCheckString(fields)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyTelegramFields(secret []byte, fields map[string]string, now time.Time, maxAge time.Duration) error {
+	if fields["hash"] == "" {
+		return errors.New("telegram hash is missing")
+	}
+
+	if !hmac.Equal([]byte(TelegramHash(secret, fields)), []byte(strings.ToLower(fields["hash"]))) {
+		return errors.New("invalid telegram signature")
+	}
+
+	authDate, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return errors.New("invalid auth_date")
+	}
+	if maxAge > 0 && now.Sub(time.Unix(authDate, 0)) > maxAge {
+		return errors.New("telegram login data expired")
+	}
+
+	return nil
+}
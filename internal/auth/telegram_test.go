@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:test-bot-token"
+
+var testNow = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// login widget fields signed like telegram does
+func signedLogin(fields map[string]string) map[string]string {
+	secret := sha256.Sum256([]byte(testBotToken))
+	fields["hash"] = TelegramHash(secret[:], fields)
+	return fields
+}
+
+// webapp init data query string signed like telegram does
+func signedWebApp(fields map[string]string) string {
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(testBotToken))
+	fields["hash"] = TelegramHash(mac.Sum(nil), fields)
+
+	values := url.Values{}
+	for key, value := range fields {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+func authDate(age time.Duration) string {
+	return strconv.FormatInt(testNow.Add(-age).Unix(), 10)
+}
+
+func TestVerifyTelegramLogin(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  func() map[string]string
+		wantId  int64
+		wantErr string
+	}{
+		{
+			name: "valid",
+			fields: func() map[string]string {
+				return signedLogin(map[string]string{"id": "42", "first_name": "Ivan", "username": "ivan", "auth_date": authDate(time.Hour)})
+			},
+			wantId: 42,
+		},
+		{
+			name: "tampered field",
+			fields: func() map[string]string {
+				fields := signedLogin(map[string]string{"id": "42", "first_name": "Ivan", "auth_date": authDate(time.Hour)})
+				fields["id"] = "43"
+				return fields
+			},
+			wantErr: "invalid telegram signature",
+		},
+		{
+			name: "expired auth_date",
+			fields: func() map[string]string {
+				return signedLogin(map[string]string{"id": "42", "auth_date": authDate(25 * time.Hour)})
+			},
+			wantErr: "telegram login data expired",
+		},
+		{
+			name: "missing hash",
+			fields: func() map[string]string {
+				return map[string]string{"id": "42", "auth_date": authDate(time.Hour)}
+			},
+			wantErr: "telegram hash is missing",
+		},
+		{
+			name: "signed with another token",
+			fields: func() map[string]string {
+				fields := map[string]string{"id": "42", "auth_date": authDate(time.Hour)}
+				secret := sha256.Sum256([]byte("654321:other-token"))
+				fields["hash"] = TelegramHash(secret[:], fields)
+				return fields
+			},
+			wantErr: "invalid telegram signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := VerifyTelegramLogin(testBotToken, tt.fields(), testNow, 24*time.Hour)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user.Id != tt.wantId {
+				t.Errorf("id = %d, want %d", user.Id, tt.wantId)
+			}
+		})
+	}
+}
+
+func TestVerifyTelegramWebApp(t *testing.T) {
+	const user = `{"id":42,"first_name":"Ivan","username":"ivan"}`
+
+	tests := []struct {
+		name     string
+		initData func() string
+		wantId   int64
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			initData: func() string {
+				return signedWebApp(map[string]string{"user": user, "query_id": "AAE", "auth_date": authDate(time.Hour)})
+			},
+			wantId: 42,
+		},
+		{
+			name: "tampered field",
+			initData: func() string {
+				values, _ := url.ParseQuery(signedWebApp(map[string]string{"user": user, "auth_date": authDate(time.Hour)}))
+				values.Set("user", `{"id":43,"first_name":"Ivan"}`)
+				return values.Encode()
+			},
+			wantErr: "invalid telegram signature",
+		},
+		{
+			name: "expired auth_date",
+			initData: func() string {
+				return signedWebApp(map[string]string{"user": user, "auth_date": authDate(48 * time.Hour)})
+			},
+			wantErr: "telegram login data expired",
+		},
+		{
+			name: "missing hash",
+			initData: func() string {
+				return url.Values{"user": {user}, "auth_date": {authDate(time.Hour)}}.Encode()
+			},
+			wantErr: "telegram hash is missing",
+		},
+		{
+			name: "login widget secret",
+			initData: func() string {
+				fields := signedLogin(map[string]string{"user": user, "auth_date": authDate(time.Hour)})
+				values := url.Values{}
+				for key, value := range fields {
+					values.Set(key, value)
+				}
+				return values.Encode()
+			},
+			wantErr: "invalid telegram signature",
+		},
+		{
+			name: "user without id",
+			initData: func() string {
+				return signedWebApp(map[string]string{"user": `{"first_name":"Ivan"}`, "auth_date": authDate(time.Hour)})
+			},
+			wantErr: "invalid telegram user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := VerifyTelegramWebApp(testBotToken, tt.initData(), testNow, 24*time.Hour)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user.Id != tt.wantId {
+				t.Errorf("id = %d, want %d", user.Id, tt.wantId)
+			}
+		})
+	}
+}
@@ -1,29 +1,60 @@
 package debugging
 
 import (
+	"sync"
 	"time"
 
 	"github.com/helltale/api-finances/internal/models"
 )
 
 var (
-	Incomes         []*models.Income
-	IncomesExpected []*models.IncomeExpected
-	Accounts        []*models.Account
-	Expences        []*models.Expence
-	Remains         []*models.Remain
-	Goals           []*models.Goal
-	Cashbacks       []*models.Cashback
+	Incomes           []*models.Income
+	IncomesExpected   []*models.IncomeExpected
+	Accounts          []*models.Account
+	Expences          []*models.Expence
+	Remains           []*models.Remain
+	Goals             []*models.Goal
+	GoalContributions []*models.GoalContribution
+	Cashbacks         []*models.Cashback
+	CashbackCredits   []*models.CashbackCredit
+	Transfers         []*models.Transfer
+	LedgerAccounts    []*models.LedgerAccount
+	JournalEntries    []*models.JournalEntry
+	Groups            []*models.Group
+	GroupMembers      []*models.GroupMember
+	GroupInvites      []*models.GroupInvite
+	ApiKeys           []*models.ApiKey
+	RefreshTokens     []*models.RefreshToken
+	AuditRecords      []*models.AuditRecord
+	JobRuns           []*models.JobRun
+	Budgets           []*models.Budget
+	Categories        []*models.Category
+	BudgetEvents      []*models.BudgetEvent
+	ImportProfiles    []*models.ImportProfile
+	Duplicates        []*models.Duplicate
+	Rules             []*models.Rule
+	Classifiers       []*models.Classifier
+	Tags              []*models.Tag
 )
 
+// guards every slice of the store. Requests and scheduler jobs hold it for
+// their whole run, so they never see each other half done
+var Mu sync.Mutex
+
 func Init() {
+	category()
 	income()
 	incomeExpected()
 	account()
+	group()
 	expence()
 	remain()
 	goal()
 	cashback()
+	budget()
+	importProfile()
+	rule()
+	tag()
 }
 
 func remain() {
@@ -120,9 +151,32 @@ func account() {
 	Accounts = []*models.Account{account1, account2}
 }
 
+func group() {
+	group1 := &models.Group{}
+	group1.SetIdGroup(1)
+	group1.SetName("family")
+
+	Groups = []*models.Group{group1}
+
+	GroupMembers = nil
+	GroupInvites = nil
+	for i, account := range Accounts {
+		member := &models.GroupMember{}
+		member.SetIdGroup(account.GetGroupId())
+		member.SetIdAccaunt(account.GetIdAccaunt())
+		if i == 0 {
+			member.SetRole(models.RoleOwner)
+		} else {
+			member.SetRole(models.RoleMember)
+		}
+		GroupMembers = append(GroupMembers, member)
+	}
+}
+
 func expence() {
 	expence1 := &models.Expence{}
 	expence1.SetIdExpence(1)
+	expence1.SetIdAccaunt(1)
 	expence1.SetGroupExpence("Utilities")
 	expence1.SetTitleExpence("Electricity Bill")
 	expence1.SetDescriptionExpence("Monthly electricity bill payment")
@@ -135,17 +189,33 @@ func expence() {
 
 	expence2 := &models.Expence{}
 	expence2.SetIdExpence(2)
+	expence2.SetIdAccaunt(2)
 	expence2.SetGroupExpence("Groceries")
 	expence2.SetTitleExpence("Weekly Groceries")
 	expence2.SetDescriptionExpence("Weekly grocery shopping")
 	expence2.SetRepeat(0) // 0 - единоразовая
+	expence2.SetBankName("Bank B")
 	expence2.SetAmount(50.0)
 	expence2.SetDate(time.Now())
 	expence2.SetUpdBy("admin")
 	expence2.SetDateActualFrom(time.Now())
 	expence2.SetDateActualTo(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))
 
-	Expences = []*models.Expence{expence1, expence2}
+	expence3 := &models.Expence{}
+	expence3.SetIdExpence(3)
+	expence3.SetIdAccaunt(1)
+	expence3.SetBankName("Bank C")
+	expence3.SetGroupExpence("Dining")
+	expence3.SetTitleExpence("Dinner")
+	expence3.SetDescriptionExpence("Dinner at a cafe")
+	expence3.SetRepeat(0)
+	expence3.SetAmount(80.0)
+	expence3.SetDate(time.Now())
+	expence3.SetUpdBy("admin")
+	expence3.SetDateActualFrom(time.Now())
+	expence3.SetDateActualTo(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))
+
+	Expences = []*models.Expence{expence1, expence2, expence3}
 }
 
 func goal() {
@@ -168,6 +238,27 @@ func goal() {
 	goal2.SetDateActualTo(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))
 
 	Goals = []*models.Goal{goal1, goal2}
+
+	contribution1 := &models.GoalContribution{}
+	contribution1.SetIdContribution(1)
+	contribution1.SetIdGoal(1)
+	contribution1.SetIdAccaunt(1)
+	contribution1.SetKind(models.GoalContributionExplicit)
+	contribution1.SetAmount(200.0)
+	contribution1.SetDate(time.Now())
+	contribution1.SetUpdBy("admin")
+
+	contribution2 := &models.GoalContribution{}
+	contribution2.SetIdContribution(2)
+	contribution2.SetIdGoal(2)
+	contribution2.SetIdAccaunt(2)
+	contribution2.SetKind(models.GoalContributionEarmark)
+	contribution2.SetIdRemains(2)
+	contribution2.SetShare(0.2) // пятая часть остатка
+	contribution2.SetDate(time.Now())
+	contribution2.SetUpdBy("admin")
+
+	GoalContributions = []*models.GoalContribution{contribution1, contribution2}
 }
 
 func cashback() {
@@ -191,5 +282,114 @@ func cashback() {
 	cashback2.SetDateActualFrom(time.Now())
 	cashback2.SetDateActualTo(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))
 
-	Cashbacks = []*models.Cashback{cashback1, cashback2}
+	cashback3 := &models.Cashback{}
+	cashback3.SetIdCashback(3)
+	cashback3.SetIdAccaunt(1)
+	cashback3.SetBankName("Bank C")
+	cashback3.SetCategory("Food") // вся еда, с лимитом в месяц
+	cashback3.SetPercent(7)
+	cashback3.SetMonthlyCap(5)
+	cashback3.SetUpdBy("admin")
+	cashback3.SetDateActualFrom(time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.Local))
+	cashback3.SetDateActualTo(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))
+
+	Cashbacks = []*models.Cashback{cashback1, cashback2, cashback3}
+	CashbackCredits = nil
+}
+
+func budget() {
+	budget1 := &models.Budget{}
+	budget1.SetIdBudget(1)
+	budget1.SetIdAccaunt(1)
+	budget1.SetGroupExpence("Utilities")
+	budget1.SetAmount(120.0)
+	budget1.SetPeriod(models.BudgetPeriodMonthly)
+	budget1.SetRollover(true)
+	budget1.SetThresholds([]int{80, 100})
+	budget1.SetDateStart(time.Now().AddDate(0, -2, 0)) // остаток переносится за 2 месяца
+	budget1.SetUpdBy("admin")
+
+	budget2 := &models.Budget{}
+	budget2.SetIdBudget(2)
+	budget2.SetIdAccaunt(1)
+	budget2.SetIdGroup(1) // на всю семью
+	budget2.SetGroupExpence("Groceries")
+	budget2.SetAmount(60.0)
+	budget2.SetPeriod(models.BudgetPeriodWeekly)
+	budget2.SetThresholds([]int{80, 100})
+	budget2.SetDateStart(time.Now())
+	budget2.SetUpdBy("admin")
+
+	Budgets = []*models.Budget{budget1, budget2}
+	BudgetEvents = nil
+}
+
+func category() {
+	newCategory := func(id, idParent int64, code string, names map[string]string, aliases ...string) *models.Category {
+		category := &models.Category{}
+		category.SetIdCategory(id)
+		category.SetIdParent(idParent)
+		category.SetCode(code)
+		category.SetNames(names)
+		category.SetAliases(aliases)
+		category.SetUpdBy("admin")
+		return category
+	}
+
+	Categories = []*models.Category{
+		newCategory(1, 0, "food", map[string]string{"en": "Food", "ru": "Еда"}),
+		newCategory(2, 1, "groceries", map[string]string{"en": "Groceries", "ru": "Продукты"}, "supermarket"),
+		newCategory(3, 1, "dining", map[string]string{"en": "Dining", "ru": "Рестораны"}, "restaurants", "cafe"),
+		newCategory(4, 0, "utilities", map[string]string{"en": "Utilities", "ru": "Коммунальные услуги"}, "коммуналка"),
+		newCategory(5, 0, "transport", map[string]string{"en": "Transport", "ru": "Транспорт"}, "taxi"),
+	}
+}
+
+func importProfile() {
+	profile1 := &models.ImportProfile{}
+	profile1.SetIdProfile(1)
+	profile1.SetIdAccaunt(1)
+	profile1.SetName("Bank A CSV")
+	profile1.SetBankName("Bank A")
+	profile1.SetDelimiter(";")
+	profile1.SetHasHeader(true)
+	profile1.SetColumns(map[string]string{
+		models.ImportFieldDate:        "Дата операции",
+		models.ImportFieldAmount:      "Сумма операции",
+		models.ImportFieldDescription: "Описание",
+		models.ImportFieldCategory:    "Категория",
+	})
+	profile1.SetDateFormat("02.01.2006")
+	profile1.SetDecimalSeparator(",")
+	profile1.SetSignConvention(models.ImportSignNegativeExpence)
+	profile1.SetEncoding(models.ImportEncodingCP1251) // выгрузка в windows-1251
+	profile1.SetUpdBy("admin")
+
+	ImportProfiles = []*models.ImportProfile{profile1}
+}
+
+func rule() {
+	rule1 := &models.Rule{}
+	rule1.SetIdRule(1)
+	rule1.SetIdAccaunt(1)
+	rule1.SetName("Taxi rides")
+	rule1.SetPriority(10)
+	rule1.SetTitleRegex(`taxi|uber|яндекс\s*го`)
+	rule1.SetIdCategory(5)
+	rule1.SetGroupExpence("Transport")
+	rule1.SetTags([]string{"commute"})
+	rule1.SetUpdBy("admin")
+
+	Rules = []*models.Rule{rule1}
+}
+
+func tag() {
+	tag1 := &models.Tag{}
+	tag1.SetIdTag(1)
+	tag1.SetIdAccaunt(1)
+	tag1.SetName("commute")
+	tag1.SetDescription("дорога на работу и обратно")
+	tag1.SetUpdBy("admin")
+
+	Tags = []*models.Tag{tag1}
 }
@@ -2,12 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/helltale/api-finances/config"
-	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
 	"github.com/helltale/api-finances/internal/services"
@@ -26,12 +26,7 @@ func AccountGetAll(w http.ResponseWriter, r *http.Request, logger *logger.Combin
 
 	w.Header().Set("Content-Type", "application/json")
 
-	var accounts []*models.Account
-	if config.AppMode == "debug" {
-		accounts = debugging.Accounts
-	} else {
-		accounts = []*models.Account{}
-	}
+	accounts := services.NewAccountService().WithScope(requestScope(r)).GetAllAccounts()
 
 	var accountsJSON []models.AccountJSON
 	for _, account := range accounts {
@@ -75,17 +70,8 @@ func AccountGetByIdAccount(w http.ResponseWriter, r *http.Request, logger *logge
 		return
 	}
 
-	var foundAccount *models.Account
-	if config.AppMode == "debug" {
-		for _, account := range debugging.Accounts {
-			if account.GetIdAccaunt() == idAccaunt {
-				foundAccount = account
-				break
-			}
-		}
-	}
-
-	if foundAccount == nil {
+	foundAccount, err := services.NewAccountService().WithScope(requestScope(r)).GetAccountById(idAccaunt)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Account not found"), http.StatusNotFound)
 		return
 	}
@@ -125,26 +111,48 @@ func AccountPost(w http.ResponseWriter, r *http.Request, logger *logger.Combined
 		return
 	}
 
+	// tg_id is bound only by verified telegram login at /auth/telegram
+	if newAccountJSON.TgId != 0 {
+		http.Error(w, u.JsonErrorResponse("tg_id is set by telegram login"), http.StatusBadRequest)
+		return
+	}
+
 	newAccount := &models.Account{}
 	newAccount.SetIdAccaunt(newAccountJSON.IdAccaunt)
-	newAccount.SetTgId(newAccountJSON.TgId)
 	newAccount.SetName(newAccountJSON.Name)
 	newAccount.SetGroupId(newAccountJSON.GroupId)
 
+	// anonymous sign up can't join groups, owners add members
+	if requestAccount(r) == nil {
+		newAccount.SetGroupId(0)
+		newAccountJSON.GroupId = 0
+	}
+
 	// service
-	accountService := services.NewAccountService()
+	accountService := services.NewAccountService().WithScope(requestScope(r))
 	if err := accountService.AddNewAccount(newAccount); err != nil {
 		logger.Error("error adding account", "error", err)
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusConflict)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
+		return
+	}
+
+	// first api key of the account, shown only once
+	_, plainApiKey, err := services.NewAuthService(config).CreateApiKey(newAccount.GetIdAccaunt(), "default")
+	if err != nil {
+		logger.Error("Error creating api key", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error creating api key"), http.StatusInternalServerError)
 		return
 	}
 
+	audit(r, logger, "account", newAccount.GetIdAccaunt(), models.AuditCreate, nil, newAccountJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 
 	response := map[string]interface{}{
 		"message": "Account created successfully",
 		"account": newAccountJSON,
+		"api_key": plainApiKey,
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -177,16 +185,15 @@ func AccountPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 	// Создание нового аккаунта для обновления
 	newAccount := &models.Account{}
 	newAccount.SetIdAccaunt(updatedAccountJSON.IdAccaunt)
-	newAccount.SetTgId(updatedAccountJSON.TgId)
 	newAccount.SetName(updatedAccountJSON.Name)
 	newAccount.SetGroupId(updatedAccountJSON.GroupId)
 
-	accountService := services.NewAccountService()
+	accountService := services.NewAccountService().WithScope(requestScope(r))
 
 	// Обновление аккаунта
 	oldAccount, err := accountService.UpdateAccount(newAccount)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
@@ -198,6 +205,9 @@ func AccountPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		return
 	}
 
+	updatedAccountJSON.TgId = newAccount.GetTgId()
+	audit(r, logger, "account", newAccount.GetIdAccaunt(), models.AuditUpdate, oldAccountJSON, updatedAccountJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -237,13 +247,23 @@ func AccountDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combin
 		return
 	}
 
-	accountService := services.NewAccountService()
+	accountService := services.NewAccountService().WithScope(requestScope(r))
 
-	if err := accountService.DeleteAccount(deleteAccountJSON.IdAccaunt); err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+	oldAccount, err := accountService.DeleteAccount(deleteAccountJSON.IdAccaunt, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
+	oldAccountJSON, err := oldAccount.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old account to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old account"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "account", deleteAccountJSON.IdAccaunt, models.AuditDelete, oldAccountJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -260,3 +280,51 @@ func AccountDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combin
 
 	logger.Info("Successfully deleted account", "status", http.StatusOK)
 }
+
+// daily balance forecast (/accounts/{id}/forecast?horizon=6m)
+func AccountForecast(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAccountForecast called", "method", r.Method)
+
+	urlParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(urlParts) != 3 || urlParts[2] != "forecast" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idAccaunt, err := strconv.ParseInt(urlParts[1], 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_accaunt"), http.StatusBadRequest)
+		return
+	}
+
+	horizon := r.URL.Query().Get("horizon")
+	if horizon == "" {
+		horizon = services.DefaultForecastHorizon
+	}
+
+	forecastService := services.NewForecastService().WithScope(requestScope(r))
+	forecast, err := forecastService.Forecast(idAccaunt, horizon)
+	if errors.Is(err, services.ErrInvalidHorizon) {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(forecast); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved forecast", "id_accaunt", idAccaunt, "negative_days", forecast.NegativeDays, "status", http.StatusOK)
+}
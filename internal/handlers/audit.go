@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/request"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// write audit record of a change made by the request, failure is only logged
+func audit(r *http.Request, logger *logger.CombinedLogger, entity string, entityId int64, operation string, before, after interface{}) {
+	record := &models.AuditRecord{}
+	if account := requestAccount(r); account != nil {
+		record.SetIdActor(account.GetIdAccaunt())
+	}
+	record.SetActor(requestUpdBy(r))
+	record.SetEntity(entity)
+	record.SetEntityId(entityId)
+	record.SetOperation(operation)
+	record.SetClientIp(request.ClientIPFromContext(r.Context()))
+	record.SetRequestId(request.IdFromContext(r.Context()))
+
+	if err := services.NewAuditService().Record(record, before, after); err != nil {
+		logger.Error("Error writing audit record", "entity", entity, "id", entityId, "error", err)
+	}
+}
+
+// get audit records (/audit?entity=&actor=&from=&to=)
+func AuditGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAudit called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := services.AuditFilter{
+		Entity: query.Get("entity"),
+		Actor:  query.Get("actor"),
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid from date format"), http.StatusBadRequest)
+			return
+		}
+		filter.From = from
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid to date format"), http.StatusBadRequest)
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	auditService := services.NewAuditService().WithScope(requestScope(r))
+	records := auditService.GetAuditRecords(filter)
+
+	response := make([]models.AuditRecordJSON, 0, len(records))
+	for _, record := range records {
+		recordJSON, err := record.ToJSON()
+		if err != nil {
+			logger.Error("Error converting audit record to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting audit record to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *recordJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved audit records", "status", http.StatusOK)
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// header with plain api key
+const ApiKeyHeader = "X-Api-Key"
+
+// exchange api key for access + refresh token
+func AuthToken(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("AuthToken called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	apiKey := r.Header.Get(ApiKeyHeader)
+	if apiKey == "" {
+		var tokenRequest struct {
+			ApiKey string `json:"api_key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&tokenRequest); err != nil {
+			logger.Error("Error decoding JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+			return
+		}
+		apiKey = tokenRequest.ApiKey
+	}
+
+	authService := services.NewAuthService(config)
+	account, err := authService.AuthenticateApiKey(apiKey)
+	if err != nil {
+		logger.Info("Authentication failed", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid api key"), http.StatusUnauthorized)
+		return
+	}
+
+	tokenPair, err := authService.IssueTokens(account)
+	if err != nil {
+		logger.Error("Error issuing tokens", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error issuing tokens"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tokenPair); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully issued tokens", "status", http.StatusOK)
+}
+
+// exchange refresh token for a new token pair
+func AuthRefresh(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("AuthRefresh called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var refreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&refreshRequest); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	tokenPair, err := services.NewAuthService(config).Refresh(refreshRequest.RefreshToken)
+	if err != nil {
+		logger.Info("Refresh failed", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tokenPair); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully refreshed tokens", "status", http.StatusOK)
+}
+
+// exchange signed telegram login data for access + refresh token
+// body is either Login Widget fields or {"init_data": "<WebApp initData>"}
+func AuthTelegram(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("AuthTelegram called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// numbers are kept as sent, signature covers their exact text
+	var loginData map[string]interface{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&loginData); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	authService := services.NewAuthService(config)
+
+	var tokenPair *models.TokenPairJSON
+	var err error
+	if initData, ok := loginData["init_data"].(string); ok {
+		tokenPair, err = authService.LoginTelegramWebApp(initData)
+	} else {
+		fields := make(map[string]string, len(loginData))
+		for key, value := range loginData {
+			fields[key] = fmt.Sprint(value)
+		}
+		tokenPair, err = authService.LoginTelegram(fields)
+	}
+	if err != nil {
+		logger.Info("Telegram authentication failed", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tokenPair); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully issued tokens", "status", http.StatusOK)
+}
+
+// get all api keys of the caller
+func AuthKeyGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllApiKeys called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	account := requestAccount(r)
+	if account == nil {
+		http.Error(w, u.JsonErrorResponse("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	apiKeys := services.NewAuthService(config).GetApiKeysByAccount(account.GetIdAccaunt())
+
+	response := make([]models.ApiKeyJSON, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		apiKeyJSON, err := apiKey.ToJSON()
+		if err != nil {
+			logger.Error("Error converting api key to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting api key to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *apiKeyJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved api keys", "status", http.StatusOK)
+}
+
+// create api key for the caller, plain key is returned only once
+func AuthKeyPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostApiKey called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	account := requestAccount(r)
+	if account == nil {
+		http.Error(w, u.JsonErrorResponse("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var newApiKeyJSON models.ApiKeyJSON
+	if err := json.NewDecoder(r.Body).Decode(&newApiKeyJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	apiKey, plain, err := services.NewAuthService(config).CreateApiKey(account.GetIdAccaunt(), newApiKeyJSON.Name)
+	if err != nil {
+		logger.Error("Error creating api key", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	apiKeyJSON, err := apiKey.ToJSON()
+	if err != nil {
+		logger.Error("Error converting api key to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting api key to JSON"), http.StatusInternalServerError)
+		return
+	}
+	audit(r, logger, "api_key", apiKey.GetIdApiKey(), models.AuditCreate, nil, apiKeyJSON)
+
+	writeApiKey(w, logger, apiKey, plain, "Api key created successfully", http.StatusCreated)
+}
+
+// revoke api key of the caller
+func AuthKeyRevoke(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("RevokeApiKey called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	account := requestAccount(r)
+	if account == nil {
+		http.Error(w, u.JsonErrorResponse("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	idApiKeyStr := strings.TrimPrefix(r.URL.Path, "/auth/key/revoke/")
+	idApiKey, err := strconv.ParseInt(idApiKeyStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_api_key"), http.StatusBadRequest)
+		return
+	}
+
+	apiKey, err := services.NewAuthService(config).RevokeApiKey(account.GetIdAccaunt(), idApiKey)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	apiKeyJSON, err := apiKey.ToJSON()
+	if err != nil {
+		logger.Error("Error converting api key to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting api key to JSON"), http.StatusInternalServerError)
+		return
+	}
+	audit(r, logger, "api_key", apiKey.GetIdApiKey(), models.AuditUpdate, nil, apiKeyJSON)
+
+	writeApiKey(w, logger, apiKey, "", "Api key revoked successfully", http.StatusOK)
+}
+
+// revoke api key of the caller and issue a replacement
+func AuthKeyRotate(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("RotateApiKey called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	account := requestAccount(r)
+	if account == nil {
+		http.Error(w, u.JsonErrorResponse("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	idApiKeyStr := strings.TrimPrefix(r.URL.Path, "/auth/key/rotate/")
+	idApiKey, err := strconv.ParseInt(idApiKeyStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_api_key"), http.StatusBadRequest)
+		return
+	}
+
+	apiKey, plain, err := services.NewAuthService(config).RotateApiKey(account.GetIdAccaunt(), idApiKey)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	apiKeyJSON, err := apiKey.ToJSON()
+	if err != nil {
+		logger.Error("Error converting api key to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting api key to JSON"), http.StatusInternalServerError)
+		return
+	}
+	audit(r, logger, "api_key", apiKey.GetIdApiKey(), models.AuditCreate, nil, apiKeyJSON)
+
+	writeApiKey(w, logger, apiKey, plain, "Api key rotated successfully", http.StatusCreated)
+}
+
+func writeApiKey(w http.ResponseWriter, logger *logger.CombinedLogger, apiKey *models.ApiKey, plain, message string, status int) {
+	apiKeyJSON, err := apiKey.ToJSON()
+	if err != nil {
+		logger.Error("Error converting api key to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting api key to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	response := map[string]interface{}{
+		"message": message,
+		"key":     apiKeyJSON,
+	}
+	if plain != "" {
+		response["api_key"] = plain
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(message, "status", status)
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// raise events of budget thresholds crossed by a change of expences
+func checkBudgets(logger *logger.CombinedLogger) {
+	for _, event := range services.NewBudgetService().CheckThresholds() {
+		logger.Warn("Budget threshold crossed", "id_budget", event.GetIdBudget(), "threshold", event.GetThreshold(),
+			"percent_used", event.GetPercentUsed(), "spent", event.GetSpent(), "available", event.GetAvailable())
+	}
+}
+
+func budgetFromJSON(budgetJSON models.BudgetJSON) *models.Budget {
+	budget := &models.Budget{}
+	budget.SetIdBudget(budgetJSON.IdBudget)
+	budget.SetIdAccaunt(budgetJSON.IdAccaunt)
+	budget.SetIdGroup(budgetJSON.IdGroup)
+	budget.SetGroupExpence(budgetJSON.GroupExpence)
+	budget.SetIdCategory(budgetJSON.IdCategory)
+	budget.SetAmount(budgetJSON.Amount)
+	budget.SetPeriod(budgetJSON.Period)
+	budget.SetRollover(budgetJSON.Rollover)
+	budget.SetThresholds(budgetJSON.Thresholds)
+	budget.SetUpdBy(budgetJSON.UpdBy)
+
+	if dateStart, err := time.Parse("2006-01-02T15:04:05Z", budgetJSON.DateStart); err == nil {
+		budget.SetDateStart(dateStart)
+	}
+	return budget
+}
+
+// budget id from /budgets/{id}/{action}
+func budgetIdFromPath(path, action string) (int64, bool) {
+	urlParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(urlParts) != 3 || urlParts[2] != action {
+		return 0, false
+	}
+	idBudget, err := strconv.ParseInt(urlParts[1], 10, 64)
+	return idBudget, err == nil
+}
+
+// get all
+func BudgetGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllBudgets called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+
+	response := []models.BudgetJSON{}
+	for _, budget := range budgetService.GetAllBudgets() {
+		budgetJSON, err := budget.ToJSON()
+		if err != nil {
+			logger.Error("Error converting budget to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting budget to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *budgetJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved budgets", "status", http.StatusOK)
+}
+
+// get one by id
+func BudgetGetById(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetBudgetById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idBudget, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/budget/id/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_budget"), http.StatusBadRequest)
+		return
+	}
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+	budget, err := budgetService.GetBudgetById(idBudget)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	budgetJSON, err := budget.ToJSON()
+	if err != nil {
+		logger.Error("Error converting budget to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting budget to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(budgetJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved budget", "status", http.StatusOK)
+}
+
+// create
+func BudgetPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostBudget called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newBudgetJSON models.BudgetJSON
+	if err := json.NewDecoder(r.Body).Decode(&newBudgetJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newBudgetJSON.UpdBy = requestUpdBy(r)
+	newBudget := budgetFromJSON(newBudgetJSON)
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+	if err := budgetService.AddNewBudget(newBudget); err != nil {
+		logger.Error("Error adding budget", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	budgetJSON, err := newBudget.ToJSON()
+	if err != nil {
+		logger.Error("Error converting budget to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting budget to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "budget", newBudget.GetIdBudget(), models.AuditCreate, nil, budgetJSON)
+	checkBudgets(logger)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Budget created successfully",
+		"budget":  budgetJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created budget", "status", http.StatusCreated)
+}
+
+// update
+func BudgetPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutBudget called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idBudget, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/budget/update/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var updatedBudgetJSON models.BudgetJSON
+	if err := json.NewDecoder(r.Body).Decode(&updatedBudgetJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	updatedBudgetJSON.UpdBy = requestUpdBy(r)
+	newBudget := budgetFromJSON(updatedBudgetJSON)
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+	oldBudget, err := budgetService.UpdateBudget(idBudget, newBudget)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	oldBudgetJSON, err := oldBudget.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old budget to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old budget"), http.StatusInternalServerError)
+		return
+	}
+	newBudgetJSON, err := newBudget.ToJSON()
+	if err != nil {
+		logger.Error("Error converting budget to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting budget to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "budget", idBudget, models.AuditUpdate, oldBudgetJSON, newBudgetJSON)
+	checkBudgets(logger)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":    "Budget updated successfully",
+		"old_budget": oldBudgetJSON,
+		"new_budget": newBudgetJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully updated budget", "status", http.StatusOK)
+}
+
+// delete
+func BudgetDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteBudget called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idBudget, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/budget/delete/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+	oldBudget, err := budgetService.DeleteBudget(idBudget, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldBudgetJSON, err := oldBudget.ToJSON()
+	if err != nil {
+		logger.Error("Error converting budget to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting budget to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "budget", idBudget, models.AuditDelete, oldBudgetJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Budget deleted successfully",
+		"budget":  oldBudgetJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted budget", "status", http.StatusOK)
+}
+
+// spent, remaining and burn rate of the current period (/budgets/{id}/status)
+func BudgetGetStatus(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetBudgetStatus called", "method", r.Method)
+
+	idBudget, ok := budgetIdFromPath(r.URL.Path, "status")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+	status, err := budgetService.GetStatus(idBudget)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved budget status", "id_budget", idBudget, "status", http.StatusOK)
+}
+
+// thresholds crossed (/budgets/{id}/events)
+func BudgetGetEvents(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetBudgetEvents called", "method", r.Method)
+
+	idBudget, ok := budgetIdFromPath(r.URL.Path, "events")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	budgetService := services.NewBudgetService().WithScope(requestScope(r))
+	events, err := budgetService.GetEvents(idBudget)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	response := []models.BudgetEventJSON{}
+	for _, event := range events {
+		eventJSON, err := event.ToJSON()
+		if err != nil {
+			logger.Error("Error converting budget event to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting budget event to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *eventJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved budget events", "id_budget", idBudget, "status", http.StatusOK)
+}
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/helltale/api-finances/config"
-	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
 	"github.com/helltale/api-finances/internal/services"
@@ -29,12 +28,7 @@ func CashbackGetAll(w http.ResponseWriter, r *http.Request, logger *logger.Combi
 
 	w.Header().Set("Content-Type", "application/json")
 
-	var cashbacks []*models.Cashback
-	if config.AppMode == "debug" {
-		cashbacks = debugging.Cashbacks
-	} else {
-		cashbacks = []*models.Cashback{}
-	}
+	cashbacks := services.NewCashbackService().WithScope(requestScope(r)).GetAllCashbacks()
 
 	response := make([]models.CashbackJSON, 0, len(cashbacks))
 	for _, cashback := range cashbacks {
@@ -81,17 +75,8 @@ func CashbackGetByIdCashback(w http.ResponseWriter, r *http.Request, logger *log
 		return
 	}
 
-	var foundCashback *models.Cashback
-	if config.AppMode == "debug" {
-		for _, cashback := range debugging.Cashbacks {
-			if cashback.GetIdCashback() == idCashback {
-				foundCashback = cashback
-				break
-			}
-		}
-	}
-
-	if foundCashback == nil {
+	foundCashback, err := services.NewCashbackService().WithScope(requestScope(r)).GetCashbackById(idCashback)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Cashback not found"), http.StatusNotFound)
 		return
 	}
@@ -138,14 +123,7 @@ func CashbackGetByIdAccount(w http.ResponseWriter, r *http.Request, logger *logg
 		return
 	}
 
-	var foundCashbacks []*models.Cashback
-	if config.AppMode == "debug" {
-		for _, cashback := range debugging.Cashbacks {
-			if cashback.GetIdAccaunt() == idAccaunt {
-				foundCashbacks = append(foundCashbacks, cashback)
-			}
-		}
-	}
+	foundCashbacks := services.NewCashbackService().WithScope(requestScope(r)).GetCashbacksByAccount(idAccaunt)
 
 	if len(foundCashbacks) == 0 {
 		http.Error(w, u.JsonErrorResponse("No cashbacks found for the account"), http.StatusNotFound)
@@ -193,14 +171,7 @@ func CashbackGetByBankName(w http.ResponseWriter, r *http.Request, logger *logge
 		return
 	}
 
-	var foundCashbacks []*models.Cashback
-	if config.AppMode == "debug" {
-		for _, cashback := range debugging.Cashbacks {
-			if strings.EqualFold(cashback.GetBankName(), bankName) {
-				foundCashbacks = append(foundCashbacks, cashback)
-			}
-		}
-	}
+	foundCashbacks := services.NewCashbackService().WithScope(requestScope(r)).GetCashbacksByBank(bankName)
 
 	if len(foundCashbacks) == 0 {
 		http.Error(w, u.JsonErrorResponse("No cashbacks found for the bank"), http.StatusNotFound)
@@ -247,14 +218,7 @@ func CashbackGetByCategory(w http.ResponseWriter, r *http.Request, logger *logge
 		return
 	}
 
-	var foundCashbacks []*models.Cashback
-	if config.AppMode == "debug" {
-		for _, cashback := range debugging.Cashbacks {
-			if strings.EqualFold(cashback.GetCategory(), category) {
-				foundCashbacks = append(foundCashbacks, cashback)
-			}
-		}
-	}
+	foundCashbacks := services.NewCashbackService().WithScope(requestScope(r)).GetCashbacksByCategory(category)
 
 	if len(foundCashbacks) == 0 {
 		http.Error(w, u.JsonErrorResponse("No cashbacks found for the category"), http.StatusNotFound)
@@ -284,6 +248,218 @@ func CashbackGetByCategory(w http.ResponseWriter, r *http.Request, logger *logge
 	logger.Info("Successfully retrieved cashbacks for category", "status", http.StatusOK)
 }
 
+// cards ranked by cashback for ?account=&category=&amount=&date=
+func CashbackRecommend(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("RecommendCashback called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	idAccaunt, err := strconv.ParseInt(query.Get("account"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid account"), http.StatusBadRequest)
+		return
+	}
+	amount, err := strconv.ParseFloat(query.Get("amount"), 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid amount"), http.StatusBadRequest)
+		return
+	}
+
+	var at time.Time
+	if dateStr := query.Get("date"); dateStr != "" {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid date format"), http.StatusBadRequest)
+			return
+		}
+		at = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
+	recommendation, err := cashbackService.Recommend(idAccaunt, query.Get("category"), amount, at)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recommendation); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully recommended cards", "cards", len(recommendation.Cards), "status", http.StatusOK)
+}
+
+// record cashback credited by the bank for a month
+func CashbackCreditPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostCashbackCredit called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var creditJSON models.CashbackCreditJSON
+	if err := json.NewDecoder(r.Body).Decode(&creditJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	month, err := time.Parse("2006-01", creditJSON.Month)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid month format, must be YYYY-MM"), http.StatusBadRequest)
+		return
+	}
+
+	credit := &models.CashbackCredit{}
+	credit.SetIdAccaunt(creditJSON.IdAccaunt)
+	credit.SetBankName(creditJSON.BankName)
+	credit.SetMonth(month)
+	credit.SetAmount(creditJSON.Amount)
+	credit.SetUpdBy(requestUpdBy(r))
+
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
+	oldCredit, err := cashbackService.RecordCredit(credit)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	newCreditJSON, err := credit.ToJSON()
+	if err != nil {
+		logger.Error("Error converting cashback credit to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting cashback credit to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusCreated
+	if oldCredit != nil {
+		oldCreditJSON, err := oldCredit.ToJSON()
+		if err != nil {
+			logger.Error("Error converting old cashback credit to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error processing old cashback credit"), http.StatusInternalServerError)
+			return
+		}
+		audit(r, logger, "cashback_credit", credit.GetIdCredit(), models.AuditUpdate, oldCreditJSON, newCreditJSON)
+		status = http.StatusOK
+	} else {
+		audit(r, logger, "cashback_credit", credit.GetIdCredit(), models.AuditCreate, nil, newCreditJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	response := map[string]interface{}{
+		"message": "Cashback credit recorded successfully",
+		"credit":  newCreditJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully recorded cashback credit", "status", status)
+}
+
+// best of categories offered by the bank for the month by expence history
+func CashbackSelectionSuggest(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("SuggestCashbackSelection called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var selectionJSON models.CashbackSelectionJSON
+	if err := json.NewDecoder(r.Body).Decode(&selectionJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
+	suggestion, err := cashbackService.SuggestSelection(&selectionJSON)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(suggestion); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully suggested cashback selection", "status", http.StatusOK)
+}
+
+// create cashback rules of the month from selected offers
+func CashbackSelectionAccept(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("AcceptCashbackSelection called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var selectionJSON models.CashbackSelectionJSON
+	if err := json.NewDecoder(r.Body).Decode(&selectionJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
+	created, err := cashbackService.AcceptSelection(&selectionJSON, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	cashbacksJSON := make([]models.CashbackJSON, 0, len(created))
+	for _, cashback := range created {
+		cashbackJSON, err := cashback.ToJSON()
+		if err != nil {
+			logger.Error("Error converting cashback to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting cashback to JSON"), http.StatusInternalServerError)
+			return
+		}
+		audit(r, logger, "cashback", cashback.GetIdCashback(), models.AuditCreate, nil, cashbackJSON)
+		cashbacksJSON = append(cashbacksJSON, *cashbackJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message":   "Cashback selection accepted successfully",
+		"month":     selectionJSON.Month,
+		"cashbacks": cashbacksJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully accepted cashback selection", "created", len(created), "status", http.StatusCreated)
+}
+
 // get current
 func CashbackGetCurrent(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
 	logger.Info("GetCurrentCashbacks called", "method", r.Method)
@@ -295,14 +471,7 @@ func CashbackGetCurrent(w http.ResponseWriter, r *http.Request, logger *logger.C
 		return
 	}
 
-	var foundCashbacks []*models.Cashback
-	if config.AppMode == "debug" {
-		for _, cashback := range debugging.Cashbacks {
-			if cashback.GetDateActualTo().Equal(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)) {
-				foundCashbacks = append(foundCashbacks, cashback)
-			}
-		}
-	}
+	foundCashbacks := services.NewCashbackService().WithScope(requestScope(r)).GetCurrentCashbacks()
 
 	if len(foundCashbacks) == 0 {
 		http.Error(w, u.JsonErrorResponse("No current cashbacks found"), http.StatusNotFound)
@@ -349,23 +518,32 @@ func CashbackPost(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 		return
 	}
 
+	newCashbackJSON.UpdBy = requestUpdBy(r)
+
 	newCashback := &models.Cashback{}
 	newCashback.SetIdCashback(newCashbackJSON.IdCashback)
 	newCashback.SetIdAccaunt(newCashbackJSON.IdAccaunt)
 	newCashback.SetBankName(newCashbackJSON.BankName)
 	newCashback.SetCategory(newCashbackJSON.Category)
+	newCashback.SetIdCategory(newCashbackJSON.IdCategory)
 	newCashback.SetPercent(newCashbackJSON.Percent)
+	newCashback.SetMonthlyCap(newCashbackJSON.MonthlyCap)
+	newCashback.SetMinPurchase(newCashbackJSON.MinPurchase)
 	newCashback.SetUpdBy(newCashbackJSON.UpdBy)
 	dateFrom, _ := time.Parse("2006-01-02", newCashbackJSON.DateActualFrom)
 	dateTo, _ := time.Parse("2006-01-02", newCashbackJSON.DateActualTo)
 	newCashback.SetDateActualFrom(dateFrom)
 	newCashback.SetDateActualTo(dateTo)
 
-	cashbackService := services.NewCashbackService()
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
 	if err := cashbackService.AddNewCashback(newCashback); err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusBadRequest)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
 		return
 	}
+	newCashbackJSON.Category = newCashback.GetCategory()
+	newCashbackJSON.IdCategory = newCashback.GetIdCategory()
+
+	audit(r, logger, "cashback", newCashbackJSON.IdCashback, models.AuditCreate, nil, newCashbackJSON)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -393,24 +571,41 @@ func CashbackPut(w http.ResponseWriter, r *http.Request, logger *logger.Combined
 		return
 	}
 
+	updatedCashbackJSON.UpdBy = requestUpdBy(r)
+
 	updatedCashback := &models.Cashback{}
 	updatedCashback.SetIdCashback(updatedCashbackJSON.IdCashback)
 	updatedCashback.SetIdAccaunt(updatedCashbackJSON.IdAccaunt)
 	updatedCashback.SetBankName(updatedCashbackJSON.BankName)
 	updatedCashback.SetCategory(updatedCashbackJSON.Category)
+	updatedCashback.SetIdCategory(updatedCashbackJSON.IdCategory)
 	updatedCashback.SetPercent(updatedCashbackJSON.Percent)
+	updatedCashback.SetMonthlyCap(updatedCashbackJSON.MonthlyCap)
+	updatedCashback.SetMinPurchase(updatedCashbackJSON.MinPurchase)
 	updatedCashback.SetUpdBy(updatedCashbackJSON.UpdBy)
 	dateFrom, _ := time.Parse("2006-01-02", updatedCashbackJSON.DateActualFrom)
 	dateTo, _ := time.Parse("2006-01-02", updatedCashbackJSON.DateActualTo)
 	updatedCashback.SetDateActualFrom(dateFrom)
 	updatedCashback.SetDateActualTo(dateTo)
 
-	cashbackService := services.NewCashbackService()
-	if _, err := cashbackService.UpdateCashback(updatedCashback); err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
+	oldCashback, err := cashbackService.UpdateCashback(updatedCashback)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	updatedCashbackJSON.Category = updatedCashback.GetCategory()
+	updatedCashbackJSON.IdCategory = updatedCashback.GetIdCategory()
+
+	oldCashbackJSON, err := oldCashback.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old cashback to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old cashback"), http.StatusInternalServerError)
 		return
 	}
 
+	audit(r, logger, "cashback", updatedCashbackJSON.IdCashback, models.AuditUpdate, oldCashbackJSON, updatedCashbackJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{
@@ -437,27 +632,43 @@ func CashbackUpdateWithHistory(w http.ResponseWriter, r *http.Request, logger *l
 		return
 	}
 
+	updatedCashbackJSON.UpdBy = requestUpdBy(r)
+
 	newCashback := &models.Cashback{}
 	newCashback.SetIdCashback(updatedCashbackJSON.IdCashback)
 	newCashback.SetIdAccaunt(updatedCashbackJSON.IdAccaunt)
 	newCashback.SetBankName(updatedCashbackJSON.BankName)
 	newCashback.SetCategory(updatedCashbackJSON.Category)
+	newCashback.SetIdCategory(updatedCashbackJSON.IdCategory)
 	newCashback.SetPercent(updatedCashbackJSON.Percent)
+	newCashback.SetMonthlyCap(updatedCashbackJSON.MonthlyCap)
+	newCashback.SetMinPurchase(updatedCashbackJSON.MinPurchase)
 	newCashback.SetUpdBy(updatedCashbackJSON.UpdBy)
 
-	cashbackService := services.NewCashbackService()
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
 	oldCashback, err := cashbackService.UpdateHistoryCashback(updatedCashbackJSON.IdCashback, newCashback)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	updatedCashbackJSON.Category = newCashback.GetCategory()
+	updatedCashbackJSON.IdCategory = newCashback.GetIdCategory()
+
+	oldCashbackJSON, err := oldCashback.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old cashback to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old cashback"), http.StatusInternalServerError)
 		return
 	}
 
+	audit(r, logger, "cashback", updatedCashbackJSON.IdCashback, models.AuditUpdate, oldCashbackJSON, updatedCashbackJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{
 		"message":      "Cashback updated with history successfully",
 		"id_cashback":  updatedCashbackJSON.IdCashback,
-		"old_cashback": oldCashback,
+		"old_cashback": oldCashbackJSON,
 	}
 	json.NewEncoder(w).Encode(response)
 }
@@ -480,19 +691,28 @@ func CashbackDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combi
 		return
 	}
 
-	cashbackService := services.NewCashbackService()
-	deletedCashback, err := cashbackService.DeleteCashback(idCashback)
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
+	deletedCashback, err := cashbackService.DeleteCashback(idCashback, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	deletedCashbackJSON, err := deletedCashback.ToJSON()
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		logger.Error("Error converting deleted cashback to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing deleted cashback"), http.StatusInternalServerError)
 		return
 	}
 
+	audit(r, logger, "cashback", idCashback, models.AuditDelete, deletedCashbackJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{
 		"message":      "Cashback deleted successfully",
 		"id_cashback":  idCashback,
-		"deleted_data": deletedCashback,
+		"deleted_data": deletedCashbackJSON,
 	}
 	json.NewEncoder(w).Encode(response)
 }
@@ -515,19 +735,28 @@ func CashbackDeleteAndRestore(w http.ResponseWriter, r *http.Request, logger *lo
 		return
 	}
 
-	cashbackService := services.NewCashbackService()
+	cashbackService := services.NewCashbackService().WithScope(requestScope(r))
 	restoredCashback, err := cashbackService.DeleteAndRestorePreviousCashback(idCashback)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
+	restoredCashbackJSON, err := restoredCashback.ToJSON()
+	if err != nil {
+		logger.Error("Error converting restored cashback to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing restored cashback"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "cashback", idCashback, models.AuditUpdate, nil, restoredCashbackJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{
 		"message":       "Cashback deleted and restored successfully",
 		"id_cashback":   idCashback,
-		"restored_data": restoredCashback,
+		"restored_data": restoredCashbackJSON,
 	}
 	json.NewEncoder(w).Encode(response)
 }
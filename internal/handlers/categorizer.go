@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+func writeClassifiers(w http.ResponseWriter, logger *logger.CombinedLogger, classifiers []*models.Classifier) {
+	response := []models.ClassifierJSON{}
+	for _, classifier := range classifiers {
+		classifierJSON, err := classifier.ToJSON()
+		if err != nil {
+			logger.Error("Error converting classifier to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting classifier to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *classifierJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+}
+
+// what classifiers of the caller's groups have learnt
+func CategorizerGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllClassifiers called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeClassifiers(w, logger, services.NewCategorizerService().WithScope(requestScope(r)).GetAll())
+
+	logger.Info("Successfully retrieved classifiers", "status", http.StatusOK)
+}
+
+// train classifiers again from the whole history, they learn new expences by
+// themselves
+func CategorizerTrain(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("TrainClassifiers called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeClassifiers(w, logger, services.NewCategorizerService().WithScope(requestScope(r)).Train())
+
+	logger.Info("Successfully trained classifiers", "status", http.StatusOK)
+}
+
+// groups for expence not saved yet, body is expence with account, title,
+// description and amount
+func CategorizerSuggest(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("SuggestGroup called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var expenceJSON models.ExpenceJSON
+	if err := json.NewDecoder(r.Body).Decode(&expenceJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	scope := requestScope(r)
+	if !scope.Allows(expenceJSON.IdAccaunt) {
+		http.Error(w, u.JsonErrorResponse("account not found"), http.StatusNotFound)
+		return
+	}
+
+	expence := &models.Expence{}
+	expence.SetIdAccaunt(expenceJSON.IdAccaunt)
+	expence.SetTitleExpence(expenceJSON.TitleExpence)
+	expence.SetDescriptionExpence(expenceJSON.DescriptionExpence)
+	expence.SetAmount(expenceJSON.Amount)
+
+	response := services.NewCategorizerService().WithScope(scope).Suggestions(expence)
+	if response == nil {
+		response = []models.SuggestionJSON{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully suggested groups", "count", len(response), "status", http.StatusOK)
+}
+
+// give expence the suggested group; changing the group by update corrects
+// the suggestion instead
+func CategorizerAccept(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("AcceptSuggestion called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idExpence, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/categorizer/accept/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	oldExpence, newExpence, err := services.NewCategorizerService().WithScope(requestScope(r)).Accept(idExpence, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	oldExpenceJSON, _ := oldExpence.ToJSON()
+	newExpenceJSON, err := newExpence.ToJSON()
+	if err != nil {
+		logger.Error("Error converting expence to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting expence to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "expence", idExpence, models.AuditUpdate, oldExpenceJSON, newExpenceJSON)
+	checkBudgets(logger)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":     "Suggestion accepted successfully",
+		"old_expence": oldExpenceJSON,
+		"new_expence": newExpenceJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully accepted suggestion", "status", http.StatusOK)
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+func categoryFromJSON(categoryJSON models.CategoryJSON) *models.Category {
+	names := categoryJSON.Names
+	if len(names) == 0 && categoryJSON.Name != "" {
+		names = map[string]string{models.DefaultCategoryLang: categoryJSON.Name}
+	}
+
+	category := &models.Category{}
+	category.SetIdCategory(categoryJSON.IdCategory)
+	category.SetIdParent(categoryJSON.IdParent)
+	category.SetIdAccaunt(categoryJSON.IdAccaunt)
+	category.SetCode(categoryJSON.Code)
+	category.SetNames(names)
+	category.SetAliases(categoryJSON.Aliases)
+	category.SetUpdBy(categoryJSON.UpdBy)
+	return category
+}
+
+// admins change categories of everyone, others only categories of their groups
+func categoryWriteScope(r *http.Request, config *config.Config) *services.Scope {
+	if requestIsAdmin(r, config) {
+		return nil
+	}
+	return requestScope(r)
+}
+
+// get all, names in ?lang=
+func CategoryGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllCategories called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	categoryService := services.NewCategoryService().WithScope(requestScope(r))
+
+	response := []models.CategoryJSON{}
+	for _, category := range categoryService.GetAllCategories() {
+		response = append(response, *categoryService.Localize(category, lang))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved categories", "status", http.StatusOK)
+}
+
+// categories with subcategories, names in ?lang=
+func CategoryGetTree(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetCategoryTree called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	tree := services.NewCategoryService().WithScope(requestScope(r)).Tree(r.URL.Query().Get("lang"))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tree); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved category tree", "status", http.StatusOK)
+}
+
+// get one by id
+func CategoryGetById(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetCategoryById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idCategory, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/category/id/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_category"), http.StatusBadRequest)
+		return
+	}
+
+	categoryService := services.NewCategoryService().WithScope(requestScope(r))
+	category, err := categoryService.GetCategoryById(idCategory)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(categoryService.Localize(category, r.URL.Query().Get("lang"))); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved category", "status", http.StatusOK)
+}
+
+// create
+func CategoryPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostCategory called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newCategoryJSON models.CategoryJSON
+	if err := json.NewDecoder(r.Body).Decode(&newCategoryJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newCategoryJSON.UpdBy = requestUpdBy(r)
+	newCategory := categoryFromJSON(newCategoryJSON)
+
+	if err := services.NewCategoryService().WithScope(categoryWriteScope(r, config)).AddNewCategory(newCategory); err != nil {
+		logger.Error("Error adding category", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	categoryJSON, err := newCategory.ToJSON()
+	if err != nil {
+		logger.Error("Error converting category to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting category to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "category", newCategory.GetIdCategory(), models.AuditCreate, nil, categoryJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message":  "Category created successfully",
+		"category": categoryJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created category", "status", http.StatusCreated)
+}
+
+// update
+func CategoryPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutCategory called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idCategory, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/category/update/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var updatedCategoryJSON models.CategoryJSON
+	if err := json.NewDecoder(r.Body).Decode(&updatedCategoryJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	updatedCategoryJSON.UpdBy = requestUpdBy(r)
+	newCategory := categoryFromJSON(updatedCategoryJSON)
+
+	oldCategory, err := services.NewCategoryService().WithScope(categoryWriteScope(r, config)).UpdateCategory(idCategory, newCategory)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	oldCategoryJSON, err := oldCategory.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old category to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old category"), http.StatusInternalServerError)
+		return
+	}
+	newCategoryJSON, err := newCategory.ToJSON()
+	if err != nil {
+		logger.Error("Error converting category to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting category to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "category", idCategory, models.AuditUpdate, oldCategoryJSON, newCategoryJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":      "Category updated successfully",
+		"old_category": oldCategoryJSON,
+		"new_category": newCategoryJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully updated category", "status", http.StatusOK)
+}
+
+// delete unused category without subcategories
+func CategoryDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteCategory called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idCategory, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/category/delete/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	oldCategory, err := services.NewCategoryService().WithScope(categoryWriteScope(r, config)).DeleteCategory(idCategory, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
+		return
+	}
+
+	oldCategoryJSON, err := oldCategory.ToJSON()
+	if err != nil {
+		logger.Error("Error converting category to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting category to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "category", idCategory, models.AuditDelete, oldCategoryJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":  "Category deleted successfully",
+		"category": oldCategoryJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted category", "status", http.StatusOK)
+}
+
+// map free text groups that have no category yet
+func CategoryMigrate(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("MigrateCategories called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	mappings, err := services.NewCategoryService().Migrate(requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error migrating categories", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusInternalServerError)
+		return
+	}
+	auditMigration(r, logger, mappings)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":  "Categories migrated successfully",
+		"mapped":   len(mappings),
+		"mappings": mappings,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully migrated categories", "mapped", len(mappings), "status", http.StatusOK)
+}
+
+// categories made by the migration and every record linked to a category
+func auditMigration(r *http.Request, logger *logger.CombinedLogger, mappings []models.CategoryMappingJSON) {
+	for _, mapping := range mappings {
+		if mapping.Created {
+			if category, err := services.NewCategoryService().GetCategoryById(mapping.IdCategory); err == nil {
+				if categoryJSON, err := category.ToJSON(); err == nil {
+					audit(r, logger, "category", mapping.IdCategory, models.AuditCreate, nil, categoryJSON)
+				}
+			}
+		}
+
+		before := map[string]interface{}{"id_category": 0, "value": mapping.Value}
+		after := map[string]interface{}{"id_category": mapping.IdCategory, "value": mapping.Value}
+		audit(r, logger, mapping.Entity, mapping.Id, models.AuditUpdate, before, after)
+	}
+}
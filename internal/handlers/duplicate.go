@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// pair with its expences for review
+func duplicateJSON(duplicateService *services.DuplicateService, duplicate *models.Duplicate) (*models.DuplicateJSON, error) {
+	pairJSON, err := duplicate.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	for _, expence := range duplicateService.Expences(duplicate) {
+		expenceJSON, err := expence.ToJSON()
+		if err != nil {
+			return nil, err
+		}
+		pairJSON.Expences = append(pairJSON.Expences, *expenceJSON)
+	}
+	return pairJSON, nil
+}
+
+func writeDuplicates(w http.ResponseWriter, logger *logger.CombinedLogger, duplicateService *services.DuplicateService, duplicates []*models.Duplicate) {
+	response := []models.DuplicateJSON{}
+	for _, duplicate := range duplicates {
+		pairJSON, err := duplicateJSON(duplicateService, duplicate)
+		if err != nil {
+			logger.Error("Error converting duplicate to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting duplicate to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *pairJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+}
+
+// pairs for review, ?status= is open by default, all gives every status
+func DuplicateGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllDuplicates called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.DuplicateStatusOpen
+	case "all":
+		status = ""
+	case models.DuplicateStatusOpen, models.DuplicateStatusMerged, models.DuplicateStatusDismissed:
+	default:
+		http.Error(w, u.JsonErrorResponse("Invalid status"), http.StatusBadRequest)
+		return
+	}
+
+	duplicateService := services.NewDuplicateService().WithScope(requestScope(r))
+	writeDuplicates(w, logger, duplicateService, duplicateService.GetAll(status))
+
+	logger.Info("Successfully retrieved duplicates", "status", http.StatusOK)
+}
+
+// look for new pairs now, imports do it by themselves
+func DuplicateDetect(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DetectDuplicates called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	duplicateService := services.NewDuplicateService().WithScope(requestScope(r))
+	writeDuplicates(w, logger, duplicateService, duplicateService.Detect())
+
+	logger.Info("Successfully detected duplicates", "status", http.StatusOK)
+}
+
+// /duplicates/{id}/merge keeps ?keep= expence or the earlier one and deletes
+// the other, /duplicates/{id}/dismiss marks pair as not duplicate
+func DuplicateResolve(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ResolveDuplicate called", "method", r.Method)
+
+	urlParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(urlParts) != 3 || (urlParts[2] != "merge" && urlParts[2] != "dismiss") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idDuplicate, err := strconv.ParseInt(urlParts[1], 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var keep int64
+	if keepStr := r.URL.Query().Get("keep"); keepStr != "" {
+		if keep, err = strconv.ParseInt(keepStr, 10, 64); err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid keep"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	duplicateService := services.NewDuplicateService().WithScope(requestScope(r))
+	duplicate, err := duplicateService.GetById(idDuplicate)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	oldDuplicateJSON, _ := duplicate.ToJSON()
+
+	response := map[string]interface{}{}
+	if urlParts[2] == "merge" {
+		_, oldKept, kept, deleted, err := duplicateService.Merge(idDuplicate, keep, requestUpdBy(r))
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+			return
+		}
+
+		oldKeptJSON, _ := oldKept.ToJSON()
+		keptJSON, _ := kept.ToJSON()
+		deletedJSON, _ := deleted.ToJSON()
+		if oldKept != kept {
+			audit(r, logger, "expence", kept.GetIdExpence(), models.AuditUpdate, oldKeptJSON, keptJSON)
+		}
+		audit(r, logger, "expence", deleted.GetIdExpence(), models.AuditDelete, deletedJSON, nil)
+		checkBudgets(logger)
+
+		response["message"] = "Duplicate merged successfully"
+		response["kept"] = keptJSON
+		response["deleted"] = deletedJSON
+	} else {
+		if _, err := duplicateService.Dismiss(idDuplicate, requestUpdBy(r)); err != nil {
+			http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+			return
+		}
+		response["message"] = "Duplicate dismissed successfully"
+	}
+
+	newDuplicateJSON, _ := duplicate.ToJSON()
+	audit(r, logger, "duplicate", idDuplicate, models.AuditUpdate, oldDuplicateJSON, newDuplicateJSON)
+	response["duplicate"] = newDuplicateJSON
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully resolved duplicate", "action", urlParts[2], "status", http.StatusOK)
+}
@@ -8,13 +8,26 @@ import (
 	"time"
 
 	"github.com/helltale/api-finances/config"
-	debugging "github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/recurrence"
 	"github.com/helltale/api-finances/internal/services"
 	u "github.com/helltale/api-finances/internal/utils"
 )
 
+func expenceSplitsFromJSON(splitsJSON []models.ExpenceSplitJSON) []*models.ExpenceSplit {
+	var splits []*models.ExpenceSplit
+	for _, splitJSON := range splitsJSON {
+		split := &models.ExpenceSplit{}
+		split.SetAmount(splitJSON.Amount)
+		split.SetIdCategory(splitJSON.IdCategory)
+		split.SetGroupExpence(splitJSON.GroupExpence)
+		split.SetIdAccaunt(splitJSON.IdAccaunt)
+		splits = append(splits, split)
+	}
+	return splits
+}
+
 // get all
 func ExpenceGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
 	logger.Info("GetAllExpences called", "method", r.Method)
@@ -25,8 +38,8 @@ func ExpenceGetAll(w http.ResponseWriter, r *http.Request, logger *logger.Combin
 		return
 	}
 
-	expenceService := services.NewExpenceService()
-	expences := expenceService.GetAllExpences()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
+	expences := services.FilterExpencesByTags(expenceService.GetAllExpences(), requestTags(r))
 
 	if len(expences) == 0 {
 		http.Error(w, u.JsonErrorResponse("No expences found"), http.StatusNotFound)
@@ -77,7 +90,7 @@ func ExpenceGetByIdExpence(w http.ResponseWriter, r *http.Request, logger *logge
 		return
 	}
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	expence, err := expenceService.GetExpenceById(idExpence)
 	if err != nil {
 		logger.Error("Error fetching expence", "error", err)
@@ -121,13 +134,14 @@ func ExpenceGetByIdGroup(w http.ResponseWriter, r *http.Request, logger *logger.
 
 	group := urlParts[4]
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	expences, err := expenceService.GetExpencesByGroup(group)
 	if err != nil {
 		logger.Error("Error fetching expences", "error", err)
 		http.Error(w, u.JsonErrorResponse("Error fetching expences"), http.StatusInternalServerError)
 		return
 	}
+	expences = services.FilterExpencesByTags(expences, requestTags(r))
 
 	if len(expences) == 0 {
 		http.Error(w, u.JsonErrorResponse("No expences found for the specified group"), http.StatusNotFound)
@@ -171,12 +185,13 @@ func ExpenceGetByTitle(w http.ResponseWriter, r *http.Request, logger *logger.Co
 		return
 	}
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	foundExpences, err := expenceService.GetExpencesByTitle(titleExpence)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
+	foundExpences = services.FilterExpencesByTags(foundExpences, requestTags(r))
 
 	var expencesJSON []models.ExpenceJSON
 	for _, expence := range foundExpences {
@@ -235,12 +250,13 @@ func ExpenceGetByDateBetween(w http.ResponseWriter, r *http.Request, logger *log
 		}
 	}
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	foundExpences, err := expenceService.GetExpencesByDateRange(startDate, endDate)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
+	foundExpences = services.FilterExpencesByTags(foundExpences, requestTags(r))
 
 	var expencesJSON []models.ExpenceJSON
 	for _, expence := range foundExpences {
@@ -279,19 +295,27 @@ func ExpenceGetByRepeat(w http.ResponseWriter, r *http.Request, logger *logger.C
 		return
 	}
 
+	// 0 or 1, or frequency of recurrence rule
 	repeatStr := urlParts[3]
-	repeat, err := strconv.ParseInt(repeatStr, 10, 8)
-	if err != nil || (repeat != 0 && repeat != 1) {
-		http.Error(w, u.JsonErrorResponse("Invalid repeat type, must be 0 or 1"), http.StatusBadRequest)
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
+
+	var foundExpences []*models.Expence
+	var err error
+	switch freq := strings.ToUpper(repeatStr); freq {
+	case "0", "1":
+		repeat, _ := strconv.ParseInt(repeatStr, 10, 8)
+		foundExpences, err = expenceService.GetExpencesByRepeat(int8(repeat))
+	case recurrence.Daily, recurrence.Weekly, recurrence.Monthly, recurrence.Yearly:
+		foundExpences, err = expenceService.GetExpencesByFrequency(freq)
+	default:
+		http.Error(w, u.JsonErrorResponse("Invalid repeat type, must be 0, 1, daily, weekly, monthly or yearly"), http.StatusBadRequest)
 		return
 	}
-
-	expenceService := services.NewExpenceService()
-	foundExpences, err := expenceService.GetExpencesByRepeat(int8(repeat))
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
+	foundExpences = services.FilterExpencesByTags(foundExpences, requestTags(r))
 
 	var expencesJSON []models.ExpenceJSON
 	for _, expence := range foundExpences {
@@ -345,12 +369,13 @@ func ExpenceGetByAmountBetween(w http.ResponseWriter, r *http.Request, logger *l
 		return
 	}
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	foundExpences, err := expenceService.GetExpencesByAmountRange(minAmount, maxAmount)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
+	foundExpences = services.FilterExpencesByTags(foundExpences, requestTags(r))
 
 	var expencesJSON []models.ExpenceJSON
 	for _, expence := range foundExpences {
@@ -397,12 +422,13 @@ func ExpenceGetByAmountLess(w http.ResponseWriter, r *http.Request, logger *logg
 		return
 	}
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	foundExpences, err := expenceService.GetExpencesByMaxAmount(maxAmount)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
+	foundExpences = services.FilterExpencesByTags(foundExpences, requestTags(r))
 
 	var expencesJSON []models.ExpenceJSON
 	for _, expence := range foundExpences {
@@ -449,12 +475,13 @@ func ExpencesGetByAmountMore(w http.ResponseWriter, r *http.Request, logger *log
 		return
 	}
 
-	expenceService := services.NewExpenceService()
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
 	foundExpences, err := expenceService.GetExpencesByMinAmount(minAmount)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
+	foundExpences = services.FilterExpencesByTags(foundExpences, requestTags(r))
 
 	var expencesJSON []models.ExpenceJSON
 	for _, expence := range foundExpences {
@@ -497,12 +524,21 @@ func ExpencePost(w http.ResponseWriter, r *http.Request, logger *logger.Combined
 		return
 	}
 
+	newExpenceJSON.UpdBy = requestUpdBy(r)
+
 	newExpence := &models.Expence{}
 	newExpence.SetIdExpence(newExpenceJSON.IdExpence)
+	newExpence.SetIdAccaunt(newExpenceJSON.IdAccaunt)
+	newExpence.SetBankName(newExpenceJSON.BankName)
+	newExpence.SetExternalId(newExpenceJSON.ExternalId)
+	newExpence.SetTags(newExpenceJSON.Tags)
+	newExpence.SetSplits(expenceSplitsFromJSON(newExpenceJSON.Splits))
 	newExpence.SetGroupExpence(newExpenceJSON.GroupExpence)
+	newExpence.SetIdCategory(newExpenceJSON.IdCategory)
 	newExpence.SetTitleExpence(newExpenceJSON.TitleExpence)
 	newExpence.SetDescriptionExpence(newExpenceJSON.DescriptionExpence)
 	newExpence.SetRepeat(newExpenceJSON.Repeat)
+	newExpence.SetRrule(newExpenceJSON.Rrule)
 	newExpence.SetAmount(newExpenceJSON.Amount)
 	newExpence.SetUpdBy(newExpenceJSON.UpdBy)
 
@@ -516,7 +552,30 @@ func ExpencePost(w http.ResponseWriter, r *http.Request, logger *logger.Combined
 		newExpence.SetDateActualTo(dateActualTo)
 	}
 
-	debugging.Expences = append(debugging.Expences, newExpence)
+	if err := services.NormalizeExpenceRule(newExpence); err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusBadRequest)
+		return
+	}
+	newExpenceJSON.Repeat = newExpence.GetRepeat()
+	newExpenceJSON.Rrule = newExpence.GetRrule()
+
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
+	if err := expenceService.AddNewExpence(newExpence); err != nil {
+		logger.Error("Error adding expence", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
+		return
+	}
+	newExpenceJSON.GroupExpence = newExpence.GetGroupExpence()
+	newExpenceJSON.IdCategory = newExpence.GetIdCategory()
+	newExpenceJSON.Tags = newExpence.GetTags()
+	newExpenceJSON.SuggestedGroup = newExpence.GetSuggestedGroup()
+	newExpenceJSON.SuggestedScore = newExpence.GetSuggestedScore()
+	if expenceJSON, err := newExpence.ToJSON(); err == nil {
+		newExpenceJSON.Splits = expenceJSON.Splits
+	}
+
+	audit(r, logger, "expence", newExpence.GetIdExpence(), models.AuditCreate, nil, newExpenceJSON)
+	checkBudgets(logger)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -569,34 +628,21 @@ func ExpencePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		return
 	}
 
-	var oldExpence *models.Expence
-	for i, expence := range debugging.Expences {
-		if expence.GetIdExpence() == idExpence {
-			oldExpence = expence
-
-			debugging.Expences = append(debugging.Expences[:i], debugging.Expences[i+1:]...)
-			break
-		}
-	}
-
-	if oldExpence == nil {
-		http.Error(w, u.JsonErrorResponse("Expence not found"), http.StatusNotFound)
-		return
-	}
-
-	oldExpenceJSON, err := oldExpence.ToJSON()
-	if err != nil {
-		logger.Error("Error converting old expence to JSON", "error", err)
-		http.Error(w, u.JsonErrorResponse("Error processing old expence"), http.StatusInternalServerError)
-		return
-	}
+	updatedExpenceJSON.UpdBy = requestUpdBy(r)
 
 	newExpence := &models.Expence{}
-	newExpence.SetIdExpence(updatedExpenceJSON.IdExpence)
+	newExpence.SetIdExpence(idExpence)
+	newExpence.SetIdAccaunt(updatedExpenceJSON.IdAccaunt)
+	newExpence.SetBankName(updatedExpenceJSON.BankName)
+	newExpence.SetExternalId(updatedExpenceJSON.ExternalId)
+	newExpence.SetTags(updatedExpenceJSON.Tags)
+	newExpence.SetSplits(expenceSplitsFromJSON(updatedExpenceJSON.Splits))
 	newExpence.SetGroupExpence(updatedExpenceJSON.GroupExpence)
+	newExpence.SetIdCategory(updatedExpenceJSON.IdCategory)
 	newExpence.SetTitleExpence(updatedExpenceJSON.TitleExpence)
 	newExpence.SetDescriptionExpence(updatedExpenceJSON.DescriptionExpence)
 	newExpence.SetRepeat(updatedExpenceJSON.Repeat)
+	newExpence.SetRrule(updatedExpenceJSON.Rrule)
 	newExpence.SetAmount(updatedExpenceJSON.Amount)
 	newExpence.SetUpdBy(updatedExpenceJSON.UpdBy)
 
@@ -618,7 +664,36 @@ func ExpencePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		logger.Error("Error parsing DateActualTo", "error", err)
 	}
 
-	debugging.Expences = append(debugging.Expences, newExpence)
+	if err := services.NormalizeExpenceRule(newExpence); err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusBadRequest)
+		return
+	}
+	updatedExpenceJSON.Repeat = newExpence.GetRepeat()
+	updatedExpenceJSON.Rrule = newExpence.GetRrule()
+
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
+	oldExpence, err := expenceService.UpdateExpence(newExpence)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	updatedExpenceJSON.GroupExpence = newExpence.GetGroupExpence()
+	updatedExpenceJSON.IdCategory = newExpence.GetIdCategory()
+	updatedExpenceJSON.SuggestedGroup = newExpence.GetSuggestedGroup()
+	updatedExpenceJSON.SuggestedScore = newExpence.GetSuggestedScore()
+	if expenceJSON, err := newExpence.ToJSON(); err == nil {
+		updatedExpenceJSON.Splits = expenceJSON.Splits
+	}
+
+	oldExpenceJSON, err := oldExpence.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old expence to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old expence"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "expence", idExpence, models.AuditUpdate, oldExpenceJSON, updatedExpenceJSON)
+	checkBudgets(logger)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -664,17 +739,9 @@ func ExpenceDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combin
 		return
 	}
 
-	var oldExpence *models.Expence
-	for i, expence := range debugging.Expences {
-		if expence.GetIdExpence() == idExpence {
-			oldExpence = expence
-
-			debugging.Expences = append(debugging.Expences[:i], debugging.Expences[i+1:]...)
-			break
-		}
-	}
-
-	if oldExpence == nil {
+	expenceService := services.NewExpenceService().WithScope(requestScope(r))
+	oldExpence, err := expenceService.DeleteExpence(idExpence, requestUpdBy(r))
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Expence not found"), http.StatusNotFound)
 		return
 	}
@@ -686,6 +753,8 @@ func ExpenceDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combin
 		return
 	}
 
+	audit(r, logger, "expence", idExpence, models.AuditDelete, oldExpenceJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -9,9 +9,9 @@ import (
 	"time"
 
 	"github.com/helltale/api-finances/config"
-	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
 	u "github.com/helltale/api-finances/internal/utils"
 )
 
@@ -28,12 +28,7 @@ func GoalGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 
 	w.Header().Set("Content-Type", "application/json")
 
-	var goals []*models.Goal
-	if config.AppMode == "debug" {
-		goals = debugging.Goals
-	} else {
-		goals = []*models.Goal{}
-	}
+	goals := services.NewGoalService().WithScope(requestScope(r)).GetAllGoals()
 
 	response := make([]models.GoalJSON, 0, len(goals))
 	for _, goal := range goals {
@@ -80,17 +75,8 @@ func GoalGetByIdGoal(w http.ResponseWriter, r *http.Request, logger *logger.Comb
 		return
 	}
 
-	var foundGoal *models.Goal
-	if config.AppMode == "debug" {
-		for _, goal := range debugging.Goals {
-			if goal.GetIdGoal() == idGoal {
-				foundGoal = goal
-				break
-			}
-		}
-	}
-
-	if foundGoal == nil {
+	foundGoal, err := services.NewGoalService().WithScope(requestScope(r)).GetGoalById(idGoal)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Goal not found"), http.StatusNotFound)
 		return
 	}
@@ -137,25 +123,18 @@ func GoalGetByIdAccount(w http.ResponseWriter, r *http.Request, logger *logger.C
 		return
 	}
 
-	var goals []*models.Goal
-	if config.AppMode == "debug" {
-		goals = debugging.Goals
-	} else {
-		goals = []*models.Goal{}
-	}
+	goals := services.NewGoalService().WithScope(requestScope(r)).GetGoalsByAccount(idAccaunt)
 
 	var goalsByAccountId []models.GoalJSON
 	for _, goal := range goals {
-		if goal.GetIdAccaunt() == idAccaunt {
-			goalJSON, err := goal.ToJSON()
-			if err != nil {
-				logger.Error("Error converting goal to JSON", "error", err)
-
-				http.Error(w, u.JsonErrorResponse("Error converting goal to JSON"), http.StatusInternalServerError)
-				return
-			}
-			goalsByAccountId = append(goalsByAccountId, *goalJSON)
+		goalJSON, err := goal.ToJSON()
+		if err != nil {
+			logger.Error("Error converting goal to JSON", "error", err)
+
+			http.Error(w, u.JsonErrorResponse("Error converting goal to JSON"), http.StatusInternalServerError)
+			return
 		}
+		goalsByAccountId = append(goalsByAccountId, *goalJSON)
 	}
 
 	if len(goalsByAccountId) == 0 {
@@ -213,14 +192,7 @@ func GoalGetByDateBetween(w http.ResponseWriter, r *http.Request, logger *logger
 		}
 	}
 
-	var foundGoals []*models.Goal
-	if config.AppMode == "debug" {
-		for _, goal := range debugging.Goals {
-			if goal.GetDate().After(startDate) && goal.GetDate().Before(endDate) {
-				foundGoals = append(foundGoals, goal)
-			}
-		}
-	}
+	foundGoals := services.NewGoalService().WithScope(requestScope(r)).GetGoalsByDateRange(startDate, endDate)
 
 	if len(foundGoals) == 0 {
 		http.Error(w, u.JsonErrorResponse("No goals found in the specified date range"), http.StatusNotFound)
@@ -250,7 +222,7 @@ func GoalGetByDateBetween(w http.ResponseWriter, r *http.Request, logger *logger
 	logger.Info("Successfully retrieved goals by date range", "status", http.StatusOK)
 }
 
-// get current
+// get current with progress
 func GoalGetCurrent(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
 	logger.Info("GetCurrentGoals called", "method", r.Method)
 
@@ -261,30 +233,18 @@ func GoalGetCurrent(w http.ResponseWriter, r *http.Request, logger *logger.Combi
 		return
 	}
 
-	var foundGoals []*models.Goal
-	if config.AppMode == "debug" {
-		for _, goal := range debugging.Goals {
-			if goal.GetDateActualTo().Equal(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)) {
-				foundGoals = append(foundGoals, goal)
-			}
-		}
-	}
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	goalsJSON, err := goalService.GetCurrentProgress()
+	if err != nil {
+		logger.Error("Error converting goal to JSON", "error", err)
 
-	if len(foundGoals) == 0 {
-		http.Error(w, u.JsonErrorResponse("No current goals found"), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse("Error converting goal to JSON"), http.StatusInternalServerError)
 		return
 	}
 
-	var goalsJSON []models.GoalJSON
-	for _, goal := range foundGoals {
-		goalJSON, err := goal.ToJSON()
-		if err != nil {
-			logger.Error("Error converting goal to JSON", "error", err)
-
-			http.Error(w, u.JsonErrorResponse("Error converting goal to JSON"), http.StatusInternalServerError)
-			return
-		}
-		goalsJSON = append(goalsJSON, *goalJSON)
+	if len(goalsJSON) == 0 {
+		http.Error(w, u.JsonErrorResponse("No current goals found"), http.StatusNotFound)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -330,14 +290,7 @@ func GoalGetByAmountBetween(w http.ResponseWriter, r *http.Request, logger *logg
 		return
 	}
 
-	var foundGoals []*models.Goal
-	if config.AppMode == "debug" {
-		for _, goal := range debugging.Goals {
-			if goal.GetAmount() >= minAmount && goal.GetAmount() <= maxAmount {
-				foundGoals = append(foundGoals, goal)
-			}
-		}
-	}
+	foundGoals := services.NewGoalService().WithScope(requestScope(r)).GetGoalsByAmountRange(minAmount, maxAmount)
 
 	if len(foundGoals) == 0 {
 		http.Error(w, u.JsonErrorResponse("No goals found in the specified amount range"), http.StatusNotFound)
@@ -392,14 +345,7 @@ func GoalGetByAmountLess(w http.ResponseWriter, r *http.Request, logger *logger.
 		return
 	}
 
-	var foundGoals []*models.Goal
-	if config.AppMode == "debug" {
-		for _, goal := range debugging.Goals {
-			if goal.GetAmount() < maxAmount {
-				foundGoals = append(foundGoals, goal)
-			}
-		}
-	}
+	foundGoals := services.NewGoalService().WithScope(requestScope(r)).GetGoalsByMaxAmount(maxAmount)
 
 	if len(foundGoals) == 0 {
 		http.Error(w, u.JsonErrorResponse("No goals found below the specified amount"), http.StatusNotFound)
@@ -454,14 +400,7 @@ func GoalGetByAmountMore(w http.ResponseWriter, r *http.Request, logger *logger.
 		return
 	}
 
-	var foundGoals []*models.Goal
-	if config.AppMode == "debug" {
-		for _, goal := range debugging.Goals {
-			if goal.GetAmount() > minAmount {
-				foundGoals = append(foundGoals, goal)
-			}
-		}
-	}
+	foundGoals := services.NewGoalService().WithScope(requestScope(r)).GetGoalsByMinAmount(minAmount)
 
 	if len(foundGoals) == 0 {
 		http.Error(w, u.JsonErrorResponse("No goals found above the specified amount"), http.StatusNotFound)
@@ -511,6 +450,8 @@ func GoalPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLog
 		return
 	}
 
+	newGoalJSON.UpdBy = requestUpdBy(r)
+
 	newGoal := &models.Goal{}
 	newGoal.SetIdGoal(newGoalJSON.IdGoal)
 	newGoal.SetIdAccaunt(newGoalJSON.IdAccaunt)
@@ -527,7 +468,14 @@ func GoalPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLog
 		newGoal.SetDateActualTo(dateActualTo)
 	}
 
-	debugging.Goals = append(debugging.Goals, newGoal)
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	if err := goalService.AddNewGoal(newGoal); err != nil {
+		logger.Error("Error adding goal", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	audit(r, logger, "goal", newGoal.GetIdGoal(), models.AuditCreate, nil, newGoalJSON)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -580,27 +528,7 @@ func GoalPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogg
 		return
 	}
 
-	var oldGoal *models.Goal
-	for i, goal := range debugging.Goals {
-		if goal.GetIdGoal() == idGoal {
-			oldGoal = goal
-
-			debugging.Goals = append(debugging.Goals[:i], debugging.Goals[i+1:]...)
-			break
-		}
-	}
-
-	if oldGoal == nil {
-		http.Error(w, u.JsonErrorResponse("Goal not found"), http.StatusNotFound)
-		return
-	}
-
-	oldGoalJSON, err := oldGoal.ToJSON()
-	if err != nil {
-		logger.Error("Error converting old goal to JSON", "error", err)
-		http.Error(w, u.JsonErrorResponse("Error processing old goal"), http.StatusInternalServerError)
-		return
-	}
+	updatedGoalJSON.UpdBy = requestUpdBy(r)
 
 	newGoal := &models.Goal{}
 	newGoal.SetIdGoal(updatedGoalJSON.IdGoal)
@@ -626,7 +554,21 @@ func GoalPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogg
 		logger.Error("Error parsing DateActualTo", "error", err)
 	}
 
-	debugging.Goals = append(debugging.Goals, newGoal)
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	oldGoal, err := goalService.UpdateGoal(idGoal, newGoal)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldGoalJSON, err := oldGoal.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old goal to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old goal"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "goal", idGoal, models.AuditUpdate, oldGoalJSON, updatedGoalJSON)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -672,18 +614,10 @@ func GoalDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		return
 	}
 
-	var oldGoal *models.Goal
-	for i, goal := range debugging.Goals {
-		if goal.GetIdGoal() == idGoal {
-			oldGoal = goal
-
-			debugging.Goals = append(debugging.Goals[:i], debugging.Goals[i+1:]...)
-			break
-		}
-	}
-
-	if oldGoal == nil {
-		http.Error(w, u.JsonErrorResponse("Goal not found"), http.StatusNotFound)
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	oldGoal, err := goalService.DeleteGoal(idGoal, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
@@ -694,6 +628,8 @@ func GoalDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		return
 	}
 
+	audit(r, logger, "goal", idGoal, models.AuditDelete, oldGoalJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -712,3 +648,160 @@ func GoalDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 
 	logger.Info("Successfully deleted goal", "status", http.StatusOK)
 }
+
+// saved, required saving and status (/goal/id/{id}/progress)
+func GoalGetProgress(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetGoalProgress called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Error("Method not allowed", "method", r.Method)
+
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idGoalStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/goal/id/"), "/progress")
+	idGoal, err := strconv.ParseInt(idGoalStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_goal"), http.StatusBadRequest)
+		return
+	}
+
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	progress, err := goalService.GetProgressById(idGoal)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(progress); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved goal progress", "id_goal", idGoal, "status", http.StatusOK)
+}
+
+// contributions of goal (/goal/id/{id}/contributions)
+func GoalGetContributions(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetGoalContributions called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Error("Method not allowed", "method", r.Method)
+
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idGoalStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/goal/id/"), "/contributions")
+	idGoal, err := strconv.ParseInt(idGoalStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_goal"), http.StatusBadRequest)
+		return
+	}
+
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	contributions, err := goalService.GetContributions(idGoal)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	contributionsJSON := []models.GoalContributionJSON{}
+	for _, contribution := range contributions {
+		contributionJSON, err := contribution.ToJSON()
+		if err != nil {
+			logger.Error("Error converting goal contribution to JSON", "error", err)
+
+			http.Error(w, u.JsonErrorResponse("Error converting goal contribution to JSON"), http.StatusInternalServerError)
+			return
+		}
+		contributionsJSON = append(contributionsJSON, *contributionJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(contributionsJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved goal contributions", "id_goal", idGoal, "status", http.StatusOK)
+}
+
+// put money toward goal, explicit amount or share of remain
+func GoalContributionPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostGoalContribution called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Error("Method not allowed", "method", r.Method)
+
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newContributionJSON models.GoalContributionJSON
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&newContributionJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newContribution := &models.GoalContribution{}
+	newContribution.SetIdGoal(newContributionJSON.IdGoal)
+	newContribution.SetKind(newContributionJSON.Kind)
+	newContribution.SetAmount(newContributionJSON.Amount)
+	newContribution.SetIdRemains(newContributionJSON.IdRemains)
+	newContribution.SetShare(newContributionJSON.Share)
+	newContribution.SetUpdBy(requestUpdBy(r))
+
+	if date, err := time.Parse("2006-01-02T15:04:05Z", newContributionJSON.Date); err == nil {
+		newContribution.SetDate(date)
+	}
+
+	goalService := services.NewGoalService().WithScope(requestScope(r))
+	if err := goalService.AddContribution(newContribution); err != nil {
+		logger.Error("Error adding goal contribution", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	contributionJSON, err := newContribution.ToJSON()
+	if err != nil {
+		logger.Error("Error converting goal contribution to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting goal contribution to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "goal_contribution", newContribution.GetIdContribution(), models.AuditCreate, nil, contributionJSON)
+
+	progress, err := goalService.GetProgressById(newContribution.GetIdGoal())
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message":      "Goal contribution created successfully",
+		"contribution": contributionJSON,
+		"progress":     progress,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created goal contribution", "status", http.StatusCreated)
+}
@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// get all
+func GroupGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllGroups called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	groupService := services.NewGroupService()
+	groups := groupService.GetAllGroups(requestScope(r))
+
+	response := make([]models.GroupJSON, 0, len(groups))
+	for _, group := range groups {
+		groupJSON, err := group.ToJSON()
+		if err != nil {
+			logger.Error("Error converting group to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting group to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *groupJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved groups", "status", http.StatusOK)
+}
+
+// get one by id
+func GroupGetByIdGroup(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetGroupById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idGroupStr := strings.TrimPrefix(r.URL.Path, "/group/id/")
+	idGroup, err := strconv.ParseInt(idGroupStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_group"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService()
+	group, err := groupService.GetGroupById(idGroup)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	if scope := requestScope(r); scope != nil && !groupService.IsMember(idGroup, scope.GetIdAccaunt()) {
+		http.Error(w, u.JsonErrorResponse("group not found"), http.StatusNotFound)
+		return
+	}
+
+	groupJSON, err := group.ToJSON()
+	if err != nil {
+		logger.Error("Error converting group to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting group to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(groupJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved group", "status", http.StatusOK)
+}
+
+// create
+func GroupPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostGroup called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newGroupJSON models.GroupJSON
+	if err := json.NewDecoder(r.Body).Decode(&newGroupJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newGroup := &models.Group{}
+	newGroup.SetIdGroup(newGroupJSON.IdGroup)
+	newGroup.SetName(newGroupJSON.Name)
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	if err := groupService.AddNewGroup(newGroup); err != nil {
+		logger.Error("Error adding group", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
+		return
+	}
+
+	audit(r, logger, "group", newGroup.GetIdGroup(), models.AuditCreate, nil, newGroupJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Group created successfully",
+		"group":   newGroupJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created group", "status", http.StatusCreated)
+}
+
+// update
+func GroupPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutGroup called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	urlPath := r.URL.Path
+	index := strings.TrimPrefix(urlPath, "/group/update/")
+	idGroup, err := strconv.ParseInt(index, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var updatedGroupJSON models.GroupJSON
+	if err := json.NewDecoder(r.Body).Decode(&updatedGroupJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newGroup := &models.Group{}
+	newGroup.SetIdGroup(idGroup)
+	newGroup.SetName(updatedGroupJSON.Name)
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	oldGroup, err := groupService.UpdateGroup(newGroup)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldGroupJSON, err := oldGroup.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old group to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old group"), http.StatusInternalServerError)
+		return
+	}
+
+	newGroupJSON, err := newGroup.ToJSON()
+	if err != nil {
+		logger.Error("Error converting new group to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing new group"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "group", idGroup, models.AuditUpdate, oldGroupJSON, newGroupJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":   "Group updated successfully",
+		"old_group": oldGroupJSON,
+		"new_group": newGroupJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully updated group", "status", http.StatusOK)
+}
+
+// delete
+func GroupDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteGroup called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	index := strings.TrimPrefix(r.URL.Path, "/group/delete/")
+	idGroup, err := strconv.ParseInt(index, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	oldGroup, err := groupService.DeleteGroup(idGroup)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldGroupJSON, err := oldGroup.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old group to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old group"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "group", idGroup, models.AuditDelete, oldGroupJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":   "Group deleted successfully",
+		"old_group": oldGroupJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted group", "status", http.StatusOK)
+}
+
+// get members of group
+func GroupGetMembers(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetGroupMembers called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idGroupStr := strings.TrimPrefix(r.URL.Path, "/group/members/")
+	idGroup, err := strconv.ParseInt(idGroupStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_group"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService()
+	if scope := requestScope(r); scope != nil && !groupService.IsMember(idGroup, scope.GetIdAccaunt()) {
+		http.Error(w, u.JsonErrorResponse("group not found"), http.StatusNotFound)
+		return
+	}
+
+	members, err := groupService.GetMembers(idGroup)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	response := make([]models.GroupMemberJSON, 0, len(members))
+	for _, member := range members {
+		memberJSON, err := member.ToJSON()
+		if err != nil {
+			logger.Error("Error converting group member to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting group member to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *memberJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved group members", "status", http.StatusOK)
+}
+
+// invite member, the account joins once it accepts
+func GroupMemberPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostGroupMember called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var memberJSON models.GroupMemberJSON
+	if err := json.NewDecoder(r.Body).Decode(&memberJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	invite, err := groupService.InviteMember(memberJSON.IdGroup, memberJSON.IdAccaunt, memberJSON.Role)
+	if err != nil {
+		logger.Error("Error inviting group member", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
+		return
+	}
+
+	inviteJSON, err := invite.ToJSON()
+	if err != nil {
+		logger.Error("Error converting group invite to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting group invite to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "group_invite", inviteJSON.IdGroup, models.AuditCreate, nil, inviteJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Member invited successfully",
+		"invite":  inviteJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully invited group member", "status", http.StatusCreated)
+}
+
+// invites waiting for the caller
+func GroupInviteGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllGroupInvites called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	invites := services.NewGroupService().WithScope(requestScope(r)).GetInvites()
+
+	response := make([]models.GroupInviteJSON, 0, len(invites))
+	for _, invite := range invites {
+		inviteJSON, err := invite.ToJSON()
+		if err != nil {
+			logger.Error("Error converting group invite to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting group invite to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *inviteJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved group invites", "status", http.StatusOK)
+}
+
+// caller accepts an invite and joins the group
+func GroupInviteAccept(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("AcceptGroupInvite called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var inviteJSON models.GroupInviteJSON
+	if err := json.NewDecoder(r.Body).Decode(&inviteJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	member, err := groupService.AcceptInvite(inviteJSON.IdGroup)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	memberJSON, err := member.ToJSON()
+	if err != nil {
+		logger.Error("Error converting group member to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting group member to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "group_member", memberJSON.IdGroup, models.AuditCreate, nil, memberJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Member added successfully",
+		"member":  memberJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully accepted group invite", "status", http.StatusCreated)
+}
+
+// caller declines an invite
+func GroupInviteDecline(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeclineGroupInvite called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var inviteJSON models.GroupInviteJSON
+	if err := json.NewDecoder(r.Body).Decode(&inviteJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	invite, err := groupService.DeclineInvite(inviteJSON.IdGroup)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	declinedJSON, err := invite.ToJSON()
+	if err != nil {
+		logger.Error("Error converting group invite to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting group invite to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "group_invite", declinedJSON.IdGroup, models.AuditDelete, declinedJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Invite declined successfully",
+		"invite":  declinedJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully declined group invite", "status", http.StatusOK)
+}
+
+// remove member
+func GroupMemberDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteGroupMember called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var memberJSON models.GroupMemberJSON
+	if err := json.NewDecoder(r.Body).Decode(&memberJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	if _, err := groupService.RemoveMember(memberJSON.IdGroup, memberJSON.IdAccaunt); err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	audit(r, logger, "group_member", memberJSON.IdGroup, models.AuditDelete, memberJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Member removed successfully",
+		"member":  memberJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully removed group member", "status", http.StatusOK)
+}
+
+// change role of member
+func GroupMemberRolePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutGroupMemberRole called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var memberJSON models.GroupMemberJSON
+	if err := json.NewDecoder(r.Body).Decode(&memberJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	groupService := services.NewGroupService().WithScope(requestScope(r))
+	if _, err := groupService.SetMemberRole(memberJSON.IdGroup, memberJSON.IdAccaunt, memberJSON.Role); err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	audit(r, logger, "group_member", memberJSON.IdGroup, models.AuditUpdate, nil, memberJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Member role changed successfully",
+		"member":  memberJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully changed group member role", "status", http.StatusOK)
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// largest statement accepted
+const maxImportSize = 10 << 20
+
+func importProfileFromJSON(profileJSON models.ImportProfileJSON) *models.ImportProfile {
+	profile := &models.ImportProfile{}
+	profile.SetIdProfile(profileJSON.IdProfile)
+	profile.SetIdAccaunt(profileJSON.IdAccaunt)
+	profile.SetName(profileJSON.Name)
+	profile.SetBankName(profileJSON.BankName)
+	profile.SetDelimiter(profileJSON.Delimiter)
+	profile.SetSkipRows(profileJSON.SkipRows)
+	profile.SetHasHeader(profileJSON.HasHeader)
+	profile.SetColumns(profileJSON.Columns)
+	profile.SetDateFormat(profileJSON.DateFormat)
+	profile.SetDecimalSeparator(profileJSON.DecimalSeparator)
+	profile.SetSignConvention(profileJSON.SignConvention)
+	profile.SetEncoding(profileJSON.Encoding)
+	profile.SetUpdBy(profileJSON.UpdBy)
+	return profile
+}
+
+// statement from body, account and dry run from ?account=&dry_run=
+func importRequest(w http.ResponseWriter, r *http.Request) (int64, bool, []byte, bool) {
+	idAccaunt, err := strconv.ParseInt(r.URL.Query().Get("account"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid account"), http.StatusBadRequest)
+		return 0, false, nil, false
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid dry_run"), http.StatusBadRequest)
+			return 0, false, nil, false
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Statement is too large or unreadable"), http.StatusBadRequest)
+		return 0, false, nil, false
+	}
+	if len(data) == 0 {
+		http.Error(w, u.JsonErrorResponse("Statement is empty"), http.StatusBadRequest)
+		return 0, false, nil, false
+	}
+	return idAccaunt, dryRun, data, true
+}
+
+// audit created records and answer with import result
+func importResponse(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, result *models.ImportResultJSON) {
+	status := http.StatusOK
+	if !result.DryRun {
+		for i := range result.Expences {
+			audit(r, logger, "expence", result.Expences[i].IdExpence, models.AuditCreate, nil, &result.Expences[i])
+		}
+		for i := range result.Incomes {
+			audit(r, logger, "income", result.Incomes[i].IdIncome, models.AuditCreate, nil, &result.Incomes[i])
+		}
+		for i := range result.Superseded {
+			audit(r, logger, "income", result.Superseded[i].IdIncome, models.AuditDelete, &result.Superseded[i], nil)
+		}
+		before := result.RemainBefore
+		for i := range result.Remains {
+			if before != nil {
+				audit(r, logger, "remain", result.Remains[i].IdRemains, models.AuditUpdate, before, &result.Remains[i])
+			} else {
+				audit(r, logger, "remain", result.Remains[i].IdRemains, models.AuditCreate, nil, &result.Remains[i])
+			}
+			before = &result.Remains[i]
+		}
+		if len(result.Expences) > 0 {
+			checkBudgets(logger)
+		}
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully imported statement", "format", result.Format, "dry_run", result.DryRun,
+		"expences", len(result.Expences), "incomes", len(result.Incomes), "skipped", len(result.Skipped), "duplicates", len(result.Duplicates), "status", status)
+}
+
+// import CSV read by ?profile= into ?account=, ?dry_run=true only previews
+func ImportCSV(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ImportCSV called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idProfile, err := strconv.ParseInt(r.URL.Query().Get("profile"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid profile"), http.StatusBadRequest)
+		return
+	}
+	idAccaunt, dryRun, data, ok := importRequest(w, r)
+	if !ok {
+		return
+	}
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	result, err := importService.ImportCSV(idAccaunt, idProfile, data, dryRun, requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error importing CSV", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	importResponse(w, r, logger, result)
+}
+
+// import OFX into ?account=, ?bank= names the card, ?dry_run=true only previews
+func ImportOFX(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ImportOFX called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idAccaunt, dryRun, data, ok := importRequest(w, r)
+	if !ok {
+		return
+	}
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	result, err := importService.ImportOFX(idAccaunt, r.URL.Query().Get("bank"), data, dryRun, requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error importing OFX", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	importResponse(w, r, logger, result)
+}
+
+// import QIF into ?account=, ?bank= names the card, ?date_format= is go layout
+// of dates, ?dry_run=true only previews
+func ImportQIF(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ImportQIF called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idAccaunt, dryRun, data, ok := importRequest(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	importService := services.NewImportService().WithScope(requestScope(r))
+	result, err := importService.ImportQIF(idAccaunt, query.Get("bank"), query.Get("date_format"), data, dryRun, requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error importing QIF", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	importResponse(w, r, logger, result)
+}
+
+// import camt.053 into ?account=, ?bank= names the card, ?dry_run=true only
+// previews
+func ImportCAMT(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ImportCAMT called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idAccaunt, dryRun, data, ok := importRequest(w, r)
+	if !ok {
+		return
+	}
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	result, err := importService.ImportCAMT(idAccaunt, r.URL.Query().Get("bank"), data, dryRun, requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error importing camt.053", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	importResponse(w, r, logger, result)
+}
+
+// import MT940 into ?account=, ?bank= names the card, ?dry_run=true only
+// previews
+func ImportMT940(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ImportMT940 called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idAccaunt, dryRun, data, ok := importRequest(w, r)
+	if !ok {
+		return
+	}
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	result, err := importService.ImportMT940(idAccaunt, r.URL.Query().Get("bank"), data, dryRun, requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error importing MT940", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	importResponse(w, r, logger, result)
+}
+
+// get all
+func ImportProfileGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllImportProfiles called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+
+	response := []models.ImportProfileJSON{}
+	for _, profile := range importService.GetAllProfiles() {
+		profileJSON, err := profile.ToJSON()
+		if err != nil {
+			logger.Error("Error converting import profile to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting import profile to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *profileJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved import profiles", "status", http.StatusOK)
+}
+
+// get one by id
+func ImportProfileGetById(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetImportProfileById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idProfile, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/import/profile/id/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_profile"), http.StatusBadRequest)
+		return
+	}
+
+	profile, err := services.NewImportService().WithScope(requestScope(r)).GetProfileById(idProfile)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	profileJSON, err := profile.ToJSON()
+	if err != nil {
+		logger.Error("Error converting import profile to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting import profile to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(profileJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved import profile", "status", http.StatusOK)
+}
+
+// create
+func ImportProfilePost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostImportProfile called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newProfileJSON models.ImportProfileJSON
+	if err := json.NewDecoder(r.Body).Decode(&newProfileJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newProfileJSON.UpdBy = requestUpdBy(r)
+	newProfile := importProfileFromJSON(newProfileJSON)
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	if err := importService.AddNewProfile(newProfile); err != nil {
+		logger.Error("Error adding import profile", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	profileJSON, err := newProfile.ToJSON()
+	if err != nil {
+		logger.Error("Error converting import profile to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting import profile to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "import_profile", newProfile.GetIdProfile(), models.AuditCreate, nil, profileJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Import profile created successfully",
+		"profile": profileJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created import profile", "status", http.StatusCreated)
+}
+
+// update
+func ImportProfilePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutImportProfile called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idProfile, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/import/profile/update/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var updatedProfileJSON models.ImportProfileJSON
+	if err := json.NewDecoder(r.Body).Decode(&updatedProfileJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	updatedProfileJSON.UpdBy = requestUpdBy(r)
+	newProfile := importProfileFromJSON(updatedProfileJSON)
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	oldProfile, err := importService.UpdateProfile(idProfile, newProfile)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	oldProfileJSON, err := oldProfile.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old import profile to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old import profile"), http.StatusInternalServerError)
+		return
+	}
+	newProfileJSON, err := newProfile.ToJSON()
+	if err != nil {
+		logger.Error("Error converting import profile to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting import profile to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "import_profile", idProfile, models.AuditUpdate, oldProfileJSON, newProfileJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":     "Import profile updated successfully",
+		"old_profile": oldProfileJSON,
+		"new_profile": newProfileJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully updated import profile", "status", http.StatusOK)
+}
+
+// delete
+func ImportProfileDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteImportProfile called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idProfile, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/import/profile/delete/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	importService := services.NewImportService().WithScope(requestScope(r))
+	oldProfile, err := importService.DeleteProfile(idProfile, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldProfileJSON, err := oldProfile.ToJSON()
+	if err != nil {
+		logger.Error("Error converting import profile to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting import profile to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "import_profile", idProfile, models.AuditDelete, oldProfileJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Import profile deleted successfully",
+		"profile": oldProfileJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted import profile", "status", http.StatusOK)
+}
@@ -8,9 +8,9 @@ import (
 	"time"
 
 	"github.com/helltale/api-finances/config"
-	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
 	u "github.com/helltale/api-finances/internal/utils"
 )
 
@@ -27,17 +27,17 @@ func IncomeGetAll(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 		return
 	}
 
+	tags := requestTags(r)
+
 	w.Header().Set("Content-Type", "application/json")
 
-	var incomes []*models.Income
-	if config.AppMode == "debug" {
-		incomes = debugging.Incomes
-	} else {
-		incomes = []*models.Income{}
-	}
+	incomes := services.NewIncomeService().WithScope(requestScope(r)).GetAllIncomes()
 
 	response := make([]models.IncomeJSON, 0, len(incomes))
 	for _, income := range incomes {
+		if !services.HasTags(income.GetTags(), tags) {
+			continue
+		}
 		incomeJSON, err := income.ToJSON()
 		if err != nil {
 			logger.Error("Error converting income to JSON", "error", err)
@@ -77,17 +77,8 @@ func IncomeGetByIdIncome(w http.ResponseWriter, r *http.Request, logger *logger.
 		return
 	}
 
-	var foundIncome *models.Income
-	if config.AppMode == "debug" {
-		for _, income := range debugging.Incomes {
-			if income.GetIdIncome() == idIncome {
-				foundIncome = income
-				break
-			}
-		}
-	}
-
-	if foundIncome == nil {
+	foundIncome, err := services.NewIncomeService().WithScope(requestScope(r)).GetIncomeById(idIncome)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Income not found"), http.StatusNotFound)
 		return
 	}
@@ -118,6 +109,8 @@ func IncomeGetByIdAccount(w http.ResponseWriter, r *http.Request, logger *logger
 		return
 	}
 
+	tags := requestTags(r)
+
 	idAccauntStr := strings.TrimPrefix(r.URL.Path, "/income/account/")
 	if idAccauntStr == "" {
 		http.Error(w, u.JsonErrorResponse("id_accaunt is required"), http.StatusBadRequest)
@@ -130,24 +123,20 @@ func IncomeGetByIdAccount(w http.ResponseWriter, r *http.Request, logger *logger
 		return
 	}
 
-	var incomes []*models.Income
-	if config.AppMode == "debug" {
-		incomes = debugging.Incomes
-	} else {
-		incomes = []*models.Income{}
-	}
+	incomes := services.NewIncomeService().WithScope(requestScope(r)).GetIncomesByAccount(idAccaunt)
 
 	var incomesByAccountId []models.IncomeJSON
 	for _, income := range incomes {
-		if income.GetIdAccaunt() == idAccaunt {
-			incomeJSON, err := income.ToJSON()
-			if err != nil {
-				logger.Error("Error converting income to JSON", "error", err)
-				http.Error(w, u.JsonErrorResponse("Error converting income to JSON"), http.StatusInternalServerError)
-				return
-			}
-			incomesByAccountId = append(incomesByAccountId, *incomeJSON)
+		if !services.HasTags(income.GetTags(), tags) {
+			continue
+		}
+		incomeJSON, err := income.ToJSON()
+		if err != nil {
+			logger.Error("Error converting income to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting income to JSON"), http.StatusInternalServerError)
+			return
 		}
+		incomesByAccountId = append(incomesByAccountId, *incomeJSON)
 	}
 
 	if len(incomesByAccountId) == 0 {
@@ -182,15 +171,27 @@ func IncomePost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		return
 	}
 
+	newIncomeJSON.UpdBy = requestUpdBy(r)
+
+	switch newIncomeJSON.Status {
+	case "", models.IncomeStatusReceived, models.IncomeStatusPending:
+	default:
+		http.Error(w, u.JsonErrorResponse("Invalid status, must be received or pending"), http.StatusBadRequest)
+		return
+	}
+
 	newIncome := &models.Income{}
 	newIncome.SetIdIncome(newIncomeJSON.IdIncome)
 	newIncome.SetIdAccaunt(newIncomeJSON.IdAccaunt)
+	newIncome.SetExternalId(newIncomeJSON.ExternalId)
+	newIncome.SetTags(newIncomeJSON.Tags)
 	newIncome.SetIdIncomeExpected(newIncomeJSON.IdIncomeExpected)
 	newIncome.SetAmount(newIncomeJSON.Amount)
 	newIncome.SetExpectedAmount(newIncomeJSON.ExpectedAmount)
 	newIncome.SetTypeIncome(newIncomeJSON.TypeIncome)
 	newIncome.SetIncomeMonthMonth(newIncomeJSON.IncomeMonthMonth)
 	newIncome.SetIncomeMonthDate(newIncomeJSON.IncomeMonthDate)
+	newIncome.SetStatus(newIncomeJSON.Status)
 	newIncome.SetUpdBy(newIncomeJSON.UpdBy)
 
 	if dateActualFrom, err := time.Parse("2006-01-02T15:04:05Z", newIncomeJSON.DateActualFrom); err == nil {
@@ -205,7 +206,16 @@ func IncomePost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		logger.Error("Error parsing DateActualTo", "error", err)
 	}
 
-	debugging.Incomes = append(debugging.Incomes, newIncome)
+	incomeService := services.NewIncomeService().WithScope(requestScope(r))
+	if err := incomeService.AddNewIncome(newIncome); err != nil {
+		logger.Error("Error adding income", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
+		return
+	}
+	newIncomeJSON.Tags = newIncome.GetTags()
+
+	audit(r, logger, "income", newIncome.GetIdIncome(), models.AuditCreate, nil, newIncomeJSON)
+	auditSuperseded(r, logger, incomeService.Superseded())
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -255,36 +265,27 @@ func IncomePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLo
 		return
 	}
 
-	var oldIncome *models.Income
-	for i, income := range debugging.Incomes {
-		if income.GetIdIncome() == idIncome {
-			oldIncome = income
-
-			debugging.Incomes = append(debugging.Incomes[:i], debugging.Incomes[i+1:]...)
-			break
-		}
-	}
+	updatedIncomeJSON.UpdBy = requestUpdBy(r)
 
-	if oldIncome == nil {
-		http.Error(w, u.JsonErrorResponse("Income not found"), http.StatusNotFound)
-		return
-	}
-
-	oldIncomeJSON, err := oldIncome.ToJSON()
-	if err != nil {
-		logger.Error("Error converting old income to JSON", "error", err)
-		http.Error(w, u.JsonErrorResponse("Error processing old income"), http.StatusInternalServerError)
+	switch updatedIncomeJSON.Status {
+	case "", models.IncomeStatusReceived, models.IncomeStatusPending:
+	default:
+		http.Error(w, u.JsonErrorResponse("Invalid status, must be received or pending"), http.StatusBadRequest)
 		return
 	}
 
 	newIncome := &models.Income{}
+	newIncome.SetIdIncome(idIncome)
 	newIncome.SetIdAccaunt(updatedIncomeJSON.IdAccaunt)
+	newIncome.SetExternalId(updatedIncomeJSON.ExternalId)
+	newIncome.SetTags(updatedIncomeJSON.Tags)
 	newIncome.SetIdIncomeExpected(updatedIncomeJSON.IdIncomeExpected)
 	newIncome.SetAmount(updatedIncomeJSON.Amount)
 	newIncome.SetExpectedAmount(updatedIncomeJSON.ExpectedAmount)
 	newIncome.SetTypeIncome(updatedIncomeJSON.TypeIncome)
 	newIncome.SetIncomeMonthMonth(updatedIncomeJSON.IncomeMonthMonth)
 	newIncome.SetIncomeMonthDate(updatedIncomeJSON.IncomeMonthDate)
+	newIncome.SetStatus(updatedIncomeJSON.Status)
 	newIncome.SetUpdBy(updatedIncomeJSON.UpdBy)
 
 	if dateActualFrom, err := time.Parse("2006-01-02T15:04:05Z", updatedIncomeJSON.DateActualFrom); err == nil {
@@ -299,7 +300,23 @@ func IncomePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLo
 		logger.Error("Error parsing DateActualTo", "error", err)
 	}
 
-	debugging.Incomes = append(debugging.Incomes, newIncome)
+	incomeService := services.NewIncomeService().WithScope(requestScope(r))
+	oldIncome, err := incomeService.UpdateIncome(newIncome)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+	updatedIncomeJSON.Tags = newIncome.GetTags()
+
+	oldIncomeJSON, err := oldIncome.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old income to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old income"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "income", idIncome, models.AuditUpdate, oldIncomeJSON, updatedIncomeJSON)
+	auditSuperseded(r, logger, incomeService.Superseded())
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -343,17 +360,9 @@ func IncomeDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 		return
 	}
 
-	var oldIncome *models.Income
-	for i, income := range debugging.Incomes {
-		if income.GetIdIncome() == idIncome {
-			oldIncome = income
-
-			debugging.Incomes = append(debugging.Incomes[:i], debugging.Incomes[i+1:]...)
-			break
-		}
-	}
-
-	if oldIncome == nil {
+	incomeService := services.NewIncomeService().WithScope(requestScope(r))
+	oldIncome, err := incomeService.DeleteIncome(idIncome, requestUpdBy(r))
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Income not found"), http.StatusNotFound)
 		return
 	}
@@ -365,6 +374,8 @@ func IncomeDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 		return
 	}
 
+	audit(r, logger, "income", idIncome, models.AuditDelete, oldIncomeJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -383,3 +394,15 @@ func IncomeDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 
 	logger.Info("Successfully deleted income", "status", http.StatusOK)
 }
+
+// pending placeholders replaced by a received income are audited as deletes
+func auditSuperseded(r *http.Request, logger *logger.CombinedLogger, incomes []*models.Income) {
+	for _, income := range incomes {
+		incomeJSON, err := income.ToJSON()
+		if err != nil {
+			logger.Error("Error converting superseded income to JSON", "id", income.GetIdIncome(), "error", err)
+			continue
+		}
+		audit(r, logger, "income", income.GetIdIncome(), models.AuditDelete, incomeJSON, nil)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/helltale/api-finances/config"
-	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
 	"github.com/helltale/api-finances/internal/services"
@@ -29,12 +28,7 @@ func IncomesExpectedGetAll(w http.ResponseWriter, r *http.Request, logger *logge
 
 	w.Header().Set("Content-Type", "application/json")
 
-	var incomesExpected []*models.IncomeExpected
-	if config.AppMode == "debug" {
-		incomesExpected = debugging.IncomesExpected
-	} else {
-		incomesExpected = []*models.IncomeExpected{}
-	}
+	incomesExpected := services.NewIncomeExpectedService().WithScope(requestScope(r)).GetAllIncomesExpected()
 
 	response := make([]models.IncomeExpectedJSON, 0, len(incomesExpected))
 	for _, incomeExpected := range incomesExpected {
@@ -78,17 +72,8 @@ func IncomeExpectedGetByIncomeExpectedId(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	var foundIncomeExpected *models.IncomeExpected
-	if config.AppMode == "debug" {
-		for _, incomeExpected := range debugging.IncomesExpected {
-			if incomeExpected.GetIdIncomeEx() == idIncomeEx {
-				foundIncomeExpected = incomeExpected
-				break
-			}
-		}
-	}
-
-	if foundIncomeExpected == nil {
+	foundIncomeExpected, err := services.NewIncomeExpectedService().WithScope(requestScope(r)).GetIncomeExpectedById(idIncomeEx)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Income not found"), http.StatusNotFound)
 		return
 	}
@@ -133,24 +118,17 @@ func IncomesExpectedGetByAccountId(w http.ResponseWriter, r *http.Request, logge
 		return
 	}
 
-	var incomesExpected []*models.IncomeExpected
-	if config.AppMode == "debug" {
-		incomesExpected = debugging.IncomesExpected
-	} else {
-		incomesExpected = []*models.IncomeExpected{}
-	}
+	incomesExpected := services.NewIncomeExpectedService().WithScope(requestScope(r)).GetIncomesExpectedByAccount(idAccaunt)
 
 	var incomesByAccountId []models.IncomeExpectedJSON
 	for _, incomeExpected := range incomesExpected {
-		if incomeExpected.GetIdAccaunt() == idAccaunt {
-			incomeExpectedJSON, err := incomeExpected.ToJSON()
-			if err != nil {
-				logger.Error("Error converting expected income to JSON", "error", err)
-				http.Error(w, u.JsonErrorResponse("Error converting expected income to JSON"), http.StatusInternalServerError)
-				return
-			}
-			incomesByAccountId = append(incomesByAccountId, *incomeExpectedJSON)
+		incomeExpectedJSON, err := incomeExpected.ToJSON()
+		if err != nil {
+			logger.Error("Error converting expected income to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting expected income to JSON"), http.StatusInternalServerError)
+			return
 		}
+		incomesByAccountId = append(incomesByAccountId, *incomeExpectedJSON)
 	}
 
 	if len(incomesByAccountId) == 0 {
@@ -186,6 +164,8 @@ func IncomeExpectedPost(w http.ResponseWriter, r *http.Request, logger *logger.C
 		return
 	}
 
+	newIncomeExpectedJSON.UpdBy = requestUpdBy(r)
+
 	newIncomeExpected := &models.IncomeExpected{}
 	newIncomeExpected.SetIdAccaunt(newIncomeExpectedJSON.IdAccaunt)
 	newIncomeExpected.SetIdIncomeEx(newIncomeExpectedJSON.IdIncomeEx)
@@ -202,13 +182,15 @@ func IncomeExpectedPost(w http.ResponseWriter, r *http.Request, logger *logger.C
 	}
 
 	// Use the service to add the new income expected
-	incomeExpectedService := services.NewIncomeExpectedService()
+	incomeExpectedService := services.NewIncomeExpectedService().WithScope(requestScope(r))
 	if err := incomeExpectedService.AddNewIncomeExpected(newIncomeExpected); err != nil {
 		logger.Error("Error adding income expected", "error", err)
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusConflict)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusConflict))
 		return
 	}
 
+	audit(r, logger, "income_expected", newIncomeExpected.GetIdIncomeEx(), models.AuditCreate, nil, newIncomeExpectedJSON)
+
 	// Response with success message and created income expected data
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -246,6 +228,8 @@ func IncomeExpectedPut(w http.ResponseWriter, r *http.Request, logger *logger.Co
 		return
 	}
 
+	updatedIncomeExpectedJSON.UpdBy = requestUpdBy(r)
+
 	// Convert JSON struct to model struct
 	newIncomeExpected := &models.IncomeExpected{}
 	newIncomeExpected.SetIdAccaunt(updatedIncomeExpectedJSON.IdAccaunt)
@@ -267,7 +251,7 @@ func IncomeExpectedPut(w http.ResponseWriter, r *http.Request, logger *logger.Co
 		logger.Error("Error parsing DateActualTo", "error", err)
 	}
 
-	incomeExpectedService := services.NewIncomeExpectedService()
+	incomeExpectedService := services.NewIncomeExpectedService().WithScope(requestScope(r))
 	oldIncomeExpected, err := incomeExpectedService.UpdateIncomeExpected(newIncomeExpected)
 	if err != nil {
 		logger.Error("Income expected not found", "error", err)
@@ -282,6 +266,8 @@ func IncomeExpectedPut(w http.ResponseWriter, r *http.Request, logger *logger.Co
 		return
 	}
 
+	audit(r, logger, "income_expected", newIncomeExpected.GetIdIncomeEx(), models.AuditUpdate, oldIncomeExpectedJSON, updatedIncomeExpectedJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -333,6 +319,8 @@ func IncomeExpectedVersionUpdate(w http.ResponseWriter, r *http.Request, logger
 		return
 	}
 
+	updatedIncomeExpectedJSON.UpdBy = requestUpdBy(r)
+
 	// Создаем новую сущность `IncomeExpected` с новыми данными
 	newIncomeExpected := &models.IncomeExpected{}
 	newIncomeExpected.SetIdAccaunt(updatedIncomeExpectedJSON.IdAccaunt)
@@ -342,11 +330,11 @@ func IncomeExpectedVersionUpdate(w http.ResponseWriter, r *http.Request, logger
 	newIncomeExpected.SetIncomeMonthDate(updatedIncomeExpectedJSON.IncomeMonthDate)
 	newIncomeExpected.SetUpdBy(updatedIncomeExpectedJSON.UpdBy)
 
-	incomeService := services.NewIncomeExpectedService()
+	incomeService := services.NewIncomeExpectedService().WithScope(requestScope(r))
 
 	oldIncomeExpected, err := incomeService.UpdateHistoryIncomeExpected(idIncomeEx, newIncomeExpected)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
@@ -357,6 +345,8 @@ func IncomeExpectedVersionUpdate(w http.ResponseWriter, r *http.Request, logger
 		return
 	}
 
+	audit(r, logger, "income_expected", idIncomeEx, models.AuditUpdate, oldIncomeExpectedJSON, updatedIncomeExpectedJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -396,9 +386,9 @@ func IncomeExpectedDelete(w http.ResponseWriter, r *http.Request, logger *logger
 		return
 	}
 
-	incomeExpectedService := services.NewIncomeExpectedService()
+	incomeExpectedService := services.NewIncomeExpectedService().WithScope(requestScope(r))
 
-	oldIncomeExpected, err := incomeExpectedService.DeleteIncomeExpected(deleteIncomeExpectedJSON.IdIncomeEx)
+	oldIncomeExpected, err := incomeExpectedService.DeleteIncomeExpected(deleteIncomeExpectedJSON.IdIncomeEx, requestUpdBy(r))
 	if err != nil {
 		logger.Error("Income expected not found", "error", err)
 		http.Error(w, u.JsonErrorResponse("Income expected not found"), http.StatusNotFound)
@@ -412,6 +402,8 @@ func IncomeExpectedDelete(w http.ResponseWriter, r *http.Request, logger *logger
 		return
 	}
 
+	audit(r, logger, "income_expected", deleteIncomeExpectedJSON.IdIncomeEx, models.AuditDelete, oldIncomeExpectedJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -453,12 +445,12 @@ func IncomeExpectedDeleteRestore(w http.ResponseWriter, r *http.Request, logger
 		return
 	}
 
-	incomeService := services.NewIncomeExpectedService()
+	incomeService := services.NewIncomeExpectedService().WithScope(requestScope(r))
 
 	// delete current and restore historical
 	restoredRecord, err := incomeService.DeleteAndRestorePreviousIncomeExpexted(idIncomeEx)
 	if err != nil {
-		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
@@ -469,6 +461,8 @@ func IncomeExpectedDeleteRestore(w http.ResponseWriter, r *http.Request, logger
 		return
 	}
 
+	audit(r, logger, "income_expected", idIncomeEx, models.AuditUpdate, nil, restoredRecordJSON)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -4,12 +4,29 @@ import (
 	"github.com/helltale/api-finances/config"
 	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/services"
 )
 
 func Init(logger *logger.CombinedLogger, config *config.Config) {
 	switch config.AppMode {
 	case "debug":
 		debugging.Init()
+		// free text groups of seeded records get categories
+		if mappings, err := services.NewCategoryService().Migrate("migration"); err != nil {
+			logger.Error("category migration failed", "error", err)
+		} else {
+			logger.Info("category migration done", "mapped", len(mappings))
+		}
+		if err := services.NewLedgerService().Backfill(); err != nil {
+			logger.Error("ledger backfill failed", "error", err)
+		}
+		// seeded accounts get api keys so the debug server is usable
+		authService := services.NewAuthService(config)
+		for _, account := range debugging.Accounts {
+			if _, plain, err := authService.CreateApiKey(account.GetIdAccaunt(), "debug"); err == nil {
+				logger.Info("debug api key", "id_accaunt", account.GetIdAccaunt(), "api_key", plain)
+			}
+		}
 		logger.Info("run in debug mode")
 	case "release":
 		logger.Info("run in release mode")
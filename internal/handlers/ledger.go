@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// get all ledger accounts
+func LedgerAccountGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllLedgerAccounts called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	ledgerAccounts := ledgerService.GetAllLedgerAccounts()
+
+	response := make([]models.LedgerAccountJSON, 0, len(ledgerAccounts))
+	for _, ledgerAccount := range ledgerAccounts {
+		ledgerAccountJSON, err := ledgerAccount.ToJSON()
+		if err != nil {
+			logger.Error("Error converting ledger account to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting ledger account to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *ledgerAccountJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved ledger accounts", "status", http.StatusOK)
+}
+
+// create ledger account
+func LedgerAccountPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostLedgerAccount called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newLedgerAccountJSON models.LedgerAccountJSON
+	if err := json.NewDecoder(r.Body).Decode(&newLedgerAccountJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newLedgerAccount := &models.LedgerAccount{}
+	newLedgerAccount.SetIdLedgerAccount(newLedgerAccountJSON.IdLedgerAccount)
+	newLedgerAccount.SetIdAccaunt(newLedgerAccountJSON.IdAccaunt)
+	newLedgerAccount.SetName(newLedgerAccountJSON.Name)
+	newLedgerAccount.SetTypeAccount(newLedgerAccountJSON.TypeAccount)
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	if err := ledgerService.AddNewLedgerAccount(newLedgerAccount); err != nil {
+		logger.Error("Error adding ledger account", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	ledgerAccountJSON, err := newLedgerAccount.ToJSON()
+	if err != nil {
+		logger.Error("Error converting ledger account to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting ledger account to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "ledger_account", newLedgerAccount.GetIdLedgerAccount(), models.AuditCreate, nil, ledgerAccountJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message":        "Ledger account created successfully",
+		"ledger_account": ledgerAccountJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created ledger account", "status", http.StatusCreated)
+}
+
+// get all journal entries
+func LedgerEntryGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllJournalEntries called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	entries := ledgerService.GetAllJournalEntries()
+
+	response := make([]models.JournalEntryJSON, 0, len(entries))
+	for _, entry := range entries {
+		entryJSON, err := entry.ToJSON()
+		if err != nil {
+			logger.Error("Error converting journal entry to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting journal entry to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *entryJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved journal entries", "status", http.StatusOK)
+}
+
+// get one journal entry by id
+func LedgerEntryGetByIdEntry(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetJournalEntryById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idEntryStr := strings.TrimPrefix(r.URL.Path, "/ledger/entry/id/")
+	idEntry, err := strconv.ParseInt(idEntryStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_entry"), http.StatusBadRequest)
+		return
+	}
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	entry, err := ledgerService.GetJournalEntryById(idEntry)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	entryJSON, err := entry.ToJSON()
+	if err != nil {
+		logger.Error("Error converting journal entry to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting journal entry to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entryJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved journal entry", "status", http.StatusOK)
+}
+
+// create manual journal entry: refunds, card payments, cashback credits
+func LedgerEntryPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostJournalEntry called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newEntryJSON models.JournalEntryJSON
+	if err := json.NewDecoder(r.Body).Decode(&newEntryJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newEntryJSON.UpdBy = requestUpdBy(r)
+
+	newEntry := &models.JournalEntry{}
+	newEntry.SetDescription(newEntryJSON.Description)
+	newEntry.SetSourceType(models.JournalSourceManual)
+	newEntry.SetSourceId(newEntryJSON.SourceId)
+	newEntry.SetUpdBy(newEntryJSON.UpdBy)
+	if date, err := time.Parse("2006-01-02T15:04:05Z", newEntryJSON.Date); err == nil {
+		newEntry.SetDate(date)
+	}
+	for _, posting := range newEntryJSON.Postings {
+		newEntry.AddPosting(posting.IdLedgerAccount, posting.Amount)
+	}
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	if err := ledgerService.PostEntry(newEntry); err != nil {
+		logger.Error("Error posting journal entry", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	entryJSON, err := newEntry.ToJSON()
+	if err != nil {
+		logger.Error("Error converting journal entry to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting journal entry to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "journal_entry", newEntry.GetIdEntry(), models.AuditCreate, nil, entryJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Journal entry posted successfully",
+		"entry":   entryJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully posted journal entry", "status", http.StatusCreated)
+}
+
+// trial balance at date (?date=2006-01-02), now by default
+func LedgerTrialBalance(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetTrialBalance called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	at := time.Now()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid date format"), http.StatusBadRequest)
+			return
+		}
+		at = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	lines, err := ledgerService.TrialBalance(at)
+	if err != nil {
+		logger.Error("Trial balance check failed", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lines); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved trial balance", "status", http.StatusOK)
+}
+
+// general ledger of one account (/ledger/general/{id}?from=&to=)
+func LedgerGeneral(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetGeneralLedger called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/ledger/general/")
+	idLedgerAccount, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_ledger_account"), http.StatusBadRequest)
+		return
+	}
+
+	startDate := time.Time{}
+	endDate := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		if startDate, err = time.Parse("2006-01-02", fromStr); err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid from date format"), http.StatusBadRequest)
+			return
+		}
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		if endDate, err = time.Parse("2006-01-02", toStr); err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid to date format"), http.StatusBadRequest)
+			return
+		}
+		endDate = endDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	ledgerService := services.NewLedgerService().WithScope(requestScope(r))
+	lines, err := ledgerService.GeneralLedger(idLedgerAccount, startDate, endDate)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	if lines == nil {
+		lines = []models.GeneralLedgerLineJSON{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lines); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved general ledger", "status", http.StatusOK)
+}
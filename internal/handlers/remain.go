@@ -9,9 +9,9 @@ import (
 	"time"
 
 	"github.com/helltale/api-finances/config"
-	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
 	u "github.com/helltale/api-finances/internal/utils"
 )
 
@@ -28,12 +28,7 @@ func RemainGetAll(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 
 	w.Header().Set("Content-Type", "application/json")
 
-	var remains []*models.Remain
-	if config.AppMode == "debug" {
-		remains = debugging.Remains
-	} else {
-		remains = []*models.Remain{}
-	}
+	remains := services.NewRemainService().WithScope(requestScope(r)).GetAllRemains()
 
 	response := make([]models.RemainJSON, 0, len(remains))
 	for _, remain := range remains {
@@ -80,17 +75,8 @@ func RemainGetByIdRemain(w http.ResponseWriter, r *http.Request, logger *logger.
 		return
 	}
 
-	var foundRemain *models.Remain
-	if config.AppMode == "debug" {
-		for _, remain := range debugging.Remains {
-			if remain.GetIdRemains() == idRemains {
-				foundRemain = remain
-				break
-			}
-		}
-	}
-
-	if foundRemain == nil {
+	foundRemain, err := services.NewRemainService().WithScope(requestScope(r)).GetRemainById(idRemains)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Remain not found"), http.StatusNotFound)
 		return
 	}
@@ -137,25 +123,18 @@ func RemainGetByIdAccount(w http.ResponseWriter, r *http.Request, logger *logger
 		return
 	}
 
-	var remains []*models.Remain
-	if config.AppMode == "debug" {
-		remains = debugging.Remains
-	} else {
-		remains = []*models.Remain{}
-	}
+	remains := services.NewRemainService().WithScope(requestScope(r)).GetRemainsByAccount(idAccaunt)
 
 	var remainsByAccountId []models.RemainJSON
 	for _, remain := range remains {
-		if remain.GetIdAccaunt() == idAccaunt {
-			remainJSON, err := remain.ToJSON()
-			if err != nil {
-				logger.Error("Error converting remain to JSON", "error", err)
-
-				http.Error(w, u.JsonErrorResponse("Error converting remain to JSON"), http.StatusInternalServerError)
-				return
-			}
-			remainsByAccountId = append(remainsByAccountId, *remainJSON)
+		remainJSON, err := remain.ToJSON()
+		if err != nil {
+			logger.Error("Error converting remain to JSON", "error", err)
+
+			http.Error(w, u.JsonErrorResponse("Error converting remain to JSON"), http.StatusInternalServerError)
+			return
 		}
+		remainsByAccountId = append(remainsByAccountId, *remainJSON)
 	}
 
 	if len(remainsByAccountId) == 0 {
@@ -197,19 +176,8 @@ func RemainGetByIdLastEntry(w http.ResponseWriter, r *http.Request, logger *logg
 		return
 	}
 
-	var lastRemain *models.Remain
-	if config.AppMode == "debug" {
-		for _, remain := range debugging.Remains {
-			if remain.GetIdRemains() == remainId {
-				if remain.GetDateActualTo().Format("2006-01-02") == "9999-12-31" {
-					lastRemain = remain
-					break
-				}
-			}
-		}
-	}
-
-	if lastRemain == nil {
+	lastRemain, err := services.NewRemainService().WithScope(requestScope(r)).GetLastRemainById(remainId)
+	if err != nil {
 		http.Error(w, u.JsonErrorResponse("Remain entry not found or not active"), http.StatusNotFound)
 		return
 	}
@@ -267,15 +235,7 @@ func RemainGetByDateBetween(w http.ResponseWriter, r *http.Request, logger *logg
 		return
 	}
 
-	var foundRemains []*models.Remain
-	if config.AppMode == "debug" {
-		for _, remain := range debugging.Remains {
-			dateActualFrom := remain.GetDateActualFrom()
-			if dateActualFrom.After(startDate) && dateActualFrom.Before(endDate) {
-				foundRemains = append(foundRemains, remain)
-			}
-		}
-	}
+	foundRemains := services.NewRemainService().WithScope(requestScope(r)).GetRemainsByDateRange(startDate, endDate)
 
 	if len(foundRemains) == 0 {
 		http.Error(w, u.JsonErrorResponse("No remains found in the specified date range"), http.StatusNotFound)
@@ -325,6 +285,8 @@ func RemainPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		return
 	}
 
+	newRemainJSON.UpdBy = requestUpdBy(r)
+
 	newRemain := &models.Remain{}
 	newRemain.SetIdRemains(newRemainJSON.IdRemains)
 	newRemain.SetIdAccaunt(newRemainJSON.IdAccaunt)
@@ -341,7 +303,14 @@ func RemainPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedL
 		newRemain.SetDateActualTo(dateActualTo)
 	}
 
-	debugging.Remains = append(debugging.Remains, newRemain)
+	remainService := services.NewRemainService().WithScope(requestScope(r))
+	if err := remainService.AddNewRemain(newRemain); err != nil {
+		logger.Error("Error adding remain", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	audit(r, logger, "remain", newRemain.GetIdRemains(), models.AuditCreate, nil, newRemainJSON)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -394,27 +363,7 @@ func RemainPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLo
 		return
 	}
 
-	var oldRemain *models.Remain
-	for i, remain := range debugging.Remains {
-		if remain.GetIdRemains() == idRemain {
-			oldRemain = remain
-
-			debugging.Remains = append(debugging.Remains[:i], debugging.Remains[i+1:]...)
-			break
-		}
-	}
-
-	if oldRemain == nil {
-		http.Error(w, u.JsonErrorResponse("Remain not found"), http.StatusNotFound)
-		return
-	}
-
-	oldRemainJSON, err := oldRemain.ToJSON()
-	if err != nil {
-		logger.Error("Error converting old remain to JSON", "error", err)
-		http.Error(w, u.JsonErrorResponse("Error processing old remain"), http.StatusInternalServerError)
-		return
-	}
+	updatedRemainJSON.UpdBy = requestUpdBy(r)
 
 	newRemain := &models.Remain{}
 	newRemain.SetIdRemains(updatedRemainJSON.IdRemains)
@@ -437,7 +386,21 @@ func RemainPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLo
 		logger.Error("Error parsing DateActualTo", "error", err)
 	}
 
-	debugging.Remains = append(debugging.Remains, newRemain)
+	remainService := services.NewRemainService().WithScope(requestScope(r))
+	oldRemain, err := remainService.UpdateRemain(idRemain, newRemain)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldRemainJSON, err := oldRemain.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old remain to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old remain"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "remain", idRemain, models.AuditUpdate, oldRemainJSON, updatedRemainJSON)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -483,18 +446,10 @@ func RemainDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 		return
 	}
 
-	var oldRemain *models.Remain
-	for i, remain := range debugging.Remains {
-		if remain.GetIdRemains() == idRemain {
-			oldRemain = remain
-
-			debugging.Remains = append(debugging.Remains[:i], debugging.Remains[i+1:]...)
-			break
-		}
-	}
-
-	if oldRemain == nil {
-		http.Error(w, u.JsonErrorResponse("Remain not found"), http.StatusNotFound)
+	remainService := services.NewRemainService().WithScope(requestScope(r))
+	oldRemain, err := remainService.DeleteRemain(idRemain, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
 		return
 	}
 
@@ -505,6 +460,8 @@ func RemainDelete(w http.ResponseWriter, r *http.Request, logger *logger.Combine
 		return
 	}
 
+	audit(r, logger, "remain", idRemain, models.AuditDelete, oldRemainJSON, nil)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
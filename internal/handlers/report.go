@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// expected against received income (/reports/income-variance?month=2024-05)
+func ReportIncomeVariance(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetIncomeVariance called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	month := time.Now()
+	if monthStr := r.URL.Query().Get("month"); monthStr != "" {
+		parsed, err := time.Parse("2006-01", monthStr)
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid month format, must be YYYY-MM"), http.StatusBadRequest)
+			return
+		}
+		month = parsed
+	}
+
+	reportService := services.NewReportService().WithScope(requestScope(r))
+	report := reportService.IncomeVariance(month.Year(), month.Month())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved income variance", "status", http.StatusOK)
+}
+
+// expected cashback per card and category against credited for ?month=YYYY-MM
+func ReportCashbackEarned(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetCashbackEarned called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	month := time.Now()
+	if monthStr := r.URL.Query().Get("month"); monthStr != "" {
+		parsed, err := time.Parse("2006-01", monthStr)
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid month format, must be YYYY-MM"), http.StatusBadRequest)
+			return
+		}
+		month = parsed
+	}
+
+	reportService := services.NewReportService().WithScope(requestScope(r))
+	report := reportService.CashbackEarned(month.Year(), month.Month())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved cashback earned", "status", http.StatusOK)
+}
+
+// expences and incomes per tag over ?from=&to= (YYYY-MM-DD, both included),
+// current month by default
+func ReportTags(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetTagTotals called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromStr, now.Location())
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid from format, must be YYYY-MM-DD"), http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toStr, now.Location())
+		if err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid to format, must be YYYY-MM-DD"), http.StatusBadRequest)
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		http.Error(w, u.JsonErrorResponse("from is after to"), http.StatusBadRequest)
+		return
+	}
+
+	reportService := services.NewReportService().WithScope(requestScope(r))
+	report := reportService.TagTotals(from, to)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved tag totals", "status", http.StatusOK)
+}
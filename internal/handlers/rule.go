@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+func ruleFromJSON(ruleJSON models.RuleJSON) *models.Rule {
+	rule := &models.Rule{}
+	rule.SetIdRule(ruleJSON.IdRule)
+	rule.SetIdAccaunt(ruleJSON.IdAccaunt)
+	rule.SetName(ruleJSON.Name)
+	rule.SetPriority(ruleJSON.Priority)
+	rule.SetTitleContains(ruleJSON.TitleContains)
+	rule.SetTitleRegex(ruleJSON.TitleRegex)
+	rule.SetMinAmount(ruleJSON.MinAmount)
+	rule.SetMaxAmount(ruleJSON.MaxAmount)
+	rule.SetMatchAccaunt(ruleJSON.MatchAccaunt)
+	rule.SetIdCategory(ruleJSON.IdCategory)
+	rule.SetGroupExpence(ruleJSON.GroupExpence)
+	rule.SetTags(ruleJSON.Tags)
+	rule.SetDisabled(ruleJSON.Disabled)
+	rule.SetUpdBy(ruleJSON.UpdBy)
+	return rule
+}
+
+// get all
+func RuleGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllRules called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ruleService := services.NewRuleService().WithScope(requestScope(r))
+
+	response := []models.RuleJSON{}
+	for _, rule := range ruleService.GetAllRules() {
+		ruleJSON, err := rule.ToJSON()
+		if err != nil {
+			logger.Error("Error converting rule to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting rule to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *ruleJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved rules", "status", http.StatusOK)
+}
+
+// get one by id
+func RuleGetById(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetRuleById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idRule, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/rules/id/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_rule"), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := services.NewRuleService().WithScope(requestScope(r)).GetRuleById(idRule)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	ruleJSON, err := rule.ToJSON()
+	if err != nil {
+		logger.Error("Error converting rule to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting rule to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ruleJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved rule", "status", http.StatusOK)
+}
+
+// create
+func RulePost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostRule called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newRuleJSON models.RuleJSON
+	if err := json.NewDecoder(r.Body).Decode(&newRuleJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newRuleJSON.UpdBy = requestUpdBy(r)
+	newRule := ruleFromJSON(newRuleJSON)
+
+	ruleService := services.NewRuleService().WithScope(requestScope(r))
+	if err := ruleService.AddNewRule(newRule); err != nil {
+		logger.Error("Error adding rule", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	ruleJSON, err := newRule.ToJSON()
+	if err != nil {
+		logger.Error("Error converting rule to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting rule to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "rule", newRule.GetIdRule(), models.AuditCreate, nil, ruleJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Rule created successfully",
+		"rule":    ruleJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created rule", "status", http.StatusCreated)
+}
+
+// update
+func RulePut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutRule called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idRule, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/rules/update/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var updatedRuleJSON models.RuleJSON
+	if err := json.NewDecoder(r.Body).Decode(&updatedRuleJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	updatedRuleJSON.UpdBy = requestUpdBy(r)
+	newRule := ruleFromJSON(updatedRuleJSON)
+
+	ruleService := services.NewRuleService().WithScope(requestScope(r))
+	oldRule, err := ruleService.UpdateRule(idRule, newRule)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	oldRuleJSON, err := oldRule.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old rule to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old rule"), http.StatusInternalServerError)
+		return
+	}
+	newRuleJSON, err := newRule.ToJSON()
+	if err != nil {
+		logger.Error("Error converting rule to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting rule to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "rule", idRule, models.AuditUpdate, oldRuleJSON, newRuleJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":  "Rule updated successfully",
+		"old_rule": oldRuleJSON,
+		"new_rule": newRuleJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully updated rule", "status", http.StatusOK)
+}
+
+// delete
+func RuleDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteRule called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idRule, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/rules/delete/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	ruleService := services.NewRuleService().WithScope(requestScope(r))
+	oldRule, err := ruleService.DeleteRule(idRule, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldRuleJSON, err := oldRule.ToJSON()
+	if err != nil {
+		logger.Error("Error converting rule to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting rule to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "rule", idRule, models.AuditDelete, oldRuleJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Rule deleted successfully",
+		"rule":    oldRuleJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted rule", "status", http.StatusOK)
+}
+
+// run rules over stored expences again, ?dry_run=true only shows what would
+// change
+func RuleApply(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("ApplyRules called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			http.Error(w, u.JsonErrorResponse("Invalid dry_run"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, checked, err := services.NewRuleService().WithScope(requestScope(r)).Apply(dryRun, requestUpdBy(r))
+	if err != nil {
+		logger.Error("Error applying rules", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	response := models.RuleApplyJSON{DryRun: dryRun, Checked: checked, Changes: []models.RuleChangeJSON{}}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, models.RuleChangeJSON{
+			IdExpence:        change.Before.GetIdExpence(),
+			TitleExpence:     change.Before.GetTitleExpence(),
+			IdRule:           change.Rule.GetIdRule(),
+			GroupBefore:      change.Before.GetGroupExpence(),
+			GroupAfter:       change.After.GetGroupExpence(),
+			IdCategoryBefore: change.Before.GetIdCategory(),
+			IdCategoryAfter:  change.After.GetIdCategory(),
+			TagsBefore:       append([]string{}, change.Before.GetTags()...),
+			TagsAfter:        append([]string{}, change.After.GetTags()...),
+		})
+		if !dryRun {
+			beforeJSON, _ := change.Before.ToJSON()
+			afterJSON, _ := change.After.ToJSON()
+			audit(r, logger, "expence", change.After.GetIdExpence(), models.AuditUpdate, beforeJSON, afterJSON)
+		}
+	}
+	if !dryRun && len(changes) > 0 {
+		checkBudgets(logger)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully applied rules", "dry_run", dryRun, "changes", len(changes), "status", http.StatusOK)
+}
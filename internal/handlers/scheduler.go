@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// get last runs of scheduler jobs
+func SchedulerJobGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetSchedulerJobs called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobRuns := services.NewSchedulerService().GetJobRuns()
+
+	response := make([]models.JobRunJSON, 0, len(jobRuns))
+	for _, jobRun := range jobRuns {
+		jobRunJSON, err := jobRun.ToJSON()
+		if err != nil {
+			logger.Error("Error converting job run to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting job run to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *jobRunJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved scheduler jobs", "status", http.StatusOK)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/auth"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+)
+
+// authenticated account of the request, nil if anonymous
+func requestAccount(r *http.Request) *models.Account {
+	account, ok := auth.AccountFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	return account
+}
+
+// visibility scope of the caller, nil if the caller is not identified
+func requestScope(r *http.Request) *services.Scope {
+	account := requestAccount(r)
+	if account == nil {
+		return nil
+	}
+	return services.NewGroupService().ScopeFor(account.GetIdAccaunt())
+}
+
+// caller is listed in admin-accounts
+func requestIsAdmin(r *http.Request, config *config.Config) bool {
+	account := requestAccount(r)
+	if account == nil {
+		return false
+	}
+	for _, idAccaunt := range config.AdminAccounts {
+		if idAccaunt == account.GetIdAccaunt() {
+			return true
+		}
+	}
+	return false
+}
+
+// upd_by of changes made by the caller, body upd_by is ignored
+func requestUpdBy(r *http.Request) string {
+	account := requestAccount(r)
+	if account == nil {
+		return ""
+	}
+	if account.GetName() != "" {
+		return account.GetName()
+	}
+	return strconv.FormatInt(account.GetIdAccaunt(), 10)
+}
+
+// policy denials are 403, other service errors keep their status
+func errorStatus(err error, status int) int {
+	if errors.Is(err, services.ErrForbidden) {
+		return http.StatusForbidden
+	}
+	return status
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+func tagFromJSON(tagJSON models.TagJSON) *models.Tag {
+	tag := &models.Tag{}
+	tag.SetIdTag(tagJSON.IdTag)
+	tag.SetIdAccaunt(tagJSON.IdAccaunt)
+	tag.SetName(tagJSON.Name)
+	tag.SetDescription(tagJSON.Description)
+	tag.SetUpdBy(tagJSON.UpdBy)
+	return tag
+}
+
+// tags records of a list must carry, ?tag=kids&tag=vacation-2026 or
+// ?tag=kids,vacation-2026
+func requestTags(r *http.Request) []string {
+	var tags []string
+	for _, value := range r.URL.Query()["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// get all
+func TagGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllTags called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	tagService := services.NewTagService().WithScope(requestScope(r))
+
+	response := []models.TagJSON{}
+	for _, tag := range tagService.GetAllTags() {
+		tagJSON, err := tag.ToJSON()
+		if err != nil {
+			logger.Error("Error converting tag to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting tag to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *tagJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved tags", "status", http.StatusOK)
+}
+
+// get one by id
+func TagGetById(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetTagById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTag, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/tags/id/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_tag"), http.StatusBadRequest)
+		return
+	}
+
+	tag, err := services.NewTagService().WithScope(requestScope(r)).GetTagById(idTag)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusNotFound)
+		return
+	}
+
+	tagJSON, err := tag.ToJSON()
+	if err != nil {
+		logger.Error("Error converting tag to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting tag to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tagJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved tag", "status", http.StatusOK)
+}
+
+// create
+func TagPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostTag called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newTagJSON models.TagJSON
+	if err := json.NewDecoder(r.Body).Decode(&newTagJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newTagJSON.UpdBy = requestUpdBy(r)
+	newTag := tagFromJSON(newTagJSON)
+
+	tagService := services.NewTagService().WithScope(requestScope(r))
+	if err := tagService.AddNewTag(newTag); err != nil {
+		logger.Error("Error adding tag", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	tagJSON, err := newTag.ToJSON()
+	if err != nil {
+		logger.Error("Error converting tag to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting tag to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "tag", newTag.GetIdTag(), models.AuditCreate, nil, tagJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message": "Tag created successfully",
+		"tag":     tagJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created tag", "status", http.StatusCreated)
+}
+
+// update
+func TagPut(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PutTag called", "method", r.Method)
+
+	if r.Method != http.MethodPut {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTag, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/tags/update/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	var updatedTagJSON models.TagJSON
+	if err := json.NewDecoder(r.Body).Decode(&updatedTagJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	updatedTagJSON.UpdBy = requestUpdBy(r)
+	newTag := tagFromJSON(updatedTagJSON)
+
+	tagService := services.NewTagService().WithScope(requestScope(r))
+	oldTag, err := tagService.UpdateTag(idTag, newTag)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	oldTagJSON, err := oldTag.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old tag to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old tag"), http.StatusInternalServerError)
+		return
+	}
+	newTagJSON, err := newTag.ToJSON()
+	if err != nil {
+		logger.Error("Error converting tag to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting tag to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "tag", idTag, models.AuditUpdate, oldTagJSON, newTagJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Tag updated successfully",
+		"old_tag": oldTagJSON,
+		"new_tag": newTagJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully updated tag", "status", http.StatusOK)
+}
+
+// delete
+func TagDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteTag called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTag, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/tags/delete/"), 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	tagService := services.NewTagService().WithScope(requestScope(r))
+	oldTag, err := tagService.DeleteTag(idTag, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldTagJSON, err := oldTag.ToJSON()
+	if err != nil {
+		logger.Error("Error converting tag to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting tag to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "tag", idTag, models.AuditDelete, oldTagJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Tag deleted successfully",
+		"tag":     oldTagJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted tag", "status", http.StatusOK)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// get all
+func TransferGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetAllTransfers called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	transferService := services.NewTransferService().WithScope(requestScope(r))
+	transfers := transferService.GetAllTransfers()
+
+	response := make([]models.TransferJSON, 0, len(transfers))
+	for _, transfer := range transfers {
+		transferJSON, err := transfer.ToJSON()
+		if err != nil {
+			logger.Error("Error converting transfer to JSON", "error", err)
+			http.Error(w, u.JsonErrorResponse("Error converting transfer to JSON"), http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *transferJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved transfers", "status", http.StatusOK)
+}
+
+// get one by id
+func TransferGetByIdTransfer(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetTransferById called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTransferStr := strings.TrimPrefix(r.URL.Path, "/transfer/id/")
+	idTransfer, err := strconv.ParseInt(idTransferStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid id_transfer"), http.StatusBadRequest)
+		return
+	}
+
+	transferService := services.NewTransferService().WithScope(requestScope(r))
+	transfer, err := transferService.GetTransferById(idTransfer)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	transferJSON, err := transfer.ToJSON()
+	if err != nil {
+		logger.Error("Error converting transfer to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error converting transfer to JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(transferJSON); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved transfer", "status", http.StatusOK)
+}
+
+// create
+func TransferPost(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PostTransfer called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var newTransferJSON models.TransferJSON
+	if err := json.NewDecoder(r.Body).Decode(&newTransferJSON); err != nil {
+		logger.Error("Error decoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	newTransferJSON.UpdBy = requestUpdBy(r)
+
+	newTransfer := &models.Transfer{}
+	newTransfer.SetIdTransfer(newTransferJSON.IdTransfer)
+	newTransfer.SetIdAccauntFrom(newTransferJSON.IdAccauntFrom)
+	newTransfer.SetIdAccauntTo(newTransferJSON.IdAccauntTo)
+	newTransfer.SetAmount(newTransferJSON.Amount)
+	newTransfer.SetUpdBy(newTransferJSON.UpdBy)
+	if date, err := time.Parse("2006-01-02T15:04:05Z", newTransferJSON.Date); err == nil {
+		newTransfer.SetDate(date)
+	} else {
+		newTransfer.SetDate(time.Now())
+	}
+
+	transferService := services.NewTransferService().WithScope(requestScope(r))
+	if err := transferService.AddNewTransfer(newTransfer); err != nil {
+		logger.Error("Error adding transfer", "error", err)
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	audit(r, logger, "transfer", newTransfer.GetIdTransfer(), models.AuditCreate, nil, newTransferJSON)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := map[string]interface{}{
+		"message":  "Transfer created successfully",
+		"transfer": newTransferJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully created transfer", "status", http.StatusCreated)
+}
+
+// delete
+func TransferDelete(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("DeleteTransfer called", "method", r.Method)
+
+	if r.Method != http.MethodDelete {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTransferStr := strings.TrimPrefix(r.URL.Path, "/transfer/delete/")
+	idTransfer, err := strconv.ParseInt(idTransferStr, 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	transferService := services.NewTransferService().WithScope(requestScope(r))
+	oldTransfer, err := transferService.DeleteTransfer(idTransfer, requestUpdBy(r))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	oldTransferJSON, err := oldTransfer.ToJSON()
+	if err != nil {
+		logger.Error("Error converting old transfer to JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error processing old transfer"), http.StatusInternalServerError)
+		return
+	}
+
+	audit(r, logger, "transfer", idTransfer, models.AuditDelete, oldTransferJSON, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":        "Transfer deleted successfully",
+		"index_transfer": idTransfer,
+		"old_transfer":   oldTransferJSON,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully deleted transfer", "status", http.StatusOK)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// admins see the whole trash, e.g. deleted accounts and categories of everyone
+func trashScope(r *http.Request, config *config.Config) *services.Scope {
+	if requestIsAdmin(r, config) {
+		return nil
+	}
+	return requestScope(r)
+}
+
+// get deleted records (/trash?entity=)
+func TrashGetAll(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("GetTrash called", "method", r.Method)
+
+	if r.Method != http.MethodGet {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	trashService := services.NewTrashService().WithScope(trashScope(r, config))
+	items, err := trashService.GetTrash(r.URL.Query().Get("entity"))
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully retrieved trash", "status", http.StatusOK)
+}
+
+// restore (/{entity}/{id}/restore)
+func TrashRestore(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("RestoreTrash called", "method", r.Method)
+
+	urlParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(urlParts) != 3 || urlParts[2] != "restore" || !services.ValidTrashEntity(urlParts[0]) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	entity := urlParts[0]
+	id, err := strconv.ParseInt(urlParts[1], 10, 64)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse("Invalid index format"), http.StatusBadRequest)
+		return
+	}
+
+	trashService := services.NewTrashService().WithScope(trashScope(r, config))
+	oldItem, restoredItem, err := trashService.Restore(entity, id)
+	if err != nil {
+		http.Error(w, u.JsonErrorResponse(err.Error()), errorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	audit(r, logger, entity, id, models.AuditUpdate, oldItem.Data, restoredItem.Data)
+	if entity == services.TrashExpence || entity == services.TrashBudget {
+		checkBudgets(logger)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message":  "Record restored successfully",
+		"entity":   entity,
+		"index":    id,
+		"restored": restoredItem.Data,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully restored record", "entity", entity, "id", id, "status", http.StatusOK)
+}
+
+// hard delete records older than retention (/trash/purge)
+func TrashPurge(w http.ResponseWriter, r *http.Request, logger *logger.CombinedLogger, config *config.Config) {
+	logger.Info("PurgeTrash called", "method", r.Method)
+
+	if r.Method != http.MethodPost {
+		logger.Info("Method not allowed", "method", r.Method)
+		http.Error(w, u.JsonErrorResponse("Method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	retention := defaultTrashRetention
+	if config.TrashRetention != "" {
+		parsed, err := time.ParseDuration(config.TrashRetention)
+		if err != nil || parsed < 0 {
+			logger.Error("Invalid trash retention", "trash_retention", config.TrashRetention)
+			http.Error(w, u.JsonErrorResponse("Invalid trash retention"), http.StatusInternalServerError)
+			return
+		}
+		retention = parsed
+	}
+
+	trashService := services.NewTrashService()
+	before := trashService.PurgeCutoff(retention)
+	purged, err := trashService.Purge(before)
+	if err != nil {
+		logger.Error("Error purging trash", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error purging trash"), http.StatusInternalServerError)
+		return
+	}
+
+	for _, item := range purged {
+		audit(r, logger, item.Entity, item.Id, models.AuditDelete, item.Data, nil)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"message": "Trash purged successfully",
+		"before":  before.Format("2006-01-02 15:04:05"),
+		"purged":  len(purged),
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Error encoding JSON", "error", err)
+		http.Error(w, u.JsonErrorResponse("Error encoding JSON"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Successfully purged trash", "purged", len(purged), "status", http.StatusOK)
+}
@@ -1,10 +1,15 @@
 package models
 
+import "time"
+
 type Account struct { // (db) entity gorup + account
 	idAccaunt int64
 	tgId      int64
 	name      string
 	groupId   int64
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
 }
 
 type AccountJSON struct {
@@ -12,6 +17,8 @@ type AccountJSON struct {
 	TgId      int64  `json:"tg_id"`
 	Name      string `json:"name"`
 	GroupId   int64  `json:"group_id"`
+	DeletedAt string `json:"deleted_at"`
+	DeletedBy string `json:"deleted_by"`
 }
 
 func (a *Account) ToJSON() (*AccountJSON, error) {
@@ -20,6 +27,8 @@ func (a *Account) ToJSON() (*AccountJSON, error) {
 		TgId:      a.tgId,
 		Name:      a.name,
 		GroupId:   a.groupId,
+		DeletedAt: formatDeletedAt(a.deletedAt),
+		DeletedBy: a.deletedBy,
 	}, nil
 }
 
@@ -54,3 +63,23 @@ func (a *Account) SetName(name string) {
 func (a *Account) SetGroupId(id int64) {
 	a.groupId = id
 }
+
+func (a *Account) GetDeletedAt() time.Time {
+	return a.deletedAt
+}
+
+func (a *Account) GetDeletedBy() string {
+	return a.deletedBy
+}
+
+func (a *Account) IsDeleted() bool {
+	return !a.deletedAt.IsZero()
+}
+
+func (a *Account) SetDeletedAt(deletedAt time.Time) {
+	a.deletedAt = deletedAt
+}
+
+func (a *Account) SetDeletedBy(deletedBy string) {
+	a.deletedBy = deletedBy
+}
@@ -0,0 +1,144 @@
+package models
+
+import "time"
+
+type ApiKey struct {
+	idApiKey  int64
+	idAccaunt int64     // owner
+	name      string    // what the key is for
+	prefix    string    // first chars of key to recognize it
+	keyHash   string    // sha256 of key, key itself is never stored
+	createdAt time.Time // created
+	revokedAt time.Time // zero if active
+}
+
+type ApiKeyJSON struct {
+	IdApiKey  int64  `json:"id_api_key"`
+	IdAccaunt int64  `json:"id_accaunt"`
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`
+	CreatedAt string `json:"created_at"`
+	RevokedAt string `json:"revoked_at"`
+}
+
+func (k *ApiKey) ToJSON() (*ApiKeyJSON, error) {
+	revokedAt := ""
+	if !k.revokedAt.IsZero() {
+		revokedAt = k.revokedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return &ApiKeyJSON{
+		IdApiKey:  k.idApiKey,
+		IdAccaunt: k.idAccaunt,
+		Name:      k.name,
+		Prefix:    k.prefix,
+		CreatedAt: k.createdAt.Format("2006-01-02 15:04:05"),
+		RevokedAt: revokedAt,
+	}, nil
+}
+
+func (k *ApiKey) GetIdApiKey() int64 {
+	return k.idApiKey
+}
+
+func (k *ApiKey) GetIdAccaunt() int64 {
+	return k.idAccaunt
+}
+
+func (k *ApiKey) GetName() string {
+	return k.name
+}
+
+func (k *ApiKey) GetPrefix() string {
+	return k.prefix
+}
+
+func (k *ApiKey) GetKeyHash() string {
+	return k.keyHash
+}
+
+func (k *ApiKey) GetCreatedAt() time.Time {
+	return k.createdAt
+}
+
+func (k *ApiKey) GetRevokedAt() time.Time {
+	return k.revokedAt
+}
+
+func (k *ApiKey) IsRevoked() bool {
+	return !k.revokedAt.IsZero()
+}
+
+func (k *ApiKey) SetIdApiKey(id int64) {
+	k.idApiKey = id
+}
+
+func (k *ApiKey) SetIdAccaunt(id int64) {
+	k.idAccaunt = id
+}
+
+func (k *ApiKey) SetName(name string) {
+	k.name = name
+}
+
+func (k *ApiKey) SetPrefix(prefix string) {
+	k.prefix = prefix
+}
+
+func (k *ApiKey) SetKeyHash(keyHash string) {
+	k.keyHash = keyHash
+}
+
+func (k *ApiKey) SetCreatedAt(date time.Time) {
+	k.createdAt = date
+}
+
+func (k *ApiKey) SetRevokedAt(date time.Time) {
+	k.revokedAt = date
+}
+
+type RefreshToken struct {
+	idAccaunt int64
+	tokenHash string    // sha256 of token
+	expiresAt time.Time // valid until
+	used      bool      // refresh tokens are single use
+}
+
+func (t *RefreshToken) GetIdAccaunt() int64 {
+	return t.idAccaunt
+}
+
+func (t *RefreshToken) GetTokenHash() string {
+	return t.tokenHash
+}
+
+func (t *RefreshToken) GetExpiresAt() time.Time {
+	return t.expiresAt
+}
+
+func (t *RefreshToken) IsUsed() bool {
+	return t.used
+}
+
+func (t *RefreshToken) SetIdAccaunt(id int64) {
+	t.idAccaunt = id
+}
+
+func (t *RefreshToken) SetTokenHash(tokenHash string) {
+	t.tokenHash = tokenHash
+}
+
+func (t *RefreshToken) SetExpiresAt(date time.Time) {
+	t.expiresAt = date
+}
+
+func (t *RefreshToken) SetUsed(used bool) {
+	t.used = used
+}
+
+type TokenPairJSON struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds
+}
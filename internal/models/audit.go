@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+type AuditRecord struct { // append only
+	idAudit   int64
+	idActor   int64     // account that made the change, 0 if anonymous
+	actor     string    // name of the account
+	date      time.Time // when
+	entity    string    // expence, goal, ...
+	entityId  int64     // id of changed entity
+	operation string    // create, update, delete
+	before    []byte    // json of entity before change, nil on create
+	after     []byte    // json of entity after change, nil on delete
+	clientIp  string
+	requestId string
+}
+
+type AuditRecordJSON struct {
+	IdAudit   int64           `json:"id_audit"`
+	IdActor   int64           `json:"id_actor"`
+	Actor     string          `json:"actor"`
+	Date      string          `json:"date"`
+	Entity    string          `json:"entity"`
+	EntityId  int64           `json:"entity_id"`
+	Operation string          `json:"operation"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	ClientIp  string          `json:"client_ip"`
+	RequestId string          `json:"request_id"`
+}
+
+func (a *AuditRecord) ToJSON() (*AuditRecordJSON, error) {
+	return &AuditRecordJSON{
+		IdAudit:   a.idAudit,
+		IdActor:   a.idActor,
+		Actor:     a.actor,
+		Date:      a.date.Format("2006-01-02 15:04:05"),
+		Entity:    a.entity,
+		EntityId:  a.entityId,
+		Operation: a.operation,
+		Before:    rawOrNull(a.before),
+		After:     rawOrNull(a.after),
+		ClientIp:  a.clientIp,
+		RequestId: a.requestId,
+	}, nil
+}
+
+func rawOrNull(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(data)
+}
+
+func (a *AuditRecord) GetIdAudit() int64 {
+	return a.idAudit
+}
+
+func (a *AuditRecord) GetIdActor() int64 {
+	return a.idActor
+}
+
+func (a *AuditRecord) GetActor() string {
+	return a.actor
+}
+
+func (a *AuditRecord) GetDate() time.Time {
+	return a.date
+}
+
+func (a *AuditRecord) GetEntity() string {
+	return a.entity
+}
+
+func (a *AuditRecord) GetEntityId() int64 {
+	return a.entityId
+}
+
+func (a *AuditRecord) GetOperation() string {
+	return a.operation
+}
+
+func (a *AuditRecord) GetBefore() []byte {
+	return a.before
+}
+
+func (a *AuditRecord) GetAfter() []byte {
+	return a.after
+}
+
+func (a *AuditRecord) GetClientIp() string {
+	return a.clientIp
+}
+
+func (a *AuditRecord) GetRequestId() string {
+	return a.requestId
+}
+
+func (a *AuditRecord) SetIdAudit(id int64) {
+	a.idAudit = id
+}
+
+func (a *AuditRecord) SetIdActor(id int64) {
+	a.idActor = id
+}
+
+func (a *AuditRecord) SetActor(actor string) {
+	a.actor = actor
+}
+
+func (a *AuditRecord) SetDate(date time.Time) {
+	a.date = date
+}
+
+func (a *AuditRecord) SetEntity(entity string) {
+	a.entity = entity
+}
+
+func (a *AuditRecord) SetEntityId(id int64) {
+	a.entityId = id
+}
+
+func (a *AuditRecord) SetOperation(operation string) {
+	a.operation = operation
+}
+
+func (a *AuditRecord) SetBefore(before []byte) {
+	a.before = before
+}
+
+func (a *AuditRecord) SetAfter(after []byte) {
+	a.after = after
+}
+
+func (a *AuditRecord) SetClientIp(clientIp string) {
+	a.clientIp = clientIp
+}
+
+func (a *AuditRecord) SetRequestId(requestId string) {
+	a.requestId = requestId
+}
@@ -0,0 +1,296 @@
+package models
+
+import "time"
+
+// периоды бюджета
+const (
+	BudgetPeriodMonthly = "monthly" // calendar month
+	BudgetPeriodWeekly  = "weekly"  // monday to sunday
+)
+
+// limit of spending of an expence group per period, for one account or for
+// every member of a group of accounts
+type Budget struct {
+	idBudget     int64     // id
+	idAccaunt    int64     // account id, owner of group budget
+	idGroup      int64     // group id, 0 for budget of one account
+	groupExpence string    // expence group limited
+	idCategory   int64     // category of the group, subcategories count too
+	amount       float64   // limit of one period
+	period       string    // monthly or weekly
+	rollover     bool      // unused amount moves to the next period
+	thresholds   []int     // percents of limit raising an event
+	dateStart    time.Time // first period, rollover is counted from it
+	updBy        string    // who changed
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
+}
+
+type BudgetJSON struct {
+	IdBudget     int64   `json:"id_budget"`
+	IdAccaunt    int64   `json:"id_accaunt"`
+	IdGroup      int64   `json:"id_group"`
+	GroupExpence string  `json:"group_expence"`
+	IdCategory   int64   `json:"id_category"`
+	Amount       float64 `json:"amount"`
+	Period       string  `json:"period"`
+	Rollover     bool    `json:"rollover"`
+	Thresholds   []int   `json:"thresholds"`
+	DateStart    string  `json:"date_start"`
+	UpdBy        string  `json:"upd_by"`
+	DeletedAt    string  `json:"deleted_at"`
+	DeletedBy    string  `json:"deleted_by"`
+}
+
+func (b *Budget) ToJSON() (*BudgetJSON, error) {
+	return &BudgetJSON{
+		IdBudget:     b.idBudget,
+		IdAccaunt:    b.idAccaunt,
+		IdGroup:      b.idGroup,
+		GroupExpence: b.groupExpence,
+		IdCategory:   b.idCategory,
+		Amount:       b.amount,
+		Period:       b.period,
+		Rollover:     b.rollover,
+		Thresholds:   b.thresholds,
+		DateStart:    b.dateStart.Format("2006-01-02 15:04:05"),
+		UpdBy:        b.updBy,
+		DeletedAt:    formatDeletedAt(b.deletedAt),
+		DeletedBy:    b.deletedBy,
+	}, nil
+}
+
+func (b *Budget) GetIdBudget() int64 {
+	return b.idBudget
+}
+
+func (b *Budget) GetIdAccaunt() int64 {
+	return b.idAccaunt
+}
+
+func (b *Budget) GetIdGroup() int64 {
+	return b.idGroup
+}
+
+func (b *Budget) GetGroupExpence() string {
+	return b.groupExpence
+}
+
+func (b *Budget) GetIdCategory() int64 {
+	return b.idCategory
+}
+
+func (b *Budget) GetAmount() float64 {
+	return b.amount
+}
+
+func (b *Budget) GetPeriod() string {
+	return b.period
+}
+
+func (b *Budget) GetRollover() bool {
+	return b.rollover
+}
+
+func (b *Budget) GetThresholds() []int {
+	return b.thresholds
+}
+
+func (b *Budget) GetDateStart() time.Time {
+	return b.dateStart
+}
+
+func (b *Budget) GetUpdBy() string {
+	return b.updBy
+}
+
+func (b *Budget) GetDeletedAt() time.Time {
+	return b.deletedAt
+}
+
+func (b *Budget) GetDeletedBy() string {
+	return b.deletedBy
+}
+
+func (b *Budget) IsDeleted() bool {
+	return !b.deletedAt.IsZero()
+}
+
+func (b *Budget) SetIdBudget(idBudget int64) {
+	b.idBudget = idBudget
+}
+
+func (b *Budget) SetIdAccaunt(idAccaunt int64) {
+	b.idAccaunt = idAccaunt
+}
+
+func (b *Budget) SetIdGroup(idGroup int64) {
+	b.idGroup = idGroup
+}
+
+func (b *Budget) SetGroupExpence(groupExpence string) {
+	b.groupExpence = groupExpence
+}
+
+func (b *Budget) SetIdCategory(idCategory int64) {
+	b.idCategory = idCategory
+}
+
+func (b *Budget) SetAmount(amount float64) {
+	b.amount = amount
+}
+
+func (b *Budget) SetPeriod(period string) {
+	b.period = period
+}
+
+func (b *Budget) SetRollover(rollover bool) {
+	b.rollover = rollover
+}
+
+func (b *Budget) SetThresholds(thresholds []int) {
+	b.thresholds = thresholds
+}
+
+func (b *Budget) SetDateStart(dateStart time.Time) {
+	b.dateStart = dateStart
+}
+
+func (b *Budget) SetUpdBy(updBy string) {
+	b.updBy = updBy
+}
+
+func (b *Budget) SetDeletedAt(deletedAt time.Time) {
+	b.deletedAt = deletedAt
+}
+
+func (b *Budget) SetDeletedBy(deletedBy string) {
+	b.deletedBy = deletedBy
+}
+
+// статусы бюджета за период
+const (
+	BudgetStatusOk      = "ok"
+	BudgetStatusWarning = "warning" // lowest threshold is crossed
+	BudgetStatusOver    = "over"    // limit is spent
+)
+
+type BudgetStatusJSON struct {
+	IdBudget       int64   `json:"id_budget"`
+	GroupExpence   string  `json:"group_expence"`
+	PeriodStart    string  `json:"period_start"`
+	PeriodEnd      string  `json:"period_end"` // exclusive
+	Amount         float64 `json:"amount"`
+	Carried        float64 `json:"carried"`   // unused amount of previous periods
+	Available      float64 `json:"available"` // amount + carried
+	Spent          float64 `json:"spent"`
+	Remaining      float64 `json:"remaining"`
+	PercentUsed    float64 `json:"percent_used"`
+	DaysElapsed    int     `json:"days_elapsed"`
+	DaysLeft       int     `json:"days_left"`
+	BurnRate       float64 `json:"burn_rate"`       // spent per day so far
+	ProjectedSpent float64 `json:"projected_spent"` // at burn rate by the period end
+	SafeDaily      float64 `json:"safe_daily"`      // remaining per day left
+	Status         string  `json:"status"`
+}
+
+// threshold of budget crossed in a period, raised once per period and threshold
+type BudgetEvent struct {
+	idEvent     int64
+	idBudget    int64
+	periodStart time.Time
+	threshold   int     // percent of available
+	percentUsed float64 // when crossed
+	spent       float64
+	available   float64
+	createdAt   time.Time
+}
+
+type BudgetEventJSON struct {
+	IdEvent     int64   `json:"id_event"`
+	IdBudget    int64   `json:"id_budget"`
+	PeriodStart string  `json:"period_start"`
+	Threshold   int     `json:"threshold"`
+	PercentUsed float64 `json:"percent_used"`
+	Spent       float64 `json:"spent"`
+	Available   float64 `json:"available"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+func (e *BudgetEvent) ToJSON() (*BudgetEventJSON, error) {
+	return &BudgetEventJSON{
+		IdEvent:     e.idEvent,
+		IdBudget:    e.idBudget,
+		PeriodStart: e.periodStart.Format("2006-01-02"),
+		Threshold:   e.threshold,
+		PercentUsed: e.percentUsed,
+		Spent:       e.spent,
+		Available:   e.available,
+		CreatedAt:   e.createdAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func (e *BudgetEvent) GetIdEvent() int64 {
+	return e.idEvent
+}
+
+func (e *BudgetEvent) GetIdBudget() int64 {
+	return e.idBudget
+}
+
+func (e *BudgetEvent) GetPeriodStart() time.Time {
+	return e.periodStart
+}
+
+func (e *BudgetEvent) GetThreshold() int {
+	return e.threshold
+}
+
+func (e *BudgetEvent) GetPercentUsed() float64 {
+	return e.percentUsed
+}
+
+func (e *BudgetEvent) GetSpent() float64 {
+	return e.spent
+}
+
+func (e *BudgetEvent) GetAvailable() float64 {
+	return e.available
+}
+
+func (e *BudgetEvent) GetCreatedAt() time.Time {
+	return e.createdAt
+}
+
+func (e *BudgetEvent) SetIdEvent(idEvent int64) {
+	e.idEvent = idEvent
+}
+
+func (e *BudgetEvent) SetIdBudget(idBudget int64) {
+	e.idBudget = idBudget
+}
+
+func (e *BudgetEvent) SetPeriodStart(periodStart time.Time) {
+	e.periodStart = periodStart
+}
+
+func (e *BudgetEvent) SetThreshold(threshold int) {
+	e.threshold = threshold
+}
+
+func (e *BudgetEvent) SetPercentUsed(percentUsed float64) {
+	e.percentUsed = percentUsed
+}
+
+func (e *BudgetEvent) SetSpent(spent float64) {
+	e.spent = spent
+}
+
+func (e *BudgetEvent) SetAvailable(available float64) {
+	e.available = available
+}
+
+func (e *BudgetEvent) SetCreatedAt(createdAt time.Time) {
+	e.createdAt = createdAt
+}
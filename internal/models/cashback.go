@@ -3,26 +3,37 @@ package models
 import "time"
 
 type Cashback struct {
-	idCashback int64
-	idAccaunt  int64
-	bankName   string
-	category   string
-	percent    int8
+	idCashback  int64
+	idAccaunt   int64
+	bankName    string
+	category    string
+	idCategory  int64 // category of the rule
+	percent     int8
+	monthlyCap  float64 // most cashback paid by the rule in a month, 0 for no cap
+	minPurchase float64 // smaller purchases earn nothing
 
 	updBy          string    // who changed
 	dateActualFrom time.Time // actual from
 	dateActualTo   time.Time // actual to if 9999-12-31 to now
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
 }
 
 type CashbackJSON struct {
-	IdCashback     int64  `json:"id_cashback"`
-	IdAccaunt      int64  `json:"id_accaunt"`
-	BankName       string `json:"bank_name"`
-	Category       string `json:"category"`
-	Percent        int8   `json:"percent"`
-	UpdBy          string `json:"upd_by"`
-	DateActualFrom string `json:"date_actual_from"`
-	DateActualTo   string `json:"date_actual_to"`
+	IdCashback     int64   `json:"id_cashback"`
+	IdAccaunt      int64   `json:"id_accaunt"`
+	BankName       string  `json:"bank_name"`
+	Category       string  `json:"category"`
+	IdCategory     int64   `json:"id_category"`
+	Percent        int8    `json:"percent"`
+	MonthlyCap     float64 `json:"monthly_cap"`
+	MinPurchase    float64 `json:"min_purchase"`
+	UpdBy          string  `json:"upd_by"`
+	DateActualFrom string  `json:"date_actual_from"`
+	DateActualTo   string  `json:"date_actual_to"`
+	DeletedAt      string  `json:"deleted_at"`
+	DeletedBy      string  `json:"deleted_by"`
 }
 
 func (c *Cashback) ToJSON() (*CashbackJSON, error) {
@@ -31,10 +42,15 @@ func (c *Cashback) ToJSON() (*CashbackJSON, error) {
 		IdAccaunt:      c.idAccaunt,
 		BankName:       c.bankName,
 		Category:       c.category,
+		IdCategory:     c.idCategory,
 		Percent:        c.percent,
+		MonthlyCap:     c.monthlyCap,
+		MinPurchase:    c.minPurchase,
 		UpdBy:          c.updBy,
 		DateActualFrom: c.dateActualFrom.Format("2006-01-02 15:04:05"),
 		DateActualTo:   c.dateActualTo.Format("2006-01-02 15:04:05"),
+		DeletedAt:      formatDeletedAt(c.deletedAt),
+		DeletedBy:      c.deletedBy,
 	}, nil
 }
 
@@ -54,10 +70,22 @@ func (c *Cashback) GetCategory() string {
 	return c.category
 }
 
+func (c *Cashback) GetIdCategory() int64 {
+	return c.idCategory
+}
+
 func (c *Cashback) GetPercent() int8 {
 	return c.percent
 }
 
+func (c *Cashback) GetMonthlyCap() float64 {
+	return c.monthlyCap
+}
+
+func (c *Cashback) GetMinPurchase() float64 {
+	return c.minPurchase
+}
+
 func (c *Cashback) GetUpdBy() string {
 	return c.updBy
 }
@@ -86,10 +114,22 @@ func (c *Cashback) SetCategory(category string) {
 	c.category = category
 }
 
+func (c *Cashback) SetIdCategory(idCategory int64) {
+	c.idCategory = idCategory
+}
+
 func (c *Cashback) SetPercent(percent int8) {
 	c.percent = percent
 }
 
+func (c *Cashback) SetMonthlyCap(monthlyCap float64) {
+	c.monthlyCap = monthlyCap
+}
+
+func (c *Cashback) SetMinPurchase(minPurchase float64) {
+	c.minPurchase = minPurchase
+}
+
 func (c *Cashback) SetUpdBy(updBy string) {
 	c.updBy = updBy
 }
@@ -101,3 +141,45 @@ func (c *Cashback) SetDateActualFrom(date time.Time) {
 func (c *Cashback) SetDateActualTo(date time.Time) {
 	c.dateActualTo = date
 }
+
+func (c *Cashback) GetDeletedAt() time.Time {
+	return c.deletedAt
+}
+
+func (c *Cashback) GetDeletedBy() string {
+	return c.deletedBy
+}
+
+func (c *Cashback) IsDeleted() bool {
+	return !c.deletedAt.IsZero()
+}
+
+func (c *Cashback) SetDeletedAt(date time.Time) {
+	c.deletedAt = date
+}
+
+func (c *Cashback) SetDeletedBy(deletedBy string) {
+	c.deletedBy = deletedBy
+}
+
+// card ranked for a purchase by cashback it pays
+type CardRecommendationJSON struct {
+	Rank       int     `json:"rank"`
+	IdAccaunt  int64   `json:"id_accaunt"`
+	BankName   string  `json:"bank_name"`
+	IdCashback int64   `json:"id_cashback"` // 0 when no rule covers the purchase
+	Category   string  `json:"category"`
+	Percent    int8    `json:"percent"`
+	MonthlyCap float64 `json:"monthly_cap"`
+	Cashback   float64 `json:"cashback"`
+	Capped     bool    `json:"capped"` // cashback is limited by monthly cap
+}
+
+type CashbackRecommendationJSON struct {
+	IdAccaunt  int64                    `json:"id_accaunt"`
+	Category   string                   `json:"category"`
+	IdCategory int64                    `json:"id_category"`
+	Amount     float64                  `json:"amount"`
+	Date       string                   `json:"date"`
+	Cards      []CardRecommendationJSON `json:"cards"`
+}
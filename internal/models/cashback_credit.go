@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// cashback the bank actually credited to the card for a month
+type CashbackCredit struct {
+	idCredit  int64
+	idAccaunt int64
+	bankName  string
+	month     time.Time // first day of the month
+	amount    float64
+	updBy     string    // who changed
+	createdAt time.Time // when recorded
+}
+
+type CashbackCreditJSON struct {
+	IdCredit  int64   `json:"id_credit"`
+	IdAccaunt int64   `json:"id_accaunt"`
+	BankName  string  `json:"bank_name"`
+	Month     string  `json:"month"`
+	Amount    float64 `json:"amount"`
+	UpdBy     string  `json:"upd_by"`
+	CreatedAt string  `json:"created_at"`
+}
+
+func (c *CashbackCredit) ToJSON() (*CashbackCreditJSON, error) {
+	return &CashbackCreditJSON{
+		IdCredit:  c.idCredit,
+		IdAccaunt: c.idAccaunt,
+		BankName:  c.bankName,
+		Month:     c.month.Format("2006-01"),
+		Amount:    c.amount,
+		UpdBy:     c.updBy,
+		CreatedAt: c.createdAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func (c *CashbackCredit) GetIdCredit() int64 {
+	return c.idCredit
+}
+
+func (c *CashbackCredit) GetIdAccaunt() int64 {
+	return c.idAccaunt
+}
+
+func (c *CashbackCredit) GetBankName() string {
+	return c.bankName
+}
+
+func (c *CashbackCredit) GetMonth() time.Time {
+	return c.month
+}
+
+func (c *CashbackCredit) GetAmount() float64 {
+	return c.amount
+}
+
+func (c *CashbackCredit) GetUpdBy() string {
+	return c.updBy
+}
+
+func (c *CashbackCredit) GetCreatedAt() time.Time {
+	return c.createdAt
+}
+
+func (c *CashbackCredit) SetIdCredit(idCredit int64) {
+	c.idCredit = idCredit
+}
+
+func (c *CashbackCredit) SetIdAccaunt(idAccaunt int64) {
+	c.idAccaunt = idAccaunt
+}
+
+func (c *CashbackCredit) SetBankName(bankName string) {
+	c.bankName = bankName
+}
+
+func (c *CashbackCredit) SetMonth(month time.Time) {
+	c.month = month
+}
+
+func (c *CashbackCredit) SetAmount(amount float64) {
+	c.amount = amount
+}
+
+func (c *CashbackCredit) SetUpdBy(updBy string) {
+	c.updBy = updBy
+}
+
+func (c *CashbackCredit) SetCreatedAt(createdAt time.Time) {
+	c.createdAt = createdAt
+}
@@ -0,0 +1,26 @@
+package models
+
+// category the bank offers for the month, scored by expence history
+type CashbackOfferJSON struct {
+	Category     string  `json:"category"`
+	IdCategory   int64   `json:"id_category"`
+	Percent      int8    `json:"percent"`
+	MonthlyCap   float64 `json:"monthly_cap"`
+	MinPurchase  float64 `json:"min_purchase"`
+	MonthlySpent float64 `json:"monthly_spent"` // average spent in the category a month
+	Expected     float64 `json:"expected"`      // average cashback a month if chosen alone
+	Selected     bool    `json:"selected"`
+	Rank         int     `json:"rank"`
+}
+
+// categories to pick from for a card, the suggestion marks the best ones selected
+// and is accepted as is or after changing selected offers
+type CashbackSelectionJSON struct {
+	IdAccaunt     int64               `json:"id_accaunt"`
+	BankName      string              `json:"bank_name"`
+	Month         string              `json:"month"`          // YYYY-MM, next month when empty
+	Pick          int                 `json:"pick"`           // how many categories the bank lets choose
+	HistoryMonths int                 `json:"history_months"` // months of expences before the month to score by
+	Expected      float64             `json:"expected"`       // average cashback a month of selected offers together
+	Offers        []CashbackOfferJSON `json:"offers"`
+}
@@ -0,0 +1,154 @@
+package models
+
+import "time"
+
+// language of category name used when the requested one is missing
+const DefaultCategoryLang = "en"
+
+// node of category tree, expences, cashback rules and budgets refer to it by id
+type Category struct {
+	idCategory int64             // id
+	idParent   int64             // parent category, 0 for root
+	idAccaunt  int64             // owner, shared with accounts of his groups; 0 for categories of everyone
+	code       string            // stable readable key, e.g. groceries
+	names      map[string]string // name by language
+	aliases    []string          // other spellings matched to the category
+	updBy      string            // who changed
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
+}
+
+type CategoryJSON struct {
+	IdCategory int64             `json:"id_category"`
+	IdParent   int64             `json:"id_parent"`
+	IdAccaunt  int64             `json:"id_accaunt"`
+	Code       string            `json:"code"`
+	Name       string            `json:"name"` // in requested language
+	Names      map[string]string `json:"names"`
+	Aliases    []string          `json:"aliases"`
+	UpdBy      string            `json:"upd_by"`
+	DeletedAt  string            `json:"deleted_at"`
+	DeletedBy  string            `json:"deleted_by"`
+}
+
+func (c *Category) ToJSON() (*CategoryJSON, error) {
+	return &CategoryJSON{
+		IdCategory: c.idCategory,
+		IdParent:   c.idParent,
+		IdAccaunt:  c.idAccaunt,
+		Code:       c.code,
+		Name:       c.GetName(DefaultCategoryLang),
+		Names:      c.names,
+		Aliases:    c.aliases,
+		UpdBy:      c.updBy,
+		DeletedAt:  formatDeletedAt(c.deletedAt),
+		DeletedBy:  c.deletedBy,
+	}, nil
+}
+
+func (c *Category) GetIdCategory() int64 {
+	return c.idCategory
+}
+
+func (c *Category) GetIdParent() int64 {
+	return c.idParent
+}
+
+func (c *Category) GetIdAccaunt() int64 {
+	return c.idAccaunt
+}
+
+func (c *Category) GetCode() string {
+	return c.code
+}
+
+func (c *Category) GetNames() map[string]string {
+	return c.names
+}
+
+// name in language, default language or code when missing
+func (c *Category) GetName(lang string) string {
+	if name, ok := c.names[lang]; ok && name != "" {
+		return name
+	}
+	if name, ok := c.names[DefaultCategoryLang]; ok && name != "" {
+		return name
+	}
+	for _, name := range c.names {
+		if name != "" {
+			return name
+		}
+	}
+	return c.code
+}
+
+func (c *Category) GetAliases() []string {
+	return c.aliases
+}
+
+func (c *Category) GetUpdBy() string {
+	return c.updBy
+}
+
+func (c *Category) SetIdCategory(idCategory int64) {
+	c.idCategory = idCategory
+}
+
+func (c *Category) SetIdParent(idParent int64) {
+	c.idParent = idParent
+}
+
+func (c *Category) SetIdAccaunt(idAccaunt int64) {
+	c.idAccaunt = idAccaunt
+}
+
+func (c *Category) SetCode(code string) {
+	c.code = code
+}
+
+func (c *Category) SetNames(names map[string]string) {
+	c.names = names
+}
+
+func (c *Category) SetAliases(aliases []string) {
+	c.aliases = aliases
+}
+
+func (c *Category) SetUpdBy(updBy string) {
+	c.updBy = updBy
+}
+
+func (c *Category) GetDeletedAt() time.Time {
+	return c.deletedAt
+}
+
+func (c *Category) GetDeletedBy() string {
+	return c.deletedBy
+}
+
+func (c *Category) IsDeleted() bool {
+	return !c.deletedAt.IsZero()
+}
+
+func (c *Category) SetDeletedAt(deletedAt time.Time) {
+	c.deletedAt = deletedAt
+}
+
+func (c *Category) SetDeletedBy(deletedBy string) {
+	c.deletedBy = deletedBy
+}
+
+type CategoryTreeJSON struct {
+	CategoryJSON
+	Children []CategoryTreeJSON `json:"children"`
+}
+
+// free text value mapped to a category by migration
+type CategoryMappingJSON struct {
+	Entity     string `json:"entity"`
+	Id         int64  `json:"id"`
+	Value      string `json:"value"`
+	IdCategory int64  `json:"id_category"`
+	Created    bool   `json:"created"` // category was made for the value
+}
@@ -0,0 +1,176 @@
+package models
+
+import "time"
+
+// naive Bayes counts learnt from categorized expences of one group, account
+// outside groups has its own
+type Classifier struct {
+	idGroup    int64                      // 0 for classifier of one account
+	idAccaunt  int64                      // account of classifier without group
+	samples    map[int64]ClassifierSample // what each expence was learnt as
+	docs       map[string]int             // expences learnt per group of expence
+	tokens     map[string]map[string]int  // token counts per group of expence
+	totals     map[string]int             // tokens learnt per group of expence
+	vocabulary map[string]int             // expences the token was seen in
+	trainedAt  time.Time                  // last full training
+	updatedAt  time.Time                  // last change of counts
+}
+
+// tokens of one expence and the group it was learnt as
+type ClassifierSample struct {
+	Group  string
+	Tokens []string
+}
+
+type ClassifierJSON struct {
+	IdGroup    int64          `json:"id_group"`
+	IdAccaunt  int64          `json:"id_accaunt"`
+	Samples    int            `json:"samples"`
+	Groups     map[string]int `json:"groups"` // expences learnt per group of expence
+	Vocabulary int            `json:"vocabulary"`
+	TrainedAt  string         `json:"trained_at"`
+	UpdatedAt  string         `json:"updated_at"`
+}
+
+// group the categorizer offers and how sure it is
+type SuggestionJSON struct {
+	GroupExpence string  `json:"group_expence"`
+	IdCategory   int64   `json:"id_category"`
+	Score        float64 `json:"score"` // 0-1, share of probability among groups
+}
+
+func (c *Classifier) ToJSON() (*ClassifierJSON, error) {
+	groups := make(map[string]int, len(c.docs))
+	for group, docs := range c.docs {
+		groups[group] = docs
+	}
+	return &ClassifierJSON{
+		IdGroup:    c.idGroup,
+		IdAccaunt:  c.idAccaunt,
+		Samples:    len(c.samples),
+		Groups:     groups,
+		Vocabulary: len(c.vocabulary),
+		TrainedAt:  formatDeletedAt(c.trainedAt),
+		UpdatedAt:  formatDeletedAt(c.updatedAt),
+	}, nil
+}
+
+func (c *Classifier) GetIdGroup() int64 {
+	return c.idGroup
+}
+
+func (c *Classifier) GetIdAccaunt() int64 {
+	return c.idAccaunt
+}
+
+func (c *Classifier) GetTrainedAt() time.Time {
+	return c.trainedAt
+}
+
+func (c *Classifier) GetUpdatedAt() time.Time {
+	return c.updatedAt
+}
+
+// expences learnt
+func (c *Classifier) GetSamples() int {
+	return len(c.samples)
+}
+
+// expences learnt per group of expence
+func (c *Classifier) GetDocs() map[string]int {
+	return c.docs
+}
+
+func (c *Classifier) GetTokenCount(group, token string) int {
+	return c.tokens[group][token]
+}
+
+func (c *Classifier) GetTokenTotal(group string) int {
+	return c.totals[group]
+}
+
+func (c *Classifier) GetVocabularySize() int {
+	return len(c.vocabulary)
+}
+
+func (c *Classifier) SetIdGroup(idGroup int64) {
+	c.idGroup = idGroup
+}
+
+func (c *Classifier) SetIdAccaunt(idAccaunt int64) {
+	c.idAccaunt = idAccaunt
+}
+
+func (c *Classifier) SetTrainedAt(date time.Time) {
+	c.trainedAt = date
+}
+
+func (c *Classifier) SetUpdatedAt(date time.Time) {
+	c.updatedAt = date
+}
+
+// forget everything learnt
+func (c *Classifier) Reset() {
+	c.samples = make(map[int64]ClassifierSample)
+	c.docs = make(map[string]int)
+	c.tokens = make(map[string]map[string]int)
+	c.totals = make(map[string]int)
+	c.vocabulary = make(map[string]int)
+}
+
+// count expence as example of group, earlier sample of it is forgotten first
+func (c *Classifier) Learn(idExpence int64, sample ClassifierSample) {
+	if c.samples == nil {
+		c.Reset()
+	}
+	c.Forget(idExpence)
+	c.samples[idExpence] = sample
+	c.count(sample, 1)
+}
+
+// take expence back out of the counts, false if it was not learnt
+func (c *Classifier) Forget(idExpence int64) bool {
+	sample, ok := c.samples[idExpence]
+	if !ok {
+		return false
+	}
+	delete(c.samples, idExpence)
+	c.count(sample, -1)
+	return true
+}
+
+// sample the expence was learnt as
+func (c *Classifier) Sample(idExpence int64) (ClassifierSample, bool) {
+	sample, ok := c.samples[idExpence]
+	return sample, ok
+}
+
+func (c *Classifier) count(sample ClassifierSample, delta int) {
+	c.docs[sample.Group] += delta
+	if c.docs[sample.Group] <= 0 {
+		delete(c.docs, sample.Group)
+	}
+
+	if c.tokens[sample.Group] == nil {
+		c.tokens[sample.Group] = make(map[string]int)
+	}
+	seen := make(map[string]bool)
+	for _, token := range sample.Tokens {
+		c.tokens[sample.Group][token] += delta
+		if c.tokens[sample.Group][token] <= 0 {
+			delete(c.tokens[sample.Group], token)
+		}
+		c.totals[sample.Group] += delta
+		if !seen[token] {
+			seen[token] = true
+			c.vocabulary[token] += delta
+			if c.vocabulary[token] <= 0 {
+				delete(c.vocabulary, token)
+			}
+		}
+	}
+	if c.totals[sample.Group] <= 0 {
+		delete(c.totals, sample.Group)
+		delete(c.tokens, sample.Group)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import "time"
+
+// статусы пары дубликатов
+const (
+	DuplicateStatusOpen      = "open"      // waits for review
+	DuplicateStatusMerged    = "merged"    // one expence of the pair is deleted
+	DuplicateStatusDismissed = "dismissed" // not a duplicate, pair is not found again
+)
+
+// pair of expences that look like the same purchase
+type Duplicate struct {
+	idDuplicate    int64
+	idExpence      int64 // earlier one of the pair
+	idExpenceOther int64
+	score          float64  // 0-1, how alike expences are
+	reasons        []string // what made them alike
+	status         string
+	detectedAt     time.Time
+	resolvedAt     time.Time // zero while open
+	resolvedBy     string
+}
+
+type DuplicateJSON struct {
+	IdDuplicate    int64         `json:"id_duplicate"`
+	IdExpence      int64         `json:"id_expence"`
+	IdExpenceOther int64         `json:"id_expence_other"`
+	Score          float64       `json:"score"`
+	Reasons        []string      `json:"reasons"`
+	Status         string        `json:"status"`
+	DetectedAt     string        `json:"detected_at"`
+	ResolvedAt     string        `json:"resolved_at"`
+	ResolvedBy     string        `json:"resolved_by"`
+	Expences       []ExpenceJSON `json:"expences,omitempty"` // the pair, for review
+}
+
+func (d *Duplicate) ToJSON() (*DuplicateJSON, error) {
+	return &DuplicateJSON{
+		IdDuplicate:    d.idDuplicate,
+		IdExpence:      d.idExpence,
+		IdExpenceOther: d.idExpenceOther,
+		Score:          d.score,
+		Reasons:        d.reasons,
+		Status:         d.status,
+		DetectedAt:     d.detectedAt.Format("2006-01-02 15:04:05"),
+		ResolvedAt:     formatDeletedAt(d.resolvedAt),
+		ResolvedBy:     d.resolvedBy,
+	}, nil
+}
+
+func (d *Duplicate) GetIdDuplicate() int64 {
+	return d.idDuplicate
+}
+
+func (d *Duplicate) GetIdExpence() int64 {
+	return d.idExpence
+}
+
+func (d *Duplicate) GetIdExpenceOther() int64 {
+	return d.idExpenceOther
+}
+
+func (d *Duplicate) GetScore() float64 {
+	return d.score
+}
+
+func (d *Duplicate) GetReasons() []string {
+	return d.reasons
+}
+
+func (d *Duplicate) GetStatus() string {
+	return d.status
+}
+
+func (d *Duplicate) GetDetectedAt() time.Time {
+	return d.detectedAt
+}
+
+func (d *Duplicate) GetResolvedAt() time.Time {
+	return d.resolvedAt
+}
+
+func (d *Duplicate) GetResolvedBy() string {
+	return d.resolvedBy
+}
+
+// expence is one of the pair
+func (d *Duplicate) Has(idExpence int64) bool {
+	return d.idExpence == idExpence || d.idExpenceOther == idExpence
+}
+
+func (d *Duplicate) SetIdDuplicate(idDuplicate int64) {
+	d.idDuplicate = idDuplicate
+}
+
+func (d *Duplicate) SetIdExpence(idExpence int64) {
+	d.idExpence = idExpence
+}
+
+func (d *Duplicate) SetIdExpenceOther(idExpence int64) {
+	d.idExpenceOther = idExpence
+}
+
+func (d *Duplicate) SetScore(score float64) {
+	d.score = score
+}
+
+func (d *Duplicate) SetReasons(reasons []string) {
+	d.reasons = reasons
+}
+
+func (d *Duplicate) SetStatus(status string) {
+	d.status = status
+}
+
+func (d *Duplicate) SetDetectedAt(date time.Time) {
+	d.detectedAt = date
+}
+
+func (d *Duplicate) SetResolvedAt(date time.Time) {
+	d.resolvedAt = date
+}
+
+func (d *Duplicate) SetResolvedBy(resolvedBy string) {
+	d.resolvedBy = resolvedBy
+}
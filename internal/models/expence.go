@@ -6,43 +6,90 @@ import (
 
 // оставить одну модель на ежемес траты и единоразовые, но проверять через repeat + dateActualFrom + dateActualTo
 type Expence struct {
-	idExpence          int64  // айди траты
-	groupExpence       string // группа траты
-	titleExpence       string // название траты
-	descriptionExpence string // доп инфа о трате
-	repeat             int8   // ежемес или нет
+	idExpence          int64           // айди траты
+	idAccaunt          int64           // кто платил
+	bankName           string          // card paid with, empty if unknown
+	externalId         string          // id of transaction in bank statement, FITID of OFX
+	groupExpence       string          // группа траты
+	idCategory         int64           // category of the group
+	tags               []string        // labels besides the group, lower case
+	suggestedGroup     string          // group the categorizer offers for expence without one
+	suggestedScore     float64         // 0-1, confidence of the categorizer
+	splits             []*ExpenceSplit // lines by category or member, empty if not split
+	titleExpence       string          // название траты
+	descriptionExpence string          // доп инфа о трате
+	repeat             int8            // ежемес или нет
+	rrule              string          // recurrence rule, empty with repeat=1 is monthly
 	amount             float64
 	date               time.Time // дата совершения единоразовой покупки
 	updBy              string    // who changed
 	dateActualFrom     time.Time // actual from
 	dateActualTo       time.Time // actual to if 9999-12-31 to now
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
+
+	occurrence bool // generated occurrence of recurring expence, not stored
 }
 
 type ExpenceJSON struct {
-	IdExpence          int64   `json:"id_expence"`
-	GroupExpence       string  `json:"group_expence"`
-	TitleExpence       string  `json:"title_expence"`
-	DescriptionExpence string  `json:"description_expence"`
-	Repeat             int8    `json:"repeat"`
-	Amount             float64 `json:"amount"`
-	Date               string  `json:"date"`
-	UpdBy              string  `json:"upd_by"`
-	DateActualFrom     string  `json:"date_actual_from"`
-	DateActualTo       string  `json:"date_actual_to"`
+	IdExpence          int64              `json:"id_expence"`
+	IdAccaunt          int64              `json:"id_accaunt"`
+	BankName           string             `json:"bank_name"`
+	ExternalId         string             `json:"external_id"`
+	GroupExpence       string             `json:"group_expence"`
+	IdCategory         int64              `json:"id_category"`
+	Tags               []string           `json:"tags"`
+	SuggestedGroup     string             `json:"suggested_group"`
+	SuggestedScore     float64            `json:"suggested_score"`
+	Splits             []ExpenceSplitJSON `json:"splits"`
+	TitleExpence       string             `json:"title_expence"`
+	DescriptionExpence string             `json:"description_expence"`
+	Repeat             int8               `json:"repeat"`
+	Rrule              string             `json:"rrule"`
+	Amount             float64            `json:"amount"`
+	Date               string             `json:"date"`
+	UpdBy              string             `json:"upd_by"`
+	DateActualFrom     string             `json:"date_actual_from"`
+	DateActualTo       string             `json:"date_actual_to"`
+	DeletedAt          string             `json:"deleted_at"`
+	DeletedBy          string             `json:"deleted_by"`
+	Occurrence         bool               `json:"occurrence"`
 }
 
 func (e *Expence) ToJSON() (*ExpenceJSON, error) {
+	splits := []ExpenceSplitJSON{}
+	for _, split := range e.splits {
+		splitJSON, err := split.ToJSON()
+		if err != nil {
+			return nil, err
+		}
+		splits = append(splits, *splitJSON)
+	}
+
 	return &ExpenceJSON{
 		IdExpence:          e.idExpence,
+		IdAccaunt:          e.idAccaunt,
+		BankName:           e.bankName,
+		ExternalId:         e.externalId,
 		GroupExpence:       e.groupExpence,
+		IdCategory:         e.idCategory,
+		Tags:               append([]string{}, e.tags...),
+		SuggestedGroup:     e.suggestedGroup,
+		SuggestedScore:     e.suggestedScore,
+		Splits:             splits,
 		TitleExpence:       e.titleExpence,
 		DescriptionExpence: e.descriptionExpence,
 		Repeat:             e.repeat,
+		Rrule:              e.rrule,
 		Amount:             e.amount,
 		Date:               e.date.Format("2006-01-02 15:04:05"),
 		UpdBy:              e.updBy,
 		DateActualFrom:     e.dateActualFrom.Format("2006-01-02 15:04:05"),
 		DateActualTo:       e.dateActualTo.Format("2006-01-02 15:04:05"),
+		DeletedAt:          formatDeletedAt(e.deletedAt),
+		DeletedBy:          e.deletedBy,
+		Occurrence:         e.occurrence,
 	}, nil
 }
 
@@ -50,10 +97,42 @@ func (e *Expence) GetIdExpence() int64 {
 	return e.idExpence
 }
 
+func (e *Expence) GetIdAccaunt() int64 {
+	return e.idAccaunt
+}
+
+func (e *Expence) GetBankName() string {
+	return e.bankName
+}
+
+func (e *Expence) GetTags() []string {
+	return e.tags
+}
+
+func (e *Expence) GetSuggestedGroup() string {
+	return e.suggestedGroup
+}
+
+func (e *Expence) GetSuggestedScore() float64 {
+	return e.suggestedScore
+}
+
+func (e *Expence) GetSplits() []*ExpenceSplit {
+	return e.splits
+}
+
+func (e *Expence) GetExternalId() string {
+	return e.externalId
+}
+
 func (e *Expence) GetGroupExpence() string {
 	return e.groupExpence
 }
 
+func (e *Expence) GetIdCategory() int64 {
+	return e.idCategory
+}
+
 func (e *Expence) GetTitleExpence() string {
 	return e.titleExpence
 }
@@ -66,6 +145,10 @@ func (e *Expence) GetRepeat() int8 {
 	return e.repeat
 }
 
+func (e *Expence) GetRrule() string {
+	return e.rrule
+}
+
 func (e *Expence) GetAmount() float64 {
 	return e.amount
 }
@@ -90,10 +173,40 @@ func (e *Expence) SetIdExpence(id int64) {
 	e.idExpence = id
 }
 
+func (e *Expence) SetIdAccaunt(id int64) {
+	e.idAccaunt = id
+}
+
+func (e *Expence) SetBankName(bankName string) {
+	e.bankName = bankName
+}
+
+func (e *Expence) SetTags(tags []string) {
+	e.tags = tags
+}
+
+// group offered by the categorizer, empty group clears suggestion
+func (e *Expence) SetSuggestion(group string, score float64) {
+	e.suggestedGroup = group
+	e.suggestedScore = score
+}
+
+func (e *Expence) SetSplits(splits []*ExpenceSplit) {
+	e.splits = splits
+}
+
+func (e *Expence) SetExternalId(externalId string) {
+	e.externalId = externalId
+}
+
 func (e *Expence) SetGroupExpence(group string) {
 	e.groupExpence = group
 }
 
+func (e *Expence) SetIdCategory(idCategory int64) {
+	e.idCategory = idCategory
+}
+
 func (e *Expence) SetTitleExpence(title string) {
 	e.titleExpence = title
 }
@@ -106,6 +219,10 @@ func (e *Expence) SetRepeat(repeat int8) {
 	e.repeat = repeat
 }
 
+func (e *Expence) SetRrule(rrule string) {
+	e.rrule = rrule
+}
+
 func (e *Expence) SetAmount(amount float64) {
 	e.amount = amount
 }
@@ -125,3 +242,57 @@ func (e *Expence) SetDateActualFrom(date time.Time) {
 func (e *Expence) SetDateActualTo(date time.Time) {
 	e.dateActualTo = date
 }
+
+func (e *Expence) GetDeletedAt() time.Time {
+	return e.deletedAt
+}
+
+func (e *Expence) GetDeletedBy() string {
+	return e.deletedBy
+}
+
+func (e *Expence) IsDeleted() bool {
+	return !e.deletedAt.IsZero()
+}
+
+func (e *Expence) SetDeletedAt(date time.Time) {
+	e.deletedAt = date
+}
+
+func (e *Expence) SetDeletedBy(deletedBy string) {
+	e.deletedBy = deletedBy
+}
+
+func (e *Expence) IsOccurrence() bool {
+	return e.occurrence
+}
+
+// expence as its split lines, each a copy with amount, category and account of
+// the line; expence without lines is its only part
+func (e *Expence) Parts() []*Expence {
+	if len(e.splits) == 0 {
+		return []*Expence{e}
+	}
+
+	parts := make([]*Expence, 0, len(e.splits))
+	for _, split := range e.splits {
+		part := *e
+		part.amount = split.amount
+		part.idCategory = split.idCategory
+		part.groupExpence = split.groupExpence
+		if split.idAccaunt != 0 {
+			part.idAccaunt = split.idAccaunt
+		}
+		part.splits = nil
+		parts = append(parts, &part)
+	}
+	return parts
+}
+
+// copy of recurring expence dated at one of its occurrences
+func (e *Expence) Occurrence(date time.Time) *Expence {
+	occurrence := *e
+	occurrence.date = date
+	occurrence.occurrence = true
+	return &occurrence
+}
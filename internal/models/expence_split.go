@@ -0,0 +1,58 @@
+package models
+
+// line of expence split across categories or group members, lines of one
+// expence sum to its amount
+type ExpenceSplit struct {
+	amount       float64
+	idCategory   int64  // category of the line, parent category if not given
+	groupExpence string // text of the category
+	idAccaunt    int64  // member responsible for the line, 0 is the payer
+}
+
+type ExpenceSplitJSON struct {
+	Amount       float64 `json:"amount"`
+	IdCategory   int64   `json:"id_category"`
+	GroupExpence string  `json:"group_expence"`
+	IdAccaunt    int64   `json:"id_accaunt"`
+}
+
+func (es *ExpenceSplit) ToJSON() (*ExpenceSplitJSON, error) {
+	return &ExpenceSplitJSON{
+		Amount:       es.amount,
+		IdCategory:   es.idCategory,
+		GroupExpence: es.groupExpence,
+		IdAccaunt:    es.idAccaunt,
+	}, nil
+}
+
+func (es *ExpenceSplit) GetAmount() float64 {
+	return es.amount
+}
+
+func (es *ExpenceSplit) GetIdCategory() int64 {
+	return es.idCategory
+}
+
+func (es *ExpenceSplit) GetGroupExpence() string {
+	return es.groupExpence
+}
+
+func (es *ExpenceSplit) GetIdAccaunt() int64 {
+	return es.idAccaunt
+}
+
+func (es *ExpenceSplit) SetAmount(amount float64) {
+	es.amount = amount
+}
+
+func (es *ExpenceSplit) SetIdCategory(idCategory int64) {
+	es.idCategory = idCategory
+}
+
+func (es *ExpenceSplit) SetGroupExpence(group string) {
+	es.groupExpence = group
+}
+
+func (es *ExpenceSplit) SetIdAccaunt(idAccaunt int64) {
+	es.idAccaunt = idAccaunt
+}
@@ -0,0 +1,25 @@
+package models
+
+// one day of balance forecast
+type ForecastDayJSON struct {
+	Date     string  `json:"date"`
+	Income   float64 `json:"income"`  // expected incomes of the day
+	Expence  float64 `json:"expence"` // recurring expences of the day
+	Goal     float64 `json:"goal"`    // goal contributions of the day
+	Balance  float64 `json:"balance"` // at the end of the day
+	Negative bool    `json:"negative"`
+}
+
+type ForecastJSON struct {
+	IdAccaunt         int64             `json:"id_accaunt"`
+	Horizon           string            `json:"horizon"`
+	From              string            `json:"from"`
+	To                string            `json:"to"`
+	StartBalance      float64           `json:"start_balance"` // current remain
+	EndBalance        float64           `json:"end_balance"`
+	MinBalance        float64           `json:"min_balance"`
+	MinBalanceDate    string            `json:"min_balance_date"`
+	NegativeDays      int               `json:"negative_days"`
+	FirstNegativeDate string            `json:"first_negative_date"` // empty if never negative
+	Days              []ForecastDayJSON `json:"days"`
+}
@@ -10,6 +10,9 @@ type Goal struct {
 	updBy          string    // who changed
 	dateActualFrom time.Time // actual from
 	dateActualTo   time.Time // actual to if 9999-12-31 to now
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
 }
 
 type GoalJSON struct {
@@ -20,6 +23,8 @@ type GoalJSON struct {
 	UpdBy          string  `json:"upd_by"`
 	DateActualFrom string  `json:"date_actual_from"`
 	DateActualTo   string  `json:"date_actual_to"`
+	DeletedAt      string  `json:"deleted_at"`
+	DeletedBy      string  `json:"deleted_by"`
 }
 
 func (g *Goal) ToJSON() (*GoalJSON, error) {
@@ -31,6 +36,8 @@ func (g *Goal) ToJSON() (*GoalJSON, error) {
 		UpdBy:          g.updBy,
 		DateActualFrom: g.dateActualFrom.Format("2006-01-02 15:04:05"),
 		DateActualTo:   g.dateActualTo.Format("2006-01-02 15:04:05"),
+		DeletedAt:      formatDeletedAt(g.deletedAt),
+		DeletedBy:      g.deletedBy,
 	}, nil
 }
 
@@ -89,3 +96,23 @@ func (g *Goal) SetDateActualFrom(date time.Time) {
 func (g *Goal) SetDateActualTo(date time.Time) {
 	g.dateActualTo = date
 }
+
+func (g *Goal) GetDeletedAt() time.Time {
+	return g.deletedAt
+}
+
+func (g *Goal) GetDeletedBy() string {
+	return g.deletedBy
+}
+
+func (g *Goal) IsDeleted() bool {
+	return !g.deletedAt.IsZero()
+}
+
+func (g *Goal) SetDeletedAt(date time.Time) {
+	g.deletedAt = date
+}
+
+func (g *Goal) SetDeletedBy(deletedBy string) {
+	g.deletedBy = deletedBy
+}
@@ -0,0 +1,141 @@
+package models
+
+import "time"
+
+// виды взносов в цель
+const (
+	GoalContributionExplicit = "explicit" // fixed amount put aside, negative to take back
+	GoalContributionEarmark  = "earmark"  // share of a remain, follows the remain amount
+)
+
+// money put toward a goal, contributions are never changed, a new earmark
+// of the same remain replaces the previous share
+type GoalContribution struct {
+	idContribution int64     // id
+	idGoal         int64     // goal id
+	idAccaunt      int64     // account id
+	kind           string    // explicit or earmark
+	amount         float64   // sum of explicit contribution
+	idRemains      int64     // remain id of earmark
+	share          float64   // part of remain for earmark, 0 releases it
+	date           time.Time // when put aside
+	updBy          string    // who changed
+}
+
+type GoalContributionJSON struct {
+	IdContribution int64   `json:"id_contribution"`
+	IdGoal         int64   `json:"id_goal"`
+	IdAccaunt      int64   `json:"id_accaunt"`
+	Kind           string  `json:"kind"`
+	Amount         float64 `json:"amount"`
+	IdRemains      int64   `json:"id_remains"`
+	Share          float64 `json:"share"`
+	Date           string  `json:"date"`
+	UpdBy          string  `json:"upd_by"`
+}
+
+func (c *GoalContribution) ToJSON() (*GoalContributionJSON, error) {
+	return &GoalContributionJSON{
+		IdContribution: c.idContribution,
+		IdGoal:         c.idGoal,
+		IdAccaunt:      c.idAccaunt,
+		Kind:           c.kind,
+		Amount:         c.amount,
+		IdRemains:      c.idRemains,
+		Share:          c.share,
+		Date:           c.date.Format("2006-01-02 15:04:05"),
+		UpdBy:          c.updBy,
+	}, nil
+}
+
+func (c *GoalContribution) GetIdContribution() int64 {
+	return c.idContribution
+}
+
+func (c *GoalContribution) GetIdGoal() int64 {
+	return c.idGoal
+}
+
+func (c *GoalContribution) GetIdAccaunt() int64 {
+	return c.idAccaunt
+}
+
+func (c *GoalContribution) GetKind() string {
+	return c.kind
+}
+
+func (c *GoalContribution) GetAmount() float64 {
+	return c.amount
+}
+
+func (c *GoalContribution) GetIdRemains() int64 {
+	return c.idRemains
+}
+
+func (c *GoalContribution) GetShare() float64 {
+	return c.share
+}
+
+func (c *GoalContribution) GetDate() time.Time {
+	return c.date
+}
+
+func (c *GoalContribution) GetUpdBy() string {
+	return c.updBy
+}
+
+func (c *GoalContribution) SetIdContribution(idContribution int64) {
+	c.idContribution = idContribution
+}
+
+func (c *GoalContribution) SetIdGoal(idGoal int64) {
+	c.idGoal = idGoal
+}
+
+func (c *GoalContribution) SetIdAccaunt(idAccaunt int64) {
+	c.idAccaunt = idAccaunt
+}
+
+func (c *GoalContribution) SetKind(kind string) {
+	c.kind = kind
+}
+
+func (c *GoalContribution) SetAmount(amount float64) {
+	c.amount = amount
+}
+
+func (c *GoalContribution) SetIdRemains(idRemains int64) {
+	c.idRemains = idRemains
+}
+
+func (c *GoalContribution) SetShare(share float64) {
+	c.share = share
+}
+
+func (c *GoalContribution) SetDate(date time.Time) {
+	c.date = date
+}
+
+func (c *GoalContribution) SetUpdBy(updBy string) {
+	c.updBy = updBy
+}
+
+// статусы прогресса цели
+const (
+	GoalStatusOnTrack  = "on_track"
+	GoalStatusBehind   = "behind"
+	GoalStatusAchieved = "achieved"
+	GoalStatusOverdue  = "overdue" // deadline passed, target not reached
+)
+
+// goal with its progress
+type GoalProgressJSON struct {
+	GoalJSON
+	Saved           float64 `json:"saved"`
+	Remaining       float64 `json:"remaining"`
+	Percent         float64 `json:"percent"`
+	MonthsLeft      int     `json:"months_left"`
+	RequiredMonthly float64 `json:"required_monthly"` // to reach the target by the deadline
+	ExpectedSaved   float64 `json:"expected_saved"`   // by now at even pace from the goal start
+	Status          string  `json:"status"`
+}
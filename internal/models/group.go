@@ -0,0 +1,154 @@
+package models
+
+import "time"
+
+type Group struct { // family or household
+	idGroup int64
+	name    string
+}
+
+type GroupJSON struct {
+	IdGroup int64  `json:"id_group"`
+	Name    string `json:"name"`
+}
+
+func (g *Group) ToJSON() (*GroupJSON, error) {
+	return &GroupJSON{
+		IdGroup: g.idGroup,
+		Name:    g.name,
+	}, nil
+}
+
+func (g *Group) GetIdGroup() int64 {
+	return g.idGroup
+}
+
+func (g *Group) GetName() string {
+	return g.name
+}
+
+func (g *Group) SetIdGroup(id int64) {
+	g.idGroup = id
+}
+
+func (g *Group) SetName(name string) {
+	g.name = name
+}
+
+const (
+	RoleOwner  = "owner"  // manages membership and shared records
+	RoleMember = "member" // edits own records
+	RoleViewer = "viewer" // read only
+)
+
+type GroupMember struct {
+	idGroup   int64
+	idAccaunt int64
+	role      string
+}
+
+type GroupMemberJSON struct {
+	IdGroup   int64  `json:"id_group"`
+	IdAccaunt int64  `json:"id_accaunt"`
+	Role      string `json:"role"`
+}
+
+func (gm *GroupMember) ToJSON() (*GroupMemberJSON, error) {
+	return &GroupMemberJSON{
+		IdGroup:   gm.idGroup,
+		IdAccaunt: gm.idAccaunt,
+		Role:      gm.role,
+	}, nil
+}
+
+func (gm *GroupMember) GetIdGroup() int64 {
+	return gm.idGroup
+}
+
+func (gm *GroupMember) GetIdAccaunt() int64 {
+	return gm.idAccaunt
+}
+
+func (gm *GroupMember) GetRole() string {
+	return gm.role
+}
+
+func (gm *GroupMember) SetIdGroup(id int64) {
+	gm.idGroup = id
+}
+
+func (gm *GroupMember) SetIdAccaunt(id int64) {
+	gm.idAccaunt = id
+}
+
+func (gm *GroupMember) SetRole(role string) {
+	gm.role = role
+}
+
+// membership offered by an owner, becomes a GroupMember once the invited
+// account accepts it
+type GroupInvite struct {
+	idGroup   int64
+	idAccaunt int64
+	role      string
+	invitedBy int64
+	createdAt time.Time
+}
+
+type GroupInviteJSON struct {
+	IdGroup   int64     `json:"id_group"`
+	IdAccaunt int64     `json:"id_accaunt"`
+	Role      string    `json:"role"`
+	InvitedBy int64     `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (gi *GroupInvite) ToJSON() (*GroupInviteJSON, error) {
+	return &GroupInviteJSON{
+		IdGroup:   gi.idGroup,
+		IdAccaunt: gi.idAccaunt,
+		Role:      gi.role,
+		InvitedBy: gi.invitedBy,
+		CreatedAt: gi.createdAt,
+	}, nil
+}
+
+func (gi *GroupInvite) GetIdGroup() int64 {
+	return gi.idGroup
+}
+
+func (gi *GroupInvite) GetIdAccaunt() int64 {
+	return gi.idAccaunt
+}
+
+func (gi *GroupInvite) GetRole() string {
+	return gi.role
+}
+
+func (gi *GroupInvite) GetInvitedBy() int64 {
+	return gi.invitedBy
+}
+
+func (gi *GroupInvite) GetCreatedAt() time.Time {
+	return gi.createdAt
+}
+
+func (gi *GroupInvite) SetIdGroup(id int64) {
+	gi.idGroup = id
+}
+
+func (gi *GroupInvite) SetIdAccaunt(id int64) {
+	gi.idAccaunt = id
+}
+
+func (gi *GroupInvite) SetRole(role string) {
+	gi.role = role
+}
+
+func (gi *GroupInvite) SetInvitedBy(id int64) {
+	gi.invitedBy = id
+}
+
+func (gi *GroupInvite) SetCreatedAt(createdAt time.Time) {
+	gi.createdAt = createdAt
+}
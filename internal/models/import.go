@@ -0,0 +1,242 @@
+package models
+
+import "time"
+
+// поля выписки, в профиле им сопоставляются колонки
+const (
+	ImportFieldDate        = "date"
+	ImportFieldAmount      = "amount"
+	ImportFieldDebit       = "debit"  // money spent, with debit_credit sign convention
+	ImportFieldCredit      = "credit" // money received, with debit_credit sign convention
+	ImportFieldDescription = "description"
+	ImportFieldCategory    = "category"
+	ImportFieldReference   = "reference"
+)
+
+// знак суммы в выписке
+const (
+	ImportSignNegativeExpence = "negative_expence" // spent amounts are negative
+	ImportSignPositiveExpence = "positive_expence" // spent amounts are positive
+	ImportSignDebitCredit     = "debit_credit"     // spent and received amounts are in own columns
+)
+
+// кодировки выписки
+const (
+	ImportEncodingUTF8   = "utf-8"
+	ImportEncodingCP1251 = "cp1251"
+)
+
+// форматы выписки
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatOFX   = "ofx" // OFX 1.x SGML and OFX 2.x XML
+	ImportFormatQIF   = "qif"
+	ImportFormatCAMT  = "camt.053"
+	ImportFormatMT940 = "mt940"
+)
+
+// saved way to read CSV export of a bank
+type ImportProfile struct {
+	idProfile        int64
+	idAccaunt        int64 // owner
+	name             string
+	bankName         string            // card imported expences are paid with
+	delimiter        string            // "," when empty
+	skipRows         int               // lines before header or data
+	hasHeader        bool              // columns are given by header names
+	columns          map[string]string // field to column name, or to 1-based number without header
+	dateFormat       string            // go layout, e.g. 02.01.2006
+	decimalSeparator string            // "." or ","
+	signConvention   string
+	encoding         string
+	updBy            string // who changed
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
+}
+
+type ImportProfileJSON struct {
+	IdProfile        int64             `json:"id_profile"`
+	IdAccaunt        int64             `json:"id_accaunt"`
+	Name             string            `json:"name"`
+	BankName         string            `json:"bank_name"`
+	Delimiter        string            `json:"delimiter"`
+	SkipRows         int               `json:"skip_rows"`
+	HasHeader        bool              `json:"has_header"`
+	Columns          map[string]string `json:"columns"`
+	DateFormat       string            `json:"date_format"`
+	DecimalSeparator string            `json:"decimal_separator"`
+	SignConvention   string            `json:"sign_convention"`
+	Encoding         string            `json:"encoding"`
+	UpdBy            string            `json:"upd_by"`
+	DeletedAt        string            `json:"deleted_at"`
+	DeletedBy        string            `json:"deleted_by"`
+}
+
+func (p *ImportProfile) ToJSON() (*ImportProfileJSON, error) {
+	return &ImportProfileJSON{
+		IdProfile:        p.idProfile,
+		IdAccaunt:        p.idAccaunt,
+		Name:             p.name,
+		BankName:         p.bankName,
+		Delimiter:        p.delimiter,
+		SkipRows:         p.skipRows,
+		HasHeader:        p.hasHeader,
+		Columns:          p.columns,
+		DateFormat:       p.dateFormat,
+		DecimalSeparator: p.decimalSeparator,
+		SignConvention:   p.signConvention,
+		Encoding:         p.encoding,
+		UpdBy:            p.updBy,
+		DeletedAt:        formatDeletedAt(p.deletedAt),
+		DeletedBy:        p.deletedBy,
+	}, nil
+}
+
+func (p *ImportProfile) GetIdProfile() int64 {
+	return p.idProfile
+}
+
+func (p *ImportProfile) GetIdAccaunt() int64 {
+	return p.idAccaunt
+}
+
+func (p *ImportProfile) GetName() string {
+	return p.name
+}
+
+func (p *ImportProfile) GetBankName() string {
+	return p.bankName
+}
+
+func (p *ImportProfile) GetDelimiter() string {
+	return p.delimiter
+}
+
+func (p *ImportProfile) GetSkipRows() int {
+	return p.skipRows
+}
+
+func (p *ImportProfile) GetHasHeader() bool {
+	return p.hasHeader
+}
+
+func (p *ImportProfile) GetColumns() map[string]string {
+	return p.columns
+}
+
+func (p *ImportProfile) GetDateFormat() string {
+	return p.dateFormat
+}
+
+func (p *ImportProfile) GetDecimalSeparator() string {
+	return p.decimalSeparator
+}
+
+func (p *ImportProfile) GetSignConvention() string {
+	return p.signConvention
+}
+
+func (p *ImportProfile) GetEncoding() string {
+	return p.encoding
+}
+
+func (p *ImportProfile) GetUpdBy() string {
+	return p.updBy
+}
+
+func (p *ImportProfile) SetIdProfile(idProfile int64) {
+	p.idProfile = idProfile
+}
+
+func (p *ImportProfile) SetIdAccaunt(idAccaunt int64) {
+	p.idAccaunt = idAccaunt
+}
+
+func (p *ImportProfile) SetName(name string) {
+	p.name = name
+}
+
+func (p *ImportProfile) SetBankName(bankName string) {
+	p.bankName = bankName
+}
+
+func (p *ImportProfile) SetDelimiter(delimiter string) {
+	p.delimiter = delimiter
+}
+
+func (p *ImportProfile) SetSkipRows(skipRows int) {
+	p.skipRows = skipRows
+}
+
+func (p *ImportProfile) SetHasHeader(hasHeader bool) {
+	p.hasHeader = hasHeader
+}
+
+func (p *ImportProfile) SetColumns(columns map[string]string) {
+	p.columns = columns
+}
+
+func (p *ImportProfile) SetDateFormat(dateFormat string) {
+	p.dateFormat = dateFormat
+}
+
+func (p *ImportProfile) SetDecimalSeparator(decimalSeparator string) {
+	p.decimalSeparator = decimalSeparator
+}
+
+func (p *ImportProfile) SetSignConvention(signConvention string) {
+	p.signConvention = signConvention
+}
+
+func (p *ImportProfile) SetEncoding(encoding string) {
+	p.encoding = encoding
+}
+
+func (p *ImportProfile) SetUpdBy(updBy string) {
+	p.updBy = updBy
+}
+
+func (p *ImportProfile) GetDeletedAt() time.Time {
+	return p.deletedAt
+}
+
+func (p *ImportProfile) GetDeletedBy() string {
+	return p.deletedBy
+}
+
+func (p *ImportProfile) IsDeleted() bool {
+	return !p.deletedAt.IsZero()
+}
+
+func (p *ImportProfile) SetDeletedAt(deletedAt time.Time) {
+	p.deletedAt = deletedAt
+}
+
+func (p *ImportProfile) SetDeletedBy(deletedBy string) {
+	p.deletedBy = deletedBy
+}
+
+// line of statement that was not imported
+type ImportSkippedJSON struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// records made from statement, with dry run nothing is stored
+type ImportResultJSON struct {
+	DryRun    bool                `json:"dry_run"`
+	Format    string              `json:"format"`
+	IdAccaunt int64               `json:"id_accaunt"`
+	Rows      int                 `json:"rows"` // transactions read from statement
+	Expences  []ExpenceJSON       `json:"expences"`
+	Incomes   []IncomeJSON        `json:"incomes"`
+	Skipped   []ImportSkippedJSON `json:"skipped"`
+
+	Superseded []IncomeJSON `json:"superseded_incomes"` // pending incomes replaced by imported ones
+
+	Remains      []RemainJSON `json:"remains"`       // versions made of statement balances
+	RemainBefore *RemainJSON  `json:"remain_before"` // version closed by first of them
+
+	Duplicates []DuplicateJSON `json:"duplicates"` // pairs found after import, empty with dry run
+}
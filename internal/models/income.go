@@ -4,33 +4,50 @@ import "time"
 
 type CustomTime time.Time
 
+// статусы дохода
+const (
+	IncomeStatusReceived = "received" // money came, empty status means received too
+	IncomeStatusPending  = "pending"  // created from income expected, waits for money
+)
+
 type Income struct {
 	idIncome         int64
 	idAccaunt        int64
-	idIncomeExpected int64   // id expected
-	amount           float64 // real amount
-	expectedAmount   float64 // expected amount
-	typeIncome       string  // salary or award
-	incomeMonthMonth int8    // 1-12
-	incomeMonthDate  int8    // 1-31
+	idIncomeExpected int64    // id expected
+	amount           float64  // real amount
+	expectedAmount   float64  // expected amount
+	typeIncome       string   // salary or award
+	incomeMonthMonth int8     // 1-12
+	incomeMonthDate  int8     // 1-31
+	status           string   // received or pending
+	externalId       string   // id of transaction in bank statement, FITID of OFX
+	tags             []string // labels, lower case
 
 	updBy          string    // who changed
 	dateActualFrom time.Time // actual from
 	dateActualTo   time.Time // actual to if 9999-12-31 to now
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
 }
 
 type IncomeJSON struct {
-	IdIncome         int64   `json:"id_income"`
-	IdAccaunt        int64   `json:"id_accaunt"`
-	IdIncomeExpected int64   `json:"id_income_expected"`
-	Amount           float64 `json:"amount"`
-	ExpectedAmount   float64 `json:"expected_amount"`
-	TypeIncome       string  `json:"type_income"`
-	IncomeMonthMonth int8    `json:"income_month_month"`
-	IncomeMonthDate  int8    `json:"income_month_date"`
-	UpdBy            string  `json:"upd_by"`
-	DateActualFrom   string  `json:"date_actual_from"`
-	DateActualTo     string  `json:"date_actual_to"`
+	IdIncome         int64    `json:"id_income"`
+	IdAccaunt        int64    `json:"id_accaunt"`
+	IdIncomeExpected int64    `json:"id_income_expected"`
+	Amount           float64  `json:"amount"`
+	ExpectedAmount   float64  `json:"expected_amount"`
+	TypeIncome       string   `json:"type_income"`
+	IncomeMonthMonth int8     `json:"income_month_month"`
+	IncomeMonthDate  int8     `json:"income_month_date"`
+	Status           string   `json:"status"`
+	ExternalId       string   `json:"external_id"`
+	Tags             []string `json:"tags"`
+	UpdBy            string   `json:"upd_by"`
+	DateActualFrom   string   `json:"date_actual_from"`
+	DateActualTo     string   `json:"date_actual_to"`
+	DeletedAt        string   `json:"deleted_at"`
+	DeletedBy        string   `json:"deleted_by"`
 }
 
 func (i *Income) ToJSON() (*IncomeJSON, error) {
@@ -43,9 +60,14 @@ func (i *Income) ToJSON() (*IncomeJSON, error) {
 		TypeIncome:       i.typeIncome,
 		IncomeMonthMonth: i.incomeMonthMonth,
 		IncomeMonthDate:  i.incomeMonthDate,
+		Status:           i.GetStatus(),
+		ExternalId:       i.externalId,
+		Tags:             append([]string{}, i.tags...),
 		UpdBy:            i.updBy,
 		DateActualFrom:   i.dateActualFrom.Format("2006-01-02 15:04:05"),
 		DateActualTo:     i.dateActualTo.Format("2006-01-02 15:04:05"),
+		DeletedAt:        formatDeletedAt(i.deletedAt),
+		DeletedBy:        i.deletedBy,
 	}, nil
 }
 
@@ -81,6 +103,17 @@ func (i *Income) GetIncomeMonthDate() int8 {
 	return i.incomeMonthDate
 }
 
+func (i *Income) GetStatus() string {
+	if i.status == "" {
+		return IncomeStatusReceived
+	}
+	return i.status
+}
+
+func (i *Income) IsPending() bool {
+	return i.status == IncomeStatusPending
+}
+
 func (i *Income) GetUpdBy() string {
 	return i.updBy
 }
@@ -93,6 +126,14 @@ func (i *Income) GetDateActualTo() time.Time {
 	return i.dateActualTo
 }
 
+func (i *Income) GetTags() []string {
+	return i.tags
+}
+
+func (i *Income) GetExternalId() string {
+	return i.externalId
+}
+
 func (i *Income) SetIdIncome(id int64) {
 	i.idIncome = id
 }
@@ -125,6 +166,18 @@ func (i *Income) SetIncomeMonthDate(date int8) {
 	i.incomeMonthDate = date
 }
 
+func (i *Income) SetStatus(status string) {
+	i.status = status
+}
+
+func (i *Income) SetTags(tags []string) {
+	i.tags = tags
+}
+
+func (i *Income) SetExternalId(externalId string) {
+	i.externalId = externalId
+}
+
 func (i *Income) SetUpdBy(updBy string) {
 	i.updBy = updBy
 }
@@ -136,3 +189,23 @@ func (i *Income) SetDateActualFrom(date time.Time) {
 func (i *Income) SetDateActualTo(date time.Time) {
 	i.dateActualTo = date
 }
+
+func (i *Income) GetDeletedAt() time.Time {
+	return i.deletedAt
+}
+
+func (i *Income) GetDeletedBy() string {
+	return i.deletedBy
+}
+
+func (i *Income) IsDeleted() bool {
+	return !i.deletedAt.IsZero()
+}
+
+func (i *Income) SetDeletedAt(date time.Time) {
+	i.deletedAt = date
+}
+
+func (i *Income) SetDeletedBy(deletedBy string) {
+	i.deletedBy = deletedBy
+}
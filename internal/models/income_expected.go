@@ -13,6 +13,9 @@ type IncomeExpected struct {
 	updBy           string    //who changed
 	dateActualFrom  time.Time //actual from
 	dateActualTo    time.Time //actual to if 9999-12-31 to nowday
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
 }
 
 type IncomeExpectedJSON struct {
@@ -24,6 +27,8 @@ type IncomeExpectedJSON struct {
 	UpdBy           string  `json:"upd_by"`
 	DateActualFrom  string  `json:"date_actual_from"`
 	DateActualTo    string  `json:"date_actual_to"`
+	DeletedAt       string  `json:"deleted_at"`
+	DeletedBy       string  `json:"deleted_by"`
 }
 
 func (ie *IncomeExpected) ToJSON() (*IncomeExpectedJSON, error) {
@@ -36,6 +41,8 @@ func (ie *IncomeExpected) ToJSON() (*IncomeExpectedJSON, error) {
 		UpdBy:           ie.updBy,
 		DateActualFrom:  ie.dateActualFrom.Format("2006-01-02 15:04:05"),
 		DateActualTo:    ie.dateActualTo.Format("2006-01-02 15:04:05"),
+		DeletedAt:       formatDeletedAt(ie.deletedAt),
+		DeletedBy:       ie.deletedBy,
 	}, nil
 }
 
@@ -102,3 +109,23 @@ func (ie *IncomeExpected) SetDateActualFrom(date time.Time) {
 func (ie *IncomeExpected) SetDateActualTo(date time.Time) {
 	ie.dateActualTo = date
 }
+
+func (ie *IncomeExpected) GetDeletedAt() time.Time {
+	return ie.deletedAt
+}
+
+func (ie *IncomeExpected) GetDeletedBy() string {
+	return ie.deletedBy
+}
+
+func (ie *IncomeExpected) IsDeleted() bool {
+	return !ie.deletedAt.IsZero()
+}
+
+func (ie *IncomeExpected) SetDeletedAt(date time.Time) {
+	ie.deletedAt = date
+}
+
+func (ie *IncomeExpected) SetDeletedBy(deletedBy string) {
+	ie.deletedBy = deletedBy
+}
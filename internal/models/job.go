@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// last run of a scheduler job
+type JobRun struct {
+	name       string
+	lastRun    time.Time // clock of the last run
+	lastPeriod string    // last period handled, e.g. 2024-05
+	processed  int       // records created by the last run
+	lastError  string    // empty if the last run succeeded
+}
+
+type JobRunJSON struct {
+	Name       string `json:"name"`
+	LastRun    string `json:"last_run"`
+	LastPeriod string `json:"last_period"`
+	Processed  int    `json:"processed"`
+	LastError  string `json:"last_error"`
+}
+
+func (j *JobRun) ToJSON() (*JobRunJSON, error) {
+	return &JobRunJSON{
+		Name:       j.name,
+		LastRun:    j.lastRun.Format("2006-01-02 15:04:05"),
+		LastPeriod: j.lastPeriod,
+		Processed:  j.processed,
+		LastError:  j.lastError,
+	}, nil
+}
+
+func (j *JobRun) GetName() string {
+	return j.name
+}
+
+func (j *JobRun) GetLastRun() time.Time {
+	return j.lastRun
+}
+
+func (j *JobRun) GetLastPeriod() string {
+	return j.lastPeriod
+}
+
+func (j *JobRun) GetProcessed() int {
+	return j.processed
+}
+
+func (j *JobRun) GetLastError() string {
+	return j.lastError
+}
+
+func (j *JobRun) SetName(name string) {
+	j.name = name
+}
+
+func (j *JobRun) SetLastRun(date time.Time) {
+	j.lastRun = date
+}
+
+func (j *JobRun) SetLastPeriod(period string) {
+	j.lastPeriod = period
+}
+
+func (j *JobRun) SetProcessed(processed int) {
+	j.processed = processed
+}
+
+func (j *JobRun) SetLastError(lastError string) {
+	j.lastError = lastError
+}
@@ -0,0 +1,236 @@
+package models
+
+import "time"
+
+// типы счетов плана счетов
+const (
+	LedgerAccountAsset     = "asset"
+	LedgerAccountLiability = "liability"
+	LedgerAccountIncome    = "income"
+	LedgerAccountExpense   = "expense"
+)
+
+// источники проводок
+const (
+	JournalSourceExpence   = "expence"
+	JournalSourceIncome    = "income"
+	JournalSourceTransfer  = "transfer"
+	JournalSourceManual    = "manual"
+	JournalSourceRecurring = "recurring" // occurrence of recurring expence, one entry per date
+)
+
+type LedgerAccount struct {
+	idLedgerAccount int64  // id
+	idAccaunt       int64  // account id, 0 if shared
+	name            string // Cash, Utilities, Salary ...
+	typeAccount     string // asset, liability, income, expense
+}
+
+type LedgerAccountJSON struct {
+	IdLedgerAccount int64  `json:"id_ledger_account"`
+	IdAccaunt       int64  `json:"id_accaunt"`
+	Name            string `json:"name"`
+	TypeAccount     string `json:"type_account"`
+}
+
+func (la *LedgerAccount) ToJSON() (*LedgerAccountJSON, error) {
+	return &LedgerAccountJSON{
+		IdLedgerAccount: la.idLedgerAccount,
+		IdAccaunt:       la.idAccaunt,
+		Name:            la.name,
+		TypeAccount:     la.typeAccount,
+	}, nil
+}
+
+func (la *LedgerAccount) GetIdLedgerAccount() int64 {
+	return la.idLedgerAccount
+}
+
+func (la *LedgerAccount) GetIdAccaunt() int64 {
+	return la.idAccaunt
+}
+
+func (la *LedgerAccount) GetName() string {
+	return la.name
+}
+
+func (la *LedgerAccount) GetTypeAccount() string {
+	return la.typeAccount
+}
+
+func (la *LedgerAccount) SetIdLedgerAccount(id int64) {
+	la.idLedgerAccount = id
+}
+
+func (la *LedgerAccount) SetIdAccaunt(id int64) {
+	la.idAccaunt = id
+}
+
+func (la *LedgerAccount) SetName(name string) {
+	la.name = name
+}
+
+func (la *LedgerAccount) SetTypeAccount(typeAccount string) {
+	la.typeAccount = typeAccount
+}
+
+// one side of a journal entry: debit > 0, credit < 0
+type Posting struct {
+	idLedgerAccount int64
+	amount          float64
+}
+
+type PostingJSON struct {
+	IdLedgerAccount int64   `json:"id_ledger_account"`
+	Amount          float64 `json:"amount"`
+}
+
+func (p *Posting) ToJSON() (*PostingJSON, error) {
+	return &PostingJSON{
+		IdLedgerAccount: p.idLedgerAccount,
+		Amount:          p.amount,
+	}, nil
+}
+
+func (p *Posting) GetIdLedgerAccount() int64 {
+	return p.idLedgerAccount
+}
+
+func (p *Posting) GetAmount() float64 {
+	return p.amount
+}
+
+func (p *Posting) SetIdLedgerAccount(id int64) {
+	p.idLedgerAccount = id
+}
+
+func (p *Posting) SetAmount(amount float64) {
+	p.amount = amount
+}
+
+type JournalEntry struct {
+	idEntry     int64      // id
+	date        time.Time  // date of operation
+	description string     // what happened
+	sourceType  string     // expence, income, transfer, manual
+	sourceId    int64      // id of source record
+	postings    []*Posting // must sum to zero
+	updBy       string     // who changed
+}
+
+type JournalEntryJSON struct {
+	IdEntry     int64         `json:"id_entry"`
+	Date        string        `json:"date"`
+	Description string        `json:"description"`
+	SourceType  string        `json:"source_type"`
+	SourceId    int64         `json:"source_id"`
+	Postings    []PostingJSON `json:"postings"`
+	UpdBy       string        `json:"upd_by"`
+}
+
+func (je *JournalEntry) ToJSON() (*JournalEntryJSON, error) {
+	postings := make([]PostingJSON, 0, len(je.postings))
+	for _, posting := range je.postings {
+		postingJSON, err := posting.ToJSON()
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, *postingJSON)
+	}
+
+	return &JournalEntryJSON{
+		IdEntry:     je.idEntry,
+		Date:        je.date.Format("2006-01-02 15:04:05"),
+		Description: je.description,
+		SourceType:  je.sourceType,
+		SourceId:    je.sourceId,
+		Postings:    postings,
+		UpdBy:       je.updBy,
+	}, nil
+}
+
+func (je *JournalEntry) GetIdEntry() int64 {
+	return je.idEntry
+}
+
+func (je *JournalEntry) GetDate() time.Time {
+	return je.date
+}
+
+func (je *JournalEntry) GetDescription() string {
+	return je.description
+}
+
+func (je *JournalEntry) GetSourceType() string {
+	return je.sourceType
+}
+
+func (je *JournalEntry) GetSourceId() int64 {
+	return je.sourceId
+}
+
+func (je *JournalEntry) GetPostings() []*Posting {
+	return je.postings
+}
+
+func (je *JournalEntry) GetUpdBy() string {
+	return je.updBy
+}
+
+func (je *JournalEntry) SetIdEntry(id int64) {
+	je.idEntry = id
+}
+
+func (je *JournalEntry) SetDate(date time.Time) {
+	je.date = date
+}
+
+func (je *JournalEntry) SetDescription(description string) {
+	je.description = description
+}
+
+func (je *JournalEntry) SetSourceType(sourceType string) {
+	je.sourceType = sourceType
+}
+
+func (je *JournalEntry) SetSourceId(id int64) {
+	je.sourceId = id
+}
+
+func (je *JournalEntry) SetPostings(postings []*Posting) {
+	je.postings = postings
+}
+
+func (je *JournalEntry) AddPosting(idLedgerAccount int64, amount float64) {
+	posting := &Posting{}
+	posting.SetIdLedgerAccount(idLedgerAccount)
+	posting.SetAmount(amount)
+	je.postings = append(je.postings, posting)
+}
+
+func (je *JournalEntry) SetUpdBy(updBy string) {
+	je.updBy = updBy
+}
+
+// reports
+
+type TrialBalanceLineJSON struct {
+	IdLedgerAccount int64   `json:"id_ledger_account"`
+	IdAccaunt       int64   `json:"id_accaunt"`
+	Name            string  `json:"name"`
+	TypeAccount     string  `json:"type_account"`
+	Debit           float64 `json:"debit"`
+	Credit          float64 `json:"credit"`
+	Balance         float64 `json:"balance"`
+}
+
+type GeneralLedgerLineJSON struct {
+	IdEntry     int64   `json:"id_entry"`
+	Date        string  `json:"date"`
+	Description string  `json:"description"`
+	SourceType  string  `json:"source_type"`
+	SourceId    int64   `json:"source_id"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+	Balance     float64 `json:"balance"`
+}
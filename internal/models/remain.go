@@ -12,6 +12,9 @@ type Remain struct {
 	updBy            string    // who changed
 	dateActualFrom   time.Time // actual from
 	dateActualTo     time.Time // actual to if 9999-12-31 to now
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
 }
 
 type RemainJSON struct {
@@ -24,6 +27,8 @@ type RemainJSON struct {
 	UpdBy            string  `json:"upd_by"`
 	DateActualFrom   string  `json:"date_actual_from"`
 	DateActualTo     string  `json:"date_actual_to"`
+	DeletedAt        string  `json:"deleted_at"`
+	DeletedBy        string  `json:"deleted_by"`
 }
 
 func (r *Remain) ToJSON() (*RemainJSON, error) {
@@ -37,6 +42,8 @@ func (r *Remain) ToJSON() (*RemainJSON, error) {
 		UpdBy:            r.updBy,
 		DateActualFrom:   r.dateActualFrom.Format("2006-01-02 15:04:05"),
 		DateActualTo:     r.dateActualTo.Format("2006-01-02 15:04:05"),
+		DeletedAt:        formatDeletedAt(r.deletedAt),
+		DeletedBy:        r.deletedBy,
 	}, nil
 }
 
@@ -111,3 +118,23 @@ func (r *Remain) SetDateActualFrom(date time.Time) {
 func (r *Remain) SetDateActualTo(date time.Time) {
 	r.dateActualTo = date
 }
+
+func (r *Remain) GetDeletedAt() time.Time {
+	return r.deletedAt
+}
+
+func (r *Remain) GetDeletedBy() string {
+	return r.deletedBy
+}
+
+func (r *Remain) IsDeleted() bool {
+	return !r.deletedAt.IsZero()
+}
+
+func (r *Remain) SetDeletedAt(date time.Time) {
+	r.deletedAt = date
+}
+
+func (r *Remain) SetDeletedBy(deletedBy string) {
+	r.deletedBy = deletedBy
+}
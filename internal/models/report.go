@@ -0,0 +1,62 @@
+package models
+
+// статусы строки отчета по доходам
+const (
+	IncomeVarianceReceived = "received" // came in the due window
+	IncomeVarianceLate     = "late"     // came after the due window
+	IncomeVarianceMissing  = "missing"  // nothing came, due window is over
+	IncomeVariancePending  = "pending"  // nothing came yet, due window is not over
+)
+
+// one income expected of the month compared with what came
+type IncomeVarianceLineJSON struct {
+	IdIncomeEx      int64   `json:"id_income_ex"`
+	TypeIncome      string  `json:"type_income"`
+	DueDate         string  `json:"due_date"`
+	Expected        float64 `json:"expected"`
+	Received        float64 `json:"received"`
+	Variance        float64 `json:"variance"`         // received - expected
+	VariancePercent float64 `json:"variance_percent"` // of expected
+	ReceivedDate    string  `json:"received_date"`    // last receipt, empty if none
+	DaysLate        int     `json:"days_late"`        // after due date, for late and missing
+	Status          string  `json:"status"`
+}
+
+type IncomeVarianceJSON struct {
+	IdAccaunt  int64                    `json:"id_accaunt"`
+	Month      string                   `json:"month"`
+	Expected   float64                  `json:"expected"`
+	Received   float64                  `json:"received"`   // of expected incomes
+	Variance   float64                  `json:"variance"`   // received - expected
+	Unexpected float64                  `json:"unexpected"` // incomes not linked to income expected
+	Lines      []IncomeVarianceLineJSON `json:"lines"`
+}
+
+// cashback expected from one rule of the card in the month
+type CashbackEarnedLineJSON struct {
+	IdCashback int64   `json:"id_cashback"`
+	Category   string  `json:"category"`
+	IdCategory int64   `json:"id_category"`
+	Percent    int8    `json:"percent"`
+	MonthlyCap float64 `json:"monthly_cap"`
+	Purchases  int     `json:"purchases"`
+	Spent      float64 `json:"spent"`
+	Accrued    float64 `json:"accrued"`  // before monthly cap
+	Expected   float64 `json:"expected"` // after monthly cap
+	Capped     bool    `json:"capped"`
+}
+
+// cashback of one card in the month, expected against credited by the bank
+type CashbackEarnedJSON struct {
+	IdAccaunt      int64                    `json:"id_accaunt"`
+	BankName       string                   `json:"bank_name"`
+	Month          string                   `json:"month"`
+	Spent          float64                  `json:"spent"`     // every purchase made with the card
+	Uncovered      float64                  `json:"uncovered"` // purchases no rule pays for
+	Expected       float64                  `json:"expected"`
+	Credited       float64                  `json:"credited"`
+	CreditRecorded bool                     `json:"credit_recorded"`
+	Difference     float64                  `json:"difference"` // credited - expected
+	Discrepancy    bool                     `json:"discrepancy"`
+	Lines          []CashbackEarnedLineJSON `json:"lines"`
+}
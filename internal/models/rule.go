@@ -0,0 +1,216 @@
+package models
+
+import "time"
+
+// user rule putting matching expences into a category and labeling them with
+// tags, rules are tried by priority and the first matching one wins
+type Rule struct {
+	idRule        int64
+	idAccaunt     int64 // owner, rule works for expences of accounts sharing a group with him
+	name          string
+	priority      int     // lower goes first
+	titleContains string  // case aside, empty matches any title
+	titleRegex    string  // go regexp, case aside, empty matches any title
+	minAmount     float64 // 0 is no lower bound
+	maxAmount     float64 // 0 is no upper bound
+	matchAccaunt  int64   // account that paid, 0 is any
+	idCategory    int64   // category to set, 0 keeps category
+	groupExpence  string  // text of the category
+	tags          []string
+	disabled      bool
+	updBy         string // who changed
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
+}
+
+type RuleJSON struct {
+	IdRule        int64    `json:"id_rule"`
+	IdAccaunt     int64    `json:"id_accaunt"`
+	Name          string   `json:"name"`
+	Priority      int      `json:"priority"`
+	TitleContains string   `json:"title_contains"`
+	TitleRegex    string   `json:"title_regex"`
+	MinAmount     float64  `json:"min_amount"`
+	MaxAmount     float64  `json:"max_amount"`
+	MatchAccaunt  int64    `json:"match_accaunt"`
+	IdCategory    int64    `json:"id_category"`
+	GroupExpence  string   `json:"group_expence"`
+	Tags          []string `json:"tags"`
+	Disabled      bool     `json:"disabled"`
+	UpdBy         string   `json:"upd_by"`
+	DeletedAt     string   `json:"deleted_at"`
+	DeletedBy     string   `json:"deleted_by"`
+}
+
+func (r *Rule) ToJSON() (*RuleJSON, error) {
+	return &RuleJSON{
+		IdRule:        r.idRule,
+		IdAccaunt:     r.idAccaunt,
+		Name:          r.name,
+		Priority:      r.priority,
+		TitleContains: r.titleContains,
+		TitleRegex:    r.titleRegex,
+		MinAmount:     r.minAmount,
+		MaxAmount:     r.maxAmount,
+		MatchAccaunt:  r.matchAccaunt,
+		IdCategory:    r.idCategory,
+		GroupExpence:  r.groupExpence,
+		Tags:          append([]string{}, r.tags...),
+		Disabled:      r.disabled,
+		UpdBy:         r.updBy,
+		DeletedAt:     formatDeletedAt(r.deletedAt),
+		DeletedBy:     r.deletedBy,
+	}, nil
+}
+
+func (r *Rule) GetIdRule() int64 {
+	return r.idRule
+}
+
+func (r *Rule) GetIdAccaunt() int64 {
+	return r.idAccaunt
+}
+
+func (r *Rule) GetName() string {
+	return r.name
+}
+
+func (r *Rule) GetPriority() int {
+	return r.priority
+}
+
+func (r *Rule) GetTitleContains() string {
+	return r.titleContains
+}
+
+func (r *Rule) GetTitleRegex() string {
+	return r.titleRegex
+}
+
+func (r *Rule) GetMinAmount() float64 {
+	return r.minAmount
+}
+
+func (r *Rule) GetMaxAmount() float64 {
+	return r.maxAmount
+}
+
+func (r *Rule) GetMatchAccaunt() int64 {
+	return r.matchAccaunt
+}
+
+func (r *Rule) GetIdCategory() int64 {
+	return r.idCategory
+}
+
+func (r *Rule) GetGroupExpence() string {
+	return r.groupExpence
+}
+
+func (r *Rule) GetTags() []string {
+	return r.tags
+}
+
+func (r *Rule) IsDisabled() bool {
+	return r.disabled
+}
+
+func (r *Rule) GetUpdBy() string {
+	return r.updBy
+}
+
+func (r *Rule) SetIdRule(idRule int64) {
+	r.idRule = idRule
+}
+
+func (r *Rule) SetIdAccaunt(idAccaunt int64) {
+	r.idAccaunt = idAccaunt
+}
+
+func (r *Rule) SetName(name string) {
+	r.name = name
+}
+
+func (r *Rule) SetPriority(priority int) {
+	r.priority = priority
+}
+
+func (r *Rule) SetTitleContains(titleContains string) {
+	r.titleContains = titleContains
+}
+
+func (r *Rule) SetTitleRegex(titleRegex string) {
+	r.titleRegex = titleRegex
+}
+
+func (r *Rule) SetMinAmount(minAmount float64) {
+	r.minAmount = minAmount
+}
+
+func (r *Rule) SetMaxAmount(maxAmount float64) {
+	r.maxAmount = maxAmount
+}
+
+func (r *Rule) SetMatchAccaunt(matchAccaunt int64) {
+	r.matchAccaunt = matchAccaunt
+}
+
+func (r *Rule) SetIdCategory(idCategory int64) {
+	r.idCategory = idCategory
+}
+
+func (r *Rule) SetGroupExpence(groupExpence string) {
+	r.groupExpence = groupExpence
+}
+
+func (r *Rule) SetTags(tags []string) {
+	r.tags = tags
+}
+
+func (r *Rule) SetDisabled(disabled bool) {
+	r.disabled = disabled
+}
+
+func (r *Rule) SetUpdBy(updBy string) {
+	r.updBy = updBy
+}
+
+func (r *Rule) GetDeletedAt() time.Time {
+	return r.deletedAt
+}
+
+func (r *Rule) GetDeletedBy() string {
+	return r.deletedBy
+}
+
+func (r *Rule) IsDeleted() bool {
+	return !r.deletedAt.IsZero()
+}
+
+func (r *Rule) SetDeletedAt(deletedAt time.Time) {
+	r.deletedAt = deletedAt
+}
+
+func (r *Rule) SetDeletedBy(deletedBy string) {
+	r.deletedBy = deletedBy
+}
+
+// expence changed by rules, with dry run nothing is stored
+type RuleChangeJSON struct {
+	IdExpence        int64    `json:"id_expence"`
+	TitleExpence     string   `json:"title_expence"`
+	IdRule           int64    `json:"id_rule"`
+	GroupBefore      string   `json:"group_before"`
+	GroupAfter       string   `json:"group_after"`
+	IdCategoryBefore int64    `json:"id_category_before"`
+	IdCategoryAfter  int64    `json:"id_category_after"`
+	TagsBefore       []string `json:"tags_before"`
+	TagsAfter        []string `json:"tags_after"`
+}
+
+type RuleApplyJSON struct {
+	DryRun  bool             `json:"dry_run"`
+	Checked int              `json:"checked"` // expences rules were tried on
+	Changes []RuleChangeJSON `json:"changes"`
+}
@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// label put on expences and incomes besides their group, one record may carry
+// many tags and a tag many records
+type Tag struct {
+	idTag       int64
+	idAccaunt   int64  // owner, tag is shared with accounts of his groups
+	name        string // lower case, what records carry
+	description string
+	updBy       string // who changed
+
+	deletedAt time.Time     // zero if not deleted
+	deletedBy string        // who deleted
+	detached  TaggedRecords // records the deleted tag was taken off, tagged again on restore
+}
+
+// ids of records carrying a tag
+type TaggedRecords struct {
+	Expences []int64
+	Incomes  []int64
+	Rules    []int64
+}
+
+type TagJSON struct {
+	IdTag       int64  `json:"id_tag"`
+	IdAccaunt   int64  `json:"id_accaunt"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	UpdBy       string `json:"upd_by"`
+	DeletedAt   string `json:"deleted_at"`
+	DeletedBy   string `json:"deleted_by"`
+}
+
+func (t *Tag) ToJSON() (*TagJSON, error) {
+	return &TagJSON{
+		IdTag:       t.idTag,
+		IdAccaunt:   t.idAccaunt,
+		Name:        t.name,
+		Description: t.description,
+		UpdBy:       t.updBy,
+		DeletedAt:   formatDeletedAt(t.deletedAt),
+		DeletedBy:   t.deletedBy,
+	}, nil
+}
+
+func (t *Tag) GetIdTag() int64 {
+	return t.idTag
+}
+
+func (t *Tag) GetIdAccaunt() int64 {
+	return t.idAccaunt
+}
+
+func (t *Tag) GetName() string {
+	return t.name
+}
+
+func (t *Tag) GetDescription() string {
+	return t.description
+}
+
+func (t *Tag) GetUpdBy() string {
+	return t.updBy
+}
+
+func (t *Tag) SetIdTag(idTag int64) {
+	t.idTag = idTag
+}
+
+func (t *Tag) SetIdAccaunt(idAccaunt int64) {
+	t.idAccaunt = idAccaunt
+}
+
+func (t *Tag) SetName(name string) {
+	t.name = name
+}
+
+func (t *Tag) SetDescription(description string) {
+	t.description = description
+}
+
+func (t *Tag) SetUpdBy(updBy string) {
+	t.updBy = updBy
+}
+
+func (t *Tag) GetDeletedAt() time.Time {
+	return t.deletedAt
+}
+
+func (t *Tag) GetDeletedBy() string {
+	return t.deletedBy
+}
+
+func (t *Tag) IsDeleted() bool {
+	return !t.deletedAt.IsZero()
+}
+
+func (t *Tag) SetDeletedAt(deletedAt time.Time) {
+	t.deletedAt = deletedAt
+}
+
+func (t *Tag) SetDeletedBy(deletedBy string) {
+	t.deletedBy = deletedBy
+}
+
+// money of records carrying the tag over a period, a record with several tags
+// counts for each of them
+type TagTotalJSON struct {
+	Tag          string  `json:"tag"`
+	IdTag        int64   `json:"id_tag"`
+	Expences     float64 `json:"expences"`
+	ExpenceCount int     `json:"expence_count"`
+	Incomes      float64 `json:"incomes"`
+	IncomeCount  int     `json:"income_count"`
+	Net          float64 `json:"net"` // incomes less expences
+}
+
+type TagReportJSON struct {
+	From string         `json:"from"`
+	To   string         `json:"to"`
+	Tags []TagTotalJSON `json:"tags"`
+}
+
+func (t *Tag) GetDetached() TaggedRecords {
+	return t.detached
+}
+
+func (t *Tag) SetDetached(detached TaggedRecords) {
+	t.detached = detached
+}
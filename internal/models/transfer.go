@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+type Transfer struct {
+	idTransfer    int64     // id
+	idAccauntFrom int64     // account id from
+	idAccauntTo   int64     // account id to
+	amount        float64   // sum
+	date          time.Time // date of transfer
+	updBy         string    // who changed
+
+	deletedAt time.Time // zero if not deleted
+	deletedBy string    // who deleted
+}
+
+type TransferJSON struct {
+	IdTransfer    int64   `json:"id_transfer"`
+	IdAccauntFrom int64   `json:"id_accaunt_from"`
+	IdAccauntTo   int64   `json:"id_accaunt_to"`
+	Amount        float64 `json:"amount"`
+	Date          string  `json:"date"`
+	UpdBy         string  `json:"upd_by"`
+	DeletedAt     string  `json:"deleted_at"`
+	DeletedBy     string  `json:"deleted_by"`
+}
+
+func (t *Transfer) ToJSON() (*TransferJSON, error) {
+	return &TransferJSON{
+		IdTransfer:    t.idTransfer,
+		IdAccauntFrom: t.idAccauntFrom,
+		IdAccauntTo:   t.idAccauntTo,
+		Amount:        t.amount,
+		Date:          t.date.Format("2006-01-02 15:04:05"),
+		UpdBy:         t.updBy,
+		DeletedAt:     formatDeletedAt(t.deletedAt),
+		DeletedBy:     t.deletedBy,
+	}, nil
+}
+
+func (t *Transfer) GetIdTransfer() int64 {
+	return t.idTransfer
+}
+
+func (t *Transfer) GetIdAccauntFrom() int64 {
+	return t.idAccauntFrom
+}
+
+func (t *Transfer) GetIdAccauntTo() int64 {
+	return t.idAccauntTo
+}
+
+func (t *Transfer) GetAmount() float64 {
+	return t.amount
+}
+
+func (t *Transfer) GetDate() time.Time {
+	return t.date
+}
+
+func (t *Transfer) GetUpdBy() string {
+	return t.updBy
+}
+
+func (t *Transfer) SetIdTransfer(id int64) {
+	t.idTransfer = id
+}
+
+func (t *Transfer) SetIdAccauntFrom(id int64) {
+	t.idAccauntFrom = id
+}
+
+func (t *Transfer) SetIdAccauntTo(id int64) {
+	t.idAccauntTo = id
+}
+
+func (t *Transfer) SetAmount(amount float64) {
+	t.amount = amount
+}
+
+func (t *Transfer) SetDate(date time.Time) {
+	t.date = date
+}
+
+func (t *Transfer) SetUpdBy(updBy string) {
+	t.updBy = updBy
+}
+
+func (t *Transfer) GetDeletedAt() time.Time {
+	return t.deletedAt
+}
+
+func (t *Transfer) GetDeletedBy() string {
+	return t.deletedBy
+}
+
+func (t *Transfer) IsDeleted() bool {
+	return !t.deletedAt.IsZero()
+}
+
+func (t *Transfer) SetDeletedAt(date time.Time) {
+	t.deletedAt = date
+}
+
+func (t *Transfer) SetDeletedBy(deletedBy string) {
+	t.deletedBy = deletedBy
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// soft deleted record of any entity
+type TrashItemJSON struct {
+	Entity    string          `json:"entity"`
+	Id        int64           `json:"id"`
+	IdAccaunt int64           `json:"id_accaunt"`
+	DeletedAt string          `json:"deleted_at"`
+	DeletedBy string          `json:"deleted_by"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func formatDeletedAt(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,434 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// subset of iCalendar RRULE (RFC 5545) used by recurring records:
+//
+//	FREQ=DAILY|WEEKLY|MONTHLY|YEARLY (required)
+//	INTERVAL=N         every N periods
+//	COUNT=N            end after N occurrences
+//	UNTIL=YYYYMMDD[THHMMSSZ]
+//	BYDAY=MO,TU,-1FR   weekdays, ordinals inside month for MONTHLY and YEARLY
+//	                   with BYMONTH, inside year for YEARLY without it
+//	BYMONTHDAY=1,-1    days of month, negative from the end
+//	BYMONTH=1,12
+//	BYSETPOS=-1        pick from occurrences of one period
+//
+// e.g. last business day of month is FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// safety bound of generated periods
+const maxPeriods = 100000
+
+type WeekdayNum struct {
+	Ordinal int // 0 for every weekday of period, -1 for last
+	Weekday time.Weekday
+}
+
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int       // 0 for no limit
+	Until      time.Time // zero for no limit
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+func Parse(rrule string) (*Rule, error) {
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	if rrule == "" {
+		return nil, errors.New("empty rrule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(rrule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("must be positive")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(value, -31, 31)
+		case "BYMONTH":
+			rule.ByMonth, err = parseInts(value, 1, 12)
+		case "BYSETPOS":
+			rule.BySetPos, err = parseInts(value, -366, 366)
+		default:
+			err = errors.New("not supported")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule %s: %w", strings.ToUpper(key), err)
+		}
+	}
+
+	switch rule.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return nil, errors.New("rrule FREQ is required")
+	default:
+		return nil, fmt.Errorf("unsupported rrule FREQ %q", rule.Freq)
+	}
+
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("rrule COUNT and UNTIL are exclusive")
+	}
+	// ordinals above 5 only make sense when counted inside a year
+	for _, day := range rule.ByDay {
+		if (day.Ordinal > 5 || day.Ordinal < -5) && (rule.Freq != Yearly || len(rule.ByMonth) > 0) {
+			return nil, fmt.Errorf("invalid rrule BYDAY: ordinal %d outside of a year", day.Ordinal)
+		}
+	}
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	until, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, errors.New("must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+	}
+	// date only UNTIL includes the whole day
+	return until.Add(24*time.Hour - time.Second), nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+
+		day := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			ordinal, err := strconv.Atoi(prefix)
+			if err != nil || ordinal == 0 || ordinal < -53 || ordinal > 53 {
+				return nil, fmt.Errorf("invalid weekday %q", item)
+			}
+			day.Ordinal = ordinal
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseInts(value string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// canonical text of the rule
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			name := strings.ToUpper(day.Weekday.String()[:2])
+			if day.Ordinal != 0 {
+				name = strconv.Itoa(day.Ordinal) + name
+			}
+			days = append(days, name)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(values []int) string {
+	items := make([]string, len(values))
+	for i, value := range values {
+		items[i] = strconv.Itoa(value)
+	}
+	return strings.Join(items, ",")
+}
+
+// occurrences of the rule started at start that fall into [from, to],
+// COUNT is counted from start. Time of day is taken from start
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	emitted := 0
+
+	for period := 0; period < maxPeriods; period++ {
+		periodStart, candidates := r.period(start, period*r.Interval)
+		if periodStart.After(to) || (!r.Until.IsZero() && periodStart.After(r.Until)) {
+			break
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Before(start) {
+				continue
+			}
+			if candidate.After(to) || (!r.Until.IsZero() && candidate.After(r.Until)) {
+				return occurrences
+			}
+			emitted++
+			if !candidate.Before(from) {
+				occurrences = append(occurrences, candidate)
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return occurrences
+			}
+		}
+	}
+	return occurrences
+}
+
+// first day of the n-th period after start and occurrences of that period, sorted
+func (r *Rule) period(start time.Time, n int) (time.Time, []time.Time) {
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, start.Nanosecond(), start.Location())
+	}
+
+	var periodStart time.Time
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		periodStart = at(year, month, day+n)
+		if r.matchesDay(periodStart) {
+			days = []time.Time{periodStart}
+		}
+	case Weekly:
+		monday := day - (int(start.Weekday())+6)%7
+		periodStart = at(year, month, monday+7*n)
+		if len(r.ByDay) == 0 {
+			days = []time.Time{periodStart.AddDate(0, 0, (int(start.Weekday())+6)%7)}
+			break
+		}
+		for i := 0; i < 7; i++ {
+			candidate := periodStart.AddDate(0, 0, i)
+			if r.matchesDay(candidate) {
+				days = append(days, candidate)
+			}
+		}
+	case Monthly:
+		periodStart = at(year, month+time.Month(n), 1)
+		if r.monthSelected(periodStart.Month()) {
+			days = r.monthDays(periodStart, day)
+		}
+	case Yearly:
+		periodStart = at(year+n, 1, 1)
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, m := range r.ByMonth {
+				days = append(days, r.monthDays(at(year+n, time.Month(m), 1), day)...)
+			}
+		case len(r.ByMonthDay) > 0:
+			for m := time.January; m <= time.December; m++ {
+				days = append(days, r.monthDays(at(year+n, m, 1), day)...)
+			}
+		case len(r.ByDay) > 0:
+			days = r.yearDays(periodStart)
+		default:
+			days = r.monthDays(at(year+n, month, 1), day)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return periodStart, r.setPos(days)
+}
+
+// days of the month starting at first, startDay is used when no BY rule is given
+func (r *Rule) monthDays(first time.Time, startDay int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+
+	if len(r.ByMonthDay) > 0 {
+		var days []time.Time
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay < 0 {
+				monthDay = last + monthDay + 1
+			}
+			if monthDay < 1 || monthDay > last {
+				continue
+			}
+			candidate := first.AddDate(0, 0, monthDay-1)
+			if r.matchesWeekday(candidate, monthDay-1, last) {
+				days = append(days, candidate)
+			}
+		}
+		return days
+	}
+
+	if len(r.ByDay) > 0 {
+		var days []time.Time
+		for d := 0; d < last; d++ {
+			candidate := first.AddDate(0, 0, d)
+			if r.matchesWeekday(candidate, d, last) {
+				days = append(days, candidate)
+			}
+		}
+		return days
+	}
+
+	// months without the start day are skipped like in RFC 5545
+	if startDay > last {
+		return nil
+	}
+	return []time.Time{first.AddDate(0, 0, startDay-1)}
+}
+
+// days of the year starting at first matching BYDAY, ordinals counted inside the year
+func (r *Rule) yearDays(first time.Time) []time.Time {
+	length := first.AddDate(1, 0, -1).YearDay()
+	var days []time.Time
+	for d := 0; d < length; d++ {
+		candidate := first.AddDate(0, 0, d)
+		if r.matchesWeekday(candidate, d, length) {
+			days = append(days, candidate)
+		}
+	}
+	return days
+}
+
+func (r *Rule) monthSelected(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if time.Month(m) == month {
+			return true
+		}
+	}
+	return false
+}
+
+// BYDAY, BYMONTHDAY and BYMONTH filters of a single day for DAILY and WEEKLY
+func (r *Rule) matchesDay(date time.Time) bool {
+	if !r.monthSelected(date.Month()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 {
+		last := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+		found := false
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay == date.Day() || last+monthDay+1 == date.Day() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == date.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// BYDAY with ordinals counted inside the period, index is the day of the
+// period from 0 and length the number of its days
+func (r *Rule) matchesWeekday(date time.Time, index, length int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday != date.Weekday() {
+			continue
+		}
+		switch {
+		case day.Ordinal == 0:
+			return true
+		case day.Ordinal > 0 && index/7+1 == day.Ordinal:
+			return true
+		case day.Ordinal < 0 && (length-1-index)/7+1 == -day.Ordinal:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) setPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return days
+	}
+
+	var selected []time.Time
+	for i, day := range days {
+		for _, pos := range r.BySetPos {
+			if pos == i+1 || pos == i-len(days) {
+				selected = append(selected, day)
+				break
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,92 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name  string
+		rrule string
+		start time.Time
+		from  time.Time // zero for start
+		to    time.Time
+		want  []string
+	}{
+		{"count", "FREQ=DAILY;COUNT=3", day(2024, 1, 1), time.Time{}, day(2024, 12, 31),
+			[]string{"2024-01-01 09:30", "2024-01-02 09:30", "2024-01-03 09:30"}},
+		{"count from start", "FREQ=DAILY;COUNT=5", day(2024, 1, 1), day(2024, 1, 4), day(2024, 12, 31),
+			[]string{"2024-01-04 09:30", "2024-01-05 09:30"}},
+		{"until whole day", "FREQ=WEEKLY;UNTIL=20240115", day(2024, 1, 1), time.Time{}, day(2024, 12, 31),
+			[]string{"2024-01-01 09:30", "2024-01-08 09:30", "2024-01-15 09:30"}},
+		{"interval skips short months", "FREQ=MONTHLY;INTERVAL=2", day(2024, 1, 31), time.Time{}, day(2024, 12, 31),
+			[]string{"2024-01-31 09:30", "2024-03-31 09:30", "2024-05-31 09:30", "2024-07-31 09:30"}},
+		{"count skips short months", "FREQ=MONTHLY;COUNT=3", day(2024, 1, 31), time.Time{}, day(2024, 12, 31),
+			[]string{"2024-01-31 09:30", "2024-03-31 09:30", "2024-05-31 09:30"}},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1", day(2024, 1, 15), time.Time{}, day(2024, 4, 30),
+			[]string{"2024-01-31 09:30", "2024-02-29 09:30", "2024-03-31 09:30", "2024-04-30 09:30"}},
+		{"last business day", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", day(2024, 1, 1), time.Time{}, day(2024, 6, 30),
+			[]string{"2024-01-31 09:30", "2024-02-29 09:30", "2024-03-29 09:30", "2024-04-30 09:30", "2024-05-31 09:30", "2024-06-28 09:30"}},
+		{"weekly days", "FREQ=WEEKLY;BYDAY=MO,FR", day(2024, 1, 3), time.Time{}, day(2024, 1, 15),
+			[]string{"2024-01-05 09:30", "2024-01-08 09:30", "2024-01-12 09:30", "2024-01-15 09:30"}},
+		{"yearly every monday", "FREQ=YEARLY;BYDAY=MO", day(2024, 1, 1), day(2024, 11, 20), day(2025, 1, 10),
+			[]string{"2024-11-25 09:30", "2024-12-02 09:30", "2024-12-09 09:30", "2024-12-16 09:30", "2024-12-23 09:30", "2024-12-30 09:30", "2025-01-06 09:30"}},
+		{"yearly last friday", "FREQ=YEARLY;BYDAY=-1FR;COUNT=2", day(2024, 1, 1), time.Time{}, day(2030, 1, 1),
+			[]string{"2024-12-27 09:30", "2025-12-26 09:30"}},
+		{"yearly 20th monday", "FREQ=YEARLY;BYDAY=20MO;COUNT=1", day(2024, 1, 1), time.Time{}, day(2030, 1, 1),
+			[]string{"2024-05-13 09:30"}},
+		{"yearly in month", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", day(2024, 1, 1), time.Time{}, day(2025, 12, 31),
+			[]string{"2024-11-28 09:30", "2025-11-27 09:30"}},
+		{"yearly month days", "FREQ=YEARLY;BYMONTHDAY=1;COUNT=3", day(2024, 1, 15), time.Time{}, day(2030, 1, 1),
+			[]string{"2024-02-01 09:30", "2024-03-01 09:30", "2024-04-01 09:30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rrule)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.rrule, err)
+			}
+			from := tt.from
+			if from.IsZero() {
+				from = tt.start
+			}
+
+			var got []string
+			for _, occurrence := range rule.Between(tt.start, from, tt.to) {
+				got = append(got, occurrence.Format("2006-01-02 15:04"))
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		rrule string
+		want  string
+	}{
+		{"", "empty rrule"},
+		{"INTERVAL=2", "rrule FREQ is required"},
+		{"FREQ=HOURLY", `unsupported rrule FREQ "HOURLY"`},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20240101", "rrule COUNT and UNTIL are exclusive"},
+		{"FREQ=DAILY;INTERVAL=0", "invalid rrule INTERVAL: must be positive"},
+		{"FREQ=MONTHLY;BYDAY=6MO", "invalid rrule BYDAY: ordinal 6 outside of a year"},
+		{"FREQ=YEARLY;BYMONTH=1;BYDAY=-6MO", "invalid rrule BYDAY: ordinal -6 outside of a year"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.rrule)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parse %q: error = %v, want %q", tt.rrule, err, tt.want)
+		}
+	}
+}
@@ -9,19 +9,22 @@ import (
 )
 
 func account(logger *logger.CombinedLogger, config *config.Config) {
-	http.HandleFunc("/account/all", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/account/all", func(w http.ResponseWriter, r *http.Request) {
 		handlers.AccountGetAll(w, r, logger, config)
 	})
-	http.HandleFunc("/account/id/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/account/id/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.AccountGetByIdAccount(w, r, logger, config)
 	})
-	http.HandleFunc("/account/new", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/account/new", func(w http.ResponseWriter, r *http.Request) {
 		handlers.AccountPost(w, r, logger, config)
 	})
-	http.HandleFunc("/account/update", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/account/update", func(w http.ResponseWriter, r *http.Request) {
 		handlers.AccountPut(w, r, logger, config)
 	})
-	http.HandleFunc("/account/delete", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/account/delete", func(w http.ResponseWriter, r *http.Request) {
 		handlers.AccountDelete(w, r, logger, config)
 	})
+	handleFunc("/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AccountForecast(w, r, logger, config)
+	})
 }
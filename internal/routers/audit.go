@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func audit(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/audit", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuditGetAll(w, r, logger, config)
+	})
+}
@@ -0,0 +1,98 @@
+package routers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/auth"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+	u "github.com/helltale/api-finances/internal/utils"
+)
+
+// paths reachable without credentials
+var publicPaths = map[string]bool{
+	"/auth/token":    true,
+	"/auth/refresh":  true,
+	"/auth/telegram": true,
+	"/account/new":   true,
+}
+
+func authRoutes(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthToken(w, r, logger, config)
+	})
+	handleFunc("/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthRefresh(w, r, logger, config)
+	})
+	handleFunc("/auth/telegram", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthTelegram(w, r, logger, config)
+	})
+	handleFunc("/auth/key/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthKeyGetAll(w, r, logger, config)
+	})
+	handleFunc("/auth/key/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthKeyPost(w, r, logger, config)
+	})
+	handleFunc("/auth/key/revoke/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthKeyRevoke(w, r, logger, config)
+	})
+	handleFunc("/auth/key/rotate/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuthKeyRotate(w, r, logger, config)
+	})
+}
+
+// authenticate every request except public paths and check its route permission,
+// account is put into request context
+func authMiddleware(next http.Handler, logger *logger.CombinedLogger, config *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		account, err := authenticate(r, services.NewAuthService(config))
+		if err != nil {
+			logger.Info("Unauthorized request", "path", r.URL.Path, "error", err)
+			http.Error(w, u.JsonErrorResponse("Unauthorized"), http.StatusUnauthorized)
+			return
+		}
+
+		action, ok := routeAction(r.URL.Path)
+		if !ok || !allowed(account, action, config) {
+			logger.Info("Forbidden request", "path", r.URL.Path, "id_accaunt", account.GetIdAccaunt())
+			http.Error(w, u.JsonErrorResponse("Forbidden"), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithAccount(r.Context(), account)))
+	})
+}
+
+func allowed(account *models.Account, action string, config *config.Config) bool {
+	if action != services.ActionAdmin {
+		return services.NewPolicyService().Can(account.GetIdAccaunt(), action)
+	}
+	for _, idAccaunt := range config.AdminAccounts {
+		if idAccaunt == account.GetIdAccaunt() {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorization: Bearer <jwt>, Authorization: ApiKey <key> or X-Api-Key: <key>
+func authenticate(r *http.Request, authService *services.AuthService) (*models.Account, error) {
+	authorization := r.Header.Get("Authorization")
+	switch {
+	case strings.HasPrefix(authorization, "Bearer "):
+		return authService.AuthenticateBearer(strings.TrimPrefix(authorization, "Bearer "))
+	case strings.HasPrefix(authorization, "ApiKey "):
+		return authService.AuthenticateApiKey(strings.TrimPrefix(authorization, "ApiKey "))
+	default:
+		return authService.AuthenticateApiKey(r.Header.Get(handlers.ApiKeyHeader))
+	}
+}
@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func budget(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/budget/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BudgetGetAll(w, r, logger, config)
+	})
+	handleFunc("/budget/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BudgetGetById(w, r, logger, config)
+	})
+	handleFunc("/budget/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BudgetPost(w, r, logger, config)
+	})
+	handleFunc("/budget/update/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BudgetPut(w, r, logger, config)
+	})
+	handleFunc("/budget/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BudgetDelete(w, r, logger, config)
+	})
+	handleFunc("/budgets/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/events") {
+			handlers.BudgetGetEvents(w, r, logger, config)
+			return
+		}
+		handlers.BudgetGetStatus(w, r, logger, config)
+	})
+}
@@ -9,31 +9,43 @@ import (
 )
 
 func cashback(logger *logger.CombinedLogger, config *config.Config) {
-	http.HandleFunc("/cashback/all", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/all", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackGetAll(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/id/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/id/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackGetByIdCashback(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/account/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/account/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackGetByIdAccount(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/bank/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/bank/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackGetByBankName(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/category/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/category/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackGetByCategory(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/current", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/current", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackGetCurrent(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/new", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/recommend", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CashbackRecommend(w, r, logger, config)
+	})
+	handleFunc("/cashback/credit", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CashbackCreditPost(w, r, logger, config)
+	})
+	handleFunc("/cashback/selection/suggest", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CashbackSelectionSuggest(w, r, logger, config)
+	})
+	handleFunc("/cashback/selection/accept", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CashbackSelectionAccept(w, r, logger, config)
+	})
+	handleFunc("/cashback/new", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackPost(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/update/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/update/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackPut(w, r, logger, config)
 	})
-	http.HandleFunc("/cashback/delete/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/cashback/delete/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.CashbackDelete(w, r, logger, config)
 	})
 }
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func categorizer(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/categorizer", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategorizerGetAll(w, r, logger, config)
+	})
+	handleFunc("/categorizer/train", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategorizerTrain(w, r, logger, config)
+	})
+	handleFunc("/categorizer/suggest", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategorizerSuggest(w, r, logger, config)
+	})
+	handleFunc("/categorizer/accept/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategorizerAccept(w, r, logger, config)
+	})
+}
@@ -0,0 +1,33 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func category(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/category/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryGetAll(w, r, logger, config)
+	})
+	handleFunc("/category/tree", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryGetTree(w, r, logger, config)
+	})
+	handleFunc("/category/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryGetById(w, r, logger, config)
+	})
+	handleFunc("/category/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryPost(w, r, logger, config)
+	})
+	handleFunc("/category/update/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryPut(w, r, logger, config)
+	})
+	handleFunc("/category/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryDelete(w, r, logger, config)
+	})
+	handleFunc("/category/migrate", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CategoryMigrate(w, r, logger, config)
+	})
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func duplicate(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/duplicates", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DuplicateGetAll(w, r, logger, config)
+	})
+	handleFunc("/duplicates/detect", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DuplicateDetect(w, r, logger, config)
+	})
+
+	// /duplicates/{id}/merge and /duplicates/{id}/dismiss
+	handleFunc("/duplicates/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DuplicateResolve(w, r, logger, config)
+	})
+}
@@ -0,0 +1,48 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func group(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/group/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupGetAll(w, r, logger, config)
+	})
+	handleFunc("/group/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupGetByIdGroup(w, r, logger, config)
+	})
+	handleFunc("/group/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupPost(w, r, logger, config)
+	})
+	handleFunc("/group/update/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupPut(w, r, logger, config)
+	})
+	handleFunc("/group/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupDelete(w, r, logger, config)
+	})
+	handleFunc("/group/members/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupGetMembers(w, r, logger, config)
+	})
+	handleFunc("/group/member/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupMemberPost(w, r, logger, config)
+	})
+	handleFunc("/group/member/role", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupMemberRolePut(w, r, logger, config)
+	})
+	handleFunc("/group/member/remove", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupMemberDelete(w, r, logger, config)
+	})
+	handleFunc("/group/invite/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupInviteGetAll(w, r, logger, config)
+	})
+	handleFunc("/group/invite/accept", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupInviteAccept(w, r, logger, config)
+	})
+	handleFunc("/group/invite/decline", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GroupInviteDecline(w, r, logger, config)
+	})
+}
@@ -0,0 +1,42 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func statementImport(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/import/csv", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportCSV(w, r, logger, config)
+	})
+	handleFunc("/import/ofx", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportOFX(w, r, logger, config)
+	})
+	handleFunc("/import/qif", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportQIF(w, r, logger, config)
+	})
+	handleFunc("/import/camt053", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportCAMT(w, r, logger, config)
+	})
+	handleFunc("/import/mt940", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportMT940(w, r, logger, config)
+	})
+	handleFunc("/import/profile/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportProfileGetAll(w, r, logger, config)
+	})
+	handleFunc("/import/profile/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportProfileGetById(w, r, logger, config)
+	})
+	handleFunc("/import/profile/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportProfilePost(w, r, logger, config)
+	})
+	handleFunc("/import/profile/update/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportProfilePut(w, r, logger, config)
+	})
+	handleFunc("/import/profile/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ImportProfileDelete(w, r, logger, config)
+	})
+}
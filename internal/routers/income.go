@@ -9,22 +9,22 @@ import (
 )
 
 func income(logger *logger.CombinedLogger, config *config.Config) {
-	http.HandleFunc("/income/all", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/income/all", func(w http.ResponseWriter, r *http.Request) {
 		handlers.IncomeGetAll(w, r, logger, config)
 	})
-	http.HandleFunc("/income/id/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/income/id/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.IncomeGetByIdIncome(w, r, logger, config)
 	})
-	http.HandleFunc("/income/account/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/income/account/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.IncomeGetByIdAccount(w, r, logger, config)
 	})
-	http.HandleFunc("/income/new/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/income/new/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.IncomePost(w, r, logger, config)
 	})
-	http.HandleFunc("/income/update/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/income/update/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.IncomePut(w, r, logger, config)
 	})
-	http.HandleFunc("/income/delete/", func(w http.ResponseWriter, r *http.Request) {
+	handleFunc("/income/delete/", func(w http.ResponseWriter, r *http.Request) {
 		handlers.IncomeDelete(w, r, logger, config)
 	})
 }
@@ -0,0 +1,33 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func ledger(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/ledger/account/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerAccountGetAll(w, r, logger, config)
+	})
+	handleFunc("/ledger/account/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerAccountPost(w, r, logger, config)
+	})
+	handleFunc("/ledger/entry/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerEntryGetAll(w, r, logger, config)
+	})
+	handleFunc("/ledger/entry/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerEntryGetByIdEntry(w, r, logger, config)
+	})
+	handleFunc("/ledger/entry/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerEntryPost(w, r, logger, config)
+	})
+	handleFunc("/ledger/trial_balance", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerTrialBalance(w, r, logger, config)
+	})
+	handleFunc("/ledger/general/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.LedgerGeneral(w, r, logger, config)
+	})
+}
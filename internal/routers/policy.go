@@ -0,0 +1,205 @@
+package routers
+
+import (
+	"strings"
+
+	"github.com/helltale/api-finances/internal/services"
+)
+
+// action every route needs, record level checks are done by services.
+// routes ending with "/" match the whole subtree like in http.ServeMux,
+// routes missing here are denied
+var routePermissions = map[string]string{
+	"/account/all":    services.ActionRead,
+	"/account/id/":    services.ActionRead,
+	"/account/update": services.ActionWriteOwn,
+	"/account/delete": services.ActionWriteOwn,
+	"/accounts/":      services.ActionRead,
+
+	"/audit": services.ActionRead,
+
+	"/auth/key/all":     services.ActionRead,
+	"/auth/key/new":     services.ActionRead,
+	"/auth/key/revoke/": services.ActionRead,
+	"/auth/key/rotate/": services.ActionRead,
+
+	"/budget/all":     services.ActionRead,
+	"/budget/id/":     services.ActionRead,
+	"/budget/new":     services.ActionWriteOwn,
+	"/budget/update/": services.ActionWriteOwn,
+	"/budget/delete/": services.ActionWriteOwn,
+	"/budgets/":       services.ActionRead,
+
+	"/category/all":     services.ActionRead,
+	"/category/tree":    services.ActionRead,
+	"/category/id/":     services.ActionRead,
+	"/category/new":     services.ActionWriteOwn,
+	"/category/update/": services.ActionWriteOwn,
+	"/category/delete/": services.ActionAdmin,
+	"/category/migrate": services.ActionAdmin,
+
+	"/tags/all":     services.ActionRead,
+	"/tags/id/":     services.ActionRead,
+	"/tags/new":     services.ActionWriteOwn,
+	"/tags/update/": services.ActionWriteOwn,
+	"/tags/delete/": services.ActionWriteOwn,
+
+	"/cashback/all":               services.ActionRead,
+	"/cashback/id/":               services.ActionRead,
+	"/cashback/account/":          services.ActionRead,
+	"/cashback/bank/":             services.ActionRead,
+	"/cashback/category/":         services.ActionRead,
+	"/cashback/current":           services.ActionRead,
+	"/cashback/credit":            services.ActionWriteOwn,
+	"/cashback/selection/suggest": services.ActionRead,
+	"/cashback/selection/accept":  services.ActionWriteOwn,
+	"/cashback/recommend":         services.ActionRead,
+	"/cashback/new":               services.ActionWriteOwn,
+	"/cashback/update/":           services.ActionWriteOwn,
+	"/cashback/delete/":           services.ActionWriteOwn,
+
+	"/expence/all":             services.ActionRead,
+	"/expence/id/":             services.ActionRead,
+	"/expence/group/":          services.ActionRead,
+	"/expence/title/":          services.ActionRead,
+	"/expence/date/between/":   services.ActionRead,
+	"/expence/amount/between/": services.ActionRead,
+	"/expence/amount/less/":    services.ActionRead,
+	"/expence/amount/more/":    services.ActionRead,
+	"/expence/every/":          services.ActionRead,
+	"/expence/new":             services.ActionWriteOwn,
+	"/expence/update/":         services.ActionWriteOwn,
+	"/expence/delete/":         services.ActionWriteOwn,
+
+	"/goal/all":              services.ActionRead,
+	"/goal/id/":              services.ActionRead,
+	"/goal/account/":         services.ActionRead,
+	"/goal/date/between/":    services.ActionRead,
+	"/goal/amount/between/":  services.ActionRead,
+	"/goal/amount/less/":     services.ActionRead,
+	"/goal/amount/more/":     services.ActionRead,
+	"/goal/current":          services.ActionRead,
+	"/goal/contribution/new": services.ActionWriteOwn,
+	"/goal/new":              services.ActionWriteOwn,
+	"/goal/update/":          services.ActionWriteOwn,
+	"/goal/delete/":          services.ActionWriteOwn,
+
+	"/group/all":            services.ActionRead,
+	"/group/id/":            services.ActionRead,
+	"/group/members/":       services.ActionRead,
+	"/group/new":            services.ActionWriteOwn,
+	"/group/update/":        services.ActionManageGroup,
+	"/group/delete/":        services.ActionManageGroup,
+	"/group/member/add":     services.ActionManageGroup,
+	"/group/member/role":    services.ActionManageGroup,
+	"/group/member/remove":  services.ActionRead, // members may leave, removing others is checked by service
+	"/group/invite/all":     services.ActionRead,
+	"/group/invite/accept":  services.ActionRead, // acts on invites of the caller only
+	"/group/invite/decline": services.ActionRead,
+
+	"/duplicates":        services.ActionRead,
+	"/duplicates/detect": services.ActionWriteOwn,
+	"/duplicates/":       services.ActionWriteOwn,
+
+	"/rules/all":     services.ActionRead,
+	"/rules/id/":     services.ActionRead,
+	"/rules/new":     services.ActionWriteOwn,
+	"/rules/update/": services.ActionWriteOwn,
+	"/rules/delete/": services.ActionWriteOwn,
+	"/rules/apply":   services.ActionWriteOwn,
+
+	"/categorizer":         services.ActionRead,
+	"/categorizer/train":   services.ActionWriteOwn,
+	"/categorizer/suggest": services.ActionRead,
+	"/categorizer/accept/": services.ActionWriteOwn,
+
+	"/import/csv":             services.ActionWriteOwn,
+	"/import/ofx":             services.ActionWriteOwn,
+	"/import/qif":             services.ActionWriteOwn,
+	"/import/camt053":         services.ActionWriteOwn,
+	"/import/mt940":           services.ActionWriteOwn,
+	"/import/profile/all":     services.ActionRead,
+	"/import/profile/id/":     services.ActionRead,
+	"/import/profile/new":     services.ActionWriteOwn,
+	"/import/profile/update/": services.ActionWriteOwn,
+	"/import/profile/delete/": services.ActionWriteOwn,
+
+	"/income/all":      services.ActionRead,
+	"/income/id/":      services.ActionRead,
+	"/income/account/": services.ActionRead,
+	"/income/new/":     services.ActionWriteOwn,
+	"/income/update/":  services.ActionWriteOwn,
+	"/income/delete/":  services.ActionWriteOwn,
+
+	"/income_expected/all":            services.ActionRead,
+	"/income_expected/id/":            services.ActionRead,
+	"/income_expected/account/":       services.ActionRead,
+	"/income_expected/new":            services.ActionWriteOwn,
+	"/income_expected/update/":        services.ActionWriteOwn,
+	"/income_expected/update/history": services.ActionWriteOwn,
+	"/income_expected/delete/":        services.ActionWriteOwn,
+
+	"/ledger/account/all":   services.ActionRead,
+	"/ledger/account/new":   services.ActionWriteOwn,
+	"/ledger/entry/all":     services.ActionRead,
+	"/ledger/entry/id/":     services.ActionRead,
+	"/ledger/entry/new":     services.ActionWriteOwn,
+	"/ledger/trial_balance": services.ActionRead,
+	"/ledger/general/":      services.ActionRead,
+
+	"/remain/all":           services.ActionRead,
+	"/remain/id/":           services.ActionRead,
+	"/remain/account/":      services.ActionRead,
+	"/remain/last/id/":      services.ActionRead,
+	"/remain/date/between/": services.ActionRead,
+	"/remain/new":           services.ActionWriteOwn,
+	"/remain/update/":       services.ActionWriteOwn,
+	"/remain/delete/":       services.ActionWriteOwn,
+
+	"/transfer/all":     services.ActionRead,
+	"/transfer/id/":     services.ActionRead,
+	"/transfer/new":     services.ActionWriteOwn,
+	"/transfer/delete/": services.ActionWriteOwn,
+
+	"/reports/income-variance": services.ActionRead,
+	"/reports/cashback-earned": services.ActionRead,
+	"/reports/tags":            services.ActionRead,
+
+	"/scheduler/jobs": services.ActionRead,
+
+	"/trash":       services.ActionRead,
+	"/trash/purge": services.ActionAdmin,
+
+	// /{entity}/{id}/restore, other paths fall through to 404
+	"/account/":         services.ActionWriteOwn,
+	"/budget/":          services.ActionWriteOwn,
+	"/category/":        services.ActionWriteOwn,
+	"/import_profile/":  services.ActionWriteOwn,
+	"/rule/":            services.ActionWriteOwn,
+	"/tag/":             services.ActionWriteOwn,
+	"/cashback/":        services.ActionWriteOwn,
+	"/expence/":         services.ActionWriteOwn,
+	"/goal/":            services.ActionWriteOwn,
+	"/income/":          services.ActionWriteOwn,
+	"/income_expected/": services.ActionWriteOwn,
+	"/remain/":          services.ActionWriteOwn,
+	"/transfer/":        services.ActionWriteOwn,
+}
+
+// action of the route serving path, longest matching pattern wins
+func routeAction(path string) (string, bool) {
+	if action, ok := routePermissions[path]; ok {
+		return action, true
+	}
+
+	pattern := ""
+	for candidate := range routePermissions {
+		if strings.HasSuffix(candidate, "/") && strings.HasPrefix(path, candidate) && len(candidate) > len(pattern) {
+			pattern = candidate
+		}
+	}
+	if pattern == "" {
+		return "", false
+	}
+	return routePermissions[pattern], true
+}
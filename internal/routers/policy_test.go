@@ -0,0 +1,143 @@
+package routers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+)
+
+const (
+	testOwner  = 1
+	testMember = 2
+	testViewer = 3
+	testAdmin  = 4 // owner of its own group and listed in admin-accounts
+)
+
+var (
+	testConfig = &config.Config{AppMode: "debug", AuthSecret: "test", AdminAccounts: []int64{testAdmin}}
+	initOnce   sync.Once
+)
+
+// Init registers on the default mux, which can be done once per test binary
+func initRoutes() {
+	initOnce.Do(func() {
+		Init(logger.NewCombinedLogger(logger.NewSLogger(), logger.NewSLogger()), testConfig)
+	})
+}
+
+func setupGroup() {
+	debugging.GroupMembers = nil
+	for idAccaunt, role := range map[int64]string{
+		testOwner:  models.RoleOwner,
+		testMember: models.RoleMember,
+		testViewer: models.RoleViewer,
+	} {
+		member := &models.GroupMember{}
+		member.SetIdGroup(1)
+		member.SetIdAccaunt(idAccaunt)
+		member.SetRole(role)
+		debugging.GroupMembers = append(debugging.GroupMembers, member)
+	}
+
+	admin := &models.GroupMember{}
+	admin.SetIdGroup(2)
+	admin.SetIdAccaunt(testAdmin)
+	admin.SetRole(models.RoleOwner)
+	debugging.GroupMembers = append(debugging.GroupMembers, admin)
+}
+
+func testAccount(idAccaunt int64) *models.Account {
+	account := &models.Account{}
+	account.SetIdAccaunt(idAccaunt)
+	return account
+}
+
+// who passes the route check for every action
+var actionOutcomes = map[string]map[int64]bool{
+	services.ActionRead:        {testOwner: true, testMember: true, testViewer: true, testAdmin: true},
+	services.ActionWriteOwn:    {testOwner: true, testMember: true, testViewer: false, testAdmin: true},
+	services.ActionWriteGroup:  {testOwner: true, testMember: false, testViewer: false, testAdmin: true},
+	services.ActionManageGroup: {testOwner: true, testMember: false, testViewer: false, testAdmin: true},
+	services.ActionAdmin:       {testOwner: false, testMember: false, testViewer: false, testAdmin: true},
+}
+
+func TestEveryRegisteredRouteHasPermission(t *testing.T) {
+	initRoutes()
+	setupGroup()
+
+	if len(registeredRoutes) == 0 {
+		t.Fatal("no routes registered")
+	}
+
+	for _, pattern := range registeredRoutes {
+		t.Run(pattern, func(t *testing.T) {
+			if publicPaths[pattern] {
+				return
+			}
+			action, ok := routePermissions[pattern]
+			if !ok {
+				t.Fatalf("route %s has no routePermissions entry", pattern)
+			}
+			outcomes, ok := actionOutcomes[action]
+			if !ok {
+				t.Fatalf("route %s needs unknown action %q", pattern, action)
+			}
+			for idAccaunt, want := range outcomes {
+				if got := allowed(testAccount(idAccaunt), action, testConfig); got != want {
+					t.Errorf("account %d on %s (%s): allowed = %v, want %v", idAccaunt, pattern, action, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEveryPermissionIsRegistered(t *testing.T) {
+	initRoutes()
+
+	registered := make(map[string]bool, len(registeredRoutes))
+	for _, pattern := range registeredRoutes {
+		registered[pattern] = true
+	}
+	for pattern := range routePermissions {
+		if !registered[pattern] {
+			t.Errorf("routePermissions entry %s is not registered by Init", pattern)
+		}
+	}
+}
+
+func TestRouteAccessByRole(t *testing.T) {
+	setupGroup()
+
+	tests := []struct {
+		path      string
+		idAccaunt int64
+		want      bool
+	}{
+		{"/goal/all", testViewer, true},
+		{"/goal/new", testViewer, false},
+		{"/goal/new", testMember, true},
+		{"/cashback/delete/7", testViewer, false},
+		{"/cashback/delete/7", testMember, true},
+		{"/expence/id/3", testViewer, true},
+		{"/expence/1/restore", testViewer, false},
+		{"/group/member/add", testMember, false},
+		{"/group/member/add", testOwner, true},
+		{"/group/member/remove", testViewer, true},
+		{"/trash/purge", testOwner, false},
+		{"/trash/purge", testAdmin, true},
+		{"/unknown", testOwner, false},
+	}
+
+	for _, tt := range tests {
+		action, ok := routeAction(tt.path)
+		got := ok && allowed(testAccount(tt.idAccaunt), action, testConfig)
+		if got != tt.want {
+			t.Errorf("account %d on %s: allowed = %v, want %v", tt.idAccaunt, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func report(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/reports/income-variance", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReportIncomeVariance(w, r, logger, config)
+	})
+	handleFunc("/reports/cashback-earned", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReportCashbackEarned(w, r, logger, config)
+	})
+	handleFunc("/reports/tags", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReportTags(w, r, logger, config)
+	})
+}
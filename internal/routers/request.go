@@ -0,0 +1,36 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/request"
+)
+
+// request id from client is kept if sane, otherwise a new one is generated.
+// Client address is resolved once, forwarding headers count only from trusted proxies
+func requestIdMiddleware(next http.Handler, config *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(request.IdHeader)
+		if id == "" || len(id) > 64 {
+			id = request.NewId()
+		}
+
+		ctx := request.WithId(r.Context(), id)
+		ctx = request.WithClientIP(ctx, request.ClientIP(r, config.TrustedProxies))
+
+		w.Header().Set(request.IdHeader, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requests touch the store one at a time, scheduler jobs take the same lock
+func storeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		debugging.Mu.Lock()
+		defer debugging.Mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,16 +1,44 @@
 package routers
 
 import (
+	"net/http"
+
 	"github.com/helltale/api-finances/config"
 	"github.com/helltale/api-finances/internal/logger"
 )
 
-func Init(logger *logger.CombinedLogger, config *config.Config) {
+func Init(logger *logger.CombinedLogger, config *config.Config) http.Handler {
+	authRoutes(logger, config)
 	income(logger, config)
 	income_expected(logger, config)
 	account(logger, config)
+	group(logger, config)
+	category(logger, config)
+	tag(logger, config)
 	expence(logger, config)
 	remain(logger, config)
 	goal(logger, config)
 	cashback(logger, config)
+	budget(logger, config)
+	statementImport(logger, config)
+	duplicate(logger, config)
+	rule(logger, config)
+	categorizer(logger, config)
+	transfer(logger, config)
+	ledger(logger, config)
+	audit(logger, config)
+	trash(logger, config)
+	scheduler(logger, config)
+	report(logger, config)
+
+	return requestIdMiddleware(storeMiddleware(authMiddleware(http.DefaultServeMux, logger, config)), config)
+}
+
+// patterns registered by Init, every one needs an entry in routePermissions
+var registeredRoutes []string
+
+// register handler on the default mux and remember its pattern
+func handleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	registeredRoutes = append(registeredRoutes, pattern)
+	http.HandleFunc(pattern, handler)
 }
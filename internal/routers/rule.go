@@ -0,0 +1,30 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func rule(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/rules/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RuleGetAll(w, r, logger, config)
+	})
+	handleFunc("/rules/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RuleGetById(w, r, logger, config)
+	})
+	handleFunc("/rules/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RulePost(w, r, logger, config)
+	})
+	handleFunc("/rules/update/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RulePut(w, r, logger, config)
+	})
+	handleFunc("/rules/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RuleDelete(w, r, logger, config)
+	})
+	handleFunc("/rules/apply", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RuleApply(w, r, logger, config)
+	})
+}
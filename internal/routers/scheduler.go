@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func scheduler(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/scheduler/jobs", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SchedulerJobGetAll(w, r, logger, config)
+	})
+}
@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func tag(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/tags/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TagGetAll(w, r, logger, config)
+	})
+	handleFunc("/tags/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TagGetById(w, r, logger, config)
+	})
+	handleFunc("/tags/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TagPost(w, r, logger, config)
+	})
+	handleFunc("/tags/update/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TagPut(w, r, logger, config)
+	})
+	handleFunc("/tags/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TagDelete(w, r, logger, config)
+	})
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+)
+
+func transfer(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/transfer/all", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TransferGetAll(w, r, logger, config)
+	})
+	handleFunc("/transfer/id/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TransferGetByIdTransfer(w, r, logger, config)
+	})
+	handleFunc("/transfer/new", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TransferPost(w, r, logger, config)
+	})
+	handleFunc("/transfer/delete/", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TransferDelete(w, r, logger, config)
+	})
+}
@@ -0,0 +1,26 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/handlers"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/services"
+)
+
+func trash(logger *logger.CombinedLogger, config *config.Config) {
+	handleFunc("/trash", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TrashGetAll(w, r, logger, config)
+	})
+	handleFunc("/trash/purge", func(w http.ResponseWriter, r *http.Request) {
+		handlers.TrashPurge(w, r, logger, config)
+	})
+
+	// /{entity}/{id}/restore, other paths of the subtree are 404
+	for _, entity := range services.TrashEntities {
+		handleFunc("/"+entity+"/", func(w http.ResponseWriter, r *http.Request) {
+			handlers.TrashRestore(w, r, logger, config)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/services"
+)
+
+const defaultInterval = time.Minute
+
+type job struct {
+	name string
+	run  services.JobFunc
+}
+
+// in-process scheduler, every job runs at start and then every interval
+type Scheduler struct {
+	logger   *logger.CombinedLogger
+	interval time.Duration
+	now      func() time.Time
+	jobs     []job
+	state    string // file with last runs of jobs, empty keeps them in memory
+
+	mu      sync.Mutex // one run at a time
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func New(logger *logger.CombinedLogger, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		logger:   logger,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// scheduler with jobs of the app, nil if disabled by scheduler-interval
+func Init(logger *logger.CombinedLogger, config *config.Config) *Scheduler {
+	interval := defaultInterval
+	if config.SchedulerInterval != "" {
+		parsed, err := time.ParseDuration(config.SchedulerInterval)
+		if err != nil {
+			logger.Error("Invalid scheduler-interval, default is used", "scheduler_interval", config.SchedulerInterval, "error", err)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval <= 0 {
+		logger.Info("Scheduler is disabled")
+		return nil
+	}
+
+	schedulerService := services.NewSchedulerService()
+	s := New(logger, interval).WithState(config.SchedulerState)
+	if err := s.LoadState(); err != nil {
+		logger.Error("Scheduler state is not loaded", "scheduler_state", config.SchedulerState, "error", err)
+	}
+	s.Add(services.JobRecurringExpences, schedulerService.PostDueExpences)
+	s.Add(services.JobPendingIncomes, schedulerService.CreatePendingIncomes)
+	s.Add(services.JobBudgetThresholds, schedulerService.CheckBudgets)
+	return s
+}
+
+// clock used for job runs, time.Now by default
+func (s *Scheduler) WithClock(now func() time.Time) *Scheduler {
+	s.now = now
+	return s
+}
+
+// file the last runs are saved to after every run and loaded from at start
+func (s *Scheduler) WithState(path string) *Scheduler {
+	s.state = path
+	return s
+}
+
+// last runs saved by a previous process
+func (s *Scheduler) LoadState() error {
+	if s.state == "" {
+		return nil
+	}
+
+	debugging.Mu.Lock()
+	defer debugging.Mu.Unlock()
+	return services.NewSchedulerService().LoadJobRuns(s.state)
+}
+
+func (s *Scheduler) Add(name string, run services.JobFunc) {
+	s.jobs = append(s.jobs, job{name: name, run: run})
+}
+
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+
+	go func() {
+		defer close(s.stopped)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.RunOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.RunOnce()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Scheduler started", "interval", s.interval.String(), "jobs", len(s.jobs))
+}
+
+// stop ticking and wait for the running job
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.stop = nil
+
+	s.logger.Info("Scheduler stopped")
+}
+
+// run every job once at the scheduler clock, the run is recorded. Every job
+// holds the store lock, requests wait for it in between
+func (s *Scheduler) RunOnce() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		period, processed, err := s.runJob(job)
+
+		if err != nil {
+			s.logger.Error("Scheduler job failed", "job", job.name, "period", period, "processed", processed, "error", err)
+			continue
+		}
+		if processed > 0 {
+			s.logger.Info("Scheduler job done", "job", job.name, "period", period, "processed", processed)
+		}
+	}
+}
+
+func (s *Scheduler) runJob(job job) (string, int, error) {
+	debugging.Mu.Lock()
+	defer debugging.Mu.Unlock()
+
+	schedulerService := services.NewSchedulerService()
+	at := s.now()
+	period, processed, err := job.run(at)
+	schedulerService.RecordRun(job.name, at, period, processed, err)
+
+	if s.state != "" {
+		if saveErr := schedulerService.SaveJobRuns(s.state); saveErr != nil {
+			s.logger.Error("Scheduler state is not saved", "scheduler_state", s.state, "error", saveErr)
+		}
+	}
+	return period, processed, err
+}
@@ -0,0 +1,247 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/logger"
+	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/services"
+)
+
+var futureDate = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+func resetStore() {
+	debugging.Incomes = nil
+	debugging.IncomesExpected = nil
+	debugging.Expences = nil
+	debugging.JournalEntries = nil
+	debugging.LedgerAccounts = nil
+	debugging.JobRuns = nil
+}
+
+// scheduler with one job and a clock moved by the test
+func testScheduler(name string, run services.JobFunc, now *time.Time) *Scheduler {
+	s := New(logger.NewCombinedLogger(logger.NewSLogger(), logger.NewSLogger()), time.Minute)
+	s.WithClock(func() time.Time { return *now })
+	s.Add(name, run)
+	return s
+}
+
+func day(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+func addIncomeExpected(idIncomeEx int64, monthDate int8) {
+	incomeExpected := &models.IncomeExpected{}
+	incomeExpected.SetIdIncomeEx(idIncomeEx)
+	incomeExpected.SetIdAccaunt(1)
+	incomeExpected.SetAmount(1000)
+	incomeExpected.SetTypeIncome("salary")
+	incomeExpected.SetIncomeMonthDate(monthDate)
+	incomeExpected.SetDateActualFrom(day(2024, 1, 1))
+	incomeExpected.SetDateActualTo(futureDate)
+	debugging.IncomesExpected = append(debugging.IncomesExpected, incomeExpected)
+}
+
+func addMonthlyExpence(idExpence int64, start time.Time) {
+	expence := &models.Expence{}
+	expence.SetIdExpence(idExpence)
+	expence.SetIdAccaunt(1)
+	expence.SetGroupExpence("rent")
+	expence.SetTitleExpence("rent")
+	expence.SetAmount(500)
+	expence.SetRepeat(1)
+	expence.SetDate(start)
+	expence.SetDateActualFrom(start)
+	expence.SetDateActualTo(futureDate)
+	debugging.Expences = append(debugging.Expences, expence)
+}
+
+func recurringEntries(idExpence int64) int {
+	count := 0
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetSourceType() == models.JournalSourceRecurring && entry.GetSourceId() == idExpence {
+			count++
+		}
+	}
+	return count
+}
+
+func TestPendingIncomesOncePerMonth(t *testing.T) {
+	resetStore()
+	addIncomeExpected(1, 5)
+	addIncomeExpected(2, 31)
+
+	now := day(2024, 2, 10)
+	s := testScheduler(services.JobPendingIncomes, services.NewSchedulerService().CreatePendingIncomes, &now)
+
+	steps := []struct {
+		at        time.Time
+		wantTotal int
+		wantRun   int
+	}{
+		{day(2024, 2, 10), 2, 2},
+		{day(2024, 2, 11), 2, 0}, // same month, nothing new
+		{day(2024, 3, 1), 4, 2},
+	}
+
+	for _, step := range steps {
+		now = step.at
+		s.RunOnce()
+
+		if got := len(debugging.Incomes); got != step.wantTotal {
+			t.Fatalf("%s: incomes = %d, want %d", step.at.Format("2006-01-02"), got, step.wantTotal)
+		}
+		run := services.NewSchedulerService().GetJobRun(services.JobPendingIncomes)
+		if run == nil || run.GetProcessed() != step.wantRun || !run.GetLastRun().Equal(step.at) {
+			t.Fatalf("%s: job run = %+v, want %d processed at the clock", step.at.Format("2006-01-02"), run, step.wantRun)
+		}
+	}
+
+	for _, income := range debugging.Incomes {
+		if !income.IsPending() {
+			t.Errorf("income %d is not pending", income.GetIdIncome())
+		}
+		if income.GetIdIncomeExpected() == 2 && income.GetIncomeMonthMonth() == 2 && income.GetDateActualFrom().Day() != 29 {
+			t.Errorf("due day of february = %d, want 29", income.GetDateActualFrom().Day())
+		}
+	}
+}
+
+func TestRecurringExpencesPostedOnce(t *testing.T) {
+	resetStore()
+	addMonthlyExpence(1, day(2024, 1, 15))
+
+	now := day(2024, 3, 20)
+	s := testScheduler(services.JobRecurringExpences, services.NewSchedulerService().PostDueExpences, &now)
+
+	s.RunOnce()
+	if got := recurringEntries(1); got != 3 {
+		t.Fatalf("occurrences after first run = %d, want 3", got)
+	}
+
+	now = day(2024, 3, 21)
+	s.RunOnce()
+	if got := recurringEntries(1); got != 3 {
+		t.Fatalf("occurrences after repeated run = %d, want 3", got)
+	}
+
+	now = day(2024, 4, 16)
+	s.RunOnce()
+	if got := recurringEntries(1); got != 4 {
+		t.Fatalf("occurrences after next month = %d, want 4", got)
+	}
+
+	// created after the runs with a start in the past, still caught up
+	addMonthlyExpence(2, day(2024, 2, 1))
+	now = day(2024, 4, 17)
+	s.RunOnce()
+	if got := recurringEntries(2); got != 3 {
+		t.Fatalf("occurrences of backdated expence = %d, want 3", got)
+	}
+	if got := recurringEntries(1); got != 4 {
+		t.Fatalf("occurrences after backdated expence = %d, want 4", got)
+	}
+}
+
+func TestJobRunsSurviveRestart(t *testing.T) {
+	resetStore()
+	addIncomeExpected(1, 5)
+	state := filepath.Join(t.TempDir(), "scheduler_state.json")
+
+	now := day(2024, 5, 6)
+	s := testScheduler(services.JobPendingIncomes, services.NewSchedulerService().CreatePendingIncomes, &now)
+	s.WithState(state)
+	s.RunOnce()
+
+	debugging.JobRuns = nil
+	if err := New(nil, time.Minute).WithState(state).LoadState(); err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+
+	run := services.NewSchedulerService().GetJobRun(services.JobPendingIncomes)
+	if run == nil {
+		t.Fatal("job run is not loaded")
+	}
+	if !run.GetLastRun().Equal(now) || run.GetLastPeriod() != "2024-05" || run.GetProcessed() != 1 || run.GetLastError() != "" {
+		t.Errorf("loaded run = %s %s %d %q, want %s 2024-05 1 \"\"",
+			run.GetLastRun(), run.GetLastPeriod(), run.GetProcessed(), run.GetLastError(), now)
+	}
+}
+
+func TestLoadStateWithoutFile(t *testing.T) {
+	resetStore()
+	state := filepath.Join(t.TempDir(), "missing.json")
+
+	if err := New(nil, time.Minute).WithState(state).LoadState(); err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(debugging.JobRuns) != 0 {
+		t.Errorf("job runs = %d, want 0", len(debugging.JobRuns))
+	}
+}
+
+func TestReceivedIncomeSupersedesPlaceholder(t *testing.T) {
+	resetStore()
+	addIncomeExpected(1, 5)
+	addIncomeExpected(2, 20)
+
+	now := day(2024, 5, 1)
+	s := testScheduler(services.JobPendingIncomes, services.NewSchedulerService().CreatePendingIncomes, &now)
+	s.RunOnce()
+	if got := len(debugging.Incomes); got != 2 {
+		t.Fatalf("placeholders = %d, want 2", got)
+	}
+
+	tests := []struct {
+		name       string
+		idIncomeEx int64 // given explicitly, 0 is matched by type and date
+		date       time.Time
+		wantLinked int64
+	}{
+		{"matched by date", 0, day(2024, 5, 6), 1},
+		{"explicit income expected", 2, day(2024, 5, 12), 2},
+	}
+
+	for i, tt := range tests {
+		income := &models.Income{}
+		income.SetIdIncome(int64(10 + i))
+		income.SetIdAccaunt(1)
+		income.SetIdIncomeExpected(tt.idIncomeEx)
+		income.SetAmount(1000)
+		income.SetTypeIncome("salary")
+		income.SetStatus(models.IncomeStatusReceived)
+		income.SetUpdBy("tester")
+		income.SetDateActualFrom(tt.date)
+		income.SetDateActualTo(futureDate)
+
+		incomeService := services.NewIncomeService()
+		if err := incomeService.AddNewIncome(income); err != nil {
+			t.Fatalf("%s: add income: %v", tt.name, err)
+		}
+		if income.GetIdIncomeExpected() != tt.wantLinked {
+			t.Errorf("%s: linked to %d, want %d", tt.name, income.GetIdIncomeExpected(), tt.wantLinked)
+		}
+
+		superseded := incomeService.Superseded()
+		if len(superseded) != 1 || superseded[0].GetIdIncomeExpected() != tt.wantLinked {
+			t.Fatalf("%s: superseded = %d placeholders, want the one of %d", tt.name, len(superseded), tt.wantLinked)
+		}
+		if !superseded[0].IsDeleted() || superseded[0].GetDeletedBy() != "tester" {
+			t.Errorf("%s: placeholder deleted = %v by %q, want soft delete by tester", tt.name, superseded[0].IsDeleted(), superseded[0].GetDeletedBy())
+		}
+	}
+
+	// placeholders stay in trash and are not made again this month
+	now = day(2024, 5, 25)
+	s.RunOnce()
+	if got := len(debugging.Incomes); got != 4 {
+		t.Errorf("incomes = %d, want 2 placeholders in trash and 2 received", got)
+	}
+	if got := len(services.NewIncomeService().GetAllIncomes()); got != 2 {
+		t.Errorf("visible incomes = %d, want 2", got)
+	}
+}
@@ -2,39 +2,73 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/models"
 )
 
-type AccountService struct{}
+type AccountService struct {
+	scope *Scope
+}
 
 func NewAccountService() *AccountService {
 	return &AccountService{}
 }
 
+// restrict queries and changes to accounts of scope
+func (s *AccountService) WithScope(scope *Scope) *AccountService {
+	s.scope = scope
+	return s
+}
+
 func (s *AccountService) AddNewAccount(newAccount *models.Account) error {
 	for _, account := range debugging.Accounts {
 		if account == newAccount {
 			return errors.New("this entry already exists")
 		}
 
-		if account.GetTgId() == newAccount.GetTgId() {
+		if newAccount.GetTgId() != 0 && account.GetTgId() == newAccount.GetTgId() {
 			return errors.New("this tgid already exists")
 		}
+
+		if account.GetIdAccaunt() == newAccount.GetIdAccaunt() {
+			return errors.New("this id_accaunt already exists")
+		}
+	}
+
+	if err := s.canJoinGroup(newAccount); err != nil {
+		return err
 	}
 
 	debugging.Accounts = append(debugging.Accounts, newAccount)
+	s.joinGroup(newAccount, true)
 	return nil
 }
 
 func (s *AccountService) GetAllAccounts() []*models.Account {
-	return debugging.Accounts
+	var accounts []*models.Account
+	for _, account := range debugging.Accounts {
+		if !account.IsDeleted() && s.scope.Allows(account.GetIdAccaunt()) {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
 }
 
 func (s *AccountService) GetAccountById(idAccaunt int64) (*models.Account, error) {
 	for _, account := range debugging.Accounts {
-		if account.GetIdAccaunt() == idAccaunt {
+		if account.GetIdAccaunt() == idAccaunt && !account.IsDeleted() && s.scope.Allows(account.GetIdAccaunt()) {
+			return account, nil
+		}
+	}
+	return nil, errors.New("account not found")
+}
+
+func (s *AccountService) GetAccountByTgId(tgId int64) (*models.Account, error) {
+	for _, account := range debugging.Accounts {
+		if tgId != 0 && account.GetTgId() == tgId && !account.IsDeleted() {
 			return account, nil
 		}
 	}
@@ -43,23 +77,113 @@ func (s *AccountService) GetAccountById(idAccaunt int64) (*models.Account, error
 
 func (s *AccountService) UpdateAccount(updatedAccount *models.Account) (*models.Account, error) {
 	for i, account := range debugging.Accounts {
-		if account.GetIdAccaunt() == updatedAccount.GetIdAccaunt() {
+		if account.GetIdAccaunt() == updatedAccount.GetIdAccaunt() && !account.IsDeleted() {
+			if err := s.scope.CanWrite(account.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if account.GetGroupId() != updatedAccount.GetGroupId() {
+				if err := s.canJoinGroup(updatedAccount); err != nil {
+					return nil, err
+				}
+			}
+
+			// tg_id stays bound by telegram login
+			updatedAccount.SetTgId(account.GetTgId())
+
 			oldAccountCopy := &models.Account{}
 			*oldAccountCopy = *account
 
 			debugging.Accounts[i] = updatedAccount
+			s.joinGroup(updatedAccount, s.scope == nil || s.scope.GetIdAccaunt() == account.GetIdAccaunt())
 			return oldAccountCopy, nil
 		}
 	}
 	return nil, errors.New("account not found")
 }
 
-func (s *AccountService) DeleteAccount(idAccaunt int64) error {
-	for i, account := range debugging.Accounts {
-		if account.GetIdAccaunt() == idAccaunt {
-			debugging.Accounts = append(debugging.Accounts[:i], debugging.Accounts[i+1:]...)
-			return nil
+// move account to trash. Its records and memberships stay, so the group keeps
+// seeing them and restore brings everything back. The last owner of a group
+// must hand it over first
+func (s *AccountService) DeleteAccount(idAccaunt int64, deletedBy string) (*models.Account, error) {
+	account, err := s.GetAccountById(idAccaunt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(idAccaunt); err != nil {
+		return nil, err
+	}
+
+	groupService := NewGroupService()
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdAccaunt() == idAccaunt && member.GetRole() == models.RoleOwner && groupService.countOwners(member.GetIdGroup()) == 1 {
+			return nil, fmt.Errorf("account is the last owner of group %d", member.GetIdGroup())
+		}
+	}
+
+	invites := debugging.GroupInvites[:0]
+	for _, invite := range debugging.GroupInvites {
+		if invite.GetIdAccaunt() != idAccaunt {
+			invites = append(invites, invite)
+		}
+	}
+	debugging.GroupInvites = invites
+
+	account.SetDeletedAt(time.Now())
+	account.SetDeletedBy(deletedBy)
+	return account, nil
+}
+
+// bring a deleted account back from trash
+func (s *AccountService) RestoreAccount(idAccaunt int64) (*models.Account, error) {
+	for _, account := range debugging.Accounts {
+		if account.GetIdAccaunt() != idAccaunt || !account.IsDeleted() || !s.scope.Allows(idAccaunt) {
+			continue
+		}
+		if err := s.scope.CanWrite(idAccaunt); err != nil {
+			return nil, err
 		}
+
+		account.SetDeletedAt(time.Time{})
+		account.SetDeletedBy("")
+		return account, nil
+	}
+	return nil, errors.New("deleted account not found")
+}
+
+// ids of deleted accounts are not given again
+func (s *AccountService) NextAccountId() int64 {
+	var maxId int64
+	for _, account := range debugging.Accounts {
+		maxId = max(maxId, account.GetIdAccaunt())
+	}
+	return maxId + 1
+}
+
+// joining a group through group_id is up to the group owner
+func (s *AccountService) canJoinGroup(account *models.Account) error {
+	if account.GetGroupId() == 0 {
+		return nil
+	}
+	if _, err := NewGroupService().GetGroupById(account.GetGroupId()); err != nil {
+		return nil
+	}
+	return s.scope.CanManageGroup(account.GetGroupId())
+}
+
+// keep group_id of account in sync with group membership. The account joins
+// right away when it is new or changes itself, anyone else only gets an invite
+func (s *AccountService) joinGroup(account *models.Account, consented bool) {
+	groupService := NewGroupService()
+	if _, err := groupService.GetGroupById(account.GetGroupId()); err != nil {
+		return
+	}
+
+	if groupService.IsMember(account.GetGroupId(), account.GetIdAccaunt()) {
+		return
+	}
+	if consented {
+		groupService.addMember(account.GetGroupId(), account.GetIdAccaunt(), models.RoleMember)
+		return
 	}
-	return errors.New("account not found")
+	groupService.WithScope(s.scope).InviteMember(account.GetGroupId(), account.GetIdAccaunt(), models.RoleMember)
 }
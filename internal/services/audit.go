@@ -0,0 +1,82 @@
+package services
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type AuditFilter struct {
+	Entity string    // empty for all
+	Actor  string    // actor name or id, empty for all
+	From   time.Time // zero for no lower bound
+	To     time.Time // zero for no upper bound
+}
+
+type AuditService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{now: time.Now}
+}
+
+// only changes made by accounts visible in scope
+func (s *AuditService) WithScope(scope *Scope) *AuditService {
+	s.scope = scope
+	return s
+}
+
+// append record, before and after are stored as json, nil is stored as null
+func (s *AuditService) Record(record *models.AuditRecord, before, after interface{}) error {
+	beforeJSON, err := marshalAudit(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalAudit(after)
+	if err != nil {
+		return err
+	}
+
+	record.SetIdAudit(int64(len(debugging.AuditRecords)) + 1)
+	record.SetDate(s.now())
+	record.SetBefore(beforeJSON)
+	record.SetAfter(afterJSON)
+
+	debugging.AuditRecords = append(debugging.AuditRecords, record)
+	return nil
+}
+
+func (s *AuditService) GetAuditRecords(filter AuditFilter) []*models.AuditRecord {
+	var records []*models.AuditRecord
+	for _, record := range debugging.AuditRecords {
+		if !s.scope.Allows(record.GetIdActor()) {
+			continue
+		}
+		if filter.Entity != "" && record.GetEntity() != filter.Entity {
+			continue
+		}
+		if filter.Actor != "" && record.GetActor() != filter.Actor && strconv.FormatInt(record.GetIdActor(), 10) != filter.Actor {
+			continue
+		}
+		if !filter.From.IsZero() && record.GetDate().Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && record.GetDate().After(filter.To) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func marshalAudit(value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/auth"
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	apiKeyPrefix       = "af_"
+	refreshTokenPrefix = "rt_"
+	apiKeyShownChars   = 10
+
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+
+	defaultTelegramMaxAge = 24 * time.Hour
+)
+
+type AuthService struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+
+	telegramBotToken      string
+	telegramMaxAge        time.Duration
+	telegramAutoProvision bool
+}
+
+func NewAuthService(config *config.Config) *AuthService {
+	s := &AuthService{
+		secret:     []byte(config.AuthSecret),
+		accessTTL:  defaultAccessTTL,
+		refreshTTL: defaultRefreshTTL,
+		now:        time.Now,
+
+		telegramBotToken:      config.TelegramBotToken,
+		telegramMaxAge:        defaultTelegramMaxAge,
+		telegramAutoProvision: config.TelegramAutoProvision,
+	}
+
+	if ttl, err := time.ParseDuration(config.AuthAccessTTL); err == nil && ttl > 0 {
+		s.accessTTL = ttl
+	}
+	if ttl, err := time.ParseDuration(config.AuthRefreshTTL); err == nil && ttl > 0 {
+		s.refreshTTL = ttl
+	}
+	if ttl, err := time.ParseDuration(config.TelegramAuthMaxAge); err == nil && ttl > 0 {
+		s.telegramMaxAge = ttl
+	}
+
+	return s
+}
+
+// new api key for account, plain key is returned only once
+func (s *AuthService) CreateApiKey(idAccaunt int64, name string) (*models.ApiKey, string, error) {
+	if _, err := NewAccountService().GetAccountById(idAccaunt); err != nil {
+		return nil, "", err
+	}
+
+	plain, err := auth.GenerateSecret(apiKeyPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var maxId int64
+	for _, apiKey := range debugging.ApiKeys {
+		if apiKey.GetIdApiKey() > maxId {
+			maxId = apiKey.GetIdApiKey()
+		}
+	}
+
+	apiKey := &models.ApiKey{}
+	apiKey.SetIdApiKey(maxId + 1)
+	apiKey.SetIdAccaunt(idAccaunt)
+	apiKey.SetName(name)
+	apiKey.SetPrefix(plain[:apiKeyShownChars])
+	apiKey.SetKeyHash(auth.HashSecret(plain))
+	apiKey.SetCreatedAt(s.now())
+
+	debugging.ApiKeys = append(debugging.ApiKeys, apiKey)
+	return apiKey, plain, nil
+}
+
+func (s *AuthService) GetApiKeysByAccount(idAccaunt int64) []*models.ApiKey {
+	var apiKeys []*models.ApiKey
+	for _, apiKey := range debugging.ApiKeys {
+		if apiKey.GetIdAccaunt() == idAccaunt {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	return apiKeys
+}
+
+func (s *AuthService) RevokeApiKey(idAccaunt, idApiKey int64) (*models.ApiKey, error) {
+	for _, apiKey := range debugging.ApiKeys {
+		if apiKey.GetIdApiKey() == idApiKey && apiKey.GetIdAccaunt() == idAccaunt {
+			if apiKey.IsRevoked() {
+				return nil, errors.New("api key already revoked")
+			}
+			apiKey.SetRevokedAt(s.now())
+			return apiKey, nil
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+// revoke key and issue a new one with the same name
+func (s *AuthService) RotateApiKey(idAccaunt, idApiKey int64) (*models.ApiKey, string, error) {
+	oldApiKey, err := s.RevokeApiKey(idAccaunt, idApiKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.CreateApiKey(idAccaunt, oldApiKey.GetName())
+}
+
+func (s *AuthService) AuthenticateApiKey(plain string) (*models.Account, error) {
+	hash := auth.HashSecret(plain)
+	for _, apiKey := range debugging.ApiKeys {
+		if apiKey.GetKeyHash() == hash && !apiKey.IsRevoked() {
+			return NewAccountService().GetAccountById(apiKey.GetIdAccaunt())
+		}
+	}
+	return nil, errors.New("invalid api key")
+}
+
+func (s *AuthService) AuthenticateBearer(token string) (*models.Account, error) {
+	claims, err := auth.ParseToken(s.secret, token, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Typ != auth.TokenAccess {
+		return nil, errors.New("not an access token")
+	}
+
+	return NewAccountService().GetAccountById(claims.Sub)
+}
+
+// access JWT + single use refresh token
+func (s *AuthService) IssueTokens(account *models.Account) (*models.TokenPairJSON, error) {
+	now := s.now()
+
+	accessToken, err := auth.SignToken(s.secret, auth.Claims{
+		Sub: account.GetIdAccaunt(),
+		Iat: now.Unix(),
+		Exp: now.Add(s.accessTTL).Unix(),
+		Typ: auth.TokenAccess,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshPlain, err := auth.GenerateSecret(refreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := &models.RefreshToken{}
+	refreshToken.SetIdAccaunt(account.GetIdAccaunt())
+	refreshToken.SetTokenHash(auth.HashSecret(refreshPlain))
+	refreshToken.SetExpiresAt(now.Add(s.refreshTTL))
+	debugging.RefreshTokens = append(debugging.RefreshTokens, refreshToken)
+
+	return &models.TokenPairJSON{
+		AccessToken:  accessToken,
+		RefreshToken: refreshPlain,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *AuthService) Refresh(refreshPlain string) (*models.TokenPairJSON, error) {
+	hash := auth.HashSecret(refreshPlain)
+	for _, refreshToken := range debugging.RefreshTokens {
+		if refreshToken.GetTokenHash() != hash {
+			continue
+		}
+
+		if refreshToken.IsUsed() || !s.now().Before(refreshToken.GetExpiresAt()) {
+			return nil, errors.New("refresh token expired or already used")
+		}
+		refreshToken.SetUsed(true)
+
+		account, err := NewAccountService().GetAccountById(refreshToken.GetIdAccaunt())
+		if err != nil {
+			return nil, err
+		}
+		return s.IssueTokens(account)
+	}
+	return nil, errors.New("invalid refresh token")
+}
+
+// Login Widget fields -> token pair of account with that tg_id
+func (s *AuthService) LoginTelegram(fields map[string]string) (*models.TokenPairJSON, error) {
+	if s.telegramBotToken == "" {
+		return nil, errors.New("telegram login is not configured")
+	}
+
+	user, err := auth.VerifyTelegramLogin(s.telegramBotToken, fields, s.now(), s.telegramMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTelegramTokens(user)
+}
+
+// WebApp initData -> token pair of account with that tg_id
+func (s *AuthService) LoginTelegramWebApp(initData string) (*models.TokenPairJSON, error) {
+	if s.telegramBotToken == "" {
+		return nil, errors.New("telegram login is not configured")
+	}
+
+	user, err := auth.VerifyTelegramWebApp(s.telegramBotToken, initData, s.now(), s.telegramMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTelegramTokens(user)
+}
+
+func (s *AuthService) issueTelegramTokens(user *auth.TelegramUser) (*models.TokenPairJSON, error) {
+	accountService := NewAccountService()
+
+	account, err := accountService.GetAccountByTgId(user.Id)
+	if err != nil {
+		if !s.telegramAutoProvision {
+			return nil, err
+		}
+
+		account = &models.Account{}
+		account.SetIdAccaunt(accountService.NextAccountId())
+		account.SetTgId(user.Id)
+		account.SetName(user.DisplayName())
+		if err := accountService.AddNewAccount(account); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.IssueTokens(account)
+}
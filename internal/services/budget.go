@@ -0,0 +1,371 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// thresholds of budget created without them, percents
+var DefaultBudgetThresholds = []int{80, 100}
+
+// rollover is not counted further back than this
+const maxRolloverPeriods = 120
+
+type BudgetService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewBudgetService() *BudgetService {
+	return &BudgetService{now: time.Now}
+}
+
+// restrict queries and changes to accounts of scope
+func (s *BudgetService) WithScope(scope *Scope) *BudgetService {
+	s.scope = scope
+	return s
+}
+
+func (s *BudgetService) AddNewBudget(newBudget *models.Budget) error {
+	if err := s.validate(newBudget); err != nil {
+		return err
+	}
+	if err := s.canWrite(newBudget); err != nil {
+		return err
+	}
+	for _, budget := range debugging.Budgets {
+		if budget.GetIdBudget() == newBudget.GetIdBudget() {
+			return errors.New("budget already exists")
+		}
+	}
+
+	debugging.Budgets = append(debugging.Budgets, newBudget)
+	return nil
+}
+
+func (s *BudgetService) GetAllBudgets() []*models.Budget {
+	var budgets []*models.Budget
+	for _, budget := range debugging.Budgets {
+		if !budget.IsDeleted() && s.scope.Allows(budget.GetIdAccaunt()) {
+			budgets = append(budgets, budget)
+		}
+	}
+	return budgets
+}
+
+func (s *BudgetService) GetBudgetById(idBudget int64) (*models.Budget, error) {
+	for _, budget := range debugging.Budgets {
+		if budget.GetIdBudget() == idBudget && !budget.IsDeleted() && s.scope.Allows(budget.GetIdAccaunt()) {
+			return budget, nil
+		}
+	}
+	return nil, errors.New("budget not found")
+}
+
+// replace budget, old one is returned
+func (s *BudgetService) UpdateBudget(idBudget int64, newBudget *models.Budget) (*models.Budget, error) {
+	newBudget.SetIdBudget(idBudget)
+	if err := s.validate(newBudget); err != nil {
+		return nil, err
+	}
+
+	for i, budget := range debugging.Budgets {
+		if budget.GetIdBudget() == idBudget && !budget.IsDeleted() {
+			if err := s.canWrite(budget); err != nil {
+				return nil, err
+			}
+			if err := s.canWrite(newBudget); err != nil {
+				return nil, err
+			}
+
+			debugging.Budgets[i] = newBudget
+			return budget, nil
+		}
+	}
+	return nil, errors.New("budget not found")
+}
+
+func (s *BudgetService) DeleteBudget(idBudget int64, deletedBy string) (*models.Budget, error) {
+	budget, err := s.GetBudgetById(idBudget)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.canWrite(budget); err != nil {
+		return nil, err
+	}
+
+	budget.SetDeletedAt(s.now())
+	budget.SetDeletedBy(deletedBy)
+	return budget, nil
+}
+
+// group budget is shared by the whole group, only its managers change it
+func (s *BudgetService) canWrite(budget *models.Budget) error {
+	if err := s.scope.CanWrite(budget.GetIdAccaunt()); err != nil {
+		return err
+	}
+	if budget.GetIdGroup() != 0 {
+		return s.scope.CanManageGroup(budget.GetIdGroup())
+	}
+	return nil
+}
+
+// bring a deleted budget back from trash
+func (s *BudgetService) RestoreBudget(idBudget int64) (*models.Budget, error) {
+	for _, budget := range debugging.Budgets {
+		if budget.GetIdBudget() != idBudget || !budget.IsDeleted() {
+			continue
+		}
+		if err := s.canWrite(budget); err != nil {
+			return nil, err
+		}
+
+		budget.SetDeletedAt(time.Time{})
+		budget.SetDeletedBy("")
+		return budget, nil
+	}
+	return nil, errors.New("deleted budget not found")
+}
+
+// status of the current period of budget
+func (s *BudgetService) GetStatus(idBudget int64) (*models.BudgetStatusJSON, error) {
+	budget, err := s.GetBudgetById(idBudget)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(budget, s.now()), nil
+}
+
+func (s *BudgetService) GetEvents(idBudget int64) ([]*models.BudgetEvent, error) {
+	if _, err := s.GetBudgetById(idBudget); err != nil {
+		return nil, err
+	}
+
+	var events []*models.BudgetEvent
+	for _, event := range debugging.BudgetEvents {
+		if event.GetIdBudget() == idBudget {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// record an event for every threshold crossed in the current period that has no
+// event yet, new events are returned
+func (s *BudgetService) CheckThresholds() []*models.BudgetEvent {
+	now := s.now()
+
+	var raised []*models.BudgetEvent
+	for _, budget := range s.GetAllBudgets() {
+		status := s.status(budget, now)
+		periodStart, _ := budgetPeriod(budget.GetPeriod(), now)
+
+		for _, threshold := range budget.GetThresholds() {
+			if status.PercentUsed < float64(threshold) || s.hasEvent(budget.GetIdBudget(), periodStart, threshold) {
+				continue
+			}
+
+			event := &models.BudgetEvent{}
+			event.SetIdEvent(int64(len(debugging.BudgetEvents) + 1))
+			event.SetIdBudget(budget.GetIdBudget())
+			event.SetPeriodStart(periodStart)
+			event.SetThreshold(threshold)
+			event.SetPercentUsed(status.PercentUsed)
+			event.SetSpent(status.Spent)
+			event.SetAvailable(status.Available)
+			event.SetCreatedAt(now)
+
+			debugging.BudgetEvents = append(debugging.BudgetEvents, event)
+			raised = append(raised, event)
+		}
+	}
+	return raised
+}
+
+func (s *BudgetService) hasEvent(idBudget int64, periodStart time.Time, threshold int) bool {
+	for _, event := range debugging.BudgetEvents {
+		if event.GetIdBudget() == idBudget && event.GetPeriodStart().Equal(periodStart) && event.GetThreshold() == threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BudgetService) status(budget *models.Budget, at time.Time) *models.BudgetStatusJSON {
+	start, end := budgetPeriod(budget.GetPeriod(), at)
+	carried := s.carried(budget, start)
+	spent := s.spent(budget, start, end)
+	available := budget.GetAmount() + carried
+
+	status := &models.BudgetStatusJSON{
+		IdBudget:     budget.GetIdBudget(),
+		GroupExpence: budget.GetGroupExpence(),
+		PeriodStart:  start.Format("2006-01-02"),
+		PeriodEnd:    end.Format("2006-01-02"),
+		Amount:       budget.GetAmount(),
+		Carried:      roundAmount(carried),
+		Available:    roundAmount(available),
+		Spent:        roundAmount(spent),
+		Remaining:    roundAmount(available - spent),
+		DaysElapsed:  max(daysBetween(start, at), 1),
+	}
+	periodDays := daysBetween(start, end)
+	status.DaysLeft = max(periodDays-status.DaysElapsed, 0)
+
+	if available > 0 {
+		status.PercentUsed = roundAmount(spent / available * 100)
+	}
+	status.BurnRate = roundAmount(spent / float64(status.DaysElapsed))
+	status.ProjectedSpent = roundAmount(spent / float64(status.DaysElapsed) * float64(periodDays))
+	if status.DaysLeft > 0 && status.Remaining > 0 {
+		status.SafeDaily = roundAmount(status.Remaining / float64(status.DaysLeft))
+	}
+
+	status.Status = models.BudgetStatusOk
+	switch {
+	case status.PercentUsed >= 100:
+		status.Status = models.BudgetStatusOver
+	case len(budget.GetThresholds()) > 0 && status.PercentUsed >= float64(budget.GetThresholds()[0]):
+		status.Status = models.BudgetStatusWarning
+	}
+	return status
+}
+
+// unused amount of periods from the budget start up to the period, overspending
+// is not carried
+func (s *BudgetService) carried(budget *models.Budget, periodStart time.Time) float64 {
+	if !budget.GetRollover() || budget.GetDateStart().IsZero() {
+		return 0
+	}
+
+	first, _ := budgetPeriod(budget.GetPeriod(), budget.GetDateStart().In(periodStart.Location()))
+	var periods []time.Time
+	for start := first; start.Before(periodStart); _, start = budgetPeriod(budget.GetPeriod(), start) {
+		periods = append(periods, start)
+	}
+	if len(periods) > maxRolloverPeriods {
+		periods = periods[len(periods)-maxRolloverPeriods:]
+	}
+
+	var carried float64
+	for _, start := range periods {
+		_, end := budgetPeriod(budget.GetPeriod(), start)
+		carried = max(budget.GetAmount()+carried-s.spent(budget, start, end), 0)
+	}
+	return carried
+}
+
+// expences of the budget category and its subcategories made by the budget
+// accounts in [from, to), expences without category are matched by group text.
+// Split expence counts by its lines
+func (s *BudgetService) spent(budget *models.Budget, from, to time.Time) float64 {
+	accounts := s.accounts(budget)
+	categoryService := NewCategoryService()
+	matches := func(expence *models.Expence) bool {
+		if !accounts[expence.GetIdAccaunt()] {
+			return false
+		}
+		if expence.GetIdCategory() != 0 && budget.GetIdCategory() != 0 {
+			return categoryService.IsWithin(expence.GetIdCategory(), budget.GetIdCategory())
+		}
+		return strings.EqualFold(expence.GetGroupExpence(), budget.GetGroupExpence())
+	}
+
+	purchases := NewExpenceService().MaterializeExpences(from, to.Add(-time.Nanosecond))
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetRepeat() != 0 || expence.GetDateActualTo().Year() != 9999 {
+			continue
+		}
+		if date := expence.GetDate(); !date.Before(from) && date.Before(to) {
+			purchases = append(purchases, expence)
+		}
+	}
+
+	var spent float64
+	for _, purchase := range purchases {
+		for _, part := range purchase.Parts() {
+			if matches(part) {
+				spent += part.GetAmount()
+			}
+		}
+	}
+	return spent
+}
+
+// account of budget or every member of its group
+func (s *BudgetService) accounts(budget *models.Budget) map[int64]bool {
+	accounts := map[int64]bool{budget.GetIdAccaunt(): true}
+	if budget.GetIdGroup() == 0 {
+		return accounts
+	}
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdGroup() == budget.GetIdGroup() {
+			accounts[member.GetIdAccaunt()] = true
+		}
+	}
+	return accounts
+}
+
+// defaults are set, thresholds are sorted
+func (s *BudgetService) validate(budget *models.Budget) error {
+	idCategory, group, err := NewCategoryService().Categorize(budget.GetIdCategory(), budget.GetGroupExpence(), budget.GetIdAccaunt(), budget.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	if group == "" {
+		return errors.New("group_expence or id_category is required")
+	}
+	budget.SetIdCategory(idCategory)
+	budget.SetGroupExpence(group)
+
+	if budget.GetAmount() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if _, err := NewAccountService().GetAccountById(budget.GetIdAccaunt()); err != nil {
+		return err
+	}
+	if budget.GetIdGroup() != 0 && !NewGroupService().IsMember(budget.GetIdGroup(), budget.GetIdAccaunt()) {
+		return errors.New("account is not a member of the group")
+	}
+
+	switch budget.GetPeriod() {
+	case "":
+		budget.SetPeriod(models.BudgetPeriodMonthly)
+	case models.BudgetPeriodMonthly, models.BudgetPeriodWeekly:
+	default:
+		return errors.New("invalid period, must be monthly or weekly")
+	}
+
+	if len(budget.GetThresholds()) == 0 {
+		budget.SetThresholds(append([]int(nil), DefaultBudgetThresholds...))
+	}
+	thresholds := append([]int(nil), budget.GetThresholds()...)
+	sort.Ints(thresholds)
+	unique := thresholds[:0]
+	for i, threshold := range thresholds {
+		if threshold <= 0 || threshold > 1000 {
+			return errors.New("thresholds must be percents between 1 and 1000")
+		}
+		if i == 0 || threshold != thresholds[i-1] {
+			unique = append(unique, threshold)
+		}
+	}
+	budget.SetThresholds(unique)
+	return nil
+}
+
+// bounds of the period containing date, end is exclusive
+func budgetPeriod(period string, date time.Time) (time.Time, time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	if period == models.BudgetPeriodWeekly {
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	}
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, 1, 0)
+}
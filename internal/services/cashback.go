@@ -2,45 +2,121 @@ package services
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/models"
 )
 
-type CashbackService struct{}
+type CashbackService struct {
+	scope *Scope
+	now   func() time.Time
+}
 
 func NewCashbackService() *CashbackService {
-	return &CashbackService{}
+	return &CashbackService{now: time.Now}
+}
+
+// restrict queries and changes to accounts of scope
+func (s *CashbackService) WithScope(scope *Scope) *CashbackService {
+	s.scope = scope
+	return s
 }
 
 func (s *CashbackService) AddNewCashback(newCashback *models.Cashback) error {
+	if err := s.scope.CanWrite(newCashback.GetIdAccaunt()); err != nil {
+		return err
+	}
+
 	for _, cashback := range debugging.Cashbacks {
 		if cashback.GetIdCashback() == newCashback.GetIdCashback() {
 			return errors.New("cashback with this ID already exists")
 		}
 	}
+	if err := s.categorize(newCashback); err != nil {
+		return err
+	}
 
 	debugging.Cashbacks = append(debugging.Cashbacks, newCashback)
 	return nil
 }
 
 func (s *CashbackService) GetAllCashbacks() []*models.Cashback {
-	return debugging.Cashbacks
+	var cashbacks []*models.Cashback
+	for _, cashback := range debugging.Cashbacks {
+		if !cashback.IsDeleted() && s.scope.Allows(cashback.GetIdAccaunt()) {
+			cashbacks = append(cashbacks, cashback)
+		}
+	}
+	return cashbacks
 }
 
 func (s *CashbackService) GetCashbackById(idCashback int64) (*models.Cashback, error) {
 	for _, cashback := range debugging.Cashbacks {
-		if cashback.GetIdCashback() == idCashback {
+		if cashback.GetIdCashback() == idCashback && !cashback.IsDeleted() && s.scope.Allows(cashback.GetIdAccaunt()) {
 			return cashback, nil
 		}
 	}
 	return nil, errors.New("cashback not found")
 }
 
+func (s *CashbackService) GetCashbacksByAccount(idAccaunt int64) []*models.Cashback {
+	var cashbacks []*models.Cashback
+	for _, cashback := range s.GetAllCashbacks() {
+		if cashback.GetIdAccaunt() == idAccaunt {
+			cashbacks = append(cashbacks, cashback)
+		}
+	}
+	return cashbacks
+}
+
+// bank name is compared ignoring case
+func (s *CashbackService) GetCashbacksByBank(bankName string) []*models.Cashback {
+	var cashbacks []*models.Cashback
+	for _, cashback := range s.GetAllCashbacks() {
+		if strings.EqualFold(cashback.GetBankName(), bankName) {
+			cashbacks = append(cashbacks, cashback)
+		}
+	}
+	return cashbacks
+}
+
+// category is compared ignoring case
+func (s *CashbackService) GetCashbacksByCategory(category string) []*models.Cashback {
+	var cashbacks []*models.Cashback
+	for _, cashback := range s.GetAllCashbacks() {
+		if strings.EqualFold(cashback.GetCategory(), category) {
+			cashbacks = append(cashbacks, cashback)
+		}
+	}
+	return cashbacks
+}
+
+// current versions of cashbacks
+func (s *CashbackService) GetCurrentCashbacks() []*models.Cashback {
+	var cashbacks []*models.Cashback
+	for _, cashback := range s.GetAllCashbacks() {
+		if cashback.GetDateActualTo().Equal(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)) {
+			cashbacks = append(cashbacks, cashback)
+		}
+	}
+	return cashbacks
+}
+
 func (s *CashbackService) UpdateCashback(updatedCashback *models.Cashback) (*models.Cashback, error) {
 	for i, cashback := range debugging.Cashbacks {
-		if cashback.GetIdCashback() == updatedCashback.GetIdCashback() {
+		if cashback.GetIdCashback() == updatedCashback.GetIdCashback() && !cashback.IsDeleted() {
+			if err := s.scope.CanWrite(cashback.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(updatedCashback.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.categorize(updatedCashback); err != nil {
+				return nil, err
+			}
+
 			oldCashbackCopy := &models.Cashback{}
 			*oldCashbackCopy = *cashback
 
@@ -56,7 +132,17 @@ func (s *CashbackService) UpdateHistoryCashback(idCashback int64, newCashback *m
 
 	var oldCashback *models.Cashback
 	for i, cashback := range debugging.Cashbacks {
-		if cashback.GetIdCashback() == idCashback {
+		if cashback.GetIdCashback() == idCashback && !cashback.IsDeleted() {
+			if err := s.scope.CanWrite(cashback.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(newCashback.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.categorize(newCashback); err != nil {
+				return nil, err
+			}
+
 			oldCashback = cashback
 			debugging.Cashbacks[i].SetDateActualTo(today)
 			break
@@ -76,14 +162,61 @@ func (s *CashbackService) UpdateHistoryCashback(idCashback int64, newCashback *m
 	return oldCashback, nil
 }
 
-func (s *CashbackService) DeleteCashback(idCashback int64) (*models.Cashback, error) {
-	for i, cashback := range debugging.Cashbacks {
+// mark every version of the cashback deleted, history stays in place
+func (s *CashbackService) DeleteCashback(idCashback int64, deletedBy string) (*models.Cashback, error) {
+	var deleted *models.Cashback
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.GetIdCashback() != idCashback || cashback.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(cashback.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if deleted == nil || cashback.GetDateActualTo().Year() == 9999 {
+			deleted = cashback
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("cashback not found")
+	}
+
+	now := time.Now()
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.GetIdCashback() == idCashback && !cashback.IsDeleted() {
+			cashback.SetDeletedAt(now)
+			cashback.SetDeletedBy(deletedBy)
+		}
+	}
+	return deleted, nil
+}
+
+// bring a deleted cashback back from trash
+func (s *CashbackService) RestoreCashback(idCashback int64) (*models.Cashback, error) {
+	var restored *models.Cashback
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.GetIdCashback() != idCashback || !cashback.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(cashback.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if restored == nil || cashback.GetDateActualTo().Year() == 9999 {
+			restored = cashback
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted cashback not found")
+	}
+
+	for _, cashback := range debugging.Cashbacks {
 		if cashback.GetIdCashback() == idCashback {
-			debugging.Cashbacks = append(debugging.Cashbacks[:i], debugging.Cashbacks[i+1:]...)
-			return cashback, nil
+			cashback.SetDeletedAt(time.Time{})
+			cashback.SetDeletedBy("")
 		}
 	}
-	return nil, errors.New("cashback not found")
+	return restored, nil
 }
 
 func (s *CashbackService) DeleteAndRestorePreviousCashback(idCashback int64) (*models.Cashback, error) {
@@ -92,7 +225,7 @@ func (s *CashbackService) DeleteAndRestorePreviousCashback(idCashback int64) (*m
 	maxDate := time.Time{}
 
 	for _, cashback := range debugging.Cashbacks {
-		if cashback.GetIdCashback() == idCashback {
+		if cashback.GetIdCashback() == idCashback && !cashback.IsDeleted() {
 			if cashback.GetDateActualTo().Year() == 9999 {
 				currentRecord = cashback
 			} else if cashback.GetDateActualTo().After(maxDate) {
@@ -110,6 +243,10 @@ func (s *CashbackService) DeleteAndRestorePreviousCashback(idCashback int64) (*m
 		return nil, errors.New("no historical record found to restore")
 	}
 
+	if err := s.scope.CanWrite(currentRecord.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
 	for i, cashback := range debugging.Cashbacks {
 		if cashback == currentRecord {
 			debugging.Cashbacks = append(debugging.Cashbacks[:i], debugging.Cashbacks[i+1:]...)
@@ -122,3 +259,14 @@ func (s *CashbackService) DeleteAndRestorePreviousCashback(idCashback int64) (*m
 
 	return lastHistoricalRecord, nil
 }
+
+// link cashback rule to category by id or by category text
+func (s *CashbackService) categorize(cashback *models.Cashback) error {
+	idCategory, category, err := NewCategoryService().Categorize(cashback.GetIdCategory(), cashback.GetCategory(), cashback.GetIdAccaunt(), cashback.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	cashback.SetIdCategory(idCategory)
+	cashback.SetCategory(category)
+	return nil
+}
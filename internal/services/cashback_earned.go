@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// credited cashback may differ from expected by this much before it is flagged,
+// banks round cashback of each purchase down
+const CashbackCreditTolerance = 1.0
+
+// cashback of one purchase made with a card, split purchase accrues by its lines
+type cashbackAccrual struct {
+	expence  *models.Expence  // purchase, its account and bank name are the card
+	part     *models.Expence  // line of the purchase, the purchase itself if not split
+	cashback *models.Cashback // nil when no rule pays for the line
+	accrued  float64          // before monthly cap
+	earned   float64          // after monthly cap
+}
+
+// record what the bank credited to the card for the month, a credit recorded
+// before for the same card and month is replaced and returned
+func (s *CashbackService) RecordCredit(credit *models.CashbackCredit) (*models.CashbackCredit, error) {
+	credit.SetBankName(strings.TrimSpace(credit.GetBankName()))
+	if credit.GetBankName() == "" {
+		return nil, errors.New("bank_name is required")
+	}
+	if credit.GetAmount() < 0 {
+		return nil, errors.New("amount can not be negative")
+	}
+	if credit.GetMonth().IsZero() {
+		return nil, errors.New("month is required")
+	}
+	if _, err := NewAccountService().GetAccountById(credit.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(credit.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
+	month := credit.GetMonth()
+	credit.SetMonth(time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location()))
+	credit.SetCreatedAt(s.now())
+
+	for i, existing := range debugging.CashbackCredits {
+		if existing.GetIdAccaunt() == credit.GetIdAccaunt() && strings.EqualFold(existing.GetBankName(), credit.GetBankName()) &&
+			sameMonth(existing.GetMonth(), credit.GetMonth()) {
+			credit.SetIdCredit(existing.GetIdCredit())
+			debugging.CashbackCredits[i] = credit
+			return existing, nil
+		}
+	}
+
+	credit.SetIdCredit(int64(len(debugging.CashbackCredits) + 1))
+	debugging.CashbackCredits = append(debugging.CashbackCredits, credit)
+	return nil, nil
+}
+
+// expected cashback per card and rule of the month against credited by banks
+func (s *ReportService) CashbackEarned(year int, month time.Month) []models.CashbackEarnedJSON {
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, s.now().Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	reports := make(map[string]*models.CashbackEarnedJSON)
+	lines := make(map[string]map[int64]*models.CashbackEarnedLineJSON)
+	report := func(idAccaunt int64, bankName string) *models.CashbackEarnedJSON {
+		key := cardKey(idAccaunt, bankName)
+		if reports[key] == nil {
+			reports[key] = &models.CashbackEarnedJSON{
+				IdAccaunt: idAccaunt,
+				BankName:  bankName,
+				Month:     monthStart.Format("2006-01"),
+				Lines:     []models.CashbackEarnedLineJSON{},
+			}
+			lines[key] = make(map[int64]*models.CashbackEarnedLineJSON)
+		}
+		return reports[key]
+	}
+
+	type counted struct {
+		idCashback int64
+		expence    *models.Expence
+	}
+	purchases := make(map[counted]bool)
+
+	for _, accrual := range cashbackAccruals(s.scope, monthStart, monthEnd) {
+		expence := accrual.expence
+		cardReport := report(expence.GetIdAccaunt(), expence.GetBankName())
+		cardReport.Spent += accrual.part.GetAmount()
+		if accrual.cashback == nil {
+			cardReport.Uncovered += accrual.part.GetAmount()
+			continue
+		}
+
+		cardLines := lines[cardKey(expence.GetIdAccaunt(), expence.GetBankName())]
+		line, ok := cardLines[accrual.cashback.GetIdCashback()]
+		if !ok {
+			line = &models.CashbackEarnedLineJSON{
+				IdCashback: accrual.cashback.GetIdCashback(),
+				Category:   accrual.cashback.GetCategory(),
+				IdCategory: accrual.cashback.GetIdCategory(),
+				Percent:    accrual.cashback.GetPercent(),
+				MonthlyCap: accrual.cashback.GetMonthlyCap(),
+			}
+			cardLines[accrual.cashback.GetIdCashback()] = line
+		}
+		// lines of one purchase paid by the same rule are one purchase
+		purchase := counted{idCashback: accrual.cashback.GetIdCashback(), expence: expence}
+		if !purchases[purchase] {
+			purchases[purchase] = true
+			line.Purchases++
+		}
+		line.Spent += accrual.part.GetAmount()
+		line.Accrued += accrual.accrued
+		line.Expected += accrual.earned
+		line.Capped = line.Capped || accrual.earned < accrual.accrued
+		cardReport.Expected += accrual.earned
+	}
+
+	for _, credit := range debugging.CashbackCredits {
+		if !sameMonth(credit.GetMonth(), monthStart) || !s.scope.Allows(credit.GetIdAccaunt()) {
+			continue
+		}
+		cardReport := report(credit.GetIdAccaunt(), credit.GetBankName())
+		cardReport.Credited = credit.GetAmount()
+		cardReport.CreditRecorded = true
+	}
+
+	result := make([]models.CashbackEarnedJSON, 0, len(reports))
+	for key, cardReport := range reports {
+		for _, line := range lines[key] {
+			line.Spent = roundAmount(line.Spent)
+			line.Accrued = roundAmount(line.Accrued)
+			line.Expected = roundAmount(line.Expected)
+			cardReport.Lines = append(cardReport.Lines, *line)
+		}
+		sort.Slice(cardReport.Lines, func(i, j int) bool {
+			return cardReport.Lines[i].IdCashback < cardReport.Lines[j].IdCashback
+		})
+
+		cardReport.Spent = roundAmount(cardReport.Spent)
+		cardReport.Uncovered = roundAmount(cardReport.Uncovered)
+		cardReport.Expected = roundAmount(cardReport.Expected)
+		if cardReport.CreditRecorded {
+			cardReport.Difference = roundAmount(cardReport.Credited - cardReport.Expected)
+			cardReport.Discrepancy = cardReport.Difference > CashbackCreditTolerance || cardReport.Difference < -CashbackCreditTolerance
+		}
+		result = append(result, *cardReport)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].IdAccaunt != result[j].IdAccaunt {
+			return result[i].IdAccaunt < result[j].IdAccaunt
+		}
+		return result[i].BankName < result[j].BankName
+	})
+	return result
+}
+
+// purchases made with cards in [from, to) joined to the rule active for the card
+// and category at the purchase date, monthly caps are applied in purchase order.
+// Lines of split purchase are joined by their categories, minimum purchase is
+// checked against the whole purchase the bank sees
+func cashbackAccruals(scope *Scope, from, to time.Time) []cashbackAccrual {
+	var purchases []*models.Expence
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetRepeat() != 0 || expence.GetDateActualTo().Year() != 9999 {
+			continue
+		}
+		if date := expence.GetDate(); date.Before(from) || !date.Before(to) {
+			continue
+		}
+		purchases = append(purchases, expence)
+	}
+	purchases = append(purchases, NewExpenceService().MaterializeExpences(from, to.Add(-time.Nanosecond))...)
+	sort.SliceStable(purchases, func(i, j int) bool {
+		return purchases[i].GetDate().Before(purchases[j].GetDate())
+	})
+
+	categoryService := NewCategoryService()
+	used := make(map[string]float64)
+
+	var accruals []cashbackAccrual
+	for _, expence := range purchases {
+		if expence.GetBankName() == "" || !scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+
+		for _, part := range expence.Parts() {
+			accrual := cashbackAccrual{expence: expence, part: part}
+			for _, cashback := range debugging.Cashbacks {
+				if cashback.IsDeleted() || cashback.GetIdAccaunt() != expence.GetIdAccaunt() ||
+					!strings.EqualFold(cashback.GetBankName(), expence.GetBankName()) ||
+					!actualAt(cashback.GetDateActualFrom(), cashback.GetDateActualTo(), expence.GetDate()) ||
+					expence.GetAmount() < cashback.GetMinPurchase() ||
+					!cashbackCovers(categoryService, cashback, part.GetIdCategory(), part.GetGroupExpence()) {
+					continue
+				}
+				if accrual.cashback == nil || cashback.GetPercent() > accrual.cashback.GetPercent() {
+					accrual.cashback = cashback
+				}
+			}
+
+			if accrual.cashback != nil {
+				key := strconv.FormatInt(accrual.cashback.GetIdCashback(), 10) + ":" + expence.GetDate().Format("2006-01")
+				accrual.accrued, accrual.earned = cashbackFor(accrual.cashback, part.GetAmount(), used[key])
+				used[key] += accrual.earned
+			}
+			accruals = append(accruals, accrual)
+		}
+	}
+	return accruals
+}
+
+// rule pays for the category, by category tree when both have ids
+func cashbackCovers(categoryService *CategoryService, cashback *models.Cashback, idCategory int64, category string) bool {
+	if cashback.GetIdCategory() != 0 && idCategory != 0 {
+		return categoryService.IsWithin(idCategory, cashback.GetIdCategory())
+	}
+	return strings.EqualFold(cashback.GetCategory(), category)
+}
+
+// cashback of the rule for a purchase before and after monthly cap, used is
+// cashback the rule already paid in the month
+func cashbackFor(cashback *models.Cashback, amount, used float64) (float64, float64) {
+	accrued := roundAmount(amount * float64(cashback.GetPercent()) / 100)
+	if limit := cashback.GetMonthlyCap(); limit > 0 {
+		return accrued, roundAmount(min(accrued, max(limit-used, 0)))
+	}
+	return accrued, accrued
+}
+
+func cardKey(idAccaunt int64, bankName string) string {
+	return strconv.FormatInt(idAccaunt, 10) + ":" + strings.ToLower(bankName)
+}
+
+func sameMonth(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+func TestCashbackEarnedBySplitLines(t *testing.T) {
+	debugging.Categories = nil
+	for idCategory, code := range map[int64]string{1: "groceries", 2: "transport"} {
+		category := &models.Category{}
+		category.SetIdCategory(idCategory)
+		category.SetCode(code)
+		debugging.Categories = append(debugging.Categories, category)
+	}
+
+	date := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	futureDate := time.Date(9999, 12, 31, 0, 0, 0, 0, time.Local)
+
+	cashback := &models.Cashback{}
+	cashback.SetIdCashback(1)
+	cashback.SetIdAccaunt(1)
+	cashback.SetBankName("Tinkoff")
+	cashback.SetIdCategory(1)
+	cashback.SetPercent(5)
+	cashback.SetMinPurchase(800) // the whole purchase counts, not the line
+	cashback.SetDateActualFrom(date.AddDate(0, -1, 0))
+	cashback.SetDateActualTo(futureDate)
+	debugging.Cashbacks = []*models.Cashback{cashback}
+
+	newLine := func(amount float64, idCategory, idAccaunt int64) *models.ExpenceSplit {
+		line := &models.ExpenceSplit{}
+		line.SetAmount(amount)
+		line.SetIdCategory(idCategory)
+		line.SetIdAccaunt(idAccaunt)
+		return line
+	}
+	expence := &models.Expence{}
+	expence.SetIdExpence(1)
+	expence.SetIdAccaunt(1)
+	expence.SetBankName("Tinkoff")
+	expence.SetIdCategory(2)
+	expence.SetAmount(1000)
+	expence.SetDate(date)
+	expence.SetDateActualFrom(date)
+	expence.SetDateActualTo(futureDate)
+	expence.SetSplits([]*models.ExpenceSplit{newLine(500, 1, 0), newLine(100, 1, 2), newLine(400, 2, 0)})
+	debugging.Expences = []*models.Expence{expence}
+	debugging.CashbackCredits = nil
+
+	reports := NewReportService().CashbackEarned(2024, time.May)
+	if len(reports) != 1 {
+		t.Fatalf("reports = %d, want 1 card", len(reports))
+	}
+
+	report := reports[0]
+	if report.IdAccaunt != 1 || report.Spent != 1000 || report.Uncovered != 400 || report.Expected != 30 {
+		t.Errorf("card = account %d spent %v uncovered %v expected %v, want account 1 spent 1000 uncovered 400 expected 30",
+			report.IdAccaunt, report.Spent, report.Uncovered, report.Expected)
+	}
+	if len(report.Lines) != 1 {
+		t.Fatalf("lines = %d, want 1", len(report.Lines))
+	}
+	if line := report.Lines[0]; line.Purchases != 1 || line.Spent != 600 || line.Expected != 30 {
+		t.Errorf("line = %d purchases spent %v expected %v, want 1 purchase spent 600 expected 30", line.Purchases, line.Spent, line.Expected)
+	}
+}
+
+func TestSuggestSelectionBySplitLines(t *testing.T) {
+	setupPolicyAccounts()
+	debugging.Categories = nil
+	for idCategory, code := range map[int64]string{1: "groceries", 2: "transport"} {
+		category := &models.Category{}
+		category.SetIdCategory(idCategory)
+		category.SetCode(code)
+		debugging.Categories = append(debugging.Categories, category)
+	}
+
+	newLine := func(amount float64, idCategory int64) *models.ExpenceSplit {
+		line := &models.ExpenceSplit{}
+		line.SetAmount(amount)
+		line.SetIdCategory(idCategory)
+		return line
+	}
+	date := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	expence := &models.Expence{}
+	expence.SetIdExpence(1)
+	expence.SetIdAccaunt(1)
+	expence.SetBankName("Tinkoff")
+	expence.SetIdCategory(2)
+	expence.SetAmount(1000)
+	expence.SetDate(date)
+	expence.SetDateActualFrom(date)
+	expence.SetDateActualTo(time.Date(9999, 12, 31, 0, 0, 0, 0, time.Local))
+	expence.SetSplits([]*models.ExpenceSplit{newLine(600, 1), newLine(400, 2)})
+	debugging.Expences = []*models.Expence{expence}
+
+	selection, err := NewCashbackService().SuggestSelection(&models.CashbackSelectionJSON{
+		IdAccaunt:     1,
+		BankName:      "Tinkoff",
+		Month:         "2024-06",
+		Pick:          1,
+		HistoryMonths: 1,
+		Offers: []models.CashbackOfferJSON{
+			{IdCategory: 2, Percent: 5},
+			{IdCategory: 1, Percent: 5, MinPurchase: 800}, // the whole purchase counts, not the line
+		},
+	})
+	if err != nil {
+		t.Fatalf("suggest: %v", err)
+	}
+
+	want := map[int64]struct {
+		spent, expected float64
+		selected        bool
+	}{
+		1: {600, 30, true},
+		2: {400, 20, false},
+	}
+	for _, offer := range selection.Offers {
+		w := want[offer.IdCategory]
+		if offer.MonthlySpent != w.spent || offer.Expected != w.expected || offer.Selected != w.selected {
+			t.Errorf("offer %d = spent %v expected %v selected %v, want spent %v expected %v selected %v",
+				offer.IdCategory, offer.MonthlySpent, offer.Expected, offer.Selected, w.spent, w.expected, w.selected)
+		}
+	}
+	if selection.Expected != 30 {
+		t.Errorf("expected = %v, want 30", selection.Expected)
+	}
+}
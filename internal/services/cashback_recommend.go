@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// cards of the account and accounts sharing a group with it ranked by cashback
+// for the purchase at the time, category is given by id, code, name or alias
+func (s *CashbackService) Recommend(idAccaunt int64, category string, amount float64, at time.Time) (*models.CashbackRecommendationJSON, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	if !s.scope.Allows(idAccaunt) {
+		return nil, ErrForbidden
+	}
+	if _, err := NewAccountService().GetAccountById(idAccaunt); err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = s.now()
+	}
+
+	recommendation := &models.CashbackRecommendationJSON{
+		IdAccaunt: idAccaunt,
+		Category:  strings.TrimSpace(category),
+		Amount:    amount,
+		Date:      at.Format("2006-01-02"),
+		Cards:     []models.CardRecommendationJSON{},
+	}
+	if recommendation.Category == "" {
+		return nil, errors.New("category is required")
+	}
+	categoryService := categoriesOf(idAccaunt)
+	if idCategory, err := strconv.ParseInt(recommendation.Category, 10, 64); err == nil {
+		found, err := categoryService.GetCategoryById(idCategory)
+		if err != nil {
+			return nil, err
+		}
+		recommendation.IdCategory = found.GetIdCategory()
+		recommendation.Category = found.GetName(models.DefaultCategoryLang)
+	} else if found, ok := categoryService.Resolve(recommendation.Category); ok {
+		recommendation.IdCategory = found.GetIdCategory()
+	}
+
+	// cashback rules already paid this month count against their caps
+	monthStart := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+	used := make(map[int64]float64)
+	for _, accrual := range cashbackAccruals(nil, monthStart, at) {
+		if accrual.cashback != nil {
+			used[accrual.cashback.GetIdCashback()] += accrual.earned
+		}
+	}
+
+	accounts := NewGroupService().ScopeFor(idAccaunt).accounts
+	cards := make(map[string]*models.CardRecommendationJSON)
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.IsDeleted() || !accounts[cashback.GetIdAccaunt()] || !s.scope.Allows(cashback.GetIdAccaunt()) {
+			continue
+		}
+		if !actualAt(cashback.GetDateActualFrom(), cashback.GetDateActualTo(), at) {
+			continue
+		}
+
+		key := cardKey(cashback.GetIdAccaunt(), cashback.GetBankName())
+		card, ok := cards[key]
+		if !ok {
+			card = &models.CardRecommendationJSON{
+				IdAccaunt: cashback.GetIdAccaunt(),
+				BankName:  cashback.GetBankName(),
+			}
+			cards[key] = card
+		}
+		if amount < cashback.GetMinPurchase() || !cashbackCovers(categoryService, cashback, recommendation.IdCategory, recommendation.Category) {
+			continue
+		}
+
+		accrued, earned := cashbackFor(cashback, amount, used[cashback.GetIdCashback()])
+		if card.IdCashback != 0 && (earned < card.Cashback || earned == card.Cashback && cashback.GetPercent() <= card.Percent) {
+			continue
+		}
+		card.IdCashback = cashback.GetIdCashback()
+		card.Category = cashback.GetCategory()
+		card.Percent = cashback.GetPercent()
+		card.MonthlyCap = cashback.GetMonthlyCap()
+		card.Cashback = earned
+		card.Capped = earned < accrued
+	}
+
+	for _, card := range cards {
+		recommendation.Cards = append(recommendation.Cards, *card)
+	}
+	sort.Slice(recommendation.Cards, func(i, j int) bool {
+		a, b := recommendation.Cards[i], recommendation.Cards[j]
+		if a.Cashback != b.Cashback {
+			return a.Cashback > b.Cashback
+		}
+		if a.Percent != b.Percent {
+			return a.Percent > b.Percent
+		}
+		if a.BankName != b.BankName {
+			return a.BankName < b.BankName
+		}
+		return a.IdAccaunt < b.IdAccaunt
+	})
+	for i := range recommendation.Cards {
+		recommendation.Cards[i].Rank = i + 1
+	}
+	return recommendation, nil
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// defaults of monthly category selection
+const (
+	DefaultCashbackPick          = 3
+	DefaultCashbackHistoryMonths = 3
+)
+
+// mark offers that together earn the most cashback on expences of the account in
+// months before the selection month, offers are taken one by one by what they add
+func (s *CashbackService) SuggestSelection(selection *models.CashbackSelectionJSON) (*models.CashbackSelectionJSON, error) {
+	monthStart, err := s.selectionMonth(selection)
+	if err != nil {
+		return nil, err
+	}
+	if !s.scope.Allows(selection.IdAccaunt) {
+		return nil, ErrForbidden
+	}
+	if selection.Pick <= 0 {
+		selection.Pick = DefaultCashbackPick
+	}
+	if selection.HistoryMonths <= 0 {
+		selection.HistoryMonths = DefaultCashbackHistoryMonths
+	}
+
+	categoryService := categoriesOf(selection.IdAccaunt)
+	rules := make([]*models.Cashback, len(selection.Offers))
+	for i := range selection.Offers {
+		offer := &selection.Offers[i]
+		if offer.IdCategory != 0 {
+			category, err := categoryService.GetCategoryById(offer.IdCategory)
+			if err != nil {
+				return nil, err
+			}
+			if offer.Category == "" {
+				offer.Category = category.GetName(models.DefaultCategoryLang)
+			}
+		} else if category, ok := categoryService.Resolve(offer.Category); ok {
+			offer.IdCategory = category.GetIdCategory()
+		}
+		rules[i] = offerRule(selection, offer)
+	}
+
+	// expences of every month of history
+	history := make([][]*models.Expence, selection.HistoryMonths)
+	for i := range history {
+		from := monthStart.AddDate(0, i-selection.HistoryMonths, 0)
+		history[i] = s.purchases(selection.IdAccaunt, from, from.AddDate(0, 1, 0))
+	}
+
+	// average cashback a month the rules earn together, a line of a purchase
+	// earns by the best rule covering its category. Minimum purchase is checked
+	// on the whole purchase like banks do
+	score := func(chosen []int) float64 {
+		var total float64
+		for _, purchases := range history {
+			used := make(map[int]float64)
+			for _, expence := range purchases {
+				for _, part := range expence.Parts() {
+					best := -1
+					for _, i := range chosen {
+						if expence.GetAmount() < rules[i].GetMinPurchase() ||
+							!cashbackCovers(categoryService, rules[i], part.GetIdCategory(), part.GetGroupExpence()) {
+							continue
+						}
+						if best < 0 || rules[i].GetPercent() > rules[best].GetPercent() {
+							best = i
+						}
+					}
+					if best >= 0 {
+						_, earned := cashbackFor(rules[best], part.GetAmount(), used[best])
+						used[best] += earned
+						total += earned
+					}
+				}
+			}
+		}
+		return total / float64(len(history))
+	}
+
+	for i := range selection.Offers {
+		offer := &selection.Offers[i]
+		offer.Expected = roundAmount(score([]int{i}))
+		offer.Selected = false
+
+		var spent float64
+		for _, purchases := range history {
+			for _, expence := range purchases {
+				for _, part := range expence.Parts() {
+					if cashbackCovers(categoryService, rules[i], part.GetIdCategory(), part.GetGroupExpence()) {
+						spent += part.GetAmount()
+					}
+				}
+			}
+		}
+		offer.MonthlySpent = roundAmount(spent / float64(len(history)))
+	}
+
+	var chosen []int
+	var expected float64
+	for len(chosen) < selection.Pick && len(chosen) < len(selection.Offers) {
+		best, bestScore := -1, 0.0
+		for i, offer := range selection.Offers {
+			if offer.Selected {
+				continue
+			}
+			candidate := score(append(append([]int(nil), chosen...), i))
+			if best < 0 || candidate > bestScore ||
+				candidate == bestScore && offer.Percent > selection.Offers[best].Percent {
+				best, bestScore = i, candidate
+			}
+		}
+		chosen = append(chosen, best)
+		expected = bestScore
+		selection.Offers[best].Selected = true
+		selection.Offers[best].Rank = len(chosen)
+	}
+	selection.Expected = roundAmount(expected)
+
+	rest := make([]int, 0, len(selection.Offers)-len(chosen))
+	for i, offer := range selection.Offers {
+		if !offer.Selected {
+			rest = append(rest, i)
+		}
+	}
+	sort.SliceStable(rest, func(a, b int) bool {
+		return selection.Offers[rest[a]].Expected > selection.Offers[rest[b]].Expected
+	})
+	for i, index := range rest {
+		selection.Offers[index].Rank = len(chosen) + i + 1
+	}
+	sort.SliceStable(selection.Offers, func(a, b int) bool {
+		return selection.Offers[a].Rank < selection.Offers[b].Rank
+	})
+	return selection, nil
+}
+
+// create cashback rules of selected offers actual for the selection month only,
+// a rule of the same card and category continues as a new version, nothing is
+// changed when any offer is invalid
+func (s *CashbackService) AcceptSelection(selection *models.CashbackSelectionJSON, updBy string) ([]*models.Cashback, error) {
+	monthStart, err := s.selectionMonth(selection)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(selection.IdAccaunt); err != nil {
+		return nil, err
+	}
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	var offers []*models.CashbackOfferJSON
+	for i := range selection.Offers {
+		if selection.Offers[i].Selected {
+			offers = append(offers, &selection.Offers[i])
+		}
+	}
+	if len(offers) == 0 {
+		return nil, errors.New("no offer is selected")
+	}
+	if selection.Pick > 0 && len(offers) > selection.Pick {
+		return nil, errors.New("more offers are selected than the bank allows")
+	}
+
+	for _, cashback := range debugging.Cashbacks {
+		if !cashback.IsDeleted() && s.sameCard(cashback, selection) && cashback.GetDateActualFrom().Equal(monthStart) {
+			return nil, errors.New("categories of the month are already selected")
+		}
+	}
+
+	categoryService := categoriesOf(selection.IdAccaunt)
+	for _, offer := range offers {
+		if offer.IdCategory != 0 {
+			if _, err := categoryService.GetCategoryById(offer.IdCategory); err != nil {
+				return nil, err
+			}
+		} else if strings.TrimSpace(offer.Category) == "" {
+			return nil, errors.New("category or id_category of offer is required")
+		}
+		if offer.Percent <= 0 || offer.Percent > 100 {
+			return nil, errors.New("percent of offer must be between 1 and 100")
+		}
+	}
+
+	nextId := int64(0)
+	for _, cashback := range debugging.Cashbacks {
+		nextId = max(nextId, cashback.GetIdCashback())
+	}
+
+	created := make([]*models.Cashback, 0, len(offers))
+	var closed []*models.Cashback
+	categories := make(map[int64]bool)
+	for _, offer := range offers {
+		rule := offerRule(selection, offer)
+		rule.SetUpdBy(updBy)
+		if err := s.categorize(rule); err != nil {
+			return nil, err
+		}
+		if categories[rule.GetIdCategory()] {
+			return nil, errors.New("category \"" + rule.GetCategory() + "\" is selected twice")
+		}
+		categories[rule.GetIdCategory()] = true
+
+		// the card paid for the category before, its rule gets a new version
+		var previous *models.Cashback
+		for _, cashback := range debugging.Cashbacks {
+			if cashback.IsDeleted() || !s.sameCard(cashback, selection) || cashback.GetIdCategory() != rule.GetIdCategory() ||
+				!cashback.GetDateActualFrom().Before(monthStart) {
+				continue
+			}
+			if previous == nil || cashback.GetDateActualFrom().After(previous.GetDateActualFrom()) {
+				previous = cashback
+			}
+		}
+		if previous != nil {
+			rule.SetIdCashback(previous.GetIdCashback())
+			if previous.GetDateActualTo().After(monthStart) {
+				closed = append(closed, previous)
+			}
+		} else {
+			nextId++
+			rule.SetIdCashback(nextId)
+		}
+
+		rule.SetDateActualFrom(monthStart)
+		rule.SetDateActualTo(monthEnd)
+		created = append(created, rule)
+	}
+
+	for _, cashback := range closed {
+		cashback.SetDateActualTo(monthStart)
+	}
+	debugging.Cashbacks = append(debugging.Cashbacks, created...)
+	return created, nil
+}
+
+// first day of the selection month, account and bank are checked
+func (s *CashbackService) selectionMonth(selection *models.CashbackSelectionJSON) (time.Time, error) {
+	selection.BankName = strings.TrimSpace(selection.BankName)
+	if selection.BankName == "" {
+		return time.Time{}, errors.New("bank_name is required")
+	}
+	if len(selection.Offers) == 0 {
+		return time.Time{}, errors.New("offers are required")
+	}
+	if _, err := NewAccountService().GetAccountById(selection.IdAccaunt); err != nil {
+		return time.Time{}, err
+	}
+
+	now := s.now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0)
+	if selection.Month != "" {
+		month, err := time.ParseInLocation("2006-01", selection.Month, now.Location())
+		if err != nil {
+			return time.Time{}, errors.New("invalid month format, must be YYYY-MM")
+		}
+		monthStart = month
+	}
+	selection.Month = monthStart.Format("2006-01")
+	return monthStart, nil
+}
+
+// expences of the account in [from, to) with recurring ones
+func (s *CashbackService) purchases(idAccaunt int64, from, to time.Time) []*models.Expence {
+	var purchases []*models.Expence
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetRepeat() != 0 || expence.GetDateActualTo().Year() != 9999 || expence.GetIdAccaunt() != idAccaunt {
+			continue
+		}
+		if date := expence.GetDate(); !date.Before(from) && date.Before(to) {
+			purchases = append(purchases, expence)
+		}
+	}
+	for _, occurrence := range NewExpenceService().MaterializeExpences(from, to.Add(-time.Nanosecond)) {
+		if occurrence.GetIdAccaunt() == idAccaunt {
+			purchases = append(purchases, occurrence)
+		}
+	}
+	sort.SliceStable(purchases, func(i, j int) bool {
+		return purchases[i].GetDate().Before(purchases[j].GetDate())
+	})
+	return purchases
+}
+
+func (s *CashbackService) sameCard(cashback *models.Cashback, selection *models.CashbackSelectionJSON) bool {
+	return cashback.GetIdAccaunt() == selection.IdAccaunt && strings.EqualFold(cashback.GetBankName(), selection.BankName)
+}
+
+// cashback rule the offer becomes when accepted
+func offerRule(selection *models.CashbackSelectionJSON, offer *models.CashbackOfferJSON) *models.Cashback {
+	rule := &models.Cashback{}
+	rule.SetIdAccaunt(selection.IdAccaunt)
+	rule.SetBankName(selection.BankName)
+	rule.SetCategory(offer.Category)
+	rule.SetIdCategory(offer.IdCategory)
+	rule.SetPercent(offer.Percent)
+	rule.SetMonthlyCap(offer.MonthlyCap)
+	rule.SetMinPurchase(offer.MinPurchase)
+	return rule
+}
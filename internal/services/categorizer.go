@@ -0,0 +1,285 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	CategorizerMinSamples = 5   // classifier knowing fewer expences does not suggest
+	CategorizerMinScore   = 0.5 // weaker suggestions are not put on expences
+	CategorizerTop        = 3   // suggestions returned on request
+)
+
+type CategorizerService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewCategorizerService() *CategorizerService {
+	return &CategorizerService{now: time.Now}
+}
+
+// restrict training and suggestions to accounts of scope
+func (s *CategorizerService) WithScope(scope *Scope) *CategorizerService {
+	s.scope = scope
+	return s
+}
+
+// classifier is kept for every group and for every account outside groups
+type classifierKey struct {
+	idGroup   int64
+	idAccaunt int64
+}
+
+// classifiers of accounts in scope, trained on first use
+func (s *CategorizerService) GetAll() []*models.Classifier {
+	var classifiers []*models.Classifier
+	for _, key := range s.scopeKeys() {
+		classifiers = append(classifiers, s.classifier(key))
+	}
+	return classifiers
+}
+
+// train classifiers of accounts in scope again from the whole history
+func (s *CategorizerService) Train() []*models.Classifier {
+	var classifiers []*models.Classifier
+	for _, key := range s.scopeKeys() {
+		classifier := s.classifier(key)
+		s.train(classifier)
+		classifiers = append(classifiers, classifier)
+	}
+	return classifiers
+}
+
+// best groups for expence, most likely first; none while classifier knows too
+// little
+func (s *CategorizerService) Suggestions(expence *models.Expence) []models.SuggestionJSON {
+	classifier := s.primary(expence.GetIdAccaunt())
+	tokens := classifierTokens(expence)
+	docs := classifier.GetDocs()
+	if classifier.GetSamples() < CategorizerMinSamples || len(docs) < 2 || len(tokens) == 0 {
+		return nil
+	}
+
+	// log probabilities with add-one smoothing, turned to shares of their sum
+	vocabulary := float64(classifier.GetVocabularySize())
+	logs := make(map[string]float64, len(docs))
+	best := math.Inf(-1)
+	for group, count := range docs {
+		logProbability := math.Log(float64(count) / float64(classifier.GetSamples()))
+		total := float64(classifier.GetTokenTotal(group))
+		for _, token := range tokens {
+			logProbability += math.Log((float64(classifier.GetTokenCount(group, token)) + 1) / (total + vocabulary))
+		}
+		logs[group] = logProbability
+		best = math.Max(best, logProbability)
+	}
+
+	var sum float64
+	for _, logProbability := range logs {
+		sum += math.Exp(logProbability - best)
+	}
+	var suggestions []models.SuggestionJSON
+	for group, logProbability := range logs {
+		suggestion := models.SuggestionJSON{GroupExpence: group, Score: math.Round(math.Exp(logProbability-best)/sum*1000) / 1000}
+		if category, ok := categoriesOf(expence.GetIdAccaunt()).Resolve(group); ok {
+			suggestion.IdCategory = category.GetIdCategory()
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].GroupExpence < suggestions[j].GroupExpence
+	})
+	if len(suggestions) > CategorizerTop {
+		suggestions = suggestions[:CategorizerTop]
+	}
+	return suggestions
+}
+
+// put best group on expence without one, suggestion is cleared when classifier
+// is not sure enough
+func (s *CategorizerService) Suggest(expence *models.Expence) {
+	expence.SetSuggestion("", 0)
+	if expence.GetIdCategory() != 0 || strings.TrimSpace(expence.GetGroupExpence()) != "" {
+		return
+	}
+	if suggestions := s.Suggestions(expence); len(suggestions) > 0 && suggestions[0].Score >= CategorizerMinScore {
+		expence.SetSuggestion(suggestions[0].GroupExpence, suggestions[0].Score)
+	}
+}
+
+// stored categorized expence becomes example for classifiers of its account,
+// expence without group is forgotten
+func (s *CategorizerService) Learn(expence *models.Expence) {
+	if !learnable(expence) {
+		s.Forget(expence.GetIdExpence())
+		return
+	}
+	sample := models.ClassifierSample{Group: expence.GetGroupExpence(), Tokens: classifierTokens(expence)}
+	for _, key := range classifierKeys(expence.GetIdAccaunt()) {
+		classifier := s.classifier(key)
+		classifier.Learn(expence.GetIdExpence(), sample)
+		classifier.SetUpdatedAt(s.now())
+	}
+}
+
+// take deleted expence out of every classifier
+func (s *CategorizerService) Forget(idExpence int64) {
+	for _, classifier := range debugging.Classifiers {
+		if classifier.Forget(idExpence) {
+			classifier.SetUpdatedAt(s.now())
+		}
+	}
+}
+
+// give expence the group suggested for it, classifier learns it as confirmed
+func (s *CategorizerService) Accept(idExpence int64, updBy string) (*models.Expence, *models.Expence, error) {
+	for _, expence := range debugging.Expences {
+		if expence.GetIdExpence() != idExpence || expence.IsDeleted() || expence.GetDateActualTo().Year() != 9999 ||
+			!s.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+		if expence.GetSuggestedGroup() == "" {
+			return nil, nil, errors.New("expence has no suggestion")
+		}
+
+		accepted := &models.Expence{}
+		*accepted = *expence
+		accepted.SetGroupExpence(expence.GetSuggestedGroup())
+		accepted.SetIdCategory(0)
+		accepted.SetUpdBy(updBy)
+		if err := NewExpenceService().WithScope(s.scope).replaceVersion(expence, accepted); err != nil {
+			return nil, nil, err
+		}
+		return expence, accepted, nil
+	}
+	return nil, nil, errors.New("expence not found")
+}
+
+// find classifier or train new one
+func (s *CategorizerService) classifier(key classifierKey) *models.Classifier {
+	for _, classifier := range debugging.Classifiers {
+		if classifier.GetIdGroup() == key.idGroup && classifier.GetIdAccaunt() == key.idAccaunt {
+			return classifier
+		}
+	}
+
+	classifier := &models.Classifier{}
+	classifier.SetIdGroup(key.idGroup)
+	classifier.SetIdAccaunt(key.idAccaunt)
+	s.train(classifier)
+	debugging.Classifiers = append(debugging.Classifiers, classifier)
+	return classifier
+}
+
+// classifier of account that knows most expences
+func (s *CategorizerService) primary(idAccaunt int64) *models.Classifier {
+	var primary *models.Classifier
+	for _, key := range classifierKeys(idAccaunt) {
+		classifier := s.classifier(key)
+		if primary == nil || classifier.GetSamples() > primary.GetSamples() {
+			primary = classifier
+		}
+	}
+	return primary
+}
+
+// learn current categorized expences of the group or the account from scratch
+func (s *CategorizerService) train(classifier *models.Classifier) {
+	accounts := map[int64]bool{classifier.GetIdAccaunt(): classifier.GetIdGroup() == 0}
+	for _, member := range debugging.GroupMembers {
+		if classifier.GetIdGroup() != 0 && member.GetIdGroup() == classifier.GetIdGroup() {
+			accounts[member.GetIdAccaunt()] = true
+		}
+	}
+
+	classifier.Reset()
+	for _, expence := range debugging.Expences {
+		if accounts[expence.GetIdAccaunt()] && learnable(expence) {
+			classifier.Learn(expence.GetIdExpence(), models.ClassifierSample{
+				Group:  expence.GetGroupExpence(),
+				Tokens: classifierTokens(expence),
+			})
+		}
+	}
+	classifier.SetTrainedAt(s.now())
+	classifier.SetUpdatedAt(s.now())
+}
+
+// classifiers of accounts visible in scope
+func (s *CategorizerService) scopeKeys() []classifierKey {
+	var keys []classifierKey
+	seen := make(map[classifierKey]bool)
+	for _, account := range debugging.Accounts {
+		if !s.scope.Allows(account.GetIdAccaunt()) {
+			continue
+		}
+		for _, key := range classifierKeys(account.GetIdAccaunt()) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// groups of account, the account itself when it has none
+func classifierKeys(idAccaunt int64) []classifierKey {
+	groupIds := NewGroupService().GetGroupIdsByAccount(idAccaunt)
+	if len(groupIds) == 0 {
+		return []classifierKey{{idAccaunt: idAccaunt}}
+	}
+	sort.Slice(groupIds, func(i, j int) bool { return groupIds[i] < groupIds[j] })
+
+	keys := make([]classifierKey, 0, len(groupIds))
+	for _, idGroup := range groupIds {
+		keys = append(keys, classifierKey{idGroup: idGroup})
+	}
+	return keys
+}
+
+// stored current expence with a group
+func learnable(expence *models.Expence) bool {
+	return !expence.IsDeleted() && !expence.IsOccurrence() && expence.GetDateActualTo().Year() == 9999 &&
+		strings.TrimSpace(expence.GetGroupExpence()) != ""
+}
+
+// words of title and description and the amount bucket, numbers and single
+// letters say little about the group
+func classifierTokens(expence *models.Expence) []string {
+	var tokens []string
+	for _, word := range strings.Fields(normalizeTitle(expence.GetTitleExpence() + " " + expence.GetDescriptionExpence())) {
+		if utf8.RuneCountInString(word) < 2 {
+			continue
+		}
+		if _, err := strconv.ParseFloat(word, 64); err == nil {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	if bucket := amountBucket(expence.GetAmount()); bucket != "" {
+		tokens = append(tokens, bucket)
+	}
+	return tokens
+}
+
+// amounts split into half-decades: 100-316, 316-1000 and so on
+func amountBucket(amount float64) string {
+	if amount <= 0 {
+		return ""
+	}
+	return "amount:" + strconv.Itoa(int(math.Floor(math.Log10(amount)*2)))
+}
@@ -0,0 +1,502 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// categories of an account are shared with accounts of his groups like tags,
+// categories without account are seen by everyone
+type CategoryService struct {
+	scope *Scope
+}
+
+func NewCategoryService() *CategoryService {
+	return &CategoryService{}
+}
+
+// restrict categories to accounts of scope, categories of everyone are seen
+// but changed only without scope
+func (s *CategoryService) WithScope(scope *Scope) *CategoryService {
+	s.scope = scope
+	return s
+}
+
+// category without account given is made for the scope account
+func (s *CategoryService) AddNewCategory(newCategory *models.Category) error {
+	if newCategory.GetIdAccaunt() == 0 && s.scope != nil {
+		newCategory.SetIdAccaunt(s.scope.idAccaunt)
+	}
+	if err := s.canWrite(newCategory); err != nil {
+		return err
+	}
+	if newCategory.GetIdCategory() == 0 {
+		newCategory.SetIdCategory(s.nextId())
+	}
+	if findCategory(newCategory.GetIdCategory()) != nil {
+		return errors.New("category with this ID already exists")
+	}
+	if err := s.validate(newCategory); err != nil {
+		return err
+	}
+
+	debugging.Categories = append(debugging.Categories, newCategory)
+	return nil
+}
+
+func (s *CategoryService) GetAllCategories() []*models.Category {
+	var categories []*models.Category
+	for _, category := range debugging.Categories {
+		if s.visible(category) {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+func (s *CategoryService) GetCategoryById(idCategory int64) (*models.Category, error) {
+	if category := findCategory(idCategory); category != nil && s.visible(category) {
+		return category, nil
+	}
+	return nil, errors.New("category not found")
+}
+
+// replace category, old one is returned. Account is kept when not given with
+// a scope
+func (s *CategoryService) UpdateCategory(idCategory int64, newCategory *models.Category) (*models.Category, error) {
+	newCategory.SetIdCategory(idCategory)
+	for i, category := range debugging.Categories {
+		if category.GetIdCategory() != idCategory || !s.visible(category) {
+			continue
+		}
+		if newCategory.GetIdAccaunt() == 0 && s.scope != nil {
+			newCategory.SetIdAccaunt(category.GetIdAccaunt())
+		}
+		if err := s.canWrite(category); err != nil {
+			return nil, err
+		}
+		if err := s.canWrite(newCategory); err != nil {
+			return nil, err
+		}
+		if err := s.validate(newCategory); err != nil {
+			return nil, err
+		}
+
+		debugging.Categories[i] = newCategory
+		return category, nil
+	}
+	return nil, errors.New("category not found")
+}
+
+// categories in use or with children are kept, unused leaves go to trash
+func (s *CategoryService) DeleteCategory(idCategory int64, deletedBy string) (*models.Category, error) {
+	category, err := s.GetCategoryById(idCategory)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.canWrite(category); err != nil {
+		return nil, err
+	}
+	for _, child := range debugging.Categories {
+		if child.GetIdParent() == idCategory && !child.IsDeleted() {
+			return nil, errors.New("category has subcategories")
+		}
+	}
+	if s.inUse(idCategory) {
+		return nil, errors.New("category is in use")
+	}
+
+	category.SetDeletedAt(time.Now())
+	category.SetDeletedBy(deletedBy)
+	return category, nil
+}
+
+// bring a deleted category back from trash, its parent must still be there
+// and its names free
+func (s *CategoryService) RestoreCategory(idCategory int64) (*models.Category, error) {
+	category := findCategory(idCategory)
+	if category == nil || !category.IsDeleted() || !s.scope.Allows(category.GetIdAccaunt()) {
+		return nil, errors.New("deleted category not found")
+	}
+	if err := s.canWrite(category); err != nil {
+		return nil, err
+	}
+
+	deletedAt := category.GetDeletedAt()
+	category.SetDeletedAt(time.Time{})
+	if err := s.validate(category); err != nil {
+		category.SetDeletedAt(deletedAt)
+		return nil, err
+	}
+	category.SetDeletedBy("")
+	return category, nil
+}
+
+// roots with their subcategories, names in lang
+func (s *CategoryService) Tree(lang string) []models.CategoryTreeJSON {
+	children := make(map[int64][]*models.Category)
+	for _, category := range s.GetAllCategories() {
+		children[category.GetIdParent()] = append(children[category.GetIdParent()], category)
+	}
+
+	var build func(idParent int64) []models.CategoryTreeJSON
+	build = func(idParent int64) []models.CategoryTreeJSON {
+		nodes := []models.CategoryTreeJSON{}
+		for _, category := range children[idParent] {
+			nodes = append(nodes, models.CategoryTreeJSON{
+				CategoryJSON: *s.Localize(category, lang),
+				Children:     build(category.GetIdCategory()),
+			})
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+		return nodes
+	}
+	return build(0)
+}
+
+// json of category with name in lang
+func (s *CategoryService) Localize(category *models.Category, lang string) *models.CategoryJSON {
+	categoryJSON, _ := category.ToJSON()
+	categoryJSON.Name = category.GetName(lang)
+	return categoryJSON
+}
+
+// category matching the text by code, any name or alias, case and spaces aside
+func (s *CategoryService) Resolve(value string) (*models.Category, bool) {
+	key := categoryKey(value)
+	if key == "" {
+		return nil, false
+	}
+	for _, category := range s.GetAllCategories() {
+		for _, candidate := range categoryKeys(category) {
+			if candidate == key {
+				return category, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// category of the text seen by the account, a root category of the account
+// named by the text is made when none matches
+func (s *CategoryService) Ensure(value string, idAccaunt int64, updBy string) (*models.Category, bool, error) {
+	if category, ok := categoriesOf(idAccaunt).Resolve(value); ok {
+		return category, false, nil
+	}
+
+	category := &models.Category{}
+	category.SetIdCategory(s.nextId())
+	category.SetIdAccaunt(idAccaunt)
+	category.SetNames(map[string]string{models.DefaultCategoryLang: strings.TrimSpace(value)})
+	category.SetUpdBy(updBy)
+	if err := s.validate(category); err != nil {
+		return nil, false, err
+	}
+
+	debugging.Categories = append(debugging.Categories, category)
+	return category, true, nil
+}
+
+// category is the ancestor or the category itself
+func (s *CategoryService) IsWithin(idCategory, idAncestor int64) bool {
+	seen := make(map[int64]bool)
+	for idCategory != 0 && !seen[idCategory] {
+		if idCategory == idAncestor {
+			return true
+		}
+		seen[idCategory] = true
+
+		category := findCategory(idCategory)
+		if category == nil {
+			return false
+		}
+		idCategory = category.GetIdParent()
+	}
+	return false
+}
+
+// id of category of a record of the account given by id or by free text, text
+// of the category is returned too
+func (s *CategoryService) Categorize(idCategory int64, value string, idAccaunt int64, updBy string) (int64, string, error) {
+	if idCategory != 0 {
+		category, err := categoriesOf(idAccaunt).GetCategoryById(idCategory)
+		if err != nil {
+			return 0, "", err
+		}
+		if strings.TrimSpace(value) == "" {
+			value = category.GetName(models.DefaultCategoryLang)
+		}
+		return idCategory, value, nil
+	}
+	if strings.TrimSpace(value) == "" {
+		return 0, value, nil
+	}
+
+	category, _, err := s.Ensure(value, idAccaunt, updBy)
+	if err != nil {
+		return 0, "", err
+	}
+	return category.GetIdCategory(), value, nil
+}
+
+// link free text groups of expences, cashback rules and budgets to categories,
+// categories of the record account are made for texts nothing matches
+func (s *CategoryService) Migrate(updBy string) ([]models.CategoryMappingJSON, error) {
+	mappings := []models.CategoryMappingJSON{}
+	mapped := make(map[string]bool)
+
+	link := func(entity string, id, idAccaunt int64, value string) (int64, error) {
+		category, created, err := s.Ensure(value, idAccaunt, updBy)
+		if err != nil {
+			return 0, err
+		}
+		key := entity + ":" + strconv.FormatInt(id, 10)
+		if !mapped[key] {
+			mapped[key] = true
+			mappings = append(mappings, models.CategoryMappingJSON{
+				Entity:     entity,
+				Id:         id,
+				Value:      value,
+				IdCategory: category.GetIdCategory(),
+				Created:    created,
+			})
+		}
+		return category.GetIdCategory(), nil
+	}
+
+	for _, expence := range debugging.Expences {
+		if expence.GetIdCategory() != 0 || strings.TrimSpace(expence.GetGroupExpence()) == "" {
+			continue
+		}
+		idCategory, err := link(TrashExpence, expence.GetIdExpence(), expence.GetIdAccaunt(), expence.GetGroupExpence())
+		if err != nil {
+			return nil, err
+		}
+		expence.SetIdCategory(idCategory)
+	}
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.GetIdCategory() != 0 || strings.TrimSpace(cashback.GetCategory()) == "" {
+			continue
+		}
+		idCategory, err := link(TrashCashback, cashback.GetIdCashback(), cashback.GetIdAccaunt(), cashback.GetCategory())
+		if err != nil {
+			return nil, err
+		}
+		cashback.SetIdCategory(idCategory)
+	}
+	for _, budget := range debugging.Budgets {
+		if budget.GetIdCategory() != 0 || strings.TrimSpace(budget.GetGroupExpence()) == "" {
+			continue
+		}
+		idCategory, err := link(TrashBudget, budget.GetIdBudget(), budget.GetIdAccaunt(), budget.GetGroupExpence())
+		if err != nil {
+			return nil, err
+		}
+		budget.SetIdCategory(idCategory)
+	}
+	return mappings, nil
+}
+
+// category is referred to by an expence, one of its split lines, a cashback
+// rule, a budget or a rule
+func (s *CategoryService) inUse(idCategory int64) bool {
+	for _, expence := range debugging.Expences {
+		if expence.GetIdCategory() == idCategory {
+			return true
+		}
+		for _, line := range expence.GetSplits() {
+			if line.GetIdCategory() == idCategory {
+				return true
+			}
+		}
+	}
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.GetIdCategory() == idCategory {
+			return true
+		}
+	}
+	for _, budget := range debugging.Budgets {
+		if budget.GetIdCategory() == idCategory {
+			return true
+		}
+	}
+	for _, rule := range debugging.Rules {
+		if rule.GetIdCategory() == idCategory {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CategoryService) visible(category *models.Category) bool {
+	if category.IsDeleted() {
+		return false
+	}
+	return category.GetIdAccaunt() == 0 || s.scope.Allows(category.GetIdAccaunt())
+}
+
+// categories of everyone are changed only without scope, e.g. by admins
+func (s *CategoryService) canWrite(category *models.Category) error {
+	if category.GetIdAccaunt() == 0 {
+		if s.scope != nil {
+			return ErrForbidden
+		}
+		return nil
+	}
+	return s.scope.CanWrite(category.GetIdAccaunt())
+}
+
+// code is made from the name when empty, names and aliases may not belong to
+// another category the owner sees, parent is seen by the owner and may not be
+// the category or its descendant
+func (s *CategoryService) validate(category *models.Category) error {
+	if category.GetIdAccaunt() != 0 {
+		if _, err := NewAccountService().GetAccountById(category.GetIdAccaunt()); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]string)
+	for lang, name := range category.GetNames() {
+		if name = strings.TrimSpace(name); name != "" {
+			names[strings.ToLower(strings.TrimSpace(lang))] = name
+		}
+	}
+	category.SetNames(names)
+
+	var aliases []string
+	for _, alias := range category.GetAliases() {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	category.SetAliases(aliases)
+
+	if len(names) == 0 && category.GetCode() == "" {
+		return errors.New("name is required")
+	}
+
+	if category.GetCode() == "" {
+		category.SetCode(s.uniqueCode(categorySlug(category.GetName(models.DefaultCategoryLang))))
+	} else {
+		category.SetCode(categorySlug(category.GetCode()))
+	}
+	if category.GetCode() == "" {
+		return errors.New("invalid code")
+	}
+
+	owner := categoriesOf(category.GetIdAccaunt())
+	if category.GetIdParent() != 0 {
+		if _, err := owner.GetCategoryById(category.GetIdParent()); err != nil {
+			return errors.New("parent category not found")
+		}
+		if s.IsWithin(category.GetIdParent(), category.GetIdCategory()) {
+			return errors.New("category can not be inside itself")
+		}
+	}
+
+	for _, key := range categoryKeys(category) {
+		for _, other := range debugging.Categories {
+			if other.GetIdCategory() == category.GetIdCategory() || other.IsDeleted() {
+				continue
+			}
+			// category of everyone is seen next to every other one
+			if category.GetIdAccaunt() != 0 && !owner.visible(other) {
+				continue
+			}
+			for _, otherKey := range categoryKeys(other) {
+				if otherKey == key {
+					return errors.New("\"" + key + "\" is used by category " + strconv.FormatInt(other.GetIdCategory(), 10))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *CategoryService) uniqueCode(code string) string {
+	if code == "" {
+		code = "category"
+	}
+	taken := func(candidate string) bool {
+		for _, category := range debugging.Categories {
+			if category.GetCode() == candidate {
+				return true
+			}
+		}
+		return false
+	}
+
+	candidate := code
+	for i := 2; taken(candidate); i++ {
+		candidate = code + "_" + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+func (s *CategoryService) nextId() int64 {
+	var maxId int64
+	for _, category := range debugging.Categories {
+		if category.GetIdCategory() > maxId {
+			maxId = category.GetIdCategory()
+		}
+	}
+	return maxId + 1
+}
+
+func findCategory(idCategory int64) *models.Category {
+	for _, category := range debugging.Categories {
+		if category.GetIdCategory() == idCategory {
+			return category
+		}
+	}
+	return nil
+}
+
+// categories seen by the account, only categories of everyone for 0
+func categoriesOf(idAccaunt int64) *CategoryService {
+	return NewCategoryService().WithScope(NewGroupService().ScopeFor(idAccaunt))
+}
+
+// every text the category is matched by
+func categoryKeys(category *models.Category) []string {
+	keys := []string{categoryKey(category.GetCode())}
+	for _, name := range category.GetNames() {
+		keys = append(keys, categoryKey(name))
+	}
+	for _, alias := range category.GetAliases() {
+		keys = append(keys, categoryKey(alias))
+	}
+	return keys
+}
+
+// lower case words of text separated by one space, "_" and "-" separate words too
+func categoryKey(value string) string {
+	value = strings.NewReplacer("_", " ", "-", " ").Replace(value)
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+// lower case letters and digits joined by "_"
+func categorySlug(value string) string {
+	var b strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if separate && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			separate = false
+			continue
+		}
+		separate = true
+	}
+	return b.String()
+}
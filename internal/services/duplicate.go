@@ -0,0 +1,379 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	DuplicateWindowDays = 3    // expences further apart are not compared
+	DuplicateMinScore   = 0.65 // pairs scored lower are not duplicates
+)
+
+type DuplicateService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewDuplicateService() *DuplicateService {
+	return &DuplicateService{now: time.Now}
+}
+
+// restrict detection and review to accounts of scope
+func (s *DuplicateService) WithScope(scope *Scope) *DuplicateService {
+	s.scope = scope
+	return s
+}
+
+// find new pairs of duplicate expences, pairs found before are not found again
+// whatever their status is
+func (s *DuplicateService) Detect() []*models.Duplicate {
+	var expences []*models.Expence
+	for _, expence := range debugging.Expences {
+		if s.comparable(expence) && s.scope.Allows(expence.GetIdAccaunt()) {
+			expences = append(expences, expence)
+		}
+	}
+	sort.Slice(expences, func(i, j int) bool {
+		return expences[i].GetIdExpence() < expences[j].GetIdExpence()
+	})
+
+	known := make(map[[2]int64]bool)
+	var maxId int64
+	for _, duplicate := range debugging.Duplicates {
+		known[[2]int64{duplicate.GetIdExpence(), duplicate.GetIdExpenceOther()}] = true
+		maxId = max(maxId, duplicate.GetIdDuplicate())
+	}
+
+	var found []*models.Duplicate
+	for i, expence := range expences {
+		for _, other := range expences[i+1:] {
+			if known[[2]int64{expence.GetIdExpence(), other.GetIdExpence()}] {
+				continue
+			}
+			score, reasons, ok := duplicateScore(expence, other)
+			if !ok || score < DuplicateMinScore {
+				continue
+			}
+
+			maxId++
+			duplicate := &models.Duplicate{}
+			duplicate.SetIdDuplicate(maxId)
+			duplicate.SetIdExpence(expence.GetIdExpence())
+			duplicate.SetIdExpenceOther(other.GetIdExpence())
+			duplicate.SetScore(roundAmount(score))
+			duplicate.SetReasons(reasons)
+			duplicate.SetStatus(models.DuplicateStatusOpen)
+			duplicate.SetDetectedAt(s.now())
+			debugging.Duplicates = append(debugging.Duplicates, duplicate)
+			found = append(found, duplicate)
+		}
+	}
+	return found
+}
+
+// pairs with the status, every status when it is empty; open pairs whose
+// expence is deleted are left out
+func (s *DuplicateService) GetAll(status string) []*models.Duplicate {
+	var duplicates []*models.Duplicate
+	for _, duplicate := range debugging.Duplicates {
+		if status != "" && duplicate.GetStatus() != status {
+			continue
+		}
+		expence, other, err := s.pair(duplicate)
+		if err != nil {
+			continue
+		}
+		if duplicate.GetStatus() == models.DuplicateStatusOpen && (expence == nil || other == nil) {
+			continue
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].GetScore() != duplicates[j].GetScore() {
+			return duplicates[i].GetScore() > duplicates[j].GetScore()
+		}
+		return duplicates[i].GetIdDuplicate() < duplicates[j].GetIdDuplicate()
+	})
+	return duplicates
+}
+
+func (s *DuplicateService) GetById(idDuplicate int64) (*models.Duplicate, error) {
+	for _, duplicate := range debugging.Duplicates {
+		if duplicate.GetIdDuplicate() != idDuplicate {
+			continue
+		}
+		if _, _, err := s.pair(duplicate); err != nil {
+			return nil, err
+		}
+		return duplicate, nil
+	}
+	return nil, errors.New("duplicate not found")
+}
+
+// current versions of the pair, nil for deleted ones; pair is not found when
+// scope sees neither of them
+func (s *DuplicateService) pair(duplicate *models.Duplicate) (*models.Expence, *models.Expence, error) {
+	var expence, other *models.Expence
+	seen := false
+	for _, candidate := range debugging.Expences {
+		if !duplicate.Has(candidate.GetIdExpence()) {
+			continue
+		}
+		if s.scope.Allows(candidate.GetIdAccaunt()) {
+			seen = true
+		}
+		if candidate.IsDeleted() || candidate.GetDateActualTo().Year() != 9999 {
+			continue
+		}
+		if candidate.GetIdExpence() == duplicate.GetIdExpence() {
+			expence = candidate
+		} else {
+			other = candidate
+		}
+	}
+	if !seen {
+		return nil, nil, errors.New("duplicate not found")
+	}
+	return expence, other, nil
+}
+
+// expences of the pair for review, deleted ones are left out
+func (s *DuplicateService) Expences(duplicate *models.Duplicate) []*models.Expence {
+	expence, other, _ := s.pair(duplicate)
+	var expences []*models.Expence
+	for _, e := range []*models.Expence{expence, other} {
+		if e != nil {
+			expences = append(expences, e)
+		}
+	}
+	return expences
+}
+
+// keep one expence of an open pair and delete the other one, fields the kept
+// expence misses are taken from the deleted one; keep is 0 for the earlier
+// expence. Kept expence before and after merge and the deleted one are returned
+func (s *DuplicateService) Merge(idDuplicate, keep int64, resolvedBy string) (*models.Duplicate, *models.Expence, *models.Expence, *models.Expence, error) {
+	duplicate, err := s.open(idDuplicate)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if keep == 0 {
+		keep = duplicate.GetIdExpence()
+	}
+	if !duplicate.Has(keep) {
+		return nil, nil, nil, nil, errors.New("expence to keep must be one of the pair")
+	}
+
+	expence, other, err := s.pair(duplicate)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if expence == nil || other == nil {
+		return nil, nil, nil, nil, errors.New("expence of the pair is deleted")
+	}
+	kept, removed := expence, other
+	if keep != expence.GetIdExpence() {
+		kept, removed = other, expence
+	}
+	if err := s.scope.CanWrite(kept.GetIdAccaunt()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := s.scope.CanWrite(removed.GetIdAccaunt()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	expenceService := NewExpenceService().WithScope(s.scope)
+	oldKept := kept
+	if merged, changed := mergeExpence(kept, removed); changed {
+		if oldKept, err = expenceService.UpdateExpence(merged); err != nil {
+			return nil, nil, nil, nil, err
+		}
+		kept = merged
+	}
+	deleted, err := expenceService.DeleteExpence(removed.GetIdExpence(), resolvedBy)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	duplicate.SetStatus(models.DuplicateStatusMerged)
+	duplicate.SetResolvedAt(s.now())
+	duplicate.SetResolvedBy(resolvedBy)
+	return duplicate, oldKept, kept, deleted, nil
+}
+
+// mark open pair as not duplicate
+func (s *DuplicateService) Dismiss(idDuplicate int64, resolvedBy string) (*models.Duplicate, error) {
+	duplicate, err := s.open(idDuplicate)
+	if err != nil {
+		return nil, err
+	}
+	expence, other, _ := s.pair(duplicate)
+	for _, e := range []*models.Expence{expence, other} {
+		if e == nil {
+			continue
+		}
+		if err := s.scope.CanWrite(e.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+	}
+
+	duplicate.SetStatus(models.DuplicateStatusDismissed)
+	duplicate.SetResolvedAt(s.now())
+	duplicate.SetResolvedBy(resolvedBy)
+	return duplicate, nil
+}
+
+func (s *DuplicateService) open(idDuplicate int64) (*models.Duplicate, error) {
+	duplicate, err := s.GetById(idDuplicate)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate.GetStatus() != models.DuplicateStatusOpen {
+		return nil, errors.New("duplicate is already " + duplicate.GetStatus())
+	}
+	return duplicate, nil
+}
+
+// current version of a one time expence
+func (s *DuplicateService) comparable(expence *models.Expence) bool {
+	return !expence.IsDeleted() && expence.GetDateActualTo().Year() == 9999 && expence.GetRepeat() == 0
+}
+
+// copy of kept expence with empty fields filled from the other one, tags of
+// both are kept
+func mergeExpence(kept, other *models.Expence) (*models.Expence, bool) {
+	merged := &models.Expence{}
+	*merged = *kept
+	changed := false
+	fill := func(value string, set func(string), from string) {
+		if value == "" && from != "" {
+			set(from)
+			changed = true
+		}
+	}
+	fill(merged.GetExternalId(), merged.SetExternalId, other.GetExternalId())
+	fill(merged.GetBankName(), merged.SetBankName, other.GetBankName())
+	fill(merged.GetTitleExpence(), merged.SetTitleExpence, other.GetTitleExpence())
+	fill(merged.GetDescriptionExpence(), merged.SetDescriptionExpence, other.GetDescriptionExpence())
+	if merged.GetIdCategory() == 0 && merged.GetGroupExpence() == "" && (other.GetIdCategory() != 0 || other.GetGroupExpence() != "") {
+		merged.SetIdCategory(other.GetIdCategory())
+		merged.SetGroupExpence(other.GetGroupExpence())
+		changed = true
+	}
+	if tags := mergeTags(merged.GetTags(), other.GetTags()); len(tags) != len(merged.GetTags()) {
+		merged.SetTags(tags)
+		changed = true
+	}
+	return merged, changed
+}
+
+// how alike two expences are by amount, date, title and account; not ok when
+// they can not be the same purchase at all
+func duplicateScore(expence, other *models.Expence) (float64, []string, bool) {
+	// two bank transactions are different purchases
+	if expence.GetExternalId() != "" && other.GetExternalId() != "" {
+		return 0, nil, false
+	}
+
+	var score float64
+	var reasons []string
+
+	switch difference := abs(expence.GetAmount() - other.GetAmount()); {
+	case sameAmount(expence.GetAmount(), other.GetAmount()):
+		score += 0.4
+		reasons = append(reasons, "same amount")
+	case difference <= max(expence.GetAmount(), other.GetAmount())*0.01:
+		score += 0.25
+		reasons = append(reasons, "close amount")
+	default:
+		return 0, nil, false
+	}
+
+	days := calendarDays(expence.GetDate(), other.GetDate())
+	switch {
+	case days > DuplicateWindowDays:
+		return 0, nil, false
+	case days == 0:
+		score += 0.3
+		reasons = append(reasons, "same day")
+	case days == 1:
+		score += 0.2
+		reasons = append(reasons, "next day")
+	default:
+		score += 0.1
+		reasons = append(reasons, "close dates")
+	}
+
+	if similarity := titleSimilarity(expence.GetTitleExpence(), other.GetTitleExpence()); similarity == 1 {
+		score += 0.2
+		reasons = append(reasons, "same title")
+	} else if similarity > 0 {
+		score += 0.2 * similarity
+		reasons = append(reasons, "similar title")
+	}
+
+	switch {
+	case expence.GetIdAccaunt() == other.GetIdAccaunt():
+		score += 0.1
+		reasons = append(reasons, "same account")
+	case !NewGroupService().ScopeFor(expence.GetIdAccaunt()).Allows(other.GetIdAccaunt()):
+		// accounts share nothing, purchase is not theirs both
+		return 0, nil, false
+	}
+	return score, reasons, true
+}
+
+// days between dates of calendar, time of day aside
+func calendarDays(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(b.Sub(a).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+// 1 for same normalized titles, 0.75 when one holds the other, share of common
+// words otherwise
+func titleSimilarity(a, b string) float64 {
+	a, b = normalizeTitle(a), normalizeTitle(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return 0.75
+	}
+
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(a) {
+		words[word] = true
+	}
+	common, all := 0, len(words)
+	for _, word := range strings.Fields(b) {
+		if words[word] {
+			common++
+			words[word] = false
+		} else if _, ok := words[word]; !ok {
+			all++
+		}
+	}
+	return float64(common) / float64(all)
+}
+
+// lower case words of letters and digits
+func normalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -2,35 +2,69 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/helltale/api-finances/internal/debugging"
 	"github.com/helltale/api-finances/internal/models"
+	"github.com/helltale/api-finances/internal/recurrence"
 )
 
-type ExpenceService struct{}
+type ExpenceService struct {
+	scope *Scope
+}
 
 func NewExpenceService() *ExpenceService {
 	return &ExpenceService{}
 }
 
+// restrict queries to accounts visible in scope
+func (s *ExpenceService) WithScope(scope *Scope) *ExpenceService {
+	s.scope = scope
+	return s
+}
+
 func (s *ExpenceService) AddNewExpence(newExpence *models.Expence) error {
+	if err := s.scope.CanWrite(newExpence.GetIdAccaunt()); err != nil {
+		return err
+	}
+
 	for _, expence := range debugging.Expences {
 		if expence.GetIdExpence() == newExpence.GetIdExpence() {
 			return errors.New("expence with this ID already exists")
 		}
 	}
+	NewRuleService().AutoCategorize(newExpence)
+	if err := s.categorize(newExpence); err != nil {
+		return err
+	}
+
+	if err := s.postExpence(newExpence); err != nil {
+		return err
+	}
+	s.classify(newExpence)
+
 	debugging.Expences = append(debugging.Expences, newExpence)
 	return nil
 }
 
 func (s *ExpenceService) GetAllExpences() []*models.Expence {
-	return debugging.Expences
+	var expences []*models.Expence
+	for _, expence := range debugging.Expences {
+		if !expence.IsDeleted() && s.scope.Allows(expence.GetIdAccaunt()) {
+			expences = append(expences, expence)
+		}
+	}
+	return expences
 }
 
 func (s *ExpenceService) GetExpenceById(idExpence int64) (*models.Expence, error) {
 	for _, expence := range debugging.Expences {
-		if expence.GetIdExpence() == idExpence {
+		if expence.GetIdExpence() == idExpence && !expence.IsDeleted() && s.scope.Allows(expence.GetIdAccaunt()) {
 			return expence, nil
 		}
 	}
@@ -39,10 +73,25 @@ func (s *ExpenceService) GetExpenceById(idExpence int64) (*models.Expence, error
 
 func (s *ExpenceService) UpdateExpence(updatedExpence *models.Expence) (*models.Expence, error) {
 	for i, expence := range debugging.Expences {
-		if expence.GetIdExpence() == updatedExpence.GetIdExpence() {
+		if expence.GetIdExpence() == updatedExpence.GetIdExpence() && !expence.IsDeleted() {
+			if err := s.canReplace(expence, updatedExpence); err != nil {
+				return nil, err
+			}
+			if err := s.categorize(updatedExpence); err != nil {
+				return nil, err
+			}
+			if updatedExpence.GetExternalId() == "" {
+				updatedExpence.SetExternalId(expence.GetExternalId())
+			}
+
 			oldExpenceCopy := &models.Expence{}
 			*oldExpenceCopy = *expence
 
+			if err := s.repostExpence(updatedExpence); err != nil {
+				return nil, err
+			}
+			s.classify(updatedExpence)
+
 			debugging.Expences[i] = updatedExpence
 			return oldExpenceCopy, nil
 		}
@@ -55,7 +104,17 @@ func (s *ExpenceService) UpdateHistoryExpence(idExpence int64, newExpence *model
 
 	var oldExpence *models.Expence
 	for i, expence := range debugging.Expences {
-		if expence.GetIdExpence() == idExpence {
+		if expence.GetIdExpence() == idExpence && !expence.IsDeleted() {
+			if err := s.canReplace(expence, newExpence); err != nil {
+				return nil, err
+			}
+			if err := s.categorize(newExpence); err != nil {
+				return nil, err
+			}
+			if newExpence.GetExternalId() == "" {
+				newExpence.SetExternalId(expence.GetExternalId())
+			}
+
 			oldExpence = expence
 			debugging.Expences[i].SetDateActualTo(today)
 			break
@@ -70,25 +129,90 @@ func (s *ExpenceService) UpdateHistoryExpence(idExpence int64, newExpence *model
 	futureDate, _ := time.Parse("2006-01-02", "9999-12-31")
 	newExpence.SetDateActualTo(futureDate)
 
+	if err := s.repostExpence(newExpence); err != nil {
+		return nil, err
+	}
+	s.classify(newExpence)
+
 	debugging.Expences = append(debugging.Expences, newExpence)
 
 	return oldExpence, nil
 }
 
-func (s *ExpenceService) DeleteExpence(idExpence int64) (*models.Expence, error) {
-	for i, expence := range debugging.Expences {
+// mark every version of the expence deleted, history stays in place
+func (s *ExpenceService) DeleteExpence(idExpence int64, deletedBy string) (*models.Expence, error) {
+	var deleted *models.Expence
+	for _, expence := range debugging.Expences {
+		if expence.GetIdExpence() != idExpence || expence.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(expence.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if deleted == nil || expence.GetDateActualTo().Year() == 9999 {
+			deleted = expence
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("expence not found")
+	}
+
+	if err := NewLedgerService().ReverseSource(models.JournalSourceExpence, idExpence, deletedBy); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, expence := range debugging.Expences {
+		if expence.GetIdExpence() == idExpence && !expence.IsDeleted() {
+			expence.SetDeletedAt(now)
+			expence.SetDeletedBy(deletedBy)
+		}
+	}
+	NewCategorizerService().Forget(idExpence)
+	return deleted, nil
+}
+
+// bring a deleted expence back from trash
+func (s *ExpenceService) RestoreExpence(idExpence int64) (*models.Expence, error) {
+	var restored *models.Expence
+	for _, expence := range debugging.Expences {
+		if expence.GetIdExpence() != idExpence || !expence.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(expence.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if restored == nil || expence.GetDateActualTo().Year() == 9999 {
+			restored = expence
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted expence not found")
+	}
+
+	if err := s.postExpence(restored); err != nil {
+		return nil, err
+	}
+
+	for _, expence := range debugging.Expences {
 		if expence.GetIdExpence() == idExpence {
-			debugging.Expences = append(debugging.Expences[:i], debugging.Expences[i+1:]...)
-			return expence, nil
+			expence.SetDeletedAt(time.Time{})
+			expence.SetDeletedBy("")
 		}
 	}
-	return nil, errors.New("expence not found")
+	NewCategorizerService().Learn(restored)
+	return restored, nil
 }
 
 func (service *ExpenceService) GetExpencesByGroup(group string) ([]*models.Expence, error) {
 	var expences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
 		if expence.GetGroupExpence() == group {
 			expences = append(expences, expence)
 		}
@@ -107,7 +231,7 @@ func (s *ExpenceService) DeleteAndRestorePreviousExpence(idExpence int64) (*mode
 	maxDate := time.Time{}
 
 	for _, expence := range debugging.Expences {
-		if expence.GetIdExpence() == idExpence {
+		if expence.GetIdExpence() == idExpence && !expence.IsDeleted() {
 			if expence.GetDateActualTo().Year() == 9999 {
 				currentRecord = expence
 			} else if expence.GetDateActualTo().After(maxDate) {
@@ -125,6 +249,10 @@ func (s *ExpenceService) DeleteAndRestorePreviousExpence(idExpence int64) (*mode
 		return nil, errors.New("no historical record found to restore")
 	}
 
+	if err := s.scope.CanWrite(currentRecord.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
 	for i, expence := range debugging.Expences {
 		if expence == currentRecord {
 			debugging.Expences = append(debugging.Expences[:i], debugging.Expences[i+1:]...)
@@ -135,13 +263,150 @@ func (s *ExpenceService) DeleteAndRestorePreviousExpence(idExpence int64) (*mode
 	futureDate, _ := time.Parse("2006-01-02", "9999-12-31")
 	lastHistoricalRecord.SetDateActualTo(futureDate)
 
+	if err := s.repostExpence(lastHistoricalRecord); err != nil {
+		return nil, err
+	}
+	s.classify(lastHistoricalRecord)
+
 	return lastHistoricalRecord, nil
 }
 
+// put updated in place of this very stored version, unlike UpdateExpence it
+// does not look the version up by id
+func (s *ExpenceService) replaceVersion(stored, updated *models.Expence) error {
+	for i, expence := range debugging.Expences {
+		if expence != stored {
+			continue
+		}
+		if err := s.canReplace(stored, updated); err != nil {
+			return err
+		}
+		if err := s.categorize(updated); err != nil {
+			return err
+		}
+		if err := s.repostExpence(updated); err != nil {
+			return err
+		}
+		s.classify(updated)
+		debugging.Expences[i] = updated
+		return nil
+	}
+	return errors.New("expence not found")
+}
+
+// both the stored and the new owner must be writable
+func (s *ExpenceService) canReplace(oldExpence, newExpence *models.Expence) error {
+	if err := s.scope.CanWrite(oldExpence.GetIdAccaunt()); err != nil {
+		return err
+	}
+	return s.scope.CanWrite(newExpence.GetIdAccaunt())
+}
+
+// link expence to category by id or by group text and its tags to tags of
+// the account
+func (s *ExpenceService) categorize(expence *models.Expence) error {
+	idCategory, group, err := NewCategoryService().Categorize(expence.GetIdCategory(), expence.GetGroupExpence(), expence.GetIdAccaunt(), expence.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	tags, err := NewTagService().Ensure(expence.GetTags(), expence.GetIdAccaunt(), expence.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	expence.SetIdCategory(idCategory)
+	expence.SetGroupExpence(group)
+	expence.SetTags(tags)
+	return s.split(expence)
+}
+
+// split lines must sum to the expence, line without category takes the one of
+// the expence and responsible member must share a group with the payer
+func (s *ExpenceService) split(expence *models.Expence) error {
+	if len(expence.GetSplits()) == 0 {
+		return nil
+	}
+	if len(expence.GetSplits()) < 2 {
+		return errors.New("split needs at least two lines")
+	}
+
+	payer := NewGroupService().ScopeFor(expence.GetIdAccaunt())
+	categoryService := NewCategoryService()
+	splits := make([]*models.ExpenceSplit, 0, len(expence.GetSplits()))
+	var sum float64
+	for i, line := range expence.GetSplits() {
+		prefix := "split line " + strconv.Itoa(i+1) + ": "
+		if line.GetAmount() <= 0 {
+			return errors.New(prefix + "amount must be positive")
+		}
+
+		idCategory, group := line.GetIdCategory(), line.GetGroupExpence()
+		if idCategory == 0 && strings.TrimSpace(group) == "" {
+			idCategory, group = expence.GetIdCategory(), expence.GetGroupExpence()
+		}
+		idCategory, group, err := categoryService.Categorize(idCategory, group, expence.GetIdAccaunt(), expence.GetUpdBy())
+		if err != nil {
+			return errors.New(prefix + err.Error())
+		}
+
+		idAccaunt := line.GetIdAccaunt()
+		if idAccaunt == expence.GetIdAccaunt() {
+			idAccaunt = 0
+		}
+		if idAccaunt != 0 {
+			if _, err := NewAccountService().GetAccountById(idAccaunt); err != nil {
+				return errors.New(prefix + err.Error())
+			}
+			if !payer.Allows(idAccaunt) {
+				return errors.New(prefix + "responsible account does not share a group with the payer")
+			}
+		}
+
+		split := &models.ExpenceSplit{}
+		split.SetAmount(roundAmount(line.GetAmount()))
+		split.SetIdCategory(idCategory)
+		split.SetGroupExpence(group)
+		split.SetIdAccaunt(idAccaunt)
+		splits = append(splits, split)
+		sum += split.GetAmount()
+	}
+
+	if math.Abs(sum-expence.GetAmount()) > 0.005 {
+		return fmt.Errorf("split lines sum to %.2f, expence is %.2f", sum, expence.GetAmount())
+	}
+	expence.SetSplits(splits)
+	return nil
+}
+
+// categorized expence teaches the categorizer, one without group gets its
+// suggestion
+func (s *ExpenceService) classify(expence *models.Expence) {
+	categorizer := NewCategorizerService()
+	categorizer.Suggest(expence)
+	categorizer.Learn(expence)
+}
+
+// one-off expences go to the ledger at once, recurring ones when they occur
+func (s *ExpenceService) postExpence(expence *models.Expence) error {
+	if expence.GetRepeat() != 0 {
+		return nil
+	}
+	return NewLedgerService().PostExpence(expence)
+}
+
+func (s *ExpenceService) repostExpence(expence *models.Expence) error {
+	if err := NewLedgerService().ReverseSource(models.JournalSourceExpence, expence.GetIdExpence(), expence.GetUpdBy()); err != nil {
+		return err
+	}
+	return s.postExpence(expence)
+}
+
 func (service *ExpenceService) GetExpencesByTitle(title string) ([]*models.Expence, error) {
 	var foundExpences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
 		if expence.GetTitleExpence() == title {
 			foundExpences = append(foundExpences, expence)
 		}
@@ -158,11 +423,23 @@ func (service *ExpenceService) GetExpencesByDateRange(startDate, endDate time.Ti
 	var foundExpences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+		if expence.GetRepeat() != 0 {
+			continue
+		}
 		if expence.GetDate().After(startDate) && expence.GetDate().Before(endDate) {
 			foundExpences = append(foundExpences, expence)
 		}
 	}
 
+	for _, occurrence := range service.MaterializeExpences(startDate, endDate) {
+		if occurrence.GetDate().After(startDate) && occurrence.GetDate().Before(endDate) {
+			foundExpences = append(foundExpences, occurrence)
+		}
+	}
+
 	if len(foundExpences) == 0 {
 		return nil, errors.New("no expences found in the specified date range")
 	}
@@ -174,6 +451,9 @@ func (service *ExpenceService) GetExpencesByRepeat(repeat int8) ([]*models.Expen
 	var foundExpences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
 		if expence.GetRepeat() == repeat {
 			foundExpences = append(foundExpences, expence)
 		}
@@ -190,6 +470,9 @@ func (service *ExpenceService) GetExpencesByAmountRange(minAmount, maxAmount flo
 	var foundExpences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
 		if expence.GetAmount() >= minAmount && expence.GetAmount() <= maxAmount {
 			foundExpences = append(foundExpences, expence)
 		}
@@ -206,6 +489,9 @@ func (service *ExpenceService) GetExpencesByMaxAmount(maxAmount float64) ([]*mod
 	var foundExpences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
 		if expence.GetAmount() < maxAmount {
 			foundExpences = append(foundExpences, expence)
 		}
@@ -222,6 +508,9 @@ func (service *ExpenceService) GetExpencesByMinAmount(minAmount float64) ([]*mod
 	var foundExpences []*models.Expence
 
 	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
 		if expence.GetAmount() > minAmount {
 			foundExpences = append(foundExpences, expence)
 		}
@@ -233,3 +522,111 @@ func (service *ExpenceService) GetExpencesByMinAmount(minAmount float64) ([]*mod
 
 	return foundExpences, nil
 }
+
+func (service *ExpenceService) GetExpencesByFrequency(freq string) ([]*models.Expence, error) {
+	var foundExpences []*models.Expence
+
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || !service.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+		rule, err := ExpenceRule(expence)
+		if err != nil || rule == nil {
+			continue
+		}
+		if rule.Freq == freq {
+			foundExpences = append(foundExpences, expence)
+		}
+	}
+
+	if len(foundExpences) == 0 {
+		return nil, errors.New("no expences found for the specified frequency")
+	}
+
+	return foundExpences, nil
+}
+
+// recurrence of expence, nil for one-off ones. Repeat without a rule is monthly
+func ExpenceRule(expence *models.Expence) (*recurrence.Rule, error) {
+	if expence.GetRrule() != "" {
+		return recurrence.Parse(expence.GetRrule())
+	}
+	if expence.GetRepeat() != 0 {
+		return &recurrence.Rule{Freq: recurrence.Monthly, Interval: 1}, nil
+	}
+	return nil, nil
+}
+
+// validate rrule and store it in canonical form, expence with a rule repeats
+func NormalizeExpenceRule(expence *models.Expence) error {
+	if expence.GetRrule() == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(expence.GetRrule())
+	if err != nil {
+		return err
+	}
+
+	expence.SetRrule(rule.String())
+	expence.SetRepeat(1)
+	return nil
+}
+
+// virtual occurrences of recurring expences in [from, to] sorted by date.
+// Every version of the history produces occurrences until it was replaced
+func (s *ExpenceService) MaterializeExpences(from, to time.Time) []*models.Expence {
+	return s.materialize(to, func(*models.Expence) time.Time { return from })
+}
+
+// occurrences up to to, window of every expence starts at fromOf of it
+func (s *ExpenceService) materialize(to time.Time, fromOf func(*models.Expence) time.Time) []*models.Expence {
+	var occurrences []*models.Expence
+
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetRepeat() == 0 || !s.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+
+		rule, err := ExpenceRule(expence)
+		if err != nil {
+			continue
+		}
+
+		start := expence.GetDate()
+		if start.IsZero() {
+			start = expence.GetDateActualFrom()
+		}
+
+		windowFrom, windowTo := fromOf(expence), to
+		if s.hasPreviousVersion(expence) && expence.GetDateActualFrom().After(windowFrom) {
+			windowFrom = expence.GetDateActualFrom()
+		}
+		actualTo := expence.GetDateActualTo()
+		if !actualTo.IsZero() && actualTo.Year() != 9999 && !actualTo.After(windowTo) {
+			windowTo = actualTo.Add(-time.Nanosecond)
+		}
+
+		for _, date := range rule.Between(start, windowFrom, windowTo) {
+			occurrences = append(occurrences, expence.Occurrence(date))
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].GetDate().Before(occurrences[j].GetDate())
+	})
+	return occurrences
+}
+
+// version replaced another one of the same expence
+func (s *ExpenceService) hasPreviousVersion(version *models.Expence) bool {
+	for _, expence := range debugging.Expences {
+		if expence == version || expence.GetIdExpence() != version.GetIdExpence() || expence.IsDeleted() {
+			continue
+		}
+		if !expence.GetDateActualTo().After(version.GetDateActualFrom()) {
+			return true
+		}
+	}
+	return false
+}
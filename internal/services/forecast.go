@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	DefaultForecastHorizon = "6m"
+	maxForecastDays        = 5 * 366
+)
+
+var (
+	ErrInvalidHorizon = errors.New("invalid horizon, must be like 30d, 8w, 6m or 1y up to 5y")
+	horizonPattern    = regexp.MustCompile(`^([1-9][0-9]*)([dwmy])$`)
+)
+
+type ForecastService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewForecastService() *ForecastService {
+	return &ForecastService{now: time.Now}
+}
+
+// only accounts visible in scope
+func (s *ForecastService) WithScope(scope *Scope) *ForecastService {
+	s.scope = scope
+	return s
+}
+
+// last day of horizon counted from day
+func ForecastHorizonEnd(day time.Time, horizon string) (time.Time, error) {
+	match := horizonPattern.FindStringSubmatch(horizon)
+	if match == nil {
+		return time.Time{}, ErrInvalidHorizon
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return time.Time{}, ErrInvalidHorizon
+	}
+
+	var end time.Time
+	switch match[2] {
+	case "d":
+		end = day.AddDate(0, 0, n)
+	case "w":
+		end = day.AddDate(0, 0, 7*n)
+	case "m":
+		end = day.AddDate(0, n, 0)
+	case "y":
+		end = day.AddDate(n, 0, 0)
+	}
+	if daysBetween(day, end) > maxForecastDays {
+		return time.Time{}, ErrInvalidHorizon
+	}
+	return end, nil
+}
+
+// daily balance of account from the current remain. Flows of today are taken as
+// already in the remain, the forecast starts tomorrow
+func (s *ForecastService) Forecast(idAccaunt int64, horizon string) (*models.ForecastJSON, error) {
+	if !s.scope.Allows(idAccaunt) {
+		return nil, errors.New("account not found")
+	}
+	if _, err := NewAccountService().GetAccountById(idAccaunt); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end, err := ForecastHorizonEnd(today, horizon)
+	if err != nil {
+		return nil, err
+	}
+	from := today.AddDate(0, 0, 1)
+
+	incomes := make(map[string]float64)
+	expences := make(map[string]float64)
+	goals := make(map[string]float64)
+	inRange := func(date time.Time) bool {
+		return !date.Before(from) && !date.After(end)
+	}
+
+	for monthStart := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, now.Location()); !monthStart.After(end); monthStart = monthStart.AddDate(0, 1, 0) {
+		for _, incomeExpected := range incomesExpectedOf(s.scope, monthStart, monthStart.AddDate(0, 1, 0)) {
+			if incomeExpected.GetIdAccaunt() != idAccaunt {
+				continue
+			}
+			due := dueDate(monthStart.Year(), monthStart.Month(), int(incomeExpected.GetIncomeMonthDate()), now.Location())
+			if !inRange(due) || s.hasReceivedIncome(incomeExpected.GetIdIncomeEx(), monthStart.Year(), monthStart.Month()) {
+				continue
+			}
+			incomes[due.Format("2006-01-02")] += incomeExpected.GetAmount()
+		}
+	}
+
+	for _, occurrence := range NewExpenceService().WithScope(s.scope).MaterializeExpences(from, end.AddDate(0, 0, 1).Add(-time.Nanosecond)) {
+		if occurrence.GetIdAccaunt() == idAccaunt {
+			expences[occurrence.GetDate().Format("2006-01-02")] += occurrence.GetAmount()
+		}
+	}
+
+	for _, goal := range debugging.Goals {
+		if goal.IsDeleted() || goal.GetIdAccaunt() != idAccaunt || !actualAt(goal.GetDateActualFrom(), goal.GetDateActualTo(), now) {
+			continue
+		}
+		remaining := goal.GetAmount() - NewGoalService().Saved(goal.GetIdGoal())
+		for date, amount := range s.goalContributions(goal, remaining, from) {
+			if inRange(date) {
+				goals[date.Format("2006-01-02")] += amount
+			}
+		}
+	}
+
+	balance := s.currentRemain(idAccaunt, now)
+	forecast := &models.ForecastJSON{
+		IdAccaunt:      idAccaunt,
+		Horizon:        horizon,
+		From:           from.Format("2006-01-02"),
+		To:             end.Format("2006-01-02"),
+		StartBalance:   roundAmount(balance),
+		MinBalance:     roundAmount(balance),
+		MinBalanceDate: today.Format("2006-01-02"),
+		Days:           []models.ForecastDayJSON{},
+	}
+
+	for day := from; !day.After(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		balance += incomes[key] - expences[key] - goals[key]
+
+		forecastDay := models.ForecastDayJSON{
+			Date:     key,
+			Income:   roundAmount(incomes[key]),
+			Expence:  roundAmount(expences[key]),
+			Goal:     roundAmount(goals[key]),
+			Balance:  roundAmount(balance),
+			Negative: roundAmount(balance) < 0,
+		}
+		forecast.Days = append(forecast.Days, forecastDay)
+
+		if forecastDay.Balance < forecast.MinBalance {
+			forecast.MinBalance = forecastDay.Balance
+			forecast.MinBalanceDate = key
+		}
+		if forecastDay.Negative {
+			forecast.NegativeDays++
+			if forecast.FirstNegativeDate == "" {
+				forecast.FirstNegativeDate = key
+			}
+		}
+	}
+	forecast.EndBalance = roundAmount(balance)
+	return forecast, nil
+}
+
+// amount of the open remain of account, zero if there is none
+func (s *ForecastService) currentRemain(idAccaunt int64, now time.Time) float64 {
+	var current *models.Remain
+	for _, remain := range debugging.Remains {
+		if remain.IsDeleted() || remain.GetIdAccaunt() != idAccaunt || !actualAt(remain.GetDateActualFrom(), remain.GetDateActualTo(), now) {
+			continue
+		}
+		if current == nil || remain.GetDateActualFrom().After(current.GetDateActualFrom()) {
+			current = remain
+		}
+	}
+	if current == nil {
+		return 0
+	}
+	return current.GetAmount()
+}
+
+// income expected of the month already came, pending placeholders do not count
+func (s *ForecastService) hasReceivedIncome(idIncomeEx int64, year int, month time.Month) bool {
+	for _, income := range debugging.Incomes {
+		if income.IsDeleted() || income.IsPending() || income.GetIdIncomeExpected() != idIncomeEx {
+			continue
+		}
+		if incomeYear, incomeMonth := incomePeriod(income); incomeYear == year && incomeMonth == month {
+			return true
+		}
+	}
+	return false
+}
+
+// amount left to save spread evenly over monthly payments on the deadline day of
+// month, from the first payment not before from up to the deadline
+func (s *ForecastService) goalContributions(goal *models.Goal, remaining float64, from time.Time) map[time.Time]float64 {
+	deadline := goal.GetDate()
+	if deadline.IsZero() || deadline.Before(from) || remaining <= 0 {
+		return nil
+	}
+
+	var dates []time.Time
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location()); ; month = month.AddDate(0, 1, 0) {
+		date := dueDate(month.Year(), month.Month(), deadline.Day(), from.Location())
+		if date.After(deadline) {
+			break
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	contributions := make(map[time.Time]float64, len(dates))
+	for _, date := range dates {
+		contributions[date] = remaining / float64(len(dates))
+	}
+	return contributions
+}
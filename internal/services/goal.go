@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type GoalService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewGoalService() *GoalService {
+	return &GoalService{now: time.Now}
+}
+
+// restrict queries and changes to accounts of scope
+func (s *GoalService) WithScope(scope *Scope) *GoalService {
+	s.scope = scope
+	return s
+}
+
+func (s *GoalService) AddNewGoal(newGoal *models.Goal) error {
+	if err := s.scope.CanWrite(newGoal.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	debugging.Goals = append(debugging.Goals, newGoal)
+	return nil
+}
+
+func (s *GoalService) GetAllGoals() []*models.Goal {
+	var goals []*models.Goal
+	for _, goal := range debugging.Goals {
+		if !goal.IsDeleted() && s.scope.Allows(goal.GetIdAccaunt()) {
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+func (s *GoalService) GetGoalById(idGoal int64) (*models.Goal, error) {
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() == idGoal && !goal.IsDeleted() && s.scope.Allows(goal.GetIdAccaunt()) {
+			return goal, nil
+		}
+	}
+	return nil, errors.New("goal not found")
+}
+
+func (s *GoalService) GetGoalsByAccount(idAccaunt int64) []*models.Goal {
+	var goals []*models.Goal
+	for _, goal := range s.GetAllGoals() {
+		if goal.GetIdAccaunt() == idAccaunt {
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+// goals dated strictly between the dates
+func (s *GoalService) GetGoalsByDateRange(startDate, endDate time.Time) []*models.Goal {
+	var goals []*models.Goal
+	for _, goal := range s.GetAllGoals() {
+		if goal.GetDate().After(startDate) && goal.GetDate().Before(endDate) {
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+func (s *GoalService) GetGoalsByAmountRange(minAmount, maxAmount float64) []*models.Goal {
+	var goals []*models.Goal
+	for _, goal := range s.GetAllGoals() {
+		if goal.GetAmount() >= minAmount && goal.GetAmount() <= maxAmount {
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+func (s *GoalService) GetGoalsByMaxAmount(maxAmount float64) []*models.Goal {
+	var goals []*models.Goal
+	for _, goal := range s.GetAllGoals() {
+		if goal.GetAmount() < maxAmount {
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+func (s *GoalService) GetGoalsByMinAmount(minAmount float64) []*models.Goal {
+	var goals []*models.Goal
+	for _, goal := range s.GetAllGoals() {
+		if goal.GetAmount() > minAmount {
+			goals = append(goals, goal)
+		}
+	}
+	return goals
+}
+
+// replace goal record, old one is returned
+func (s *GoalService) UpdateGoal(idGoal int64, newGoal *models.Goal) (*models.Goal, error) {
+	for i, goal := range debugging.Goals {
+		if goal.GetIdGoal() == idGoal && !goal.IsDeleted() {
+			if err := s.scope.CanWrite(goal.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(newGoal.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+
+			debugging.Goals = append(debugging.Goals[:i], debugging.Goals[i+1:]...)
+			debugging.Goals = append(debugging.Goals, newGoal)
+			return goal, nil
+		}
+	}
+	return nil, errors.New("goal not found")
+}
+
+// mark every version of the goal deleted, history stays in place
+func (s *GoalService) DeleteGoal(idGoal int64, deletedBy string) (*models.Goal, error) {
+	var deleted *models.Goal
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() != idGoal || goal.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(goal.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if deleted == nil || goal.GetDateActualTo().Year() == 9999 {
+			deleted = goal
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("goal not found")
+	}
+
+	now := s.now()
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() == idGoal && !goal.IsDeleted() {
+			goal.SetDeletedAt(now)
+			goal.SetDeletedBy(deletedBy)
+		}
+	}
+	return deleted, nil
+}
+
+// bring a deleted goal back from trash
+func (s *GoalService) RestoreGoal(idGoal int64) (*models.Goal, error) {
+	var restored *models.Goal
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() != idGoal || !goal.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(goal.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if restored == nil || goal.GetDateActualTo().Year() == 9999 {
+			restored = goal
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted goal not found")
+	}
+
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() == idGoal {
+			goal.SetDeletedAt(time.Time{})
+			goal.SetDeletedBy("")
+		}
+	}
+	return restored, nil
+}
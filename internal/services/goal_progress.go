@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// put money toward a goal, kind is taken from the fields when empty
+func (s *GoalService) AddContribution(contribution *models.GoalContribution) error {
+	goal, err := s.GetGoalById(contribution.GetIdGoal())
+	if err != nil {
+		return err
+	}
+	if err := s.scope.CanWrite(goal.GetIdAccaunt()); err != nil {
+		return err
+	}
+	contribution.SetIdAccaunt(goal.GetIdAccaunt())
+
+	if contribution.GetKind() == "" {
+		contribution.SetKind(models.GoalContributionExplicit)
+		if contribution.GetIdRemains() != 0 {
+			contribution.SetKind(models.GoalContributionEarmark)
+		}
+	}
+
+	switch contribution.GetKind() {
+	case models.GoalContributionExplicit:
+		if contribution.GetAmount() == 0 {
+			return errors.New("amount is required")
+		}
+		if contribution.GetIdRemains() != 0 || contribution.GetShare() != 0 {
+			return errors.New("explicit contribution has no remain")
+		}
+	case models.GoalContributionEarmark:
+		if contribution.GetShare() < 0 || contribution.GetShare() > 1 {
+			return errors.New("share must be between 0 and 1")
+		}
+		remain := currentRemainById(contribution.GetIdRemains())
+		if remain == nil {
+			return errors.New("remain not found")
+		}
+		if remain.GetIdAccaunt() != goal.GetIdAccaunt() {
+			return errors.New("remain of another account")
+		}
+		if s.earmarkedShare(contribution.GetIdRemains(), contribution.GetIdGoal())+contribution.GetShare() > 1 {
+			return errors.New("remain is earmarked over 100%")
+		}
+		contribution.SetAmount(0)
+	default:
+		return errors.New("invalid kind, must be explicit or earmark")
+	}
+
+	if contribution.GetDate().IsZero() {
+		contribution.SetDate(s.now())
+	}
+
+	var maxId int64
+	for _, existing := range debugging.GoalContributions {
+		if existing.GetIdContribution() > maxId {
+			maxId = existing.GetIdContribution()
+		}
+	}
+	contribution.SetIdContribution(maxId + 1)
+
+	debugging.GoalContributions = append(debugging.GoalContributions, contribution)
+	return nil
+}
+
+func (s *GoalService) GetContributions(idGoal int64) ([]*models.GoalContribution, error) {
+	if _, err := s.GetGoalById(idGoal); err != nil {
+		return nil, err
+	}
+
+	var contributions []*models.GoalContribution
+	for _, contribution := range debugging.GoalContributions {
+		if contribution.GetIdGoal() == idGoal {
+			contributions = append(contributions, contribution)
+		}
+	}
+	return contributions, nil
+}
+
+// goals that are actual now with progress
+func (s *GoalService) GetCurrentProgress() ([]models.GoalProgressJSON, error) {
+	progress := []models.GoalProgressJSON{}
+	for _, goal := range debugging.Goals {
+		if goal.IsDeleted() || !s.scope.Allows(goal.GetIdAccaunt()) || goal.GetDateActualTo().Year() != 9999 {
+			continue
+		}
+		goalProgress, err := s.Progress(goal)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, *goalProgress)
+	}
+	return progress, nil
+}
+
+func (s *GoalService) GetProgressById(idGoal int64) (*models.GoalProgressJSON, error) {
+	var current *models.Goal
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() != idGoal || goal.IsDeleted() || !s.scope.Allows(goal.GetIdAccaunt()) {
+			continue
+		}
+		if current == nil || goal.GetDateActualFrom().After(current.GetDateActualFrom()) {
+			current = goal
+		}
+	}
+	if current == nil {
+		return nil, errors.New("goal not found")
+	}
+	return s.Progress(current)
+}
+
+// saved against target, the pace is even from the first version of the goal to the deadline
+func (s *GoalService) Progress(goal *models.Goal) (*models.GoalProgressJSON, error) {
+	goalJSON, err := goal.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	target := goal.GetAmount()
+	saved := s.Saved(goal.GetIdGoal())
+	deadline := goal.GetDate()
+
+	progress := &models.GoalProgressJSON{
+		GoalJSON: *goalJSON,
+		Saved:    roundAmount(saved),
+	}
+	progress.Remaining = roundAmount(max(target-progress.Saved, 0))
+	if target > 0 {
+		progress.Percent = roundAmount(min(saved/target*100, 100))
+	}
+
+	if deadline.After(now) {
+		progress.MonthsLeft = monthsUntil(now, deadline)
+	}
+	if progress.Remaining > 0 {
+		progress.RequiredMonthly = roundAmount(progress.Remaining / float64(max(progress.MonthsLeft, 1)))
+	}
+
+	start := s.goalStart(goal.GetIdGoal())
+	switch {
+	case !deadline.After(start) || !now.Before(deadline):
+		progress.ExpectedSaved = target
+	case now.After(start):
+		progress.ExpectedSaved = roundAmount(target * now.Sub(start).Seconds() / deadline.Sub(start).Seconds())
+	}
+
+	switch {
+	case target > 0 && saved >= target:
+		progress.Status = models.GoalStatusAchieved
+	case !deadline.IsZero() && !now.Before(deadline):
+		progress.Status = models.GoalStatusOverdue
+	case saved >= progress.ExpectedSaved:
+		progress.Status = models.GoalStatusOnTrack
+	default:
+		progress.Status = models.GoalStatusBehind
+	}
+	return progress, nil
+}
+
+// explicit contributions plus the latest earmarked share of every remain
+func (s *GoalService) Saved(idGoal int64) float64 {
+	var saved float64
+	shares := make(map[int64]float64)
+	for _, contribution := range debugging.GoalContributions {
+		if contribution.GetIdGoal() != idGoal {
+			continue
+		}
+		switch contribution.GetKind() {
+		case models.GoalContributionExplicit:
+			saved += contribution.GetAmount()
+		case models.GoalContributionEarmark:
+			shares[contribution.GetIdRemains()] = contribution.GetShare()
+		}
+	}
+
+	for idRemains, share := range shares {
+		if remain := currentRemainById(idRemains); remain != nil && remain.GetAmount() > 0 {
+			saved += remain.GetAmount() * share
+		}
+	}
+	return saved
+}
+
+// share of remain earmarked by goals other than the given one
+func (s *GoalService) earmarkedShare(idRemains, exceptIdGoal int64) float64 {
+	shares := make(map[int64]float64)
+	for _, contribution := range debugging.GoalContributions {
+		if contribution.GetKind() == models.GoalContributionEarmark && contribution.GetIdRemains() == idRemains &&
+			contribution.GetIdGoal() != exceptIdGoal {
+			shares[contribution.GetIdGoal()] = contribution.GetShare()
+		}
+	}
+
+	var total float64
+	for idGoal, share := range shares {
+		if _, err := NewGoalService().GetGoalById(idGoal); err == nil {
+			total += share
+		}
+	}
+	return total
+}
+
+// first version of the goal
+func (s *GoalService) goalStart(idGoal int64) time.Time {
+	var start time.Time
+	for _, goal := range debugging.Goals {
+		if goal.GetIdGoal() == idGoal && (start.IsZero() || goal.GetDateActualFrom().Before(start)) {
+			start = goal.GetDateActualFrom()
+		}
+	}
+	return start
+}
+
+// open version of remain, nil if there is none
+func currentRemainById(idRemains int64) *models.Remain {
+	var current *models.Remain
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() != idRemains || remain.IsDeleted() {
+			continue
+		}
+		if current == nil || remain.GetDateActualFrom().After(current.GetDateActualFrom()) {
+			current = remain
+		}
+	}
+	return current
+}
+
+// whole months to the deadline, a started month counts
+func monthsUntil(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if from.AddDate(0, months, 0).Before(to) {
+		months++
+	}
+	return max(months, 0)
+}
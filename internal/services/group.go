@@ -0,0 +1,332 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// accounts visible to the caller, nil scope means unrestricted
+type Scope struct {
+	idAccaunt int64
+	accounts  map[int64]bool
+}
+
+func (sc *Scope) Allows(idAccaunt int64) bool {
+	return sc == nil || sc.accounts[idAccaunt]
+}
+
+// account the scope was built for, 0 for unrestricted
+func (sc *Scope) GetIdAccaunt() int64 {
+	if sc == nil {
+		return 0
+	}
+	return sc.idAccaunt
+}
+
+type GroupService struct {
+	scope *Scope
+}
+
+func NewGroupService() *GroupService {
+	return &GroupService{}
+}
+
+// changes are made on behalf of the scope account
+func (s *GroupService) WithScope(scope *Scope) *GroupService {
+	s.scope = scope
+	return s
+}
+
+// creator of the group becomes its owner
+func (s *GroupService) AddNewGroup(newGroup *models.Group) error {
+	for _, group := range debugging.Groups {
+		if group.GetIdGroup() == newGroup.GetIdGroup() {
+			return errors.New("group with this ID already exists")
+		}
+	}
+
+	debugging.Groups = append(debugging.Groups, newGroup)
+
+	if s.scope != nil {
+		s.addMember(newGroup.GetIdGroup(), s.scope.GetIdAccaunt(), models.RoleOwner)
+	}
+	return nil
+}
+
+func (s *GroupService) GetAllGroups(scope *Scope) []*models.Group {
+	var groups []*models.Group
+	for _, group := range debugging.Groups {
+		if scope == nil || s.IsMember(group.GetIdGroup(), scope.GetIdAccaunt()) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+func (s *GroupService) GetGroupById(idGroup int64) (*models.Group, error) {
+	for _, group := range debugging.Groups {
+		if group.GetIdGroup() == idGroup {
+			return group, nil
+		}
+	}
+	return nil, errors.New("group not found")
+}
+
+func (s *GroupService) UpdateGroup(updatedGroup *models.Group) (*models.Group, error) {
+	for i, group := range debugging.Groups {
+		if group.GetIdGroup() == updatedGroup.GetIdGroup() {
+			if err := s.scope.CanManageGroup(group.GetIdGroup()); err != nil {
+				return nil, err
+			}
+
+			oldGroupCopy := &models.Group{}
+			*oldGroupCopy = *group
+
+			debugging.Groups[i] = updatedGroup
+			return oldGroupCopy, nil
+		}
+	}
+	return nil, errors.New("group not found")
+}
+
+// delete group with all its memberships and invites
+func (s *GroupService) DeleteGroup(idGroup int64) (*models.Group, error) {
+	for i, group := range debugging.Groups {
+		if group.GetIdGroup() == idGroup {
+			if err := s.scope.CanManageGroup(idGroup); err != nil {
+				return nil, err
+			}
+
+			debugging.Groups = append(debugging.Groups[:i], debugging.Groups[i+1:]...)
+
+			members := debugging.GroupMembers[:0]
+			for _, member := range debugging.GroupMembers {
+				if member.GetIdGroup() != idGroup {
+					members = append(members, member)
+				}
+			}
+			debugging.GroupMembers = members
+
+			invites := debugging.GroupInvites[:0]
+			for _, invite := range debugging.GroupInvites {
+				if invite.GetIdGroup() != idGroup {
+					invites = append(invites, invite)
+				}
+			}
+			debugging.GroupInvites = invites
+
+			return group, nil
+		}
+	}
+	return nil, errors.New("group not found")
+}
+
+func (s *GroupService) GetMembers(idGroup int64) ([]*models.GroupMember, error) {
+	if _, err := s.GetGroupById(idGroup); err != nil {
+		return nil, err
+	}
+
+	var members []*models.GroupMember
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdGroup() == idGroup {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// owner offers membership, the account joins once it accepts. Empty role
+// means member
+func (s *GroupService) InviteMember(idGroup, idAccaunt int64, role string) (*models.GroupInvite, error) {
+	if _, err := s.GetGroupById(idGroup); err != nil {
+		return nil, err
+	}
+
+	if err := s.scope.CanManageGroup(idGroup); err != nil {
+		return nil, err
+	}
+
+	if role == "" {
+		role = models.RoleMember
+	}
+	if !ValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+
+	if _, err := NewAccountService().GetAccountById(idAccaunt); err != nil {
+		return nil, err
+	}
+
+	if s.IsMember(idGroup, idAccaunt) {
+		return nil, errors.New("account is already a member of this group")
+	}
+	if s.findInvite(idGroup, idAccaunt) != -1 {
+		return nil, errors.New("account is already invited to this group")
+	}
+
+	invite := &models.GroupInvite{}
+	invite.SetIdGroup(idGroup)
+	invite.SetIdAccaunt(idAccaunt)
+	invite.SetRole(role)
+	invite.SetInvitedBy(s.scope.GetIdAccaunt())
+	invite.SetCreatedAt(time.Now())
+
+	debugging.GroupInvites = append(debugging.GroupInvites, invite)
+	return invite, nil
+}
+
+// invitations waiting for the scope account, all of them for unrestricted scope
+func (s *GroupService) GetInvites() []*models.GroupInvite {
+	var invites []*models.GroupInvite
+	for _, invite := range debugging.GroupInvites {
+		if s.scope == nil || invite.GetIdAccaunt() == s.scope.GetIdAccaunt() {
+			invites = append(invites, invite)
+		}
+	}
+	return invites
+}
+
+// scope account joins the group with the role it was invited with
+func (s *GroupService) AcceptInvite(idGroup int64) (*models.GroupMember, error) {
+	i := s.findInvite(idGroup, s.scope.GetIdAccaunt())
+	if i == -1 {
+		return nil, errors.New("invite not found")
+	}
+	invite := debugging.GroupInvites[i]
+
+	if _, err := s.GetGroupById(idGroup); err != nil {
+		return nil, err
+	}
+
+	debugging.GroupInvites = append(debugging.GroupInvites[:i], debugging.GroupInvites[i+1:]...)
+	return s.addMember(idGroup, invite.GetIdAccaunt(), invite.GetRole()), nil
+}
+
+func (s *GroupService) DeclineInvite(idGroup int64) (*models.GroupInvite, error) {
+	i := s.findInvite(idGroup, s.scope.GetIdAccaunt())
+	if i == -1 {
+		return nil, errors.New("invite not found")
+	}
+	invite := debugging.GroupInvites[i]
+
+	debugging.GroupInvites = append(debugging.GroupInvites[:i], debugging.GroupInvites[i+1:]...)
+	return invite, nil
+}
+
+func (s *GroupService) findInvite(idGroup, idAccaunt int64) int {
+	for i, invite := range debugging.GroupInvites {
+		if invite.GetIdGroup() == idGroup && invite.GetIdAccaunt() == idAccaunt {
+			return i
+		}
+	}
+	return -1
+}
+
+// membership without consent checks, callers make sure the account agreed
+func (s *GroupService) addMember(idGroup, idAccaunt int64, role string) *models.GroupMember {
+	member := &models.GroupMember{}
+	member.SetIdGroup(idGroup)
+	member.SetIdAccaunt(idAccaunt)
+	member.SetRole(role)
+
+	debugging.GroupMembers = append(debugging.GroupMembers, member)
+	return member
+}
+
+// owners remove anyone, everyone can leave the group
+func (s *GroupService) RemoveMember(idGroup, idAccaunt int64) (*models.GroupMember, error) {
+	if s.scope.GetIdAccaunt() != idAccaunt {
+		if err := s.scope.CanManageGroup(idGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, member := range debugging.GroupMembers {
+		if member.GetIdGroup() == idGroup && member.GetIdAccaunt() == idAccaunt {
+			if member.GetRole() == models.RoleOwner && s.countOwners(idGroup) == 1 {
+				return nil, errors.New("group must keep at least one owner")
+			}
+
+			debugging.GroupMembers = append(debugging.GroupMembers[:i], debugging.GroupMembers[i+1:]...)
+			return member, nil
+		}
+	}
+	return nil, errors.New("membership not found")
+}
+
+func (s *GroupService) SetMemberRole(idGroup, idAccaunt int64, role string) (*models.GroupMember, error) {
+	if err := s.scope.CanManageGroup(idGroup); err != nil {
+		return nil, err
+	}
+
+	if !ValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdGroup() == idGroup && member.GetIdAccaunt() == idAccaunt {
+			if member.GetRole() == models.RoleOwner && role != models.RoleOwner && s.countOwners(idGroup) == 1 {
+				return nil, errors.New("group must keep at least one owner")
+			}
+
+			member.SetRole(role)
+			return member, nil
+		}
+	}
+	return nil, errors.New("membership not found")
+}
+
+// owners whose accounts are not in trash
+func (s *GroupService) countOwners(idGroup int64) int {
+	owners := 0
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdGroup() != idGroup || member.GetRole() != models.RoleOwner {
+			continue
+		}
+		if _, err := NewAccountService().GetAccountById(member.GetIdAccaunt()); err == nil {
+			owners++
+		}
+	}
+	return owners
+}
+
+func (s *GroupService) IsMember(idGroup, idAccaunt int64) bool {
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdGroup() == idGroup && member.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	return false
+}
+
+// groups the account belongs to
+func (s *GroupService) GetGroupIdsByAccount(idAccaunt int64) []int64 {
+	var groupIds []int64
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdAccaunt() == idAccaunt {
+			groupIds = append(groupIds, member.GetIdGroup())
+		}
+	}
+	return groupIds
+}
+
+// account sees itself and every account sharing a group with it
+func (s *GroupService) ScopeFor(idAccaunt int64) *Scope {
+	scope := &Scope{
+		idAccaunt: idAccaunt,
+		accounts:  map[int64]bool{idAccaunt: true},
+	}
+
+	for _, idGroup := range s.GetGroupIdsByAccount(idAccaunt) {
+		for _, member := range debugging.GroupMembers {
+			if member.GetIdGroup() == idGroup {
+				scope.accounts[member.GetIdAccaunt()] = true
+			}
+		}
+	}
+
+	return scope
+}
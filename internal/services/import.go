@@ -0,0 +1,683 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// transaction read from a statement, positive amount is money received
+type importTransaction struct {
+	line        int
+	date        time.Time
+	amount      float64
+	description string
+	category    string
+	reference   string
+	externalId  string // FITID of OFX, empty if bank gives none
+}
+
+// balance of a statement at date
+type importBalance struct {
+	line   int
+	amount float64
+	date   time.Time
+}
+
+// everything read from a statement file
+type importStatement struct {
+	format       string
+	bankName     string
+	transactions []importTransaction
+	skipped      []models.ImportSkippedJSON
+	balances     []importBalance // in order of dates, opening goes before closing
+}
+
+type ImportService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewImportService() *ImportService {
+	return &ImportService{now: time.Now}
+}
+
+// restrict profiles and imports to accounts of scope
+func (s *ImportService) WithScope(scope *Scope) *ImportService {
+	s.scope = scope
+	return s
+}
+
+func (s *ImportService) AddNewProfile(newProfile *models.ImportProfile) error {
+	if err := s.validateProfile(newProfile); err != nil {
+		return err
+	}
+	if err := s.scope.CanWrite(newProfile.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	var maxId int64
+	for _, profile := range debugging.ImportProfiles {
+		maxId = max(maxId, profile.GetIdProfile())
+	}
+	newProfile.SetIdProfile(maxId + 1)
+
+	debugging.ImportProfiles = append(debugging.ImportProfiles, newProfile)
+	return nil
+}
+
+func (s *ImportService) GetAllProfiles() []*models.ImportProfile {
+	var profiles []*models.ImportProfile
+	for _, profile := range debugging.ImportProfiles {
+		if !profile.IsDeleted() && s.scope.Allows(profile.GetIdAccaunt()) {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+func (s *ImportService) GetProfileById(idProfile int64) (*models.ImportProfile, error) {
+	for _, profile := range debugging.ImportProfiles {
+		if profile.GetIdProfile() == idProfile && !profile.IsDeleted() && s.scope.Allows(profile.GetIdAccaunt()) {
+			return profile, nil
+		}
+	}
+	return nil, errors.New("import profile not found")
+}
+
+// replace profile, old one is returned
+func (s *ImportService) UpdateProfile(idProfile int64, newProfile *models.ImportProfile) (*models.ImportProfile, error) {
+	newProfile.SetIdProfile(idProfile)
+	if err := s.validateProfile(newProfile); err != nil {
+		return nil, err
+	}
+
+	for i, profile := range debugging.ImportProfiles {
+		if profile.GetIdProfile() != idProfile || profile.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(profile.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if err := s.scope.CanWrite(newProfile.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+
+		debugging.ImportProfiles[i] = newProfile
+		return profile, nil
+	}
+	return nil, errors.New("import profile not found")
+}
+
+func (s *ImportService) DeleteProfile(idProfile int64, deletedBy string) (*models.ImportProfile, error) {
+	profile, err := s.GetProfileById(idProfile)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(profile.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
+	profile.SetDeletedAt(time.Now())
+	profile.SetDeletedBy(deletedBy)
+	return profile, nil
+}
+
+// bring a deleted profile back from trash
+func (s *ImportService) RestoreProfile(idProfile int64) (*models.ImportProfile, error) {
+	for _, profile := range debugging.ImportProfiles {
+		if profile.GetIdProfile() != idProfile || !profile.IsDeleted() || !s.scope.Allows(profile.GetIdAccaunt()) {
+			continue
+		}
+		if err := s.scope.CanWrite(profile.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+
+		profile.SetDeletedAt(time.Time{})
+		profile.SetDeletedBy("")
+		return profile, nil
+	}
+	return nil, errors.New("deleted import profile not found")
+}
+
+// expences and incomes of the account from CSV read by the profile
+func (s *ImportService) ImportCSV(idAccaunt, idProfile int64, data []byte, dryRun bool, updBy string) (*models.ImportResultJSON, error) {
+	profile, err := s.GetProfileById(idProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, skipped, err := readCSV(profile, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(idAccaunt, importStatement{
+		format:       models.ImportFormatCSV,
+		bankName:     profile.GetBankName(),
+		transactions: transactions,
+		skipped:      skipped,
+	}, dryRun, updBy)
+}
+
+// expences and incomes of the account from OFX, ledger balance makes remain
+// version; bank name of the statement is used when bankName is empty
+func (s *ImportService) ImportOFX(idAccaunt int64, bankName string, data []byte, dryRun bool, updBy string) (*models.ImportResultJSON, error) {
+	statement, err := readOFX(data)
+	if err != nil {
+		return nil, err
+	}
+	if bankName != "" {
+		statement.bankName = bankName
+	}
+	return s.apply(idAccaunt, *statement, dryRun, updBy)
+}
+
+// expences and incomes of the account from QIF, dateFormat is go layout of
+// dates, usual ones are tried when it is empty
+func (s *ImportService) ImportQIF(idAccaunt int64, bankName, dateFormat string, data []byte, dryRun bool, updBy string) (*models.ImportResultJSON, error) {
+	statement, err := readQIF(data, dateFormat)
+	if err != nil {
+		return nil, err
+	}
+	statement.bankName = bankName
+	return s.apply(idAccaunt, *statement, dryRun, updBy)
+}
+
+// expences and incomes of the account from camt.053, opening and closing
+// balances make remain versions
+func (s *ImportService) ImportCAMT(idAccaunt int64, bankName string, data []byte, dryRun bool, updBy string) (*models.ImportResultJSON, error) {
+	statement, err := readCAMT(data)
+	if err != nil {
+		return nil, err
+	}
+	statement.bankName = bankName
+	return s.apply(idAccaunt, *statement, dryRun, updBy)
+}
+
+// expences and incomes of the account from MT940, opening balance of first
+// message and closing balance of last one make remain versions
+func (s *ImportService) ImportMT940(idAccaunt int64, bankName string, data []byte, dryRun bool, updBy string) (*models.ImportResultJSON, error) {
+	statement, err := readMT940(data)
+	if err != nil {
+		return nil, err
+	}
+	statement.bankName = bankName
+	return s.apply(idAccaunt, *statement, dryRun, updBy)
+}
+
+// make expences of spent and incomes of received money and remain versions of
+// balances, transactions imported before are skipped, nothing is stored with
+// dry run
+func (s *ImportService) apply(idAccaunt int64, statement importStatement, dryRun bool, updBy string) (*models.ImportResultJSON, error) {
+	if _, err := NewAccountService().GetAccountById(idAccaunt); err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(idAccaunt); err != nil {
+		return nil, err
+	}
+
+	result := &models.ImportResultJSON{
+		DryRun:    dryRun,
+		Format:    statement.format,
+		IdAccaunt: idAccaunt,
+		Rows:      len(statement.transactions) + len(statement.skipped),
+		Expences:  []models.ExpenceJSON{},
+		Incomes:   []models.IncomeJSON{},
+		Skipped:   statement.skipped,
+
+		Superseded: []models.IncomeJSON{},
+	}
+	if result.Skipped == nil {
+		result.Skipped = []models.ImportSkippedJSON{}
+	}
+	imported := s.externalIds(idAccaunt)
+
+	var idExpence, idIncome int64
+	for _, expence := range debugging.Expences {
+		idExpence = max(idExpence, expence.GetIdExpence())
+	}
+	for _, income := range debugging.Incomes {
+		idIncome = max(idIncome, income.GetIdIncome())
+	}
+	actualTo := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	var expences []*models.Expence
+	var incomes []*models.Income
+	for _, transaction := range statement.transactions {
+		if transaction.externalId != "" {
+			if imported[transaction.externalId] {
+				result.Skipped = append(result.Skipped, models.ImportSkippedJSON{Line: transaction.line, Reason: "already imported"})
+				continue
+			}
+			imported[transaction.externalId] = true
+		}
+
+		if roundAmount(transaction.amount) == 0 {
+			result.Skipped = append(result.Skipped, models.ImportSkippedJSON{Line: transaction.line, Reason: "zero amount"})
+			continue
+		}
+
+		if transaction.amount < 0 {
+			idExpence++
+			expence := &models.Expence{}
+			expence.SetIdExpence(idExpence)
+			expence.SetIdAccaunt(idAccaunt)
+			expence.SetBankName(statement.bankName)
+			expence.SetExternalId(transaction.externalId)
+			expence.SetGroupExpence(transaction.category)
+			expence.SetTitleExpence(transaction.description)
+			expence.SetDescriptionExpence(transaction.reference)
+			expence.SetAmount(roundAmount(-transaction.amount))
+			expence.SetDate(transaction.date)
+			expence.SetUpdBy(updBy)
+			expence.SetDateActualFrom(transaction.date)
+			expence.SetDateActualTo(actualTo)
+			NewRuleService().AutoCategorize(expence)
+			NewCategorizerService().Suggest(expence)
+			if category, ok := categoriesOf(expence.GetIdAccaunt()).Resolve(expence.GetGroupExpence()); ok {
+				expence.SetIdCategory(category.GetIdCategory())
+			}
+			expences = append(expences, expence)
+			continue
+		}
+
+		idIncome++
+		income := &models.Income{}
+		income.SetIdIncome(idIncome)
+		income.SetIdAccaunt(idAccaunt)
+		income.SetAmount(roundAmount(transaction.amount))
+		income.SetTypeIncome(transaction.description)
+		income.SetIncomeMonthMonth(int8(transaction.date.Month()))
+		income.SetIncomeMonthDate(int8(transaction.date.Day()))
+		income.SetStatus(models.IncomeStatusReceived)
+		income.SetExternalId(transaction.externalId)
+		income.SetUpdBy(updBy)
+		income.SetDateActualFrom(transaction.date)
+		income.SetDateActualTo(actualTo)
+		incomes = append(incomes, income)
+	}
+
+	oldRemain := s.currentRemain(idAccaunt)
+	var remains []*models.Remain
+	for _, balance := range statement.balances {
+		previous := oldRemain
+		if len(remains) > 0 {
+			previous = remains[len(remains)-1]
+		}
+		remain, reason := s.balanceRemain(idAccaunt, balance, previous, updBy)
+		if reason != "" {
+			result.Skipped = append(result.Skipped, models.ImportSkippedJSON{Line: balance.line, Reason: reason})
+			continue
+		}
+		remains = append(remains, remain)
+	}
+
+	if !dryRun {
+		superseded, err := s.store(expences, incomes, remains)
+		if err != nil {
+			return nil, err
+		}
+		for _, placeholder := range superseded {
+			placeholderJSON, err := placeholder.ToJSON()
+			if err != nil {
+				return nil, err
+			}
+			result.Superseded = append(result.Superseded, *placeholderJSON)
+		}
+	}
+
+	result.Duplicates = []models.DuplicateJSON{}
+	if !dryRun && len(expences) > 0 {
+		for _, duplicate := range NewDuplicateService().WithScope(s.scope).Detect() {
+			duplicateJSON, _ := duplicate.ToJSON()
+			result.Duplicates = append(result.Duplicates, *duplicateJSON)
+		}
+	}
+
+	for _, expence := range expences {
+		expenceJSON, _ := expence.ToJSON()
+		result.Expences = append(result.Expences, *expenceJSON)
+	}
+	for _, income := range incomes {
+		incomeJSON, _ := income.ToJSON()
+		result.Incomes = append(result.Incomes, *incomeJSON)
+	}
+	result.Remains = []models.RemainJSON{}
+	for _, remain := range remains {
+		remainJSON, _ := remain.ToJSON()
+		result.Remains = append(result.Remains, *remainJSON)
+	}
+	if oldRemain != nil && len(remains) > 0 {
+		result.RemainBefore, _ = oldRemain.ToJSON()
+	}
+	return result, nil
+}
+
+// store records of a statement all at once, on any error records stored so far
+// are taken back and the store is left as it was. Returns placeholders
+// superseded by the incomes
+func (s *ImportService) store(expences []*models.Expence, incomes []*models.Income, remains []*models.Remain) ([]*models.Income, error) {
+	rollback := newImportRollback()
+	incomeService := NewIncomeService().WithScope(s.scope)
+
+	err := func() error {
+		expenceService := NewExpenceService().WithScope(s.scope)
+		for _, expence := range expences {
+			if err := expenceService.AddNewExpence(expence); err != nil {
+				return errors.New("expence " + strconv.FormatInt(expence.GetIdExpence(), 10) + ": " + err.Error())
+			}
+			rollback.expences = append(rollback.expences, expence.GetIdExpence())
+		}
+		for _, income := range incomes {
+			err := incomeService.AddNewIncome(income)
+			rollback.superseded = incomeService.Superseded()
+			if err != nil {
+				return errors.New("income " + strconv.FormatInt(income.GetIdIncome(), 10) + ": " + err.Error())
+			}
+		}
+		remainService := NewRemainService().WithScope(s.scope)
+		for _, remain := range remains {
+			oldRemain, err := remainService.AddRemainVersion(remain)
+			if err != nil {
+				return errors.New("remain " + strconv.FormatInt(remain.GetIdRemains(), 10) + ": " + err.Error())
+			}
+			if oldRemain != nil {
+				rollback.closed = append(rollback.closed, oldRemain)
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		rollback.undo()
+		return nil, err
+	}
+	return incomeService.Superseded(), nil
+}
+
+// store as it was before an import. Records are only appended, so undo cuts
+// the slices back and reverts the few changes made in place
+type importRollback struct {
+	lengths    []int
+	truncate   []func(int)
+	expences   []int64          // learned by classifiers
+	superseded []*models.Income // placeholders deleted by incomes
+	closed     []*models.Remain // remain versions closed by new ones
+	actualTo   map[*models.Remain]time.Time
+}
+
+func newImportRollback() *importRollback {
+	r := &importRollback{actualTo: make(map[*models.Remain]time.Time)}
+	r.track(len(debugging.Expences), func(n int) { debugging.Expences = debugging.Expences[:n] })
+	r.track(len(debugging.Incomes), func(n int) { debugging.Incomes = debugging.Incomes[:n] })
+	r.track(len(debugging.Remains), func(n int) { debugging.Remains = debugging.Remains[:n] })
+	r.track(len(debugging.Categories), func(n int) { debugging.Categories = debugging.Categories[:n] })
+	r.track(len(debugging.Tags), func(n int) { debugging.Tags = debugging.Tags[:n] })
+	r.track(len(debugging.Classifiers), func(n int) { debugging.Classifiers = debugging.Classifiers[:n] })
+	r.track(len(debugging.LedgerAccounts), func(n int) { debugging.LedgerAccounts = debugging.LedgerAccounts[:n] })
+	r.track(len(debugging.JournalEntries), func(n int) { debugging.JournalEntries = debugging.JournalEntries[:n] })
+	for _, remain := range debugging.Remains {
+		if !remain.IsDeleted() && remain.GetDateActualTo().Year() == 9999 {
+			r.actualTo[remain] = remain.GetDateActualTo()
+		}
+	}
+	return r
+}
+
+func (r *importRollback) track(length int, truncate func(int)) {
+	r.lengths = append(r.lengths, length)
+	r.truncate = append(r.truncate, truncate)
+}
+
+func (r *importRollback) undo() {
+	categorizer := NewCategorizerService()
+	for _, idExpence := range r.expences {
+		categorizer.Forget(idExpence)
+	}
+	for _, placeholder := range r.superseded {
+		placeholder.SetDeletedAt(time.Time{})
+		placeholder.SetDeletedBy("")
+	}
+	for _, remain := range r.closed {
+		if actualTo, ok := r.actualTo[remain]; ok {
+			remain.SetDateActualTo(actualTo)
+		}
+	}
+	for i, truncate := range r.truncate {
+		truncate(r.lengths[i])
+	}
+}
+
+// external ids of expences and incomes of the account, deleted ones may be
+// imported again
+func (s *ImportService) externalIds(idAccaunt int64) map[string]bool {
+	ids := make(map[string]bool)
+	for _, expence := range debugging.Expences {
+		if !expence.IsDeleted() && expence.GetIdAccaunt() == idAccaunt && expence.GetExternalId() != "" {
+			ids[expence.GetExternalId()] = true
+		}
+	}
+	for _, income := range debugging.Incomes {
+		if !income.IsDeleted() && income.GetIdAccaunt() == idAccaunt && income.GetExternalId() != "" {
+			ids[income.GetExternalId()] = true
+		}
+	}
+	return ids
+}
+
+// open version of the account remain, nil if account has no remain
+func (s *ImportService) currentRemain(idAccaunt int64) *models.Remain {
+	var current *models.Remain
+	for _, remain := range debugging.Remains {
+		if remain.IsDeleted() || remain.GetIdAccaunt() != idAccaunt || remain.GetDateActualTo().Year() != 9999 {
+			continue
+		}
+		if current == nil || remain.GetDateActualFrom().After(current.GetDateActualFrom()) {
+			current = remain
+		}
+	}
+	return current
+}
+
+// version of the account remain with statement balance following current one,
+// balance dated later than now starts now; reason is set when balance is not
+// newer than current version
+func (s *ImportService) balanceRemain(idAccaunt int64, balance importBalance, current *models.Remain, updBy string) (*models.Remain, string) {
+	if now := s.now(); balance.date.After(now) {
+		balance.date = now
+	}
+	if current != nil && !balance.date.After(current.GetDateActualFrom()) {
+		return nil, "balance is not newer than current remain"
+	}
+	if current != nil && sameAmount(balance.amount, current.GetAmount()) {
+		return nil, "balance equals current remain"
+	}
+
+	var maxId int64
+	for _, remain := range debugging.Remains {
+		maxId = max(maxId, remain.GetIdRemains())
+	}
+
+	remain := &models.Remain{}
+	remain.SetIdRemains(maxId + 1)
+	remain.SetIdAccaunt(idAccaunt)
+	remain.SetAmount(roundAmount(balance.amount))
+	remain.SetLastUpdateAmount(roundAmount(balance.amount))
+	remain.SetLastUpdateGroup("import")
+	remain.SetUpdBy(updBy)
+	remain.SetDateActualFrom(balance.date)
+	remain.SetDateActualTo(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))
+	if current != nil {
+		remain.SetIdRemains(current.GetIdRemains())
+		remain.SetLastUpdateAmount(roundAmount(balance.amount - current.GetAmount()))
+	}
+	return remain, ""
+}
+
+// opening balance with entries of statement must give its closing balance
+func reconcile(opening, closing float64, transactions []importTransaction) error {
+	sum := transactionsSum(transactions)
+	if !sameAmount(opening+sum, closing) {
+		return errors.New("entries do not sum to closing balance: " + formatAmount(opening) + " + " +
+			formatAmount(sum) + " != " + formatAmount(closing))
+	}
+	return nil
+}
+
+func transactionsSum(transactions []importTransaction) float64 {
+	var sum float64
+	for _, transaction := range transactions {
+		sum += transaction.amount
+	}
+	return roundAmount(sum)
+}
+
+// amounts are equal to cents
+func sameAmount(a, b float64) bool {
+	return abs(a-b) < 0.005
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// id of transaction made of its fields for statements without ids, same
+// purchases of a day differ by their number in the file
+func transactionId(format string, occurrences map[string]int, transaction importTransaction, number string) string {
+	key := strings.Join([]string{
+		transaction.date.Format("2006-01-02"),
+		formatAmount(transaction.amount),
+		transaction.description,
+		transaction.reference,
+		number,
+	}, "|")
+	occurrences[key]++
+	sum := sha1.Sum([]byte(key + "|" + strconv.Itoa(occurrences[key])))
+	return format + ":" + hex.EncodeToString(sum[:8])
+}
+
+// defaults are set, columns needed by sign convention are checked
+func (s *ImportService) validateProfile(profile *models.ImportProfile) error {
+	if strings.TrimSpace(profile.GetName()) == "" {
+		return errors.New("name is required")
+	}
+	if _, err := NewAccountService().GetAccountById(profile.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	if profile.GetDelimiter() == "" {
+		profile.SetDelimiter(",")
+	}
+	if len([]rune(profile.GetDelimiter())) != 1 {
+		return errors.New("delimiter must be one character")
+	}
+	if profile.GetSkipRows() < 0 {
+		return errors.New("skip_rows can not be negative")
+	}
+	if profile.GetDateFormat() == "" {
+		profile.SetDateFormat("2006-01-02")
+	}
+
+	switch profile.GetDecimalSeparator() {
+	case "":
+		profile.SetDecimalSeparator(".")
+	case ".", ",":
+	default:
+		return errors.New("decimal_separator must be \".\" or \",\"")
+	}
+
+	switch strings.ToLower(profile.GetEncoding()) {
+	case "", "utf8", models.ImportEncodingUTF8:
+		profile.SetEncoding(models.ImportEncodingUTF8)
+	case "windows-1251", models.ImportEncodingCP1251:
+		profile.SetEncoding(models.ImportEncodingCP1251)
+	default:
+		return errors.New("encoding must be utf-8 or cp1251")
+	}
+
+	required := []string{models.ImportFieldDate, models.ImportFieldAmount}
+	switch profile.GetSignConvention() {
+	case "":
+		profile.SetSignConvention(models.ImportSignNegativeExpence)
+	case models.ImportSignNegativeExpence, models.ImportSignPositiveExpence:
+	case models.ImportSignDebitCredit:
+		required = []string{models.ImportFieldDate, models.ImportFieldDebit, models.ImportFieldCredit}
+	default:
+		return errors.New("invalid sign_convention")
+	}
+
+	columns := make(map[string]string)
+	for field, column := range profile.GetColumns() {
+		switch field {
+		case models.ImportFieldDate, models.ImportFieldAmount, models.ImportFieldDebit, models.ImportFieldCredit,
+			models.ImportFieldDescription, models.ImportFieldCategory, models.ImportFieldReference:
+		default:
+			return errors.New("unknown field \"" + field + "\" in columns")
+		}
+		if column = strings.TrimSpace(column); column != "" {
+			columns[field] = column
+		}
+	}
+	for _, field := range required {
+		if columns[field] == "" {
+			return errors.New("column of \"" + field + "\" is required")
+		}
+	}
+	if !profile.GetHasHeader() {
+		for field, column := range columns {
+			if number, err := strconv.Atoi(column); err != nil || number < 1 {
+				return errors.New("column of \"" + field + "\" must be a number from 1 without header")
+			}
+		}
+	}
+	profile.SetColumns(columns)
+	return nil
+}
+
+// amount written with the decimal separator, spaces, thousands separators and
+// currency signs aside, (12.50) is negative
+func parseImportAmount(value, decimalSeparator string) (float64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '+':
+			b.WriteRune(r)
+		case string(r) == decimalSeparator:
+			b.WriteByte('.')
+		}
+	}
+	if value == "" {
+		return 0, errors.New("empty amount")
+	}
+
+	amount, err := strconv.ParseFloat(b.String(), 64)
+	if err != nil {
+		return 0, errors.New("invalid amount \"" + value + "\"")
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// date in layout, the value may go on with time the layout has not
+func parseImportDate(value, layout string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+		return date, nil
+	}
+	if len(value) > len(layout) {
+		if date, err := time.ParseInLocation(layout, value[:len(layout)], time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid date \"" + value + "\"")
+}
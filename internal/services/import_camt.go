@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// camt.053 document, elements are matched in any namespace version
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Balances []camtBalance `xml:"Bal"`
+	Summary  struct {
+		Count     string `xml:"TtlNtries>NbOfNtries"`
+		NetAmount string `xml:"TtlNtries>TtlNetNtry>Amt"`
+		NetSign   string `xml:"TtlNtries>TtlNetNtry>CdtDbtInd"`
+	} `xml:"TxsSummry"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code     string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount   string `xml:"Amt"`
+	Sign     string `xml:"CdtDbtInd"`
+	Date     string `xml:"Dt>Dt"`
+	DateTime string `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	Reference         string `xml:"NtryRef"`
+	Amount            string `xml:"Amt"`
+	Sign              string `xml:"CdtDbtInd"`
+	BookingDate       string `xml:"BookgDt>Dt"`
+	BookingDateTime   string `xml:"BookgDt>DtTm"`
+	ServicerReference string `xml:"AcctSvcrRef"`
+	Info              string `xml:"AddtlNtryInf"`
+	Details           []struct {
+		EndToEndId  string   `xml:"Refs>EndToEndId"`
+		Remittance  []string `xml:"RmtInf>Ustrd"`
+		Creditor    string   `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPty string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+		Debtor      string   `xml:"RltdPties>Dbtr>Nm"`
+		DebtorPty   string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	} `xml:"NtryDtls>TxDtls"`
+	line int
+}
+
+// entries and balances of camt.053, the statement is rejected when its entries
+// do not match its totals or do not lead from opening to closing balance
+func readCAMT(data []byte) (*importStatement, error) {
+	var document camtDocument
+	if err := camtDecoder(data).Decode(&document); err != nil {
+		return nil, errors.New("invalid camt.053: " + err.Error())
+	}
+	switch len(document.Statements) {
+	case 0:
+		return nil, errors.New("invalid camt.053: no statement")
+	case 1:
+	default:
+		return nil, errors.New("statement of one account is expected")
+	}
+	camt := document.Statements[0]
+	lines := camtLines(data, "Ntry")
+	balanceLines := camtLines(data, "Bal")
+
+	statement := &importStatement{format: models.ImportFormatCAMT}
+	occurrences := make(map[string]int)
+	for i, entry := range camt.Entries {
+		if i < len(lines) {
+			entry.line = lines[i]
+		}
+		transaction, err := camtTransaction(entry)
+		if err != nil {
+			statement.skipped = append(statement.skipped, models.ImportSkippedJSON{Line: entry.line, Reason: err.Error()})
+			continue
+		}
+		// entries without any reference are told apart by their contents
+		if transaction.externalId == "" {
+			transaction.externalId = transactionId(models.ImportFormatCAMT, occurrences, transaction, "")
+		}
+		statement.transactions = append(statement.transactions, transaction)
+	}
+
+	var opening, closing *importBalance
+	for i, camtBalance := range camt.Balances {
+		balance, err := camtStatementBalance(camtBalance)
+		if err != nil {
+			return nil, err
+		}
+		if i < len(balanceLines) {
+			balance.line = balanceLines[i]
+		}
+		// closing balances are at end of their day
+		if camtBalance.DateTime == "" && camtBalance.Code != "OPBD" {
+			balance.date = balance.date.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		switch camtBalance.Code {
+		case "OPBD", "PRCD":
+			opening = &balance
+		case "CLBD":
+			closing = &balance
+		}
+	}
+	if opening == nil || closing == nil {
+		return nil, errors.New("opening and closing booked balances are required")
+	}
+	statement.balances = []importBalance{*opening, *closing}
+
+	if count := camt.Summary.Count; count != "" {
+		if n, err := strconv.Atoi(count); err != nil || n != len(camt.Entries) {
+			return nil, errors.New("statement has " + strconv.Itoa(len(camt.Entries)) + " entries, its summary says " + count)
+		}
+	}
+	if camt.Summary.NetAmount != "" {
+		net, err := camtAmount(camt.Summary.NetAmount, camt.Summary.NetSign)
+		if err != nil {
+			return nil, errors.New("invalid summary: " + err.Error())
+		}
+		if sum := transactionsSum(statement.transactions); !sameAmount(sum, net) {
+			return nil, errors.New("entries sum to " + formatAmount(sum) + ", summary says " + formatAmount(net))
+		}
+	}
+	if err := reconcile(opening.amount, closing.amount, statement.transactions); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func camtTransaction(entry camtEntry) (importTransaction, error) {
+	transaction := importTransaction{line: entry.line, description: entry.Info}
+
+	amount, err := camtAmount(entry.Amount, entry.Sign)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.amount = amount
+
+	date, err := camtDate(entry.BookingDate, entry.BookingDateTime)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.date = date
+
+	transaction.externalId = entry.ServicerReference
+	if transaction.externalId == "" {
+		transaction.externalId = entry.Reference
+	}
+	if len(entry.Details) > 0 {
+		details := entry.Details[0]
+		// the other side of payment names it
+		party := details.Creditor + details.CreditorPty
+		if amount > 0 {
+			party = details.Debtor + details.DebtorPty
+		}
+		if party != "" {
+			transaction.description, transaction.reference = party, entry.Info
+		}
+		if remittance := strings.Join(details.Remittance, " "); remittance != "" {
+			transaction.reference = remittance
+		}
+		if transaction.externalId == "" && details.EndToEndId != "NOTPROVIDED" {
+			transaction.externalId = details.EndToEndId
+		}
+	}
+	if transaction.description == "" {
+		transaction.description, transaction.reference = transaction.reference, ""
+	}
+	return transaction, nil
+}
+
+func camtStatementBalance(balance camtBalance) (importBalance, error) {
+	amount, err := camtAmount(balance.Amount, balance.Sign)
+	if err != nil {
+		return importBalance{}, errors.New("invalid balance " + balance.Code + ": " + err.Error())
+	}
+	date, err := camtDate(balance.Date, balance.DateTime)
+	if err != nil {
+		return importBalance{}, errors.New("invalid balance " + balance.Code + ": " + err.Error())
+	}
+	return importBalance{amount: amount, date: date}, nil
+}
+
+// amount is always positive in camt, debit makes it spent
+func camtAmount(value, sign string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, errors.New("invalid amount \"" + value + "\"")
+	}
+	switch sign {
+	case "CRDT":
+		return amount, nil
+	case "DBIT":
+		return -amount, nil
+	}
+	return 0, errors.New("invalid credit debit indicator \"" + sign + "\"")
+}
+
+func camtDate(date, dateTime string) (time.Time, error) {
+	if dateTime != "" {
+		if parsed, err := time.Parse(time.RFC3339, dateTime); err == nil {
+			return parsed, nil
+		}
+		return parseImportDate(dateTime, "2006-01-02T15:04:05")
+	}
+	return parseImportDate(date, "2006-01-02")
+}
+
+// decoder of utf-8 or windows-1251 XML
+func camtDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		encoding := models.ImportEncodingUTF8
+		switch strings.ToLower(charset) {
+		case "utf-8", "utf8":
+		case "windows-1251", "cp1251":
+			encoding = models.ImportEncodingCP1251
+		default:
+			return nil, errors.New("unsupported encoding " + charset)
+		}
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(decodeImport(data, encoding)), nil
+	}
+	return decoder
+}
+
+// line numbers of elements with the name in order
+func camtLines(data []byte, name string) []int {
+	var lines []int
+	decoder := camtDecoder(data)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return lines
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			lines = append(lines, bytes.Count(data[:offset], []byte("\n"))+1)
+		}
+	}
+}
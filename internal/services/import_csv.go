@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// transactions of CSV read by the profile, lines that can not be read are skipped
+func readCSV(profile *models.ImportProfile, data []byte) ([]importTransaction, []models.ImportSkippedJSON, error) {
+	text := decodeImport(data, profile.GetEncoding())
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma, _ = utf8.DecodeRuneInString(profile.GetDelimiter())
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.New("invalid CSV: " + err.Error())
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) <= profile.GetSkipRows() {
+		return nil, nil, errors.New("no rows after skipped ones")
+	}
+	records, lines = records[profile.GetSkipRows():], lines[profile.GetSkipRows():]
+
+	// column number of every field
+	index := make(map[string]int)
+	if profile.GetHasHeader() {
+		header := make(map[string]int)
+		for i, name := range records[0] {
+			header[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		for field, column := range profile.GetColumns() {
+			i, ok := header[strings.ToLower(column)]
+			if !ok {
+				return nil, nil, errors.New("column \"" + column + "\" is not in header")
+			}
+			index[field] = i
+		}
+		records, lines = records[1:], lines[1:]
+	} else {
+		for field, column := range profile.GetColumns() {
+			number, _ := strconv.Atoi(column)
+			index[field] = number - 1
+		}
+	}
+
+	var transactions []importTransaction
+	var skipped []models.ImportSkippedJSON
+	for i, record := range records {
+		if isBlankRecord(record) {
+			continue
+		}
+		value := func(field string) string {
+			i, ok := index[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		transaction, err := csvTransaction(profile, value)
+		if err != nil {
+			skipped = append(skipped, models.ImportSkippedJSON{Line: lines[i], Reason: err.Error()})
+			continue
+		}
+		transaction.line = lines[i]
+		transactions = append(transactions, transaction)
+	}
+	return transactions, skipped, nil
+}
+
+func csvTransaction(profile *models.ImportProfile, value func(field string) string) (importTransaction, error) {
+	transaction := importTransaction{
+		description: value(models.ImportFieldDescription),
+		category:    value(models.ImportFieldCategory),
+		reference:   value(models.ImportFieldReference),
+	}
+
+	date, err := parseImportDate(value(models.ImportFieldDate), profile.GetDateFormat())
+	if err != nil {
+		return transaction, err
+	}
+	transaction.date = date
+
+	switch profile.GetSignConvention() {
+	case models.ImportSignDebitCredit:
+		var debit, credit float64
+		if v := value(models.ImportFieldDebit); v != "" {
+			if debit, err = parseImportAmount(v, profile.GetDecimalSeparator()); err != nil {
+				return transaction, err
+			}
+		}
+		if v := value(models.ImportFieldCredit); v != "" {
+			if credit, err = parseImportAmount(v, profile.GetDecimalSeparator()); err != nil {
+				return transaction, err
+			}
+		}
+		// debit may be written with minus too
+		transaction.amount = abs(credit) - abs(debit)
+	default:
+		amount, err := parseImportAmount(value(models.ImportFieldAmount), profile.GetDecimalSeparator())
+		if err != nil {
+			return transaction, err
+		}
+		if profile.GetSignConvention() == models.ImportSignPositiveExpence {
+			amount = -amount
+		}
+		transaction.amount = amount
+	}
+
+	if transaction.amount == 0 {
+		return transaction, errors.New("zero amount")
+	}
+	return transaction, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(value float64) float64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// text of statement in the encoding, byte order mark is dropped
+func decodeImport(data []byte, encoding string) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if encoding != models.ImportEncodingCP1251 {
+		return string(data)
+	}
+
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c >= 0xC0:
+			b.WriteRune(rune(c-0xC0) + 'А')
+		default:
+			b.WriteRune(cp1251[c-0x80])
+		}
+	}
+	return b.String()
+}
+
+// windows-1251 from 0x80 to 0xBF, letters А-я follow in order
+var cp1251 = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', '�', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	' ', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '­', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
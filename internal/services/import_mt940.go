@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/helltale/api-finances/internal/models"
+)
+
+var (
+	mt940Tag       = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+	mt940Balance   = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+(?:,\d*)?)$`)
+	mt940Line      = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+(?:,\d*)?)([A-Z][A-Z0-9]{3})(.*)$`)
+	mt940Structure = regexp.MustCompile(`^\d{3}\?`)
+)
+
+// field of MT940 with lines it goes on
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+// one message of MT940, a statement may be sent in several of them
+type mt940Message struct {
+	opening      *importBalance
+	closing      *importBalance
+	transactions []importTransaction
+}
+
+// statement lines and balances of MT940, every message is rejected when its
+// lines do not lead from opening to closing balance
+func readMT940(data []byte) (*importStatement, error) {
+	encoding := models.ImportEncodingUTF8
+	if !utf8.Valid(data) {
+		encoding = models.ImportEncodingCP1251
+	}
+	fields := mt940Fields(decodeImport(data, encoding))
+
+	statement := &importStatement{format: models.ImportFormatMT940}
+	var messages []*mt940Message
+	var message *mt940Message
+	var account string
+	var transaction *importTransaction
+	occurrences := make(map[string]int)
+	for _, field := range fields {
+		if field.tag != "86" {
+			transaction = nil
+		}
+		if field.tag == "20" || message == nil {
+			message = &mt940Message{}
+			messages = append(messages, message)
+		}
+
+		switch field.tag {
+		case "25":
+			if account != "" && account != field.value {
+				return nil, errors.New("statement of one account is expected")
+			}
+			account = field.value
+		case "60F", "60M", "62F", "62M":
+			balance, err := mt940StatementBalance(field)
+			if err != nil {
+				return nil, err
+			}
+			if field.tag[:2] == "60" {
+				message.opening = &balance
+				break
+			}
+			// closing balance is at end of its day
+			balance.date = balance.date.AddDate(0, 0, 1).Add(-time.Second)
+			message.closing = &balance
+		case "61":
+			parsed, err := mt940Transaction(field)
+			if err != nil {
+				statement.skipped = append(statement.skipped, models.ImportSkippedJSON{Line: field.line, Reason: err.Error()})
+				break
+			}
+			message.transactions = append(message.transactions, parsed)
+			transaction = &message.transactions[len(message.transactions)-1]
+		case "86":
+			if transaction != nil {
+				transaction.description, transaction.reference = mt940Information(field.value)
+				if transaction.description == "" {
+					transaction.description, transaction.reference = transaction.reference, ""
+				}
+			}
+		}
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("invalid MT940: no statement")
+	}
+
+	for i, message := range messages {
+		if message.opening == nil || message.closing == nil {
+			return nil, errors.New("message " + strconv.Itoa(i+1) + ": opening and closing balances are required")
+		}
+		if i > 0 && !sameAmount(messages[i-1].closing.amount, message.opening.amount) {
+			return nil, errors.New("message " + strconv.Itoa(i+1) + ": opening balance " + formatAmount(message.opening.amount) +
+				" differs from previous closing " + formatAmount(messages[i-1].closing.amount))
+		}
+		if err := reconcile(message.opening.amount, message.closing.amount, message.transactions); err != nil {
+			return nil, errors.New("message " + strconv.Itoa(i+1) + ": " + err.Error())
+		}
+
+		for _, transaction := range message.transactions {
+			if transaction.externalId == "" {
+				transaction.externalId = transactionId(models.ImportFormatMT940, occurrences, transaction, "")
+			}
+			statement.transactions = append(statement.transactions, transaction)
+		}
+	}
+	statement.balances = []importBalance{*messages[0].opening, *messages[len(messages)-1].closing}
+	return statement, nil
+}
+
+// fields of messages, SWIFT blocks around text of message are dropped
+func mt940Fields(text string) []mt940Field {
+	var fields []mt940Field
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "{") {
+			start := strings.Index(line, "{4:")
+			if start < 0 {
+				continue
+			}
+			line = line[start+3:]
+		}
+		if strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		if match := mt940Tag.FindStringSubmatch(line); match != nil {
+			fields = append(fields, mt940Field{tag: match[1], value: strings.TrimSpace(line[len(match[0]):]), line: i + 1})
+			continue
+		}
+		if len(fields) > 0 && strings.TrimSpace(line) != "" {
+			fields[len(fields)-1].value += "\n" + strings.TrimSpace(line)
+		}
+	}
+	return fields
+}
+
+// balance as mark, date YYMMDD, currency and amount
+func mt940StatementBalance(field mt940Field) (importBalance, error) {
+	match := mt940Balance.FindStringSubmatch(field.value)
+	if match == nil {
+		return importBalance{}, errors.New("invalid balance :" + field.tag + ": \"" + field.value + "\"")
+	}
+	date, err := time.ParseInLocation("060102", match[2], time.Local)
+	if err != nil {
+		return importBalance{}, errors.New("invalid balance :" + field.tag + ": \"" + field.value + "\"")
+	}
+	amount, err := parseImportAmount(match[4], ",")
+	if err != nil {
+		return importBalance{}, err
+	}
+	if match[1] == "D" {
+		amount = -amount
+	}
+	return importBalance{line: field.line, amount: amount, date: date}, nil
+}
+
+// statement line: value date, entry date, mark, amount, type, customer and
+// bank references
+func mt940Transaction(field mt940Field) (importTransaction, error) {
+	lines := strings.SplitN(field.value, "\n", 2)
+	match := mt940Line.FindStringSubmatch(lines[0])
+	if match == nil {
+		return importTransaction{}, errors.New("invalid statement line \"" + lines[0] + "\"")
+	}
+
+	transaction := importTransaction{line: field.line}
+	date, err := time.ParseInLocation("060102", match[1], time.Local)
+	if err != nil {
+		return transaction, errors.New("invalid date \"" + match[1] + "\"")
+	}
+	transaction.date = date
+
+	if transaction.amount, err = parseImportAmount(match[5], ","); err != nil {
+		return transaction, err
+	}
+	// reversal of credit is spent money, reversal of debit is received
+	if match[3] == "D" || match[3] == "RC" {
+		transaction.amount = -transaction.amount
+	}
+	if transaction.amount == 0 {
+		return transaction, errors.New("zero amount")
+	}
+
+	customer, bank, _ := strings.Cut(match[7], "//")
+	transaction.externalId = strings.TrimSpace(bank)
+	if customer = strings.TrimSpace(customer); transaction.externalId == "" && customer != "NONREF" {
+		transaction.externalId = customer
+	}
+	if len(lines) > 1 {
+		transaction.reference = strings.ReplaceAll(lines[1], "\n", " ")
+	}
+	return transaction, nil
+}
+
+// name of the other side and purpose of payment from :86:, structured ?NN
+// subfields are read when present
+func mt940Information(value string) (string, string) {
+	if !mt940Structure.MatchString(value) {
+		return "", strings.ReplaceAll(value, "\n", " ")
+	}
+	value = strings.ReplaceAll(value, "\n", "")
+
+	var name, purpose strings.Builder
+	for _, subfield := range strings.Split(value, "?")[1:] {
+		if len(subfield) < 2 {
+			continue
+		}
+		code, _ := strconv.Atoi(subfield[:2])
+		switch {
+		case code >= 20 && code <= 29, code >= 60 && code <= 63:
+			purpose.WriteString(subfield[2:])
+		case code == 32, code == 33:
+			name.WriteString(subfield[2:])
+		}
+	}
+	return strings.TrimSpace(name.String()), strings.TrimSpace(purpose.String())
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/models"
+)
+
+var (
+	ofxTransaction = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxLedger      = regexp.MustCompile(`(?is)<LEDGERBAL>(.*?)</LEDGERBAL>`)
+	ofxTranList    = regexp.MustCompile(`(?i)<BANKTRANLIST>`)
+	ofxCharset     = regexp.MustCompile(`(?i)(CHARSET:\s*1251|encoding="windows-1251")`)
+)
+
+// transactions and ledger balance of OFX, both SGML of 1.x and XML of 2.x are
+// read, elements of SGML have no closing tags
+func readOFX(data []byte) (*importStatement, error) {
+	encoding := models.ImportEncodingUTF8
+	if ofxCharset.Match(data[:min(len(data), 1024)]) {
+		encoding = models.ImportEncodingCP1251
+	}
+	text := decodeImport(data, encoding)
+	if !strings.Contains(strings.ToUpper(text), "<OFX>") {
+		return nil, errors.New("invalid OFX: no <OFX> element")
+	}
+	if len(ofxTranList.FindAllStringIndex(text, -1)) > 1 {
+		return nil, errors.New("statement of one account is expected")
+	}
+
+	statement := &importStatement{format: models.ImportFormatOFX, bankName: ofxElement(text, "ORG")}
+	for _, match := range ofxTransaction.FindAllStringSubmatchIndex(text, -1) {
+		line := strings.Count(text[:match[0]], "\n") + 1
+		transaction, err := ofxStatementTransaction(text[match[2]:match[3]])
+		if err != nil {
+			statement.skipped = append(statement.skipped, models.ImportSkippedJSON{Line: line, Reason: err.Error()})
+			continue
+		}
+		transaction.line = line
+		statement.transactions = append(statement.transactions, transaction)
+	}
+
+	if match := ofxLedger.FindStringSubmatchIndex(text); match != nil {
+		block := text[match[2]:match[3]]
+		balance := importBalance{line: strings.Count(text[:match[0]], "\n") + 1}
+		var err error
+		if balance.amount, err = statementAmount(ofxElement(block, "BALAMT")); err != nil {
+			return nil, errors.New("invalid ledger balance: " + err.Error())
+		}
+		if balance.date, err = parseOFXDate(ofxElement(block, "DTASOF")); err != nil {
+			return nil, errors.New("invalid ledger balance: " + err.Error())
+		}
+		statement.balances = append(statement.balances, balance)
+	}
+	return statement, nil
+}
+
+func ofxStatementTransaction(block string) (importTransaction, error) {
+	transaction := importTransaction{
+		description: ofxElement(block, "NAME"),
+		reference:   ofxElement(block, "MEMO"),
+		externalId:  ofxElement(block, "FITID"),
+	}
+	if transaction.description == "" {
+		transaction.description = ofxElement(block, "PAYEE")
+	}
+	if transaction.description == "" {
+		transaction.description, transaction.reference = transaction.reference, ""
+	}
+
+	date, err := parseOFXDate(ofxElement(block, "DTPOSTED"))
+	if err != nil {
+		return transaction, err
+	}
+	transaction.date = date
+
+	if transaction.amount, err = statementAmount(ofxElement(block, "TRNAMT")); err != nil {
+		return transaction, err
+	}
+	if transaction.amount == 0 {
+		return transaction, errors.New("zero amount")
+	}
+	return transaction, nil
+}
+
+// value of element, SGML value ends with line or next tag, XML one with closing tag
+func ofxElement(block, tag string) string {
+	re := regexp.MustCompile(`(?i)<` + tag + `>([^<\r\n]*)`)
+	match := re.FindStringSubmatch(block)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(match[1]))
+}
+
+// OFX date YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]], without offset it is local
+func parseOFXDate(value string) (time.Time, error) {
+	digits := value
+	if i := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		digits = value[:i]
+	}
+
+	var layout string
+	switch len(digits) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	case 14:
+		layout = "20060102150405"
+	default:
+		return time.Time{}, errors.New("invalid date \"" + value + "\"")
+	}
+
+	location := time.Local
+	if start := strings.Index(value, "["); start >= 0 {
+		offset := strings.TrimSuffix(value[start+1:], "]")
+		name := ""
+		if i := strings.Index(offset, ":"); i >= 0 {
+			offset, name = offset[:i], offset[i+1:]
+		}
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return time.Time{}, errors.New("invalid date \"" + value + "\"")
+		}
+		location = time.FixedZone(name, int(hours*3600))
+	}
+
+	date, err := time.ParseInLocation(layout, digits, location)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date \"" + value + "\"")
+	}
+	return date, nil
+}
+
+// amount with "." or, if there is no dot, "," as decimal separator
+func statementAmount(value string) (float64, error) {
+	separator := "."
+	if !strings.Contains(value, ".") && strings.Contains(value, ",") {
+		separator = ","
+	}
+	return parseImportAmount(value, separator)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// date layouts tried when QIF date format is not given, US order goes first as
+// in Quicken
+var qifDateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02", "02.01.2006", "02.01.06"}
+
+// QIF record being read, fields by their letter
+type qifRecord struct {
+	line   int
+	fields map[byte]string
+}
+
+// transactions of bank, cash and card sections of QIF, other sections are
+// ignored; QIF has no transaction ids, so id is made of the transaction fields
+func readQIF(data []byte, dateFormat string) (*importStatement, error) {
+	encoding := models.ImportEncodingUTF8
+	if !utf8.Valid(data) {
+		encoding = models.ImportEncodingCP1251
+	}
+	text := decodeImport(data, encoding)
+
+	var records []qifRecord
+	ignored := false
+	record := qifRecord{fields: make(map[byte]string)}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch {
+		case line[0] == '!':
+			header := strings.ToLower(strings.TrimSpace(line))
+			if header == "!account" {
+				// accounts list goes till next type
+				ignored = true
+			}
+			if strings.HasPrefix(header, "!type:") {
+				switch strings.TrimSpace(header[len("!type:"):]) {
+				case "bank", "cash", "ccard", "oth a", "oth l":
+					ignored = false
+				default:
+					ignored = true
+				}
+			}
+		case line[0] == '^':
+			if !ignored && len(record.fields) > 0 {
+				records = append(records, record)
+			}
+			record = qifRecord{fields: make(map[byte]string)}
+		default:
+			if len(record.fields) == 0 {
+				record.line = i + 1
+			}
+			// first value wins, split lines S, E, $ come many times
+			if _, ok := record.fields[line[0]]; !ok {
+				record.fields[line[0]] = strings.TrimSpace(line[1:])
+			}
+		}
+	}
+	if !ignored && len(record.fields) > 0 {
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("invalid QIF: no transactions")
+	}
+
+	statement := &importStatement{format: models.ImportFormatQIF}
+	occurrences := make(map[string]int)
+	for _, record := range records {
+		transaction, err := qifTransaction(record, dateFormat)
+		if err != nil {
+			statement.skipped = append(statement.skipped, models.ImportSkippedJSON{Line: record.line, Reason: err.Error()})
+			continue
+		}
+
+		transaction.externalId = transactionId(models.ImportFormatQIF, occurrences, transaction, record.fields['N'])
+
+		transaction.line = record.line
+		statement.transactions = append(statement.transactions, transaction)
+	}
+	return statement, nil
+}
+
+func qifTransaction(record qifRecord, dateFormat string) (importTransaction, error) {
+	transaction := importTransaction{
+		description: record.fields['P'],
+		reference:   record.fields['M'],
+	}
+	if transaction.description == "" {
+		transaction.description, transaction.reference = transaction.reference, ""
+	}
+
+	// category may go with class after "/", subcategory after ":", transfers
+	// to accounts are in brackets
+	category := record.fields['L']
+	if i := strings.Index(category, "/"); i >= 0 {
+		category = category[:i]
+	}
+	if i := strings.LastIndex(category, ":"); i >= 0 {
+		category = category[i+1:]
+	}
+	if !strings.HasPrefix(category, "[") {
+		transaction.category = category
+	}
+
+	date, err := parseQIFDate(record.fields['D'], dateFormat)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.date = date
+
+	amount := record.fields['T']
+	if amount == "" {
+		amount = record.fields['U']
+	}
+	if transaction.amount, err = statementAmount(amount); err != nil {
+		return transaction, err
+	}
+	if transaction.amount == 0 {
+		return transaction, errors.New("zero amount")
+	}
+	return transaction, nil
+}
+
+// QIF date in layout, or in one of usual ones; 1/31'24 and 1/ 2/24 of Quicken
+// are read too
+func parseQIFDate(value, layout string) (time.Time, error) {
+	value = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(value), " ", ""), "'", "/")
+	if layout != "" {
+		return parseImportDate(value, layout)
+	}
+	for _, layout := range qifDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid date \"" + value + "\"")
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+func resetImportStore() {
+	setupPolicyAccounts()
+	debugging.Expences = nil
+	debugging.Incomes = nil
+	debugging.IncomesExpected = nil
+	debugging.Remains = nil
+	debugging.Rules = nil
+	debugging.Classifiers = nil
+	debugging.Duplicates = nil
+	resetLedger()
+}
+
+// two equal coffees of a day and a refund, no entry carries a reference
+const camtWithoutReferences = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2024-05-01</Dt></Dt></Bal>
+<Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">80.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2024-05-02</Dt></Dt></Bal>
+<Ntry><Amt Ccy="EUR">15.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2024-05-02</Dt></BookgDt><AddtlNtryInf>Coffee</AddtlNtryInf></Ntry>
+<Ntry><Amt Ccy="EUR">15.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2024-05-02</Dt></BookgDt><AddtlNtryInf>Coffee</AddtlNtryInf></Ntry>
+<Ntry><Amt Ccy="EUR">10.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><BookgDt><Dt>2024-05-02</Dt></BookgDt><AddtlNtryInf>Refund</AddtlNtryInf>
+<NtryDtls><TxDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs></TxDtls></NtryDtls></Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>`
+
+func TestCAMTWithoutReferencesImportedOnce(t *testing.T) {
+	resetImportStore()
+
+	first, err := NewImportService().ImportCAMT(1, "Bank", []byte(camtWithoutReferences), false, "tester")
+	if err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	if len(first.Expences) != 2 || len(first.Incomes) != 1 {
+		t.Fatalf("first import = %d expences %d incomes, want 2 and 1", len(first.Expences), len(first.Incomes))
+	}
+	if first.Expences[0].ExternalId == "" || first.Expences[0].ExternalId == first.Expences[1].ExternalId || first.Incomes[0].ExternalId == "" {
+		t.Errorf("external ids = %q %q %q, want distinct ids made of contents",
+			first.Expences[0].ExternalId, first.Expences[1].ExternalId, first.Incomes[0].ExternalId)
+	}
+
+	second, err := NewImportService().ImportCAMT(1, "Bank", []byte(camtWithoutReferences), false, "tester")
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if len(second.Expences) != 0 || len(second.Incomes) != 0 {
+		t.Errorf("second import = %d expences %d incomes, want nothing new", len(second.Expences), len(second.Incomes))
+	}
+	already := 0
+	for _, skipped := range second.Skipped {
+		if skipped.Reason == "already imported" {
+			already++
+		}
+	}
+	if already != 3 {
+		t.Errorf("already imported = %d, want 3", already)
+	}
+	if len(debugging.Expences) != 2 || len(debugging.Incomes) != 1 {
+		t.Errorf("stored = %d expences %d incomes, want 2 and 1", len(debugging.Expences), len(debugging.Incomes))
+	}
+}
+
+func TestFailedImportLeavesStoreUnchanged(t *testing.T) {
+	resetImportStore()
+	actualTo := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+	current := &models.Remain{}
+	current.SetIdRemains(1)
+	current.SetIdAccaunt(1)
+	current.SetAmount(100)
+	current.SetDateActualFrom(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	current.SetDateActualTo(actualTo)
+	debugging.Remains = []*models.Remain{current}
+
+	expence := &models.Expence{}
+	expence.SetIdExpence(1)
+	expence.SetIdAccaunt(1)
+	expence.SetGroupExpence("food")
+	expence.SetTitleExpence("Coffee")
+	expence.SetAmount(15)
+	expence.SetDate(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	expence.SetDateActualTo(actualTo)
+	income := &models.Income{}
+	income.SetIdIncome(1)
+	income.SetIdAccaunt(1)
+	income.SetAmount(10)
+	income.SetTypeIncome("Refund")
+	income.SetStatus(models.IncomeStatusReceived)
+	income.SetDateActualFrom(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	income.SetDateActualTo(actualTo)
+
+	// second version starts before the first one, the last step fails
+	remains := make([]*models.Remain, 2)
+	for i, from := range []time.Time{time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)} {
+		remains[i] = &models.Remain{}
+		remains[i].SetIdRemains(1)
+		remains[i].SetIdAccaunt(1)
+		remains[i].SetAmount(95)
+		remains[i].SetDateActualFrom(from)
+		remains[i].SetDateActualTo(actualTo)
+	}
+
+	if _, err := NewImportService().store([]*models.Expence{expence}, []*models.Income{income}, remains); err == nil {
+		t.Fatal("store: want error of the second remain version")
+	}
+	if len(debugging.Expences) != 0 || len(debugging.Incomes) != 0 || len(debugging.Remains) != 1 {
+		t.Errorf("stored = %d expences %d incomes %d remains, want 0, 0 and 1",
+			len(debugging.Expences), len(debugging.Incomes), len(debugging.Remains))
+	}
+	if !current.GetDateActualTo().Equal(actualTo) {
+		t.Errorf("current remain actual to = %v, want it open", current.GetDateActualTo())
+	}
+	if len(debugging.LedgerAccounts) != 0 || len(debugging.JournalEntries) != 0 {
+		t.Errorf("ledger = %d accounts %d entries, want nothing posted", len(debugging.LedgerAccounts), len(debugging.JournalEntries))
+	}
+	for _, classifier := range debugging.Classifiers {
+		if classifier.Forget(1) {
+			t.Error("classifier still knows the expence")
+		}
+	}
+}
+
+const camtZeroAmount = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt><Stmt>
+<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2024-05-01</Dt></Dt></Bal>
+<Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">85.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2024-05-02</Dt></Dt></Bal>
+<Ntry><Amt Ccy="EUR">0.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><BookgDt><Dt>2024-05-02</Dt></BookgDt><AddtlNtryInf>Card check</AddtlNtryInf></Ntry>
+<Ntry><Amt Ccy="EUR">15.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2024-05-02</Dt></BookgDt><AddtlNtryInf>Coffee</AddtlNtryInf></Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>`
+
+func TestZeroAmountRowsAreSkipped(t *testing.T) {
+	resetImportStore()
+
+	result, err := NewImportService().ImportCAMT(1, "Bank", []byte(camtZeroAmount), false, "tester")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(result.Expences) != 1 || len(result.Incomes) != 0 {
+		t.Errorf("import = %d expences %d incomes, want 1 and 0", len(result.Expences), len(result.Incomes))
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Reason != "zero amount" {
+		t.Errorf("skipped = %+v, want the zero amount row", result.Skipped)
+	}
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type IncomeService struct {
+	scope      *Scope
+	superseded []*models.Income // pending placeholders deleted by received incomes
+}
+
+func NewIncomeService() *IncomeService {
+	return &IncomeService{}
+}
+
+// restrict queries to accounts visible in scope
+func (s *IncomeService) WithScope(scope *Scope) *IncomeService {
+	s.scope = scope
+	return s
+}
+
+func (s *IncomeService) AddNewIncome(newIncome *models.Income) error {
+	if err := s.scope.CanWrite(newIncome.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() == newIncome.GetIdIncome() {
+			return errors.New("income with this ID already exists")
+		}
+	}
+
+	tags, err := NewTagService().Ensure(newIncome.GetTags(), newIncome.GetIdAccaunt(), newIncome.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	newIncome.SetTags(tags)
+
+	s.matchIncomeExpected(newIncome)
+	placeholders := s.placeholdersOf(newIncome)
+
+	ledgerService := NewLedgerService()
+	if err := ledgerService.PostIncome(newIncome); err != nil {
+		return err
+	}
+
+	debugging.Incomes = append(debugging.Incomes, newIncome)
+	if err := s.supersede(placeholders, newIncome.GetUpdBy()); err != nil {
+		// take the income back, its posting is reversed like on delete
+		debugging.Incomes = debugging.Incomes[:len(debugging.Incomes)-1]
+		return errors.Join(err, ledgerService.ReverseSource(models.JournalSourceIncome, newIncome.GetIdIncome(), newIncome.GetUpdBy()))
+	}
+	return nil
+}
+
+// pending placeholders deleted by adds and updates made with the service,
+// callers audit them like any other delete
+func (s *IncomeService) Superseded() []*models.Income {
+	return s.superseded
+}
+
+func (s *IncomeService) GetAllIncomes() []*models.Income {
+	var incomes []*models.Income
+	for _, income := range debugging.Incomes {
+		if !income.IsDeleted() && s.scope.Allows(income.GetIdAccaunt()) {
+			incomes = append(incomes, income)
+		}
+	}
+	return incomes
+}
+
+func (s *IncomeService) GetIncomeById(idIncome int64) (*models.Income, error) {
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() == idIncome && !income.IsDeleted() && s.scope.Allows(income.GetIdAccaunt()) {
+			return income, nil
+		}
+	}
+	return nil, errors.New("income not found")
+}
+
+func (s *IncomeService) GetIncomesByAccount(idAccaunt int64) []*models.Income {
+	var incomes []*models.Income
+	if !s.scope.Allows(idAccaunt) {
+		return incomes
+	}
+
+	for _, income := range debugging.Incomes {
+		if income.GetIdAccaunt() == idAccaunt && !income.IsDeleted() {
+			incomes = append(incomes, income)
+		}
+	}
+	return incomes
+}
+
+func (s *IncomeService) UpdateIncome(updatedIncome *models.Income) (*models.Income, error) {
+	for i, income := range debugging.Incomes {
+		if income.GetIdIncome() == updatedIncome.GetIdIncome() && !income.IsDeleted() {
+			if err := s.scope.CanWrite(income.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(updatedIncome.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+
+			if updatedIncome.GetExternalId() == "" {
+				updatedIncome.SetExternalId(income.GetExternalId())
+			}
+			tags, err := NewTagService().Ensure(updatedIncome.GetTags(), updatedIncome.GetIdAccaunt(), updatedIncome.GetUpdBy())
+			if err != nil {
+				return nil, err
+			}
+			updatedIncome.SetTags(tags)
+
+			oldIncomeCopy := &models.Income{}
+			*oldIncomeCopy = *income
+
+			ledgerService := NewLedgerService()
+			if err := ledgerService.ReverseSource(models.JournalSourceIncome, income.GetIdIncome(), updatedIncome.GetUpdBy()); err != nil {
+				return nil, err
+			}
+			if err := ledgerService.PostIncome(updatedIncome); err != nil {
+				return nil, err
+			}
+
+			debugging.Incomes[i] = updatedIncome
+			s.matchIncomeExpected(updatedIncome)
+			if err := s.supersede(s.placeholdersOf(updatedIncome), updatedIncome.GetUpdBy()); err != nil {
+				return nil, err
+			}
+			return oldIncomeCopy, nil
+		}
+	}
+	return nil, errors.New("income not found")
+}
+
+// mark every version of the income deleted, history stays in place
+func (s *IncomeService) DeleteIncome(idIncome int64, deletedBy string) (*models.Income, error) {
+	var deleted *models.Income
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() != idIncome || income.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(income.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if deleted == nil || income.GetDateActualTo().Year() == 9999 {
+			deleted = income
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("income not found")
+	}
+
+	if err := NewLedgerService().ReverseSource(models.JournalSourceIncome, idIncome, deletedBy); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() == idIncome && !income.IsDeleted() {
+			income.SetDeletedAt(now)
+			income.SetDeletedBy(deletedBy)
+		}
+	}
+	return deleted, nil
+}
+
+// bring a deleted income back from trash
+func (s *IncomeService) RestoreIncome(idIncome int64) (*models.Income, error) {
+	var restored *models.Income
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() != idIncome || !income.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(income.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if restored == nil || income.GetDateActualTo().Year() == 9999 {
+			restored = income
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted income not found")
+	}
+
+	if err := NewLedgerService().PostIncome(restored); err != nil {
+		return nil, err
+	}
+
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() == idIncome {
+			income.SetDeletedAt(time.Time{})
+			income.SetDeletedBy("")
+		}
+	}
+	return restored, nil
+}
+
+// how far from the due date of income expected a received income is linked to it
+const IncomeMatchWindowDays = 5
+
+// link received income without income expected to the active one of the same account
+// and type that is due nearest to the income date
+func (s *IncomeService) matchIncomeExpected(income *models.Income) {
+	if income.GetIdIncomeExpected() != 0 || income.IsPending() {
+		return
+	}
+
+	date := IncomeDate(income)
+
+	var matched *models.IncomeExpected
+	var matchedDue time.Time
+	bestDistance := math.MaxFloat64
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.IsDeleted() ||
+			incomeExpected.GetIdAccaunt() != income.GetIdAccaunt() ||
+			!strings.EqualFold(incomeExpected.GetTypeIncome(), income.GetTypeIncome()) {
+			continue
+		}
+
+		// due dates of the neighbour months too, salary of 31st may come on 2nd
+		for shift := -1; shift <= 1; shift++ {
+			monthStart := time.Date(date.Year(), date.Month()+time.Month(shift), 1, 0, 0, 0, 0, date.Location())
+			due := dueDate(monthStart.Year(), monthStart.Month(), int(incomeExpected.GetIncomeMonthDate()), date.Location())
+			if !actualBetween(incomeExpected.GetDateActualFrom(), incomeExpected.GetDateActualTo(), monthStart, monthStart.AddDate(0, 1, 0)) {
+				continue
+			}
+			distance := math.Abs(date.Sub(due).Hours() / 24)
+			if distance <= IncomeMatchWindowDays && distance < bestDistance {
+				matched, matchedDue, bestDistance = incomeExpected, due, distance
+			}
+		}
+	}
+
+	if matched == nil {
+		return
+	}
+
+	income.SetIdIncomeExpected(matched.GetIdIncomeEx())
+	income.SetExpectedAmount(matched.GetAmount())
+	income.SetIncomeMonthMonth(int8(matchedDue.Month()))
+	income.SetIncomeMonthDate(matched.GetIncomeMonthDate())
+}
+
+// received income takes the place of the pending one made by scheduler for its
+// income expected and month. Placeholders are found before the income is stored
+func (s *IncomeService) placeholdersOf(income *models.Income) []*models.Income {
+	if income.IsPending() || income.GetIdIncomeExpected() == 0 {
+		return nil
+	}
+
+	year, month := s.incomeMonth(income)
+	var placeholders []*models.Income
+	found := make(map[int64]bool)
+	for _, placeholder := range debugging.Incomes {
+		if placeholder == income || placeholder.IsDeleted() || !placeholder.IsPending() ||
+			placeholder.GetIdIncome() == income.GetIdIncome() || found[placeholder.GetIdIncome()] ||
+			placeholder.GetIdAccaunt() != income.GetIdAccaunt() ||
+			placeholder.GetIdIncomeExpected() != income.GetIdIncomeExpected() ||
+			placeholder.GetIncomeMonthMonth() != int8(month) ||
+			placeholder.GetDateActualFrom().Year() != year {
+			continue
+		}
+		found[placeholder.GetIdIncome()] = true
+		placeholders = append(placeholders, placeholder)
+	}
+	return placeholders
+}
+
+// placeholders go to trash like deleted incomes, on error the ones deleted so
+// far are restored
+func (s *IncomeService) supersede(placeholders []*models.Income, deletedBy string) error {
+	superseded := len(s.superseded)
+	for _, placeholder := range placeholders {
+		deleted, err := s.DeleteIncome(placeholder.GetIdIncome(), deletedBy)
+		if err != nil {
+			for _, restore := range s.superseded[superseded:] {
+				if _, restoreErr := s.RestoreIncome(restore.GetIdIncome()); restoreErr != nil {
+					err = errors.Join(err, restoreErr)
+				}
+			}
+			s.superseded = s.superseded[:superseded]
+			return err
+		}
+		s.superseded = append(s.superseded, deleted)
+	}
+	return nil
+}
+
+// month the income is for, income_month_month wins over the date. Year follows
+// the date, december income paid in january belongs to the year before
+func (s *IncomeService) incomeMonth(income *models.Income) (int, time.Month) {
+	date := IncomeDate(income)
+	month := time.Month(income.GetIncomeMonthMonth())
+	if month < time.January || month > time.December {
+		return date.Year(), date.Month()
+	}
+
+	year := date.Year()
+	switch {
+	case month == time.December && date.Month() == time.January:
+		year--
+	case month == time.January && date.Month() == time.December:
+		year++
+	}
+	return year, month
+}
+
+// when money came, now if the record has no date
+func IncomeDate(income *models.Income) time.Time {
+	if income.GetDateActualFrom().IsZero() {
+		return time.Now()
+	}
+	return income.GetDateActualFrom()
+}
@@ -8,13 +8,25 @@ import (
 	"github.com/helltale/api-finances/internal/models"
 )
 
-type IncomeExpectedService struct{}
+type IncomeExpectedService struct {
+	scope *Scope
+}
 
 func NewIncomeExpectedService() *IncomeExpectedService {
 	return &IncomeExpectedService{}
 }
 
+// restrict queries and changes to accounts of scope
+func (s *IncomeExpectedService) WithScope(scope *Scope) *IncomeExpectedService {
+	s.scope = scope
+	return s
+}
+
 func (s *IncomeExpectedService) AddNewIncomeExpected(newIncomeExpected *models.IncomeExpected) error {
+	if err := s.scope.CanWrite(newIncomeExpected.GetIdAccaunt()); err != nil {
+		return err
+	}
+
 	for _, incomeExpected := range debugging.IncomesExpected {
 		if incomeExpected.GetIdIncomeEx() == newIncomeExpected.GetIdIncomeEx() {
 			return errors.New("income with this ID already exists")
@@ -26,21 +38,44 @@ func (s *IncomeExpectedService) AddNewIncomeExpected(newIncomeExpected *models.I
 }
 
 func (s *IncomeExpectedService) GetAllIncomesExpected() []*models.IncomeExpected {
-	return debugging.IncomesExpected
+	var incomesExpected []*models.IncomeExpected
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if !incomeExpected.IsDeleted() && s.scope.Allows(incomeExpected.GetIdAccaunt()) {
+			incomesExpected = append(incomesExpected, incomeExpected)
+		}
+	}
+	return incomesExpected
 }
 
 func (s *IncomeExpectedService) GetIncomeExpectedById(idIncomeEx int64) (*models.IncomeExpected, error) {
 	for _, incomeExpected := range debugging.IncomesExpected {
-		if incomeExpected.GetIdIncomeEx() == idIncomeEx {
+		if incomeExpected.GetIdIncomeEx() == idIncomeEx && !incomeExpected.IsDeleted() && s.scope.Allows(incomeExpected.GetIdAccaunt()) {
 			return incomeExpected, nil
 		}
 	}
 	return nil, errors.New("income expected not found")
 }
 
+func (s *IncomeExpectedService) GetIncomesExpectedByAccount(idAccaunt int64) []*models.IncomeExpected {
+	var incomesExpected []*models.IncomeExpected
+	for _, incomeExpected := range s.GetAllIncomesExpected() {
+		if incomeExpected.GetIdAccaunt() == idAccaunt {
+			incomesExpected = append(incomesExpected, incomeExpected)
+		}
+	}
+	return incomesExpected
+}
+
 func (s *IncomeExpectedService) UpdateIncomeExpected(updatedIncomeExpected *models.IncomeExpected) (*models.IncomeExpected, error) {
 	for i, incomeExpected := range debugging.IncomesExpected {
-		if incomeExpected.GetIdIncomeEx() == updatedIncomeExpected.GetIdIncomeEx() {
+		if incomeExpected.GetIdIncomeEx() == updatedIncomeExpected.GetIdIncomeEx() && !incomeExpected.IsDeleted() {
+			if err := s.scope.CanWrite(incomeExpected.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(updatedIncomeExpected.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+
 			oldIncomeExpectedCopy := &models.IncomeExpected{}
 			*oldIncomeExpectedCopy = *incomeExpected
 
@@ -56,7 +91,14 @@ func (s *IncomeExpectedService) UpdateHistoryIncomeExpected(idIncomeEx int64, ne
 
 	var oldIncomeExpected *models.IncomeExpected
 	for i, income := range debugging.IncomesExpected {
-		if income.GetIdIncomeEx() == idIncomeEx {
+		if income.GetIdIncomeEx() == idIncomeEx && !income.IsDeleted() {
+			if err := s.scope.CanWrite(income.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(newIncomeExpected.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+
 			oldIncomeExpected = income
 			debugging.IncomesExpected[i].SetDateActualTo(today)
 			break
@@ -76,14 +118,61 @@ func (s *IncomeExpectedService) UpdateHistoryIncomeExpected(idIncomeEx int64, ne
 	return oldIncomeExpected, nil
 }
 
-func (s *IncomeExpectedService) DeleteIncomeExpected(idIncomeEx int64) (*models.IncomeExpected, error) {
-	for i, incomeExpected := range debugging.IncomesExpected {
+// mark every version of the income expected deleted, history stays in place
+func (s *IncomeExpectedService) DeleteIncomeExpected(idIncomeEx int64, deletedBy string) (*models.IncomeExpected, error) {
+	var deleted *models.IncomeExpected
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.GetIdIncomeEx() != idIncomeEx || incomeExpected.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(incomeExpected.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if deleted == nil || incomeExpected.GetDateActualTo().Year() == 9999 {
+			deleted = incomeExpected
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("income expected not found")
+	}
+
+	now := time.Now()
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.GetIdIncomeEx() == idIncomeEx && !incomeExpected.IsDeleted() {
+			incomeExpected.SetDeletedAt(now)
+			incomeExpected.SetDeletedBy(deletedBy)
+		}
+	}
+	return deleted, nil
+}
+
+// bring a deleted income expected back from trash
+func (s *IncomeExpectedService) RestoreIncomeExpected(idIncomeEx int64) (*models.IncomeExpected, error) {
+	var restored *models.IncomeExpected
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.GetIdIncomeEx() != idIncomeEx || !incomeExpected.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(incomeExpected.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if restored == nil || incomeExpected.GetDateActualTo().Year() == 9999 {
+			restored = incomeExpected
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted income expected not found")
+	}
+
+	for _, incomeExpected := range debugging.IncomesExpected {
 		if incomeExpected.GetIdIncomeEx() == idIncomeEx {
-			debugging.IncomesExpected = append(debugging.IncomesExpected[:i], debugging.IncomesExpected[i+1:]...)
-			return debugging.IncomesExpected[i], nil
+			incomeExpected.SetDeletedAt(time.Time{})
+			incomeExpected.SetDeletedBy("")
 		}
 	}
-	return nil, errors.New("income expected not found")
+	return restored, nil
 }
 
 func (s *IncomeExpectedService) DeleteAndRestorePreviousIncomeExpexted(idIncomeEx int64) (*models.IncomeExpected, error) {
@@ -92,7 +181,7 @@ func (s *IncomeExpectedService) DeleteAndRestorePreviousIncomeExpexted(idIncomeE
 	maxDate := time.Time{}
 
 	for _, income := range debugging.IncomesExpected {
-		if income.GetIdIncomeEx() == idIncomeEx {
+		if income.GetIdIncomeEx() == idIncomeEx && !income.IsDeleted() {
 			if income.GetDateActualTo().Year() == 9999 {
 				currentRecord = income
 			} else if income.GetDateActualTo().After(maxDate) {
@@ -111,6 +200,10 @@ func (s *IncomeExpectedService) DeleteAndRestorePreviousIncomeExpexted(idIncomeE
 		return nil, errors.New("no historical record found to restore")
 	}
 
+	if err := s.scope.CanWrite(currentRecord.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
 	for i, income := range debugging.IncomesExpected {
 		if income == currentRecord {
 			debugging.IncomesExpected = append(debugging.IncomesExpected[:i], debugging.IncomesExpected[i+1:]...)
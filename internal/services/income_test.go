@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+func TestFailedSupersedeTakesIncomeBack(t *testing.T) {
+	resetImportStore()
+	actualTo := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	placeholder := &models.Income{}
+	placeholder.SetIdIncome(1)
+	placeholder.SetIdAccaunt(1)
+	placeholder.SetIdIncomeExpected(1)
+	placeholder.SetAmount(1000)
+	placeholder.SetTypeIncome("salary")
+	placeholder.SetStatus(models.IncomeStatusPending)
+	placeholder.SetIncomeMonthMonth(5)
+	placeholder.SetDateActualFrom(time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC))
+	placeholder.SetDateActualTo(actualTo)
+	debugging.Incomes = []*models.Income{placeholder}
+
+	// broken posting of the placeholder, its reversal on delete fails
+	broken := &models.JournalEntry{}
+	broken.SetSourceType(models.JournalSourceIncome)
+	broken.SetSourceId(1)
+	broken.AddPosting(99, 10)
+	debugging.JournalEntries = []*models.JournalEntry{broken}
+
+	income := &models.Income{}
+	income.SetIdIncome(2)
+	income.SetIdAccaunt(1)
+	income.SetIdIncomeExpected(1)
+	income.SetAmount(1000)
+	income.SetTypeIncome("salary")
+	income.SetStatus(models.IncomeStatusReceived)
+	income.SetIncomeMonthMonth(5)
+	income.SetUpdBy("tester")
+	income.SetDateActualFrom(time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC))
+	income.SetDateActualTo(actualTo)
+
+	incomeService := NewIncomeService()
+	if err := incomeService.AddNewIncome(income); err == nil {
+		t.Fatal("add income: want error of the placeholder delete")
+	}
+	if len(debugging.Incomes) != 1 || debugging.Incomes[0] != placeholder || placeholder.IsDeleted() {
+		t.Errorf("incomes = %d, placeholder deleted = %v, want only the placeholder kept", len(debugging.Incomes), placeholder.IsDeleted())
+	}
+	if len(incomeService.Superseded()) != 0 {
+		t.Errorf("superseded = %d, want none", len(incomeService.Superseded()))
+	}
+
+	// posting of the income is reversed, every ledger account nets to zero
+	net := make(map[int64]float64)
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetSourceType() == models.JournalSourceIncome && entry.GetSourceId() == 2 {
+			for _, posting := range entry.GetPostings() {
+				net[posting.GetIdLedgerAccount()] += posting.GetAmount()
+			}
+		}
+	}
+	if len(debugging.JournalEntries) != 3 {
+		t.Errorf("entries = %d, want posting of the income and its reversal", len(debugging.JournalEntries))
+	}
+	for idLedgerAccount, amount := range net {
+		if amount != 0 {
+			t.Errorf("ledger account %d nets to %.2f, want 0", idLedgerAccount, amount)
+		}
+	}
+}
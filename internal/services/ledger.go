@@ -0,0 +1,452 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// имя счета по умолчанию для денег аккаунта
+const LedgerCashAccountName = "Cash"
+
+// проводки сравниваются с точностью до копейки
+const ledgerEpsilon = 0.005
+
+type LedgerService struct {
+	scope *Scope
+}
+
+func NewLedgerService() *LedgerService {
+	return &LedgerService{}
+}
+
+// restrict reads to ledger accounts of scope and manual changes to accounts
+// writable in scope
+func (s *LedgerService) WithScope(scope *Scope) *LedgerService {
+	s.scope = scope
+	return s
+}
+
+func (s *LedgerService) GetAllLedgerAccounts() []*models.LedgerAccount {
+	var ledgerAccounts []*models.LedgerAccount
+	for _, ledgerAccount := range debugging.LedgerAccounts {
+		if s.scope.Allows(ledgerAccount.GetIdAccaunt()) {
+			ledgerAccounts = append(ledgerAccounts, ledgerAccount)
+		}
+	}
+	return ledgerAccounts
+}
+
+func (s *LedgerService) GetLedgerAccountById(idLedgerAccount int64) (*models.LedgerAccount, error) {
+	for _, ledgerAccount := range debugging.LedgerAccounts {
+		if ledgerAccount.GetIdLedgerAccount() == idLedgerAccount && s.scope.Allows(ledgerAccount.GetIdAccaunt()) {
+			return ledgerAccount, nil
+		}
+	}
+	return nil, errors.New("ledger account not found")
+}
+
+func (s *LedgerService) AddNewLedgerAccount(newLedgerAccount *models.LedgerAccount) error {
+	switch newLedgerAccount.GetTypeAccount() {
+	case models.LedgerAccountAsset, models.LedgerAccountLiability, models.LedgerAccountIncome, models.LedgerAccountExpense:
+	default:
+		return errors.New("invalid ledger account type")
+	}
+
+	if err := s.scope.CanWrite(newLedgerAccount.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	for _, ledgerAccount := range debugging.LedgerAccounts {
+		if ledgerAccount.GetIdLedgerAccount() == newLedgerAccount.GetIdLedgerAccount() {
+			return errors.New("ledger account with this ID already exists")
+		}
+	}
+
+	if newLedgerAccount.GetIdLedgerAccount() == 0 {
+		newLedgerAccount.SetIdLedgerAccount(s.nextLedgerAccountId())
+	}
+
+	debugging.LedgerAccounts = append(debugging.LedgerAccounts, newLedgerAccount)
+	return nil
+}
+
+// find ledger account by owner, type and name. Missing one is made and put
+// into created, it is stored only when entry using it is posted
+func (s *LedgerService) resolveLedgerAccount(created *[]*models.LedgerAccount, idAccaunt int64, typeAccount, name string) *models.LedgerAccount {
+	for _, ledgerAccounts := range [][]*models.LedgerAccount{debugging.LedgerAccounts, *created} {
+		for _, ledgerAccount := range ledgerAccounts {
+			if ledgerAccount.GetIdAccaunt() == idAccaunt && ledgerAccount.GetTypeAccount() == typeAccount && ledgerAccount.GetName() == name {
+				return ledgerAccount
+			}
+		}
+	}
+
+	ledgerAccount := &models.LedgerAccount{}
+	ledgerAccount.SetIdLedgerAccount(s.nextLedgerAccountId() + int64(len(*created)))
+	ledgerAccount.SetIdAccaunt(idAccaunt)
+	ledgerAccount.SetTypeAccount(typeAccount)
+	ledgerAccount.SetName(name)
+
+	*created = append(*created, ledgerAccount)
+	return ledgerAccount
+}
+
+func (s *LedgerService) GetAllJournalEntries() []*models.JournalEntry {
+	var entries []*models.JournalEntry
+	for _, entry := range debugging.JournalEntries {
+		if s.entryVisible(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (s *LedgerService) GetJournalEntryById(idEntry int64) (*models.JournalEntry, error) {
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetIdEntry() == idEntry && s.entryVisible(entry) {
+			return entry, nil
+		}
+	}
+	return nil, errors.New("journal entry not found")
+}
+
+// entry touches a ledger account of scope
+func (s *LedgerService) entryVisible(entry *models.JournalEntry) bool {
+	if s.scope == nil {
+		return true
+	}
+	for _, posting := range entry.GetPostings() {
+		if _, err := s.GetLedgerAccountById(posting.GetIdLedgerAccount()); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validate and append entry, journal is append-only
+func (s *LedgerService) PostEntry(entry *models.JournalEntry) error {
+	return s.post(entry, nil)
+}
+
+// validate entry whose postings may use ledger accounts not stored yet,
+// created accounts are stored together with the entry
+func (s *LedgerService) post(entry *models.JournalEntry, created []*models.LedgerAccount) error {
+	if len(entry.GetPostings()) < 2 {
+		return errors.New("journal entry must have at least two postings")
+	}
+
+	var sum float64
+	for _, posting := range entry.GetPostings() {
+		ledgerAccount, err := s.GetLedgerAccountById(posting.GetIdLedgerAccount())
+		for _, createdAccount := range created {
+			if createdAccount.GetIdLedgerAccount() == posting.GetIdLedgerAccount() {
+				ledgerAccount, err = createdAccount, nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("posting to unknown ledger account %d", posting.GetIdLedgerAccount())
+		}
+		if err := s.scope.CanWrite(ledgerAccount.GetIdAccaunt()); err != nil {
+			return err
+		}
+		sum += posting.GetAmount()
+	}
+
+	if math.Abs(sum) > ledgerEpsilon {
+		return fmt.Errorf("journal entry does not sum to zero: %.2f", sum)
+	}
+
+	if entry.GetDate().IsZero() {
+		entry.SetDate(time.Now())
+	}
+
+	entry.SetIdEntry(s.nextEntryId())
+	debugging.LedgerAccounts = append(debugging.LedgerAccounts, created...)
+	debugging.JournalEntries = append(debugging.JournalEntries, entry)
+	return nil
+}
+
+// expence: debit expense, credit cash of payer. Split lines debit expenses of
+// their categories and responsible members
+func (s *LedgerService) PostExpence(expence *models.Expence) error {
+	var created []*models.LedgerAccount
+	cash := s.resolveLedgerAccount(&created, expence.GetIdAccaunt(), models.LedgerAccountAsset, LedgerCashAccountName)
+
+	entry := &models.JournalEntry{}
+	entry.SetDate(expence.GetDate())
+	entry.SetDescription(expence.GetTitleExpence())
+	entry.SetSourceType(models.JournalSourceExpence)
+	entry.SetSourceId(expence.GetIdExpence())
+	entry.SetUpdBy(expence.GetUpdBy())
+	for _, part := range expence.Parts() {
+		expense := s.resolveLedgerAccount(&created, part.GetIdAccaunt(), models.LedgerAccountExpense, part.GetGroupExpence())
+		entry.AddPosting(expense.GetIdLedgerAccount(), part.GetAmount())
+	}
+	entry.AddPosting(cash.GetIdLedgerAccount(), -expence.GetAmount())
+
+	return s.post(entry, created)
+}
+
+// income: debit cash of receiver, credit income. Pending income is not posted
+func (s *LedgerService) PostIncome(income *models.Income) error {
+	if income.IsPending() {
+		return nil
+	}
+
+	var created []*models.LedgerAccount
+	cash := s.resolveLedgerAccount(&created, income.GetIdAccaunt(), models.LedgerAccountAsset, LedgerCashAccountName)
+	revenue := s.resolveLedgerAccount(&created, income.GetIdAccaunt(), models.LedgerAccountIncome, income.GetTypeIncome())
+
+	entry := &models.JournalEntry{}
+	entry.SetDate(income.GetDateActualFrom())
+	entry.SetDescription(income.GetTypeIncome())
+	entry.SetSourceType(models.JournalSourceIncome)
+	entry.SetSourceId(income.GetIdIncome())
+	entry.SetUpdBy(income.GetUpdBy())
+	entry.AddPosting(cash.GetIdLedgerAccount(), income.GetAmount())
+	entry.AddPosting(revenue.GetIdLedgerAccount(), -income.GetAmount())
+
+	return s.post(entry, created)
+}
+
+// occurrence of recurring expence: like expence, dated at the occurrence
+func (s *LedgerService) PostExpenceOccurrence(occurrence *models.Expence) error {
+	var created []*models.LedgerAccount
+	cash := s.resolveLedgerAccount(&created, occurrence.GetIdAccaunt(), models.LedgerAccountAsset, LedgerCashAccountName)
+
+	entry := &models.JournalEntry{}
+	entry.SetDate(occurrence.GetDate())
+	entry.SetDescription(occurrence.GetTitleExpence())
+	entry.SetSourceType(models.JournalSourceRecurring)
+	entry.SetSourceId(occurrence.GetIdExpence())
+	entry.SetUpdBy(occurrence.GetUpdBy())
+	for _, part := range occurrence.Parts() {
+		expense := s.resolveLedgerAccount(&created, part.GetIdAccaunt(), models.LedgerAccountExpense, part.GetGroupExpence())
+		entry.AddPosting(expense.GetIdLedgerAccount(), part.GetAmount())
+	}
+	entry.AddPosting(cash.GetIdLedgerAccount(), -occurrence.GetAmount())
+
+	return s.post(entry, created)
+}
+
+// transfer: debit cash of receiver, credit cash of sender
+func (s *LedgerService) PostTransfer(transfer *models.Transfer) error {
+	var created []*models.LedgerAccount
+	from := s.resolveLedgerAccount(&created, transfer.GetIdAccauntFrom(), models.LedgerAccountAsset, LedgerCashAccountName)
+	to := s.resolveLedgerAccount(&created, transfer.GetIdAccauntTo(), models.LedgerAccountAsset, LedgerCashAccountName)
+
+	entry := &models.JournalEntry{}
+	entry.SetDate(transfer.GetDate())
+	entry.SetDescription("Transfer")
+	entry.SetSourceType(models.JournalSourceTransfer)
+	entry.SetSourceId(transfer.GetIdTransfer())
+	entry.SetUpdBy(transfer.GetUpdBy())
+	entry.AddPosting(to.GetIdLedgerAccount(), transfer.GetAmount())
+	entry.AddPosting(from.GetIdLedgerAccount(), -transfer.GetAmount())
+
+	return s.post(entry, created)
+}
+
+// post a compensating entry so that source nets to zero
+func (s *LedgerService) ReverseSource(sourceType string, sourceId int64, updBy string) error {
+	net := make(map[int64]float64)
+	var order []int64
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetSourceType() != sourceType || entry.GetSourceId() != sourceId {
+			continue
+		}
+		for _, posting := range entry.GetPostings() {
+			if _, ok := net[posting.GetIdLedgerAccount()]; !ok {
+				order = append(order, posting.GetIdLedgerAccount())
+			}
+			net[posting.GetIdLedgerAccount()] += posting.GetAmount()
+		}
+	}
+
+	reversal := &models.JournalEntry{}
+	reversal.SetDate(time.Now())
+	reversal.SetDescription(fmt.Sprintf("Reversal of %s %d", sourceType, sourceId))
+	reversal.SetSourceType(sourceType)
+	reversal.SetSourceId(sourceId)
+	reversal.SetUpdBy(updBy)
+	for _, idLedgerAccount := range order {
+		if math.Abs(net[idLedgerAccount]) > ledgerEpsilon {
+			reversal.AddPosting(idLedgerAccount, -net[idLedgerAccount])
+		}
+	}
+
+	if len(reversal.GetPostings()) == 0 {
+		return nil
+	}
+
+	return s.PostEntry(reversal)
+}
+
+// post journal for records that existed before the ledger
+func (s *LedgerService) Backfill() error {
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetRepeat() != 0 || s.hasSource(models.JournalSourceExpence, expence.GetIdExpence()) {
+			continue
+		}
+		if err := s.PostExpence(expence); err != nil {
+			return err
+		}
+	}
+
+	for _, income := range debugging.Incomes {
+		if income.IsDeleted() || s.hasSource(models.JournalSourceIncome, income.GetIdIncome()) {
+			continue
+		}
+		if err := s.PostIncome(income); err != nil {
+			return err
+		}
+	}
+
+	for _, transfer := range debugging.Transfers {
+		if transfer.IsDeleted() || s.hasSource(models.JournalSourceTransfer, transfer.GetIdTransfer()) {
+			continue
+		}
+		if err := s.PostTransfer(transfer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// balances of ledger accounts of scope at date. The journal is checked as a
+// whole, a scope may only see one side of a transfer
+func (s *LedgerService) TrialBalance(at time.Time) ([]models.TrialBalanceLineJSON, error) {
+	debit := make(map[int64]float64)
+	credit := make(map[int64]float64)
+
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetDate().After(at) {
+			continue
+		}
+		for _, posting := range entry.GetPostings() {
+			if posting.GetAmount() > 0 {
+				debit[posting.GetIdLedgerAccount()] += posting.GetAmount()
+			} else {
+				credit[posting.GetIdLedgerAccount()] -= posting.GetAmount()
+			}
+		}
+	}
+
+	var totalDebit, totalCredit float64
+	lines := make([]models.TrialBalanceLineJSON, 0, len(debugging.LedgerAccounts))
+	for _, ledgerAccount := range debugging.LedgerAccounts {
+		id := ledgerAccount.GetIdLedgerAccount()
+		totalDebit += debit[id]
+		totalCredit += credit[id]
+		if !s.scope.Allows(ledgerAccount.GetIdAccaunt()) {
+			continue
+		}
+
+		lines = append(lines, models.TrialBalanceLineJSON{
+			IdLedgerAccount: id,
+			IdAccaunt:       ledgerAccount.GetIdAccaunt(),
+			Name:            ledgerAccount.GetName(),
+			TypeAccount:     ledgerAccount.GetTypeAccount(),
+			Debit:           debit[id],
+			Credit:          credit[id],
+			Balance:         debit[id] - credit[id],
+		})
+	}
+
+	if math.Abs(totalDebit-totalCredit) > ledgerEpsilon {
+		return lines, fmt.Errorf("trial balance is out of balance: debit %.2f, credit %.2f", totalDebit, totalCredit)
+	}
+
+	return lines, nil
+}
+
+// movements of one ledger account with running balance
+func (s *LedgerService) GeneralLedger(idLedgerAccount int64, startDate, endDate time.Time) ([]models.GeneralLedgerLineJSON, error) {
+	if _, err := s.GetLedgerAccountById(idLedgerAccount); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*models.JournalEntry, len(debugging.JournalEntries))
+	copy(entries, debugging.JournalEntries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].GetDate().Before(entries[j].GetDate())
+	})
+
+	var balance float64
+	var lines []models.GeneralLedgerLineJSON
+	for _, entry := range entries {
+		for _, posting := range entry.GetPostings() {
+			if posting.GetIdLedgerAccount() != idLedgerAccount {
+				continue
+			}
+
+			balance += posting.GetAmount()
+			if entry.GetDate().Before(startDate) || entry.GetDate().After(endDate) {
+				continue
+			}
+
+			line := models.GeneralLedgerLineJSON{
+				IdEntry:     entry.GetIdEntry(),
+				Date:        entry.GetDate().Format("2006-01-02 15:04:05"),
+				Description: entry.GetDescription(),
+				SourceType:  entry.GetSourceType(),
+				SourceId:    entry.GetSourceId(),
+				Balance:     balance,
+			}
+			if posting.GetAmount() > 0 {
+				line.Debit = posting.GetAmount()
+			} else {
+				line.Credit = -posting.GetAmount()
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+func (s *LedgerService) hasSource(sourceType string, sourceId int64) bool {
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetSourceType() == sourceType && entry.GetSourceId() == sourceId {
+			return true
+		}
+	}
+	return false
+}
+
+// entry of source dated at date exists
+func (s *LedgerService) hasSourceAt(sourceType string, sourceId int64, date time.Time) bool {
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetSourceType() == sourceType && entry.GetSourceId() == sourceId && entry.GetDate().Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *LedgerService) nextLedgerAccountId() int64 {
+	var maxId int64
+	for _, ledgerAccount := range debugging.LedgerAccounts {
+		if ledgerAccount.GetIdLedgerAccount() > maxId {
+			maxId = ledgerAccount.GetIdLedgerAccount()
+		}
+	}
+	return maxId + 1
+}
+
+func (s *LedgerService) nextEntryId() int64 {
+	var maxId int64
+	for _, entry := range debugging.JournalEntries {
+		if entry.GetIdEntry() > maxId {
+			maxId = entry.GetIdEntry()
+		}
+	}
+	return maxId + 1
+}
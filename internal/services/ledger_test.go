@@ -0,0 +1,163 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+func resetLedger() {
+	debugging.LedgerAccounts = nil
+	debugging.JournalEntries = nil
+}
+
+func postTestIncome(t *testing.T, idIncome, idAccaunt int64, amount float64) {
+	t.Helper()
+	income := &models.Income{}
+	income.SetIdIncome(idIncome)
+	income.SetIdAccaunt(idAccaunt)
+	income.SetAmount(amount)
+	income.SetTypeIncome("salary")
+	income.SetDateActualFrom(time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC))
+	if err := NewLedgerService().PostIncome(income); err != nil {
+		t.Fatalf("post income %d: %v", idIncome, err)
+	}
+}
+
+func TestLedgerReadsAreScoped(t *testing.T) {
+	setupPolicyGroup()
+	resetLedger()
+	postTestIncome(t, 1, 1, 1000) // group 1
+	postTestIncome(t, 2, 4, 700)  // outsider
+
+	tests := []struct {
+		name        string
+		scope       *Scope
+		wantOwners  []int64 // owners of visible ledger accounts
+		wantEntries int
+	}{
+		{"member sees group", NewGroupService().ScopeFor(2), []int64{1, 1}, 1},
+		{"outsider sees own", NewGroupService().ScopeFor(4), []int64{4, 4}, 1},
+		{"unrestricted", nil, []int64{1, 1, 4, 4}, 2},
+	}
+
+	for _, tt := range tests {
+		ledgerService := NewLedgerService().WithScope(tt.scope)
+
+		ledgerAccounts := ledgerService.GetAllLedgerAccounts()
+		if len(ledgerAccounts) != len(tt.wantOwners) {
+			t.Fatalf("%s: ledger accounts = %d, want %d", tt.name, len(ledgerAccounts), len(tt.wantOwners))
+		}
+		for i, ledgerAccount := range ledgerAccounts {
+			if ledgerAccount.GetIdAccaunt() != tt.wantOwners[i] {
+				t.Errorf("%s: ledger account %d of %d, want %d", tt.name, ledgerAccount.GetIdLedgerAccount(), ledgerAccount.GetIdAccaunt(), tt.wantOwners[i])
+			}
+		}
+
+		if got := len(ledgerService.GetAllJournalEntries()); got != tt.wantEntries {
+			t.Errorf("%s: entries = %d, want %d", tt.name, got, tt.wantEntries)
+		}
+
+		lines, err := ledgerService.TrialBalance(time.Now())
+		if err != nil {
+			t.Fatalf("%s: trial balance: %v", tt.name, err)
+		}
+		if len(lines) != len(tt.wantOwners) {
+			t.Errorf("%s: trial balance lines = %d, want %d", tt.name, len(lines), len(tt.wantOwners))
+		}
+	}
+
+	// records of the outsider stay hidden from the group
+	member := NewLedgerService().WithScope(NewGroupService().ScopeFor(2))
+	if _, err := member.GetJournalEntryById(2); err == nil {
+		t.Error("member read the journal entry of the outsider")
+	}
+	if _, err := member.GetLedgerAccountById(3); err == nil {
+		t.Error("member read the ledger account of the outsider")
+	}
+	if _, err := member.GeneralLedger(3, time.Time{}, time.Now()); err == nil {
+		t.Error("member read the general ledger of the outsider")
+	}
+}
+
+func TestPostEntryRejected(t *testing.T) {
+	setupPolicyGroup()
+	resetLedger()
+	postTestIncome(t, 1, 1, 1000)
+	cash, revenue := debugging.LedgerAccounts[0].GetIdLedgerAccount(), debugging.LedgerAccounts[1].GetIdLedgerAccount()
+
+	tests := []struct {
+		name     string
+		postings map[int64]float64
+		want     string
+	}{
+		{"single posting", map[int64]float64{cash: 0}, "journal entry must have at least two postings"},
+		{"not zero sum", map[int64]float64{cash: 100, revenue: -90}, "journal entry does not sum to zero: 10.00"},
+		{"unknown account", map[int64]float64{cash: 100, 99: -100}, "posting to unknown ledger account 99"},
+	}
+
+	for _, tt := range tests {
+		entry := &models.JournalEntry{}
+		for _, idLedgerAccount := range []int64{cash, revenue, 99} {
+			if amount, ok := tt.postings[idLedgerAccount]; ok {
+				entry.AddPosting(idLedgerAccount, amount)
+			}
+		}
+		if err := NewLedgerService().PostEntry(entry); errorText(err) != tt.want {
+			t.Errorf("%s: error = %q, want %q", tt.name, errorText(err), tt.want)
+		}
+	}
+	if len(debugging.JournalEntries) != 1 {
+		t.Errorf("entries = %d, want only the income", len(debugging.JournalEntries))
+	}
+
+	// income of an account out of scope creates no ledger accounts
+	income := &models.Income{}
+	income.SetIdIncome(2)
+	income.SetIdAccaunt(4)
+	income.SetAmount(500)
+	income.SetTypeIncome("bonus")
+	if err := NewLedgerService().WithScope(NewGroupService().ScopeFor(1)).PostIncome(income); err == nil {
+		t.Error("posted income of the outsider")
+	}
+	if len(debugging.LedgerAccounts) != 2 || len(debugging.JournalEntries) != 1 {
+		t.Errorf("ledger = %d accounts %d entries, want 2 and 1", len(debugging.LedgerAccounts), len(debugging.JournalEntries))
+	}
+}
+
+func TestReverseSource(t *testing.T) {
+	setupPolicyGroup()
+	resetLedger()
+	postTestIncome(t, 1, 1, 1000)
+	postTestIncome(t, 2, 1, 300)
+
+	ledgerService := NewLedgerService()
+	if err := ledgerService.ReverseSource(models.JournalSourceIncome, 1, "tester"); err != nil {
+		t.Fatalf("reverse: %v", err)
+	}
+	// source already nets to zero, nothing more is posted
+	if err := ledgerService.ReverseSource(models.JournalSourceIncome, 1, "tester"); err != nil {
+		t.Fatalf("reverse again: %v", err)
+	}
+	if len(debugging.JournalEntries) != 3 {
+		t.Fatalf("entries = %d, want 3", len(debugging.JournalEntries))
+	}
+
+	balances := make(map[int64]float64)
+	for _, entry := range debugging.JournalEntries {
+		for _, posting := range entry.GetPostings() {
+			balances[posting.GetIdLedgerAccount()] += posting.GetAmount()
+		}
+	}
+	cash := debugging.LedgerAccounts[0].GetIdLedgerAccount()
+	if balances[cash] != 300 {
+		t.Errorf("cash = %.2f, want 300 of the income left", balances[cash])
+	}
+	reversal := debugging.JournalEntries[2]
+	if reversal.GetSourceId() != 1 || reversal.GetUpdBy() != "tester" || len(reversal.GetPostings()) != 2 {
+		t.Errorf("reversal = source %d by %q with %d postings, want source 1 by tester with 2",
+			reversal.GetSourceId(), reversal.GetUpdBy(), len(reversal.GetPostings()))
+	}
+}
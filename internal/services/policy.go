@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	ActionRead        = "read"         // see records of co-members
+	ActionWriteOwn    = "write_own"    // change records of own account
+	ActionWriteGroup  = "write_group"  // change records of co-members
+	ActionManageGroup = "manage_group" // rename, delete group, change membership
+	ActionAdmin       = "admin"        // granted by config, not by roles
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// what every role is allowed to do inside its group
+var RolePermissions = map[string]map[string]bool{
+	models.RoleOwner: {
+		ActionRead:        true,
+		ActionWriteOwn:    true,
+		ActionWriteGroup:  true,
+		ActionManageGroup: true,
+	},
+	models.RoleMember: {
+		ActionRead:     true,
+		ActionWriteOwn: true,
+	},
+	models.RoleViewer: {
+		ActionRead: true,
+	},
+}
+
+func ValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+type PolicyService struct{}
+
+func NewPolicyService() *PolicyService {
+	return &PolicyService{}
+}
+
+// role of account in group, false if not a member
+func (s *PolicyService) RoleIn(idGroup, idAccaunt int64) (string, bool) {
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdGroup() == idGroup && member.GetIdAccaunt() == idAccaunt {
+			return member.GetRole(), true
+		}
+	}
+	return "", false
+}
+
+// action is granted by any role of the account,
+// account without groups owns its own data
+func (s *PolicyService) Can(idAccaunt int64, action string) bool {
+	isMember := false
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdAccaunt() != idAccaunt {
+			continue
+		}
+		isMember = true
+		if RolePermissions[member.GetRole()][action] {
+			return true
+		}
+	}
+	return !isMember && RolePermissions[models.RoleOwner][action]
+}
+
+func (s *PolicyService) CanInGroup(idAccaunt, idGroup int64, action string) bool {
+	role, ok := s.RoleIn(idGroup, idAccaunt)
+	return ok && RolePermissions[role][action]
+}
+
+// own records need write_own, co-member records need write_group in a shared group
+func (s *PolicyService) CanWriteAccount(idActor, idAccaunt int64) bool {
+	if idActor == idAccaunt {
+		return s.Can(idActor, ActionWriteOwn)
+	}
+
+	for _, member := range debugging.GroupMembers {
+		if member.GetIdAccaunt() != idAccaunt {
+			continue
+		}
+		if s.CanInGroup(idActor, member.GetIdGroup(), ActionWriteGroup) {
+			return true
+		}
+	}
+	return false
+}
+
+// nil scope is unrestricted
+func (sc *Scope) CanWrite(idAccaunt int64) error {
+	if sc == nil || NewPolicyService().CanWriteAccount(sc.idAccaunt, idAccaunt) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func (sc *Scope) CanManageGroup(idGroup int64) error {
+	if sc == nil || NewPolicyService().CanInGroup(sc.idAccaunt, idGroup, ActionManageGroup) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// action is allowed to the scope account at all
+func (sc *Scope) Can(action string) bool {
+	return sc == nil || NewPolicyService().Can(sc.idAccaunt, action)
+}
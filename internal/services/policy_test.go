@@ -0,0 +1,347 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// group 1: owner 1, member 2, viewer 3; account 4 has no group
+func setupPolicyGroup() {
+	debugging.GroupMembers = nil
+	for idAccaunt, role := range map[int64]string{1: models.RoleOwner, 2: models.RoleMember, 3: models.RoleViewer} {
+		member := &models.GroupMember{}
+		member.SetIdGroup(1)
+		member.SetIdAccaunt(idAccaunt)
+		member.SetRole(role)
+		debugging.GroupMembers = append(debugging.GroupMembers, member)
+	}
+}
+
+func TestPolicyCan(t *testing.T) {
+	setupPolicyGroup()
+
+	tests := []struct {
+		idAccaunt int64
+		action    string
+		want      bool
+	}{
+		{1, ActionRead, true},
+		{1, ActionWriteOwn, true},
+		{1, ActionWriteGroup, true},
+		{1, ActionManageGroup, true},
+		{2, ActionRead, true},
+		{2, ActionWriteOwn, true},
+		{2, ActionWriteGroup, false},
+		{2, ActionManageGroup, false},
+		{3, ActionRead, true},
+		{3, ActionWriteOwn, false},
+		{3, ActionWriteGroup, false},
+		{3, ActionManageGroup, false},
+		{4, ActionWriteOwn, true},
+		{4, ActionManageGroup, true},
+		{1, ActionAdmin, false},
+	}
+
+	for _, tt := range tests {
+		if got := NewPolicyService().Can(tt.idAccaunt, tt.action); got != tt.want {
+			t.Errorf("Can(%d, %s) = %v, want %v", tt.idAccaunt, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestScopeRecordChecks(t *testing.T) {
+	setupPolicyGroup()
+
+	tests := []struct {
+		name       string
+		actor      int64
+		owner      int64 // account of the record
+		wantWrite  bool
+		wantManage bool // manage group 1
+	}{
+		{"owner own record", 1, 1, true, true},
+		{"owner member record", 1, 2, true, true},
+		{"member own record", 2, 2, true, false},
+		{"member owner record", 2, 1, false, false},
+		{"viewer own record", 3, 3, false, false},
+		{"viewer member record", 3, 2, false, false},
+		{"outsider group record", 4, 1, false, false},
+	}
+
+	for _, tt := range tests {
+		scope := NewGroupService().ScopeFor(tt.actor)
+		if got := scope.CanWrite(tt.owner) == nil; got != tt.wantWrite {
+			t.Errorf("%s: CanWrite = %v, want %v", tt.name, got, tt.wantWrite)
+		}
+		if got := scope.CanManageGroup(1) == nil; got != tt.wantManage {
+			t.Errorf("%s: CanManageGroup = %v, want %v", tt.name, got, tt.wantManage)
+		}
+	}
+}
+
+func testBudget(idBudget, idAccaunt, idGroup int64) *models.Budget {
+	budget := &models.Budget{}
+	budget.SetIdBudget(idBudget)
+	budget.SetIdAccaunt(idAccaunt)
+	budget.SetIdGroup(idGroup)
+	budget.SetGroupExpence("food")
+	budget.SetAmount(100)
+	return budget
+}
+
+func TestGroupBudgetNeedsManager(t *testing.T) {
+	setupPolicyGroup()
+	debugging.Accounts = nil
+	for _, idAccaunt := range []int64{1, 2, 3} {
+		account := &models.Account{}
+		account.SetIdAccaunt(idAccaunt)
+		debugging.Accounts = append(debugging.Accounts, account)
+	}
+
+	tests := []struct {
+		name   string
+		actor  int64
+		stored *models.Budget // nil adds change as a new budget
+		change *models.Budget // nil deletes stored
+		want   error
+	}{
+		{"member adds own budget", 2, nil, testBudget(1, 2, 0), nil},
+		{"member adds group budget", 2, nil, testBudget(1, 2, 1), ErrForbidden},
+		{"owner adds group budget of member", 1, nil, testBudget(1, 2, 1), nil},
+		{"member moves own budget into group", 2, testBudget(1, 2, 0), testBudget(1, 2, 1), ErrForbidden},
+		{"member takes group budget out of group", 2, testBudget(1, 2, 1), testBudget(1, 2, 0), ErrForbidden},
+		{"owner changes group budget", 1, testBudget(1, 2, 1), testBudget(1, 2, 1), nil},
+		{"member deletes group budget", 2, testBudget(1, 2, 1), nil, ErrForbidden},
+		{"owner deletes group budget", 1, testBudget(1, 2, 1), nil, nil},
+	}
+
+	for _, tt := range tests {
+		debugging.Budgets = nil
+		if tt.stored != nil {
+			debugging.Budgets = append(debugging.Budgets, tt.stored)
+		}
+
+		budgetService := NewBudgetService().WithScope(NewGroupService().ScopeFor(tt.actor))
+		var err error
+		switch {
+		case tt.stored == nil:
+			err = budgetService.AddNewBudget(tt.change)
+		case tt.change == nil:
+			_, err = budgetService.DeleteBudget(tt.stored.GetIdBudget(), "tester")
+		default:
+			_, err = budgetService.UpdateBudget(tt.stored.GetIdBudget(), tt.change)
+		}
+		if err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func setupPolicyAccounts() {
+	debugging.Accounts = nil
+	for _, idAccaunt := range []int64{1, 2, 3, 4} {
+		account := &models.Account{}
+		account.SetIdAccaunt(idAccaunt)
+		debugging.Accounts = append(debugging.Accounts, account)
+	}
+}
+
+func TestCategoryScope(t *testing.T) {
+	setupPolicyGroup()
+	setupPolicyAccounts()
+	debugging.Expences = nil
+	debugging.Cashbacks = nil
+	debugging.Budgets = nil
+	debugging.Rules = nil
+
+	shared := &models.Category{}
+	shared.SetIdCategory(1)
+	shared.SetCode("food")
+	shared.SetNames(map[string]string{models.DefaultCategoryLang: "Food"})
+	debugging.Categories = []*models.Category{shared}
+
+	// same text of two households makes a category for each of them
+	pets, created, err := NewCategoryService().Ensure("Pets", 2, "tester")
+	if err != nil || !created || pets.GetIdAccaunt() != 2 {
+		t.Fatalf("Ensure for account 2 = %v created %v, %v", pets, created, err)
+	}
+	otherPets, created, err := NewCategoryService().Ensure("pets", 4, "tester")
+	if err != nil || !created || otherPets.GetIdCategory() == pets.GetIdCategory() {
+		t.Fatalf("Ensure for account 4 = %v created %v, %v", otherPets, created, err)
+	}
+	if found, _, _ := NewCategoryService().Ensure("PETS", 1, "tester"); found != pets {
+		t.Errorf("Ensure for group member found %v, want category of account 2", found)
+	}
+	if found, _, _ := NewCategoryService().Ensure("food", 4, "tester"); found != shared {
+		t.Errorf("Ensure of shared text found %v, want shared category", found)
+	}
+
+	visible := func(idAccaunt int64) map[int64]bool {
+		ids := make(map[int64]bool)
+		for _, category := range NewCategoryService().WithScope(NewGroupService().ScopeFor(idAccaunt)).GetAllCategories() {
+			ids[category.GetIdCategory()] = true
+		}
+		return ids
+	}
+	if ids := visible(4); !ids[shared.GetIdCategory()] || !ids[otherPets.GetIdCategory()] || ids[pets.GetIdCategory()] {
+		t.Errorf("account 4 sees %v, want shared and own only", ids)
+	}
+	if ids := visible(3); !ids[shared.GetIdCategory()] || !ids[pets.GetIdCategory()] || ids[otherPets.GetIdCategory()] {
+		t.Errorf("account 3 sees %v, want shared and group ones only", ids)
+	}
+
+	tests := []struct {
+		name       string
+		actor      int64 // 0 updates without scope like admins do
+		idCategory int64
+		want       string
+	}{
+		{"member changes shared category", 2, shared.GetIdCategory(), "forbidden"},
+		{"admin changes shared category", 0, shared.GetIdCategory(), ""},
+		{"outsider changes group category", 4, pets.GetIdCategory(), "category not found"},
+		{"viewer changes group category", 3, pets.GetIdCategory(), "forbidden"},
+		{"owner changes member category", 1, pets.GetIdCategory(), ""},
+		{"author changes own category", 2, pets.GetIdCategory(), ""},
+	}
+
+	for _, tt := range tests {
+		categoryService := NewCategoryService()
+		if tt.actor != 0 {
+			categoryService.WithScope(NewGroupService().ScopeFor(tt.actor))
+		}
+		stored, _ := NewCategoryService().GetCategoryById(tt.idCategory)
+
+		change := &models.Category{}
+		change.SetCode(stored.GetCode())
+		change.SetIdAccaunt(stored.GetIdAccaunt())
+		change.SetNames(stored.GetNames())
+		change.SetAliases([]string{tt.name})
+
+		_, err := categoryService.UpdateCategory(tt.idCategory, change)
+		if got := errorText(err); got != tt.want {
+			t.Errorf("%s: error = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryInUse(t *testing.T) {
+	setupPolicyAccounts()
+	debugging.Expences = nil
+	debugging.Cashbacks = nil
+	debugging.Budgets = nil
+	debugging.Rules = nil
+
+	newCategory := func(idCategory int64) {
+		category := &models.Category{}
+		category.SetIdCategory(idCategory)
+		category.SetCode("category_" + string(rune('a'+idCategory)))
+		debugging.Categories = append(debugging.Categories, category)
+	}
+	debugging.Categories = nil
+	for idCategory := int64(1); idCategory <= 3; idCategory++ {
+		newCategory(idCategory)
+	}
+
+	rule := &models.Rule{}
+	rule.SetIdRule(1)
+	rule.SetIdAccaunt(1)
+	rule.SetIdCategory(1)
+	debugging.Rules = append(debugging.Rules, rule)
+
+	line := &models.ExpenceSplit{}
+	line.SetIdCategory(2)
+	line.SetAmount(10)
+	expence := &models.Expence{}
+	expence.SetIdExpence(1)
+	expence.SetIdAccaunt(1)
+	expence.SetSplits([]*models.ExpenceSplit{line})
+	debugging.Expences = append(debugging.Expences, expence)
+
+	tests := []struct {
+		idCategory int64
+		want       string
+	}{
+		{1, "category is in use"}, // rule
+		{2, "category is in use"}, // split line
+		{3, ""},
+	}
+
+	for _, tt := range tests {
+		_, err := NewCategoryService().DeleteCategory(tt.idCategory, "tester")
+		if got := errorText(err); got != tt.want {
+			t.Errorf("delete category %d: error = %q, want %q", tt.idCategory, got, tt.want)
+		}
+	}
+}
+
+func TestGroupMembershipNeedsConsent(t *testing.T) {
+	setupPolicyGroup()
+	setupPolicyAccounts()
+	group := &models.Group{}
+	group.SetIdGroup(1)
+	debugging.Groups = []*models.Group{group}
+	debugging.GroupInvites = nil
+
+	owner := NewGroupService().WithScope(NewGroupService().ScopeFor(1))
+	if _, err := owner.InviteMember(1, 4, models.RoleMember); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	if _, err := owner.InviteMember(1, 4, models.RoleMember); err == nil {
+		t.Error("second invite of the same account is accepted")
+	}
+
+	// nor can the owner pull the account in through its group_id
+	account := &models.Account{}
+	account.SetIdAccaunt(4)
+	account.SetGroupId(1)
+	if _, err := NewAccountService().WithScope(NewGroupService().ScopeFor(1)).UpdateAccount(account); err == nil {
+		t.Error("owner updated an account outside the group")
+	}
+
+	if NewGroupService().IsMember(1, 4) || NewGroupService().ScopeFor(1).Allows(4) {
+		t.Fatal("invited account joined before it accepted")
+	}
+
+	if _, err := owner.AcceptInvite(1); err == nil {
+		t.Error("owner accepted the invite for the account")
+	}
+
+	invited := NewGroupService().WithScope(NewGroupService().ScopeFor(4))
+	if got := len(invited.GetInvites()); got != 1 {
+		t.Fatalf("invites of account 4 = %d, want 1", got)
+	}
+	member, err := invited.AcceptInvite(1)
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if member.GetRole() != models.RoleMember || !NewGroupService().ScopeFor(1).Allows(4) {
+		t.Errorf("accepted invite = role %q, visible %v, want member visible to owner", member.GetRole(), NewGroupService().ScopeFor(1).Allows(4))
+	}
+	if len(debugging.GroupInvites) != 0 {
+		t.Errorf("invites left = %d, want 0", len(debugging.GroupInvites))
+	}
+
+	// declined invites do not make a membership
+	if _, err := owner.InviteMember(1, 4, models.RoleViewer); err == nil {
+		t.Error("member invited again")
+	}
+	debugging.GroupMembers = debugging.GroupMembers[:3]
+	if _, err := owner.InviteMember(1, 4, models.RoleViewer); err != nil {
+		t.Fatalf("invite again: %v", err)
+	}
+	if _, err := invited.DeclineInvite(1); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	if NewGroupService().IsMember(1, 4) || len(debugging.GroupInvites) != 0 {
+		t.Error("declined invite made a membership or stayed pending")
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
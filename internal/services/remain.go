@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type RemainService struct {
+	scope *Scope
+}
+
+func NewRemainService() *RemainService {
+	return &RemainService{}
+}
+
+// restrict queries and changes to accounts of scope
+func (s *RemainService) WithScope(scope *Scope) *RemainService {
+	s.scope = scope
+	return s
+}
+
+func (s *RemainService) AddNewRemain(newRemain *models.Remain) error {
+	if err := s.scope.CanWrite(newRemain.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	debugging.Remains = append(debugging.Remains, newRemain)
+	return nil
+}
+
+func (s *RemainService) GetAllRemains() []*models.Remain {
+	var remains []*models.Remain
+	for _, remain := range debugging.Remains {
+		if !remain.IsDeleted() && s.scope.Allows(remain.GetIdAccaunt()) {
+			remains = append(remains, remain)
+		}
+	}
+	return remains
+}
+
+func (s *RemainService) GetRemainById(idRemains int64) (*models.Remain, error) {
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() == idRemains && !remain.IsDeleted() && s.scope.Allows(remain.GetIdAccaunt()) {
+			return remain, nil
+		}
+	}
+	return nil, errors.New("remain not found")
+}
+
+func (s *RemainService) GetRemainsByAccount(idAccaunt int64) []*models.Remain {
+	var remains []*models.Remain
+	for _, remain := range s.GetAllRemains() {
+		if remain.GetIdAccaunt() == idAccaunt {
+			remains = append(remains, remain)
+		}
+	}
+	return remains
+}
+
+// current version of the remain
+func (s *RemainService) GetLastRemainById(idRemains int64) (*models.Remain, error) {
+	for _, remain := range s.GetAllRemains() {
+		if remain.GetIdRemains() == idRemains && remain.GetDateActualTo().Format("2006-01-02") == "9999-12-31" {
+			return remain, nil
+		}
+	}
+	return nil, errors.New("remain not found")
+}
+
+// versions that became actual strictly between the dates
+func (s *RemainService) GetRemainsByDateRange(startDate, endDate time.Time) []*models.Remain {
+	var remains []*models.Remain
+	for _, remain := range s.GetAllRemains() {
+		if remain.GetDateActualFrom().After(startDate) && remain.GetDateActualFrom().Before(endDate) {
+			remains = append(remains, remain)
+		}
+	}
+	return remains
+}
+
+// replace remain record, old one is returned
+func (s *RemainService) UpdateRemain(idRemains int64, newRemain *models.Remain) (*models.Remain, error) {
+	for i, remain := range debugging.Remains {
+		if remain.GetIdRemains() == idRemains && !remain.IsDeleted() {
+			if err := s.scope.CanWrite(remain.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+			if err := s.scope.CanWrite(newRemain.GetIdAccaunt()); err != nil {
+				return nil, err
+			}
+
+			debugging.Remains = append(debugging.Remains[:i], debugging.Remains[i+1:]...)
+			debugging.Remains = append(debugging.Remains, newRemain)
+			return remain, nil
+		}
+	}
+	return nil, errors.New("remain not found")
+}
+
+// add version of the remain, current version is closed when new one starts and
+// returned
+func (s *RemainService) AddRemainVersion(newRemain *models.Remain) (*models.Remain, error) {
+	if err := s.scope.CanWrite(newRemain.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
+	var oldRemain *models.Remain
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() != newRemain.GetIdRemains() || remain.IsDeleted() || remain.GetDateActualTo().Year() != 9999 {
+			continue
+		}
+		oldRemain = remain
+	}
+
+	if oldRemain != nil {
+		if oldRemain.GetIdAccaunt() != newRemain.GetIdAccaunt() {
+			return nil, errors.New("remain belongs to another account")
+		}
+		if !newRemain.GetDateActualFrom().After(oldRemain.GetDateActualFrom()) {
+			return nil, errors.New("new version must start after current one")
+		}
+		oldRemain.SetDateActualTo(newRemain.GetDateActualFrom())
+	}
+	debugging.Remains = append(debugging.Remains, newRemain)
+	return oldRemain, nil
+}
+
+// mark every version of the remain deleted, history stays in place
+func (s *RemainService) DeleteRemain(idRemains int64, deletedBy string) (*models.Remain, error) {
+	var deleted *models.Remain
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() != idRemains || remain.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(remain.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if deleted == nil || remain.GetDateActualTo().Year() == 9999 {
+			deleted = remain
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("remain not found")
+	}
+
+	now := time.Now()
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() == idRemains && !remain.IsDeleted() {
+			remain.SetDeletedAt(now)
+			remain.SetDeletedBy(deletedBy)
+		}
+	}
+	return deleted, nil
+}
+
+// bring a deleted remain back from trash
+func (s *RemainService) RestoreRemain(idRemains int64) (*models.Remain, error) {
+	var restored *models.Remain
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() != idRemains || !remain.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(remain.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if restored == nil || remain.GetDateActualTo().Year() == 9999 {
+			restored = remain
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted remain not found")
+	}
+
+	for _, remain := range debugging.Remains {
+		if remain.GetIdRemains() == idRemains {
+			remain.SetDeletedAt(time.Time{})
+			remain.SetDeletedBy("")
+		}
+	}
+	return restored, nil
+}
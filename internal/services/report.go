@@ -0,0 +1,171 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type ReportService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewReportService() *ReportService {
+	return &ReportService{now: time.Now}
+}
+
+// only accounts visible in scope
+func (s *ReportService) WithScope(scope *Scope) *ReportService {
+	s.scope = scope
+	return s
+}
+
+// expected against received income of the month per account
+func (s *ReportService) IncomeVariance(year int, month time.Month) []models.IncomeVarianceJSON {
+	now := s.now()
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	window := time.Duration(IncomeMatchWindowDays) * 24 * time.Hour
+
+	reports := make(map[int64]*models.IncomeVarianceJSON)
+	report := func(idAccaunt int64) *models.IncomeVarianceJSON {
+		if reports[idAccaunt] == nil {
+			reports[idAccaunt] = &models.IncomeVarianceJSON{
+				IdAccaunt: idAccaunt,
+				Month:     monthStart.Format("2006-01"),
+				Lines:     []models.IncomeVarianceLineJSON{},
+			}
+		}
+		return reports[idAccaunt]
+	}
+
+	for _, incomeExpected := range incomesExpectedOf(s.scope, monthStart, monthEnd) {
+		due := dueDate(year, month, int(incomeExpected.GetIncomeMonthDate()), now.Location())
+
+		line := models.IncomeVarianceLineJSON{
+			IdIncomeEx: incomeExpected.GetIdIncomeEx(),
+			TypeIncome: incomeExpected.GetTypeIncome(),
+			DueDate:    due.Format("2006-01-02"),
+			Expected:   incomeExpected.GetAmount(),
+		}
+
+		var lastReceived time.Time
+		for _, income := range debugging.Incomes {
+			if income.IsDeleted() || income.IsPending() || income.GetIdIncomeExpected() != incomeExpected.GetIdIncomeEx() {
+				continue
+			}
+			if incomeYear, incomeMonth := incomePeriod(income); incomeYear != year || incomeMonth != month {
+				continue
+			}
+			line.Received += income.GetAmount()
+			if date := IncomeDate(income); date.After(lastReceived) {
+				lastReceived = date
+			}
+		}
+
+		switch {
+		case !lastReceived.IsZero() && lastReceived.After(due.Add(window)):
+			line.Status = models.IncomeVarianceLate
+			line.DaysLate = daysBetween(due, lastReceived)
+		case !lastReceived.IsZero():
+			line.Status = models.IncomeVarianceReceived
+		case now.After(due.Add(window)):
+			line.Status = models.IncomeVarianceMissing
+			line.DaysLate = daysBetween(due, now)
+		default:
+			line.Status = models.IncomeVariancePending
+		}
+		if !lastReceived.IsZero() {
+			line.ReceivedDate = lastReceived.Format("2006-01-02 15:04:05")
+		}
+
+		line.Variance = roundAmount(line.Received - line.Expected)
+		if line.Expected != 0 {
+			line.VariancePercent = roundAmount(line.Variance / line.Expected * 100)
+		}
+
+		accountReport := report(incomeExpected.GetIdAccaunt())
+		accountReport.Lines = append(accountReport.Lines, line)
+		accountReport.Expected += line.Expected
+		accountReport.Received += line.Received
+	}
+
+	for _, income := range debugging.Incomes {
+		if income.IsDeleted() || income.IsPending() || income.GetIdIncomeExpected() != 0 || !s.scope.Allows(income.GetIdAccaunt()) {
+			continue
+		}
+		if date := IncomeDate(income); date.Before(monthStart) || !date.Before(monthEnd) {
+			continue
+		}
+		report(income.GetIdAccaunt()).Unexpected += income.GetAmount()
+	}
+
+	result := make([]models.IncomeVarianceJSON, 0, len(reports))
+	for _, accountReport := range reports {
+		accountReport.Expected = roundAmount(accountReport.Expected)
+		accountReport.Received = roundAmount(accountReport.Received)
+		accountReport.Variance = roundAmount(accountReport.Received - accountReport.Expected)
+		accountReport.Unexpected = roundAmount(accountReport.Unexpected)
+		sort.Slice(accountReport.Lines, func(i, j int) bool {
+			return accountReport.Lines[i].DueDate < accountReport.Lines[j].DueDate
+		})
+		result = append(result, *accountReport)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].IdAccaunt < result[j].IdAccaunt })
+	return result
+}
+
+// income expected actual in the month visible in scope, the latest version of each
+func incomesExpectedOf(scope *Scope, monthStart, monthEnd time.Time) []*models.IncomeExpected {
+	latest := make(map[int64]*models.IncomeExpected)
+	var order []int64
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.IsDeleted() || !scope.Allows(incomeExpected.GetIdAccaunt()) ||
+			!actualBetween(incomeExpected.GetDateActualFrom(), incomeExpected.GetDateActualTo(), monthStart, monthEnd) {
+			continue
+		}
+		current, ok := latest[incomeExpected.GetIdIncomeEx()]
+		if !ok {
+			order = append(order, incomeExpected.GetIdIncomeEx())
+		}
+		if !ok || incomeExpected.GetDateActualFrom().After(current.GetDateActualFrom()) {
+			latest[incomeExpected.GetIdIncomeEx()] = incomeExpected
+		}
+	}
+
+	incomesExpected := make([]*models.IncomeExpected, 0, len(order))
+	for _, idIncomeEx := range order {
+		incomesExpected = append(incomesExpected, latest[idIncomeEx])
+	}
+	return incomesExpected
+}
+
+// month the income belongs to, december salary may come in january
+func incomePeriod(income *models.Income) (int, time.Month) {
+	date := IncomeDate(income)
+	year, month := date.Year(), date.Month()
+	if income.GetIncomeMonthMonth() < 1 || income.GetIncomeMonthMonth() > 12 {
+		return year, month
+	}
+
+	incomeMonth := time.Month(income.GetIncomeMonthMonth())
+	switch {
+	case incomeMonth == time.December && month == time.January:
+		year--
+	case incomeMonth == time.January && month == time.December:
+		year++
+	}
+	return year, incomeMonth
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(math.Ceil(to.Sub(from).Hours() / 24))
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type RuleService struct {
+	scope *Scope
+}
+
+func NewRuleService() *RuleService {
+	return &RuleService{}
+}
+
+// restrict rules and expences they change to accounts of scope
+func (s *RuleService) WithScope(scope *Scope) *RuleService {
+	s.scope = scope
+	return s
+}
+
+// expence changed by rule, before is the stored version
+type RuleChange struct {
+	Rule   *models.Rule
+	Before *models.Expence
+	After  *models.Expence
+}
+
+func (s *RuleService) AddNewRule(newRule *models.Rule) error {
+	if err := s.scope.CanWrite(newRule.GetIdAccaunt()); err != nil {
+		return err
+	}
+	if err := s.validate(newRule); err != nil {
+		return err
+	}
+
+	var maxId int64
+	for _, rule := range debugging.Rules {
+		maxId = max(maxId, rule.GetIdRule())
+	}
+	newRule.SetIdRule(maxId + 1)
+
+	debugging.Rules = append(debugging.Rules, newRule)
+	return nil
+}
+
+// rules in order they are tried
+func (s *RuleService) GetAllRules() []*models.Rule {
+	var rules []*models.Rule
+	for _, rule := range debugging.Rules {
+		if !rule.IsDeleted() && s.scope.Allows(rule.GetIdAccaunt()) {
+			rules = append(rules, rule)
+		}
+	}
+	sortRules(rules)
+	return rules
+}
+
+func (s *RuleService) GetRuleById(idRule int64) (*models.Rule, error) {
+	for _, rule := range debugging.Rules {
+		if rule.GetIdRule() == idRule && !rule.IsDeleted() && s.scope.Allows(rule.GetIdAccaunt()) {
+			return rule, nil
+		}
+	}
+	return nil, errors.New("rule not found")
+}
+
+// replace rule, old one is returned
+func (s *RuleService) UpdateRule(idRule int64, newRule *models.Rule) (*models.Rule, error) {
+	newRule.SetIdRule(idRule)
+	for i, rule := range debugging.Rules {
+		if rule.GetIdRule() != idRule || rule.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(rule.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if err := s.scope.CanWrite(newRule.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if err := s.validate(newRule); err != nil {
+			return nil, err
+		}
+
+		debugging.Rules[i] = newRule
+		return rule, nil
+	}
+	return nil, errors.New("rule not found")
+}
+
+func (s *RuleService) DeleteRule(idRule int64, deletedBy string) (*models.Rule, error) {
+	rule, err := s.GetRuleById(idRule)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(rule.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
+	rule.SetDeletedAt(time.Now())
+	rule.SetDeletedBy(deletedBy)
+	return rule, nil
+}
+
+// bring a deleted rule back from trash
+func (s *RuleService) RestoreRule(idRule int64) (*models.Rule, error) {
+	for _, rule := range debugging.Rules {
+		if rule.GetIdRule() != idRule || !rule.IsDeleted() || !s.scope.Allows(rule.GetIdAccaunt()) {
+			continue
+		}
+		if err := s.scope.CanWrite(rule.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+
+		rule.SetDeletedAt(time.Time{})
+		rule.SetDeletedBy("")
+		return rule, nil
+	}
+	return nil, errors.New("deleted rule not found")
+}
+
+// first enabled rule matching the expence, rules of every owner sharing a group
+// with the paying account are tried
+func (s *RuleService) Match(expence *models.Expence) *models.Rule {
+	rules := make([]*models.Rule, len(debugging.Rules))
+	copy(rules, debugging.Rules)
+	sortRules(rules)
+
+	for _, rule := range rules {
+		if rule.IsDisabled() || rule.IsDeleted() || !NewGroupService().ScopeFor(rule.GetIdAccaunt()).Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+		if ruleMatches(rule, expence) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// category and tags of matching rule for expence without category, nil when
+// expence has category or nothing matches
+func (s *RuleService) AutoCategorize(expence *models.Expence) *models.Rule {
+	if expence.GetIdCategory() != 0 || strings.TrimSpace(expence.GetGroupExpence()) != "" {
+		return nil
+	}
+	rule := s.Match(expence)
+	if rule != nil {
+		applyRule(rule, expence)
+	}
+	return rule
+}
+
+// run rules over current expences of scope again, category of matching rule
+// replaces the stored one; nothing is stored with dry run. Count of expences
+// rules were tried on is returned too
+func (s *RuleService) Apply(dryRun bool, updBy string) ([]RuleChange, int, error) {
+	var changes []RuleChange
+	checked := 0
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetDateActualTo().Year() != 9999 || s.scope.CanWrite(expence.GetIdAccaunt()) != nil {
+			continue
+		}
+		checked++
+
+		rule := s.Match(expence)
+		if rule == nil {
+			continue
+		}
+		updated := &models.Expence{}
+		*updated = *expence
+		applyRule(rule, updated)
+		updated.SetUpdBy(updBy)
+		if updated.GetIdCategory() == expence.GetIdCategory() && updated.GetGroupExpence() == expence.GetGroupExpence() &&
+			len(updated.GetTags()) == len(expence.GetTags()) {
+			continue
+		}
+		changes = append(changes, RuleChange{Rule: rule, Before: expence, After: updated})
+	}
+
+	if !dryRun {
+		expenceService := NewExpenceService().WithScope(s.scope)
+		for _, change := range changes {
+			if err := expenceService.replaceVersion(change.Before, change.After); err != nil {
+				return nil, 0, errors.New("expence " + strconv.FormatInt(change.Before.GetIdExpence(), 10) + ": " + err.Error())
+			}
+		}
+	}
+	return changes, checked, nil
+}
+
+// owner and account exist, regexp compiles, category and tags are linked
+func (s *RuleService) validate(rule *models.Rule) error {
+	if strings.TrimSpace(rule.GetName()) == "" {
+		return errors.New("name is required")
+	}
+	if _, err := NewAccountService().GetAccountById(rule.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	if rule.GetTitleRegex() != "" {
+		if _, err := regexp.Compile("(?i)" + rule.GetTitleRegex()); err != nil {
+			return errors.New("invalid title_regex: " + err.Error())
+		}
+	}
+	if rule.GetMinAmount() < 0 || rule.GetMaxAmount() < 0 {
+		return errors.New("amounts can not be negative")
+	}
+	if rule.GetMaxAmount() != 0 && rule.GetMaxAmount() < rule.GetMinAmount() {
+		return errors.New("max_amount is less than min_amount")
+	}
+	if rule.GetMatchAccaunt() != 0 {
+		if _, err := NewAccountService().GetAccountById(rule.GetMatchAccaunt()); err != nil {
+			return err
+		}
+		if !NewGroupService().ScopeFor(rule.GetIdAccaunt()).Allows(rule.GetMatchAccaunt()) {
+			return errors.New("match_accaunt does not share a group with the owner")
+		}
+	}
+	if strings.TrimSpace(rule.GetTitleContains()) == "" && rule.GetTitleRegex() == "" &&
+		rule.GetMinAmount() == 0 && rule.GetMaxAmount() == 0 && rule.GetMatchAccaunt() == 0 {
+		return errors.New("rule needs at least one condition")
+	}
+
+	idCategory, group, err := NewCategoryService().Categorize(rule.GetIdCategory(), rule.GetGroupExpence(), rule.GetIdAccaunt(), rule.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	rule.SetIdCategory(idCategory)
+	rule.SetGroupExpence(group)
+	tags, err := NewTagService().Ensure(rule.GetTags(), rule.GetIdAccaunt(), rule.GetUpdBy())
+	if err != nil {
+		return err
+	}
+	rule.SetTags(tags)
+	if idCategory == 0 && len(rule.GetTags()) == 0 {
+		return errors.New("rule must set a category or tags")
+	}
+	return nil
+}
+
+func sortRules(rules []*models.Rule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].GetPriority() != rules[j].GetPriority() {
+			return rules[i].GetPriority() < rules[j].GetPriority()
+		}
+		return rules[i].GetIdRule() < rules[j].GetIdRule()
+	})
+}
+
+// every condition of the rule holds for expence
+func ruleMatches(rule *models.Rule, expence *models.Expence) bool {
+	title := strings.ToLower(expence.GetTitleExpence())
+	if contains := strings.ToLower(strings.TrimSpace(rule.GetTitleContains())); contains != "" && !strings.Contains(title, contains) {
+		return false
+	}
+	if rule.GetTitleRegex() != "" {
+		re, err := regexp.Compile("(?i)" + rule.GetTitleRegex())
+		if err != nil || !re.MatchString(expence.GetTitleExpence()) {
+			return false
+		}
+	}
+	if rule.GetMinAmount() != 0 && expence.GetAmount() < rule.GetMinAmount() {
+		return false
+	}
+	if rule.GetMaxAmount() != 0 && expence.GetAmount() > rule.GetMaxAmount() {
+		return false
+	}
+	if rule.GetMatchAccaunt() != 0 && expence.GetIdAccaunt() != rule.GetMatchAccaunt() {
+		return false
+	}
+	return true
+}
+
+// category of the rule replaces the expence one, tags are added
+func applyRule(rule *models.Rule, expence *models.Expence) {
+	if rule.GetIdCategory() != 0 {
+		expence.SetIdCategory(rule.GetIdCategory())
+		expence.SetGroupExpence(rule.GetGroupExpence())
+	}
+	expence.SetTags(mergeTags(expence.GetTags(), rule.GetTags()))
+}
+
+// lower case tags without spaces around, empty and repeated ones dropped
+func normalizeTags(tags []string) []string {
+	return mergeTags(nil, tags)
+}
+
+// tags of both lists in order, each once
+func mergeTags(tags, more []string) []string {
+	merged := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, tags...), more...) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
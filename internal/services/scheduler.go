@@ -0,0 +1,246 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+const (
+	JobRecurringExpences = "recurring_expences"
+	JobPendingIncomes    = "pending_incomes"
+	JobBudgetThresholds  = "budget_thresholds"
+)
+
+// upd_by of records made by jobs
+const SchedulerUpdBy = "scheduler"
+
+// job body run at clock now, returns handled period and number of created records
+type JobFunc func(now time.Time) (string, int, error)
+
+type SchedulerService struct{}
+
+func NewSchedulerService() *SchedulerService {
+	return &SchedulerService{}
+}
+
+// post occurrences of recurring expences that are due, every occurrence date once.
+// Window starts at the last successful run, expences never posted before are
+// walked from their start so backdated ones are caught up
+func (s *SchedulerService) PostDueExpences(now time.Time) (string, int, error) {
+	ledgerService := NewLedgerService()
+
+	var since time.Time
+	if lastRun := s.GetJobRun(JobRecurringExpences); lastRun != nil && lastRun.GetLastError() == "" && lastRun.GetLastRun().Before(now) {
+		since = lastRun.GetLastRun()
+	}
+	fromOf := func(expence *models.Expence) time.Time {
+		if ledgerService.hasSource(models.JournalSourceRecurring, expence.GetIdExpence()) {
+			return since
+		}
+		return time.Time{}
+	}
+
+	posted := 0
+	for _, occurrence := range NewExpenceService().materialize(now, fromOf) {
+		if ledgerService.hasSourceAt(models.JournalSourceRecurring, occurrence.GetIdExpence(), occurrence.GetDate()) {
+			continue
+		}
+		if err := ledgerService.PostExpenceOccurrence(occurrence); err != nil {
+			return now.Format("2006-01-02"), posted, err
+		}
+		posted++
+	}
+	return now.Format("2006-01-02"), posted, nil
+}
+
+// pending income of the month for every actual income expected, once per month
+func (s *SchedulerService) CreatePendingIncomes(now time.Time) (string, int, error) {
+	year, month, _ := now.Date()
+	period := now.Format("2006-01")
+	futureDate, _ := time.Parse("2006-01-02", "9999-12-31")
+
+	created := 0
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.IsDeleted() || !actualAt(incomeExpected.GetDateActualFrom(), incomeExpected.GetDateActualTo(), now) {
+			continue
+		}
+		if s.hasIncomeFor(incomeExpected.GetIdIncomeEx(), year, month) {
+			continue
+		}
+
+		income := &models.Income{}
+		income.SetIdIncome(s.nextIncomeId())
+		income.SetIdAccaunt(incomeExpected.GetIdAccaunt())
+		income.SetIdIncomeExpected(incomeExpected.GetIdIncomeEx())
+		income.SetExpectedAmount(incomeExpected.GetAmount())
+		income.SetTypeIncome(incomeExpected.GetTypeIncome())
+		income.SetIncomeMonthMonth(int8(month))
+		income.SetIncomeMonthDate(incomeExpected.GetIncomeMonthDate())
+		income.SetStatus(models.IncomeStatusPending)
+		income.SetUpdBy(SchedulerUpdBy)
+		income.SetDateActualFrom(dueDate(year, month, int(incomeExpected.GetIncomeMonthDate()), now.Location()))
+		income.SetDateActualTo(futureDate)
+
+		if err := NewIncomeService().AddNewIncome(income); err != nil {
+			return period, created, err
+		}
+		created++
+	}
+	return period, created, nil
+}
+
+// budget threshold events of the current periods
+func (s *SchedulerService) CheckBudgets(now time.Time) (string, int, error) {
+	budgetService := &BudgetService{now: func() time.Time { return now }}
+	return now.Format("2006-01-02"), len(budgetService.CheckThresholds()), nil
+}
+
+// income of the month exists, deleted ones count so they are not recreated
+func (s *SchedulerService) hasIncomeFor(idIncomeEx int64, year int, month time.Month) bool {
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncomeExpected() == idIncomeEx &&
+			income.GetIncomeMonthMonth() == int8(month) &&
+			income.GetDateActualFrom().Year() == year {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SchedulerService) nextIncomeId() int64 {
+	var maxId int64
+	for _, income := range debugging.Incomes {
+		if income.GetIdIncome() > maxId {
+			maxId = income.GetIdIncome()
+		}
+	}
+	return maxId + 1
+}
+
+func (s *SchedulerService) GetJobRuns() []*models.JobRun {
+	return debugging.JobRuns
+}
+
+func (s *SchedulerService) GetJobRun(name string) *models.JobRun {
+	for _, run := range debugging.JobRuns {
+		if run.GetName() == name {
+			return run
+		}
+	}
+	return nil
+}
+
+// job run as kept in the state file
+type jobRunState struct {
+	Name       string    `json:"name"`
+	LastRun    time.Time `json:"last_run"`
+	LastPeriod string    `json:"last_period"`
+	Processed  int       `json:"processed"`
+	LastError  string    `json:"last_error"`
+}
+
+// read job runs saved by SaveJobRuns, missing file means no runs yet
+func (s *SchedulerService) LoadJobRuns(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var states []jobRunState
+	if err := json.Unmarshal(raw, &states); err != nil {
+		return err
+	}
+
+	debugging.JobRuns = nil
+	for _, state := range states {
+		jobRun := &models.JobRun{}
+		jobRun.SetName(state.Name)
+		jobRun.SetLastRun(state.LastRun)
+		jobRun.SetLastPeriod(state.LastPeriod)
+		jobRun.SetProcessed(state.Processed)
+		jobRun.SetLastError(state.LastError)
+		debugging.JobRuns = append(debugging.JobRuns, jobRun)
+	}
+	return nil
+}
+
+// write job runs to path, the file is replaced at once
+func (s *SchedulerService) SaveJobRuns(path string) error {
+	states := make([]jobRunState, 0, len(debugging.JobRuns))
+	for _, run := range debugging.JobRuns {
+		states = append(states, jobRunState{
+			Name:       run.GetName(),
+			LastRun:    run.GetLastRun(),
+			LastPeriod: run.GetLastPeriod(),
+			Processed:  run.GetProcessed(),
+			LastError:  run.GetLastError(),
+		})
+	}
+
+	raw, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// store the last run of job
+func (s *SchedulerService) RecordRun(name string, at time.Time, period string, processed int, err error) *models.JobRun {
+	jobRun := s.GetJobRun(name)
+	if jobRun == nil {
+		jobRun = &models.JobRun{}
+		jobRun.SetName(name)
+		debugging.JobRuns = append(debugging.JobRuns, jobRun)
+	}
+
+	jobRun.SetLastRun(at)
+	jobRun.SetLastPeriod(period)
+	jobRun.SetProcessed(processed)
+	jobRun.SetLastError("")
+	if err != nil {
+		jobRun.SetLastError(err.Error())
+	}
+	return jobRun
+}
+
+// record of SCD2 history is actual at date, zero bounds are open
+func actualAt(from, to, date time.Time) bool {
+	if !from.IsZero() && from.After(date) {
+		return false
+	}
+	return to.IsZero() || to.After(date)
+}
+
+// record of SCD2 history was actual at some time of [start, end)
+func actualBetween(from, to, start, end time.Time) bool {
+	if !from.IsZero() && !from.Before(end) {
+		return false
+	}
+	return to.IsZero() || to.After(start)
+}
+
+// day of month clamped to the month length, day 0 is the first
+func dueDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day < 1 {
+		day = 1
+	}
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
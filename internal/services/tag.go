@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type TagService struct {
+	scope *Scope
+}
+
+func NewTagService() *TagService {
+	return &TagService{}
+}
+
+// restrict tags to accounts of scope
+func (s *TagService) WithScope(scope *Scope) *TagService {
+	s.scope = scope
+	return s
+}
+
+func (s *TagService) AddNewTag(newTag *models.Tag) error {
+	if err := s.scope.CanWrite(newTag.GetIdAccaunt()); err != nil {
+		return err
+	}
+	if err := s.validate(newTag); err != nil {
+		return err
+	}
+
+	newTag.SetIdTag(nextTagId())
+	debugging.Tags = append(debugging.Tags, newTag)
+	return nil
+}
+
+// tags by name
+func (s *TagService) GetAllTags() []*models.Tag {
+	var tags []*models.Tag
+	for _, tag := range debugging.Tags {
+		if !tag.IsDeleted() && s.scope.Allows(tag.GetIdAccaunt()) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].GetName() < tags[j].GetName()
+	})
+	return tags
+}
+
+func (s *TagService) GetTagById(idTag int64) (*models.Tag, error) {
+	for _, tag := range debugging.Tags {
+		if tag.GetIdTag() == idTag && !tag.IsDeleted() && s.scope.Allows(tag.GetIdAccaunt()) {
+			return tag, nil
+		}
+	}
+	return nil, errors.New("tag not found")
+}
+
+// replace tag, renamed tag is renamed on every record carrying it. Old tag is
+// returned
+func (s *TagService) UpdateTag(idTag int64, newTag *models.Tag) (*models.Tag, error) {
+	newTag.SetIdTag(idTag)
+	for i, tag := range debugging.Tags {
+		if tag.GetIdTag() != idTag || tag.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(tag.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if err := s.scope.CanWrite(newTag.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if err := s.validate(newTag); err != nil {
+			return nil, err
+		}
+
+		if newTag.GetName() != tag.GetName() {
+			relabel(tag, newTag.GetName())
+		}
+		debugging.Tags[i] = newTag
+		return tag, nil
+	}
+	return nil, errors.New("tag not found")
+}
+
+// move tag to trash and take it off every record carrying it
+func (s *TagService) DeleteTag(idTag int64, deletedBy string) (*models.Tag, error) {
+	tag, err := s.GetTagById(idTag)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scope.CanWrite(tag.GetIdAccaunt()); err != nil {
+		return nil, err
+	}
+
+	tag.SetDetached(relabel(tag, ""))
+	tag.SetDeletedAt(time.Now())
+	tag.SetDeletedBy(deletedBy)
+	return tag, nil
+}
+
+// bring a deleted tag back from trash and put it on the records it was taken
+// off, its name must still be free
+func (s *TagService) RestoreTag(idTag int64) (*models.Tag, error) {
+	for _, tag := range debugging.Tags {
+		if tag.GetIdTag() != idTag || !tag.IsDeleted() || !s.scope.Allows(tag.GetIdAccaunt()) {
+			continue
+		}
+		if err := s.scope.CanWrite(tag.GetIdAccaunt()); err != nil {
+			return nil, err
+		}
+		if err := s.validate(tag); err != nil {
+			return nil, err
+		}
+
+		retag(tag)
+		tag.SetDetached(models.TaggedRecords{})
+		tag.SetDeletedAt(time.Time{})
+		tag.SetDeletedBy("")
+		return tag, nil
+	}
+	return nil, errors.New("deleted tag not found")
+}
+
+// normalized tags of a record of the account, tags unknown to the account are
+// created for it like categories are
+func (s *TagService) Ensure(names []string, idAccaunt int64, updBy string) ([]string, error) {
+	names = normalizeTags(names)
+	for _, name := range names {
+		if strings.Contains(name, ",") {
+			return nil, errors.New("tag can not contain a comma: " + name)
+		}
+		if findTag(name, idAccaunt) != nil {
+			continue
+		}
+
+		tag := &models.Tag{}
+		tag.SetIdTag(nextTagId())
+		tag.SetIdAccaunt(idAccaunt)
+		tag.SetName(name)
+		tag.SetUpdBy(updBy)
+		debugging.Tags = append(debugging.Tags, tag)
+	}
+	return names, nil
+}
+
+// owner exists, name is normalized and not used by another tag the owner sees
+func (s *TagService) validate(tag *models.Tag) error {
+	if _, err := NewAccountService().GetAccountById(tag.GetIdAccaunt()); err != nil {
+		return err
+	}
+
+	tag.SetName(strings.ToLower(strings.TrimSpace(tag.GetName())))
+	tag.SetDescription(strings.TrimSpace(tag.GetDescription()))
+	if tag.GetName() == "" {
+		return errors.New("name is required")
+	}
+	if strings.Contains(tag.GetName(), ",") {
+		return errors.New("name can not contain a comma")
+	}
+	if existing := findTag(tag.GetName(), tag.GetIdAccaunt()); existing != nil && existing.GetIdTag() != tag.GetIdTag() {
+		return errors.New("tag with this name already exists")
+	}
+	return nil
+}
+
+// money of expences and received incomes in [from, to) per tag, recurring
+// expences count every occurrence
+func (s *ReportService) TagTotals(from, to time.Time) models.TagReportJSON {
+	totals := make(map[string]*models.TagTotalJSON)
+	total := func(name string, idAccaunt int64) *models.TagTotalJSON {
+		if totals[name] == nil {
+			totals[name] = &models.TagTotalJSON{Tag: name}
+			if tag := findTag(name, idAccaunt); tag != nil {
+				totals[name].IdTag = tag.GetIdTag()
+			}
+		}
+		return totals[name]
+	}
+
+	expences := NewExpenceService().WithScope(s.scope).MaterializeExpences(from, to.Add(-time.Nanosecond))
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() || expence.GetRepeat() != 0 || expence.GetDateActualTo().Year() != 9999 ||
+			!s.scope.Allows(expence.GetIdAccaunt()) {
+			continue
+		}
+		if date := expence.GetDate(); !date.Before(from) && date.Before(to) {
+			expences = append(expences, expence)
+		}
+	}
+	for _, expence := range expences {
+		for _, name := range normalizeTags(expence.GetTags()) {
+			tagTotal := total(name, expence.GetIdAccaunt())
+			tagTotal.Expences += expence.GetAmount()
+			tagTotal.ExpenceCount++
+		}
+	}
+
+	for _, income := range debugging.Incomes {
+		if income.IsDeleted() || income.IsPending() || !s.scope.Allows(income.GetIdAccaunt()) {
+			continue
+		}
+		if date := IncomeDate(income); date.Before(from) || !date.Before(to) {
+			continue
+		}
+		for _, name := range normalizeTags(income.GetTags()) {
+			tagTotal := total(name, income.GetIdAccaunt())
+			tagTotal.Incomes += income.GetAmount()
+			tagTotal.IncomeCount++
+		}
+	}
+
+	report := models.TagReportJSON{
+		From: from.Format("2006-01-02"),
+		To:   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Tags: make([]models.TagTotalJSON, 0, len(totals)),
+	}
+	for _, tagTotal := range totals {
+		tagTotal.Expences = roundAmount(tagTotal.Expences)
+		tagTotal.Incomes = roundAmount(tagTotal.Incomes)
+		tagTotal.Net = roundAmount(tagTotal.Incomes - tagTotal.Expences)
+		report.Tags = append(report.Tags, *tagTotal)
+	}
+	sort.Slice(report.Tags, func(i, j int) bool {
+		return report.Tags[i].Tag < report.Tags[j].Tag
+	})
+	return report
+}
+
+// record carries every wanted tag
+func HasTags(tags, wanted []string) bool {
+	carried := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		carried[strings.ToLower(tag)] = true
+	}
+	for _, tag := range wanted {
+		if !carried[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// expences carrying every wanted tag, all of them without wanted tags
+func FilterExpencesByTags(expences []*models.Expence, wanted []string) []*models.Expence {
+	if len(wanted) == 0 {
+		return expences
+	}
+	var filtered []*models.Expence
+	for _, expence := range expences {
+		if HasTags(expence.GetTags(), wanted) {
+			filtered = append(filtered, expence)
+		}
+	}
+	return filtered
+}
+
+// tag with the name the account sees, its own or of accounts sharing a group
+func findTag(name string, idAccaunt int64) *models.Tag {
+	scope := NewGroupService().ScopeFor(idAccaunt)
+	for _, tag := range debugging.Tags {
+		if tag.GetName() == name && !tag.IsDeleted() && scope.Allows(tag.GetIdAccaunt()) {
+			return tag
+		}
+	}
+	return nil
+}
+
+func nextTagId() int64 {
+	var maxId int64
+	for _, tag := range debugging.Tags {
+		maxId = max(maxId, tag.GetIdTag())
+	}
+	return maxId + 1
+}
+
+// rename tag on records and rules of accounts seeing it, empty name takes it
+// off. Records changed are returned
+func relabel(tag *models.Tag, name string) models.TaggedRecords {
+	var changed models.TaggedRecords
+	scope := NewGroupService().ScopeFor(tag.GetIdAccaunt())
+	replace := func(tags []string) ([]string, bool) {
+		changed := false
+		relabeled := make([]string, 0, len(tags))
+		for _, existing := range tags {
+			if existing != tag.GetName() {
+				relabeled = append(relabeled, existing)
+				continue
+			}
+			changed = true
+			if name != "" {
+				relabeled = append(relabeled, name)
+			}
+		}
+		return mergeTags(nil, relabeled), changed
+	}
+
+	for _, expence := range debugging.Expences {
+		if scope.Allows(expence.GetIdAccaunt()) {
+			if tags, ok := replace(expence.GetTags()); ok {
+				expence.SetTags(tags)
+				changed.Expences = appendId(changed.Expences, expence.GetIdExpence())
+			}
+		}
+	}
+	for _, income := range debugging.Incomes {
+		if scope.Allows(income.GetIdAccaunt()) {
+			if tags, ok := replace(income.GetTags()); ok {
+				income.SetTags(tags)
+				changed.Incomes = appendId(changed.Incomes, income.GetIdIncome())
+			}
+		}
+	}
+	for _, rule := range debugging.Rules {
+		if scope.Allows(rule.GetIdAccaunt()) {
+			if tags, ok := replace(rule.GetTags()); ok {
+				rule.SetTags(tags)
+				changed.Rules = appendId(changed.Rules, rule.GetIdRule())
+			}
+		}
+	}
+	return changed
+}
+
+// put restored tag back on the records it was taken off, every version of them
+func retag(tag *models.Tag) {
+	detached := tag.GetDetached()
+	add := []string{tag.GetName()}
+	for _, expence := range debugging.Expences {
+		if containsId(detached.Expences, expence.GetIdExpence()) {
+			expence.SetTags(mergeTags(expence.GetTags(), add))
+		}
+	}
+	for _, income := range debugging.Incomes {
+		if containsId(detached.Incomes, income.GetIdIncome()) {
+			income.SetTags(mergeTags(income.GetTags(), add))
+		}
+	}
+	for _, rule := range debugging.Rules {
+		if containsId(detached.Rules, rule.GetIdRule()) {
+			rule.SetTags(mergeTags(rule.GetTags(), add))
+		}
+	}
+}
+
+func appendId(ids []int64, id int64) []int64 {
+	if containsId(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+func containsId(ids []int64, id int64) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+type TransferService struct {
+	scope *Scope
+}
+
+func NewTransferService() *TransferService {
+	return &TransferService{}
+}
+
+// restrict queries and changes to accounts of scope
+func (s *TransferService) WithScope(scope *Scope) *TransferService {
+	s.scope = scope
+	return s
+}
+
+func (s *TransferService) AddNewTransfer(newTransfer *models.Transfer) error {
+	if newTransfer.GetIdAccauntFrom() == newTransfer.GetIdAccauntTo() {
+		return errors.New("transfer to the same account")
+	}
+
+	if newTransfer.GetAmount() <= 0 {
+		return errors.New("transfer amount must be positive")
+	}
+
+	// money leaves a writable account and goes to a visible one
+	if err := s.scope.CanWrite(newTransfer.GetIdAccauntFrom()); err != nil {
+		return err
+	}
+	if !s.scope.Allows(newTransfer.GetIdAccauntTo()) {
+		return ErrForbidden
+	}
+
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdTransfer() == newTransfer.GetIdTransfer() {
+			return errors.New("transfer with this ID already exists")
+		}
+	}
+
+	if err := NewLedgerService().PostTransfer(newTransfer); err != nil {
+		return err
+	}
+
+	debugging.Transfers = append(debugging.Transfers, newTransfer)
+	return nil
+}
+
+func (s *TransferService) GetAllTransfers() []*models.Transfer {
+	var transfers []*models.Transfer
+	for _, transfer := range debugging.Transfers {
+		if !transfer.IsDeleted() && s.visible(transfer) {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
+
+func (s *TransferService) GetTransferById(idTransfer int64) (*models.Transfer, error) {
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdTransfer() == idTransfer && !transfer.IsDeleted() && s.visible(transfer) {
+			return transfer, nil
+		}
+	}
+	return nil, errors.New("transfer not found")
+}
+
+// transfer is seen from both accounts it moves money between
+func (s *TransferService) visible(transfer *models.Transfer) bool {
+	return s.scope.Allows(transfer.GetIdAccauntFrom()) || s.scope.Allows(transfer.GetIdAccauntTo())
+}
+
+// mark every version of the transfer deleted, history stays in place
+func (s *TransferService) DeleteTransfer(idTransfer int64, deletedBy string) (*models.Transfer, error) {
+	var deleted *models.Transfer
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdTransfer() != idTransfer || transfer.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(transfer.GetIdAccauntFrom()); err != nil {
+			return nil, err
+		}
+		if deleted == nil {
+			deleted = transfer
+		}
+	}
+
+	if deleted == nil {
+		return nil, errors.New("transfer not found")
+	}
+
+	if err := NewLedgerService().ReverseSource(models.JournalSourceTransfer, idTransfer, deletedBy); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdTransfer() == idTransfer && !transfer.IsDeleted() {
+			transfer.SetDeletedAt(now)
+			transfer.SetDeletedBy(deletedBy)
+		}
+	}
+	return deleted, nil
+}
+
+// bring a deleted transfer back from trash
+func (s *TransferService) RestoreTransfer(idTransfer int64) (*models.Transfer, error) {
+	var restored *models.Transfer
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdTransfer() != idTransfer || !transfer.IsDeleted() {
+			continue
+		}
+		if err := s.scope.CanWrite(transfer.GetIdAccauntFrom()); err != nil {
+			return nil, err
+		}
+		if restored == nil {
+			restored = transfer
+		}
+	}
+
+	if restored == nil {
+		return nil, errors.New("deleted transfer not found")
+	}
+
+	if err := NewLedgerService().PostTransfer(restored); err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdTransfer() == idTransfer {
+			transfer.SetDeletedAt(time.Time{})
+			transfer.SetDeletedBy("")
+		}
+	}
+	return restored, nil
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+// entities with soft delete, names match audit entities
+const (
+	TrashExpence        = "expence"
+	TrashGoal           = "goal"
+	TrashIncome         = "income"
+	TrashIncomeExpected = "income_expected"
+	TrashCashback       = "cashback"
+	TrashRemain         = "remain"
+	TrashTransfer       = "transfer"
+	TrashBudget         = "budget"
+	TrashAccount        = "account"
+	TrashCategory       = "category"
+	TrashTag            = "tag"
+	TrashRule           = "rule"
+	TrashImportProfile  = "import_profile"
+)
+
+var TrashEntities = []string{
+	TrashExpence,
+	TrashGoal,
+	TrashIncome,
+	TrashIncomeExpected,
+	TrashCashback,
+	TrashRemain,
+	TrashTransfer,
+	TrashBudget,
+	TrashAccount,
+	TrashCategory,
+	TrashTag,
+	TrashRule,
+	TrashImportProfile,
+}
+
+var ErrUnknownEntity = errors.New("unknown entity")
+
+// deleted version of any entity
+type trashRecord struct {
+	entity    string
+	id        int64
+	idAccaunt int64
+	current   bool // open version of SCD2 history
+	deletedAt time.Time
+	deletedBy string
+	record    interface{}
+}
+
+type TrashService struct {
+	scope *Scope
+	now   func() time.Time
+}
+
+func NewTrashService() *TrashService {
+	return &TrashService{now: time.Now}
+}
+
+// restrict trash to accounts of scope
+func (s *TrashService) WithScope(scope *Scope) *TrashService {
+	s.scope = scope
+	return s
+}
+
+func ValidTrashEntity(entity string) bool {
+	for _, name := range TrashEntities {
+		if name == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// deleted records visible in scope, one per id, empty entity for all
+func (s *TrashService) GetTrash(entity string) ([]models.TrashItemJSON, error) {
+	if entity != "" && !ValidTrashEntity(entity) {
+		return nil, ErrUnknownEntity
+	}
+
+	items := []models.TrashItemJSON{}
+	index := make(map[string]int)
+	currents := make(map[string]bool)
+	for _, record := range s.collect() {
+		if entity != "" && record.entity != entity {
+			continue
+		}
+		if !s.scope.Allows(record.idAccaunt) {
+			continue
+		}
+
+		key := record.entity + ":" + strconv.FormatInt(record.id, 10)
+		i, seen := index[key]
+		if seen && (currents[key] || !record.current) {
+			continue
+		}
+
+		item, err := newTrashItem(record)
+		if err != nil {
+			return nil, err
+		}
+		if seen {
+			items[i] = *item
+		} else {
+			index[key] = len(items)
+			items = append(items, *item)
+		}
+		currents[key] = record.current
+	}
+	return items, nil
+}
+
+// restore deleted record, the trash item before and the record after restore are returned
+func (s *TrashService) Restore(entity string, id int64) (*models.TrashItemJSON, *models.TrashItemJSON, error) {
+	items, err := s.GetTrash(entity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var oldItem *models.TrashItemJSON
+	for i := range items {
+		if items[i].Id == id {
+			oldItem = &items[i]
+			break
+		}
+	}
+	if oldItem == nil {
+		return nil, nil, errors.New(entity + " not found in trash")
+	}
+
+	var restored interface{}
+	switch entity {
+	case TrashExpence:
+		restored, err = NewExpenceService().WithScope(s.scope).RestoreExpence(id)
+	case TrashGoal:
+		restored, err = NewGoalService().WithScope(s.scope).RestoreGoal(id)
+	case TrashIncome:
+		restored, err = NewIncomeService().WithScope(s.scope).RestoreIncome(id)
+	case TrashIncomeExpected:
+		restored, err = NewIncomeExpectedService().WithScope(s.scope).RestoreIncomeExpected(id)
+	case TrashCashback:
+		restored, err = NewCashbackService().WithScope(s.scope).RestoreCashback(id)
+	case TrashRemain:
+		restored, err = NewRemainService().WithScope(s.scope).RestoreRemain(id)
+	case TrashTransfer:
+		restored, err = NewTransferService().WithScope(s.scope).RestoreTransfer(id)
+	case TrashBudget:
+		restored, err = NewBudgetService().WithScope(s.scope).RestoreBudget(id)
+	case TrashAccount:
+		restored, err = NewAccountService().WithScope(s.scope).RestoreAccount(id)
+	case TrashCategory:
+		restored, err = NewCategoryService().WithScope(s.scope).RestoreCategory(id)
+	case TrashTag:
+		restored, err = NewTagService().WithScope(s.scope).RestoreTag(id)
+	case TrashRule:
+		restored, err = NewRuleService().WithScope(s.scope).RestoreRule(id)
+	case TrashImportProfile:
+		restored, err = NewImportService().WithScope(s.scope).RestoreProfile(id)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newItem, err := newTrashItem(trashRecord{
+		entity:    entity,
+		id:        oldItem.Id,
+		idAccaunt: oldItem.IdAccaunt,
+		record:    restored,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldItem, newItem, nil
+}
+
+// remove records deleted before the cutoff for good, all their versions go.
+// removed trash items are returned
+func (s *TrashService) Purge(before time.Time) ([]models.TrashItemJSON, error) {
+	var purged []models.TrashItemJSON
+	for _, record := range s.collect() {
+		if !record.deletedAt.Before(before) {
+			continue
+		}
+		item, err := newTrashItem(record)
+		if err != nil {
+			return nil, err
+		}
+		purged = append(purged, *item)
+	}
+
+	expired := func(deletedAt time.Time) bool {
+		return !deletedAt.IsZero() && deletedAt.Before(before)
+	}
+
+	expences := debugging.Expences[:0]
+	for _, expence := range debugging.Expences {
+		if !expired(expence.GetDeletedAt()) {
+			expences = append(expences, expence)
+		}
+	}
+	debugging.Expences = expences
+
+	goals := debugging.Goals[:0]
+	for _, goal := range debugging.Goals {
+		if !expired(goal.GetDeletedAt()) {
+			goals = append(goals, goal)
+		}
+	}
+	debugging.Goals = goals
+
+	incomes := debugging.Incomes[:0]
+	for _, income := range debugging.Incomes {
+		if !expired(income.GetDeletedAt()) {
+			incomes = append(incomes, income)
+		}
+	}
+	debugging.Incomes = incomes
+
+	incomesExpected := debugging.IncomesExpected[:0]
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if !expired(incomeExpected.GetDeletedAt()) {
+			incomesExpected = append(incomesExpected, incomeExpected)
+		}
+	}
+	debugging.IncomesExpected = incomesExpected
+
+	cashbacks := debugging.Cashbacks[:0]
+	for _, cashback := range debugging.Cashbacks {
+		if !expired(cashback.GetDeletedAt()) {
+			cashbacks = append(cashbacks, cashback)
+		}
+	}
+	debugging.Cashbacks = cashbacks
+
+	remains := debugging.Remains[:0]
+	for _, remain := range debugging.Remains {
+		if !expired(remain.GetDeletedAt()) {
+			remains = append(remains, remain)
+		}
+	}
+	debugging.Remains = remains
+
+	transfers := debugging.Transfers[:0]
+	for _, transfer := range debugging.Transfers {
+		if !expired(transfer.GetDeletedAt()) {
+			transfers = append(transfers, transfer)
+		}
+	}
+	debugging.Transfers = transfers
+
+	budgets := debugging.Budgets[:0]
+	for _, budget := range debugging.Budgets {
+		if !expired(budget.GetDeletedAt()) {
+			budgets = append(budgets, budget)
+		}
+	}
+	debugging.Budgets = budgets
+
+	categories := debugging.Categories[:0]
+	for _, category := range debugging.Categories {
+		if !expired(category.GetDeletedAt()) {
+			categories = append(categories, category)
+		}
+	}
+	debugging.Categories = categories
+
+	tags := debugging.Tags[:0]
+	for _, tag := range debugging.Tags {
+		if !expired(tag.GetDeletedAt()) {
+			tags = append(tags, tag)
+		}
+	}
+	debugging.Tags = tags
+
+	rules := debugging.Rules[:0]
+	for _, rule := range debugging.Rules {
+		if !expired(rule.GetDeletedAt()) {
+			rules = append(rules, rule)
+		}
+	}
+	debugging.Rules = rules
+
+	profiles := debugging.ImportProfiles[:0]
+	for _, profile := range debugging.ImportProfiles {
+		if !expired(profile.GetDeletedAt()) {
+			profiles = append(profiles, profile)
+		}
+	}
+	debugging.ImportProfiles = profiles
+
+	// accounts go last and only once nothing refers to them any more
+	accounts := debugging.Accounts[:0]
+	for _, account := range debugging.Accounts {
+		if !expired(account.GetDeletedAt()) || accountInUse(account.GetIdAccaunt()) {
+			accounts = append(accounts, account)
+			continue
+		}
+		members := debugging.GroupMembers[:0]
+		for _, member := range debugging.GroupMembers {
+			if member.GetIdAccaunt() != account.GetIdAccaunt() {
+				members = append(members, member)
+			}
+		}
+		debugging.GroupMembers = members
+	}
+	debugging.Accounts = accounts
+
+	return purged, nil
+}
+
+// some record, deleted or not, still belongs to the account
+func accountInUse(idAccaunt int64) bool {
+	for _, expence := range debugging.Expences {
+		if expence.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+		for _, line := range expence.GetSplits() {
+			if line.GetIdAccaunt() == idAccaunt {
+				return true
+			}
+		}
+	}
+	for _, income := range debugging.Incomes {
+		if income.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, goal := range debugging.Goals {
+		if goal.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, remain := range debugging.Remains {
+		if remain.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, transfer := range debugging.Transfers {
+		if transfer.GetIdAccauntFrom() == idAccaunt || transfer.GetIdAccauntTo() == idAccaunt {
+			return true
+		}
+	}
+	for _, budget := range debugging.Budgets {
+		if budget.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, category := range debugging.Categories {
+		if category.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, tag := range debugging.Tags {
+		if tag.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, rule := range debugging.Rules {
+		if rule.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	for _, profile := range debugging.ImportProfiles {
+		if profile.GetIdAccaunt() == idAccaunt {
+			return true
+		}
+	}
+	return false
+}
+
+// cutoff of purge for records kept for retention
+func (s *TrashService) PurgeCutoff(retention time.Duration) time.Time {
+	return s.now().Add(-retention)
+}
+
+// all deleted versions of all entities
+func (s *TrashService) collect() []trashRecord {
+	var records []trashRecord
+
+	for _, expence := range debugging.Expences {
+		if expence.IsDeleted() {
+			records = append(records, trashRecord{TrashExpence, expence.GetIdExpence(), expence.GetIdAccaunt(),
+				expence.GetDateActualTo().Year() == 9999, expence.GetDeletedAt(), expence.GetDeletedBy(), expence})
+		}
+	}
+	for _, goal := range debugging.Goals {
+		if goal.IsDeleted() {
+			records = append(records, trashRecord{TrashGoal, goal.GetIdGoal(), goal.GetIdAccaunt(),
+				goal.GetDateActualTo().Year() == 9999, goal.GetDeletedAt(), goal.GetDeletedBy(), goal})
+		}
+	}
+	for _, income := range debugging.Incomes {
+		if income.IsDeleted() {
+			records = append(records, trashRecord{TrashIncome, income.GetIdIncome(), income.GetIdAccaunt(),
+				income.GetDateActualTo().Year() == 9999, income.GetDeletedAt(), income.GetDeletedBy(), income})
+		}
+	}
+	for _, incomeExpected := range debugging.IncomesExpected {
+		if incomeExpected.IsDeleted() {
+			records = append(records, trashRecord{TrashIncomeExpected, incomeExpected.GetIdIncomeEx(), incomeExpected.GetIdAccaunt(),
+				incomeExpected.GetDateActualTo().Year() == 9999, incomeExpected.GetDeletedAt(), incomeExpected.GetDeletedBy(), incomeExpected})
+		}
+	}
+	for _, cashback := range debugging.Cashbacks {
+		if cashback.IsDeleted() {
+			records = append(records, trashRecord{TrashCashback, cashback.GetIdCashback(), cashback.GetIdAccaunt(),
+				cashback.GetDateActualTo().Year() == 9999, cashback.GetDeletedAt(), cashback.GetDeletedBy(), cashback})
+		}
+	}
+	for _, remain := range debugging.Remains {
+		if remain.IsDeleted() {
+			records = append(records, trashRecord{TrashRemain, remain.GetIdRemains(), remain.GetIdAccaunt(),
+				remain.GetDateActualTo().Year() == 9999, remain.GetDeletedAt(), remain.GetDeletedBy(), remain})
+		}
+	}
+	for _, transfer := range debugging.Transfers {
+		if transfer.IsDeleted() {
+			records = append(records, trashRecord{TrashTransfer, transfer.GetIdTransfer(), transfer.GetIdAccauntFrom(),
+				true, transfer.GetDeletedAt(), transfer.GetDeletedBy(), transfer})
+		}
+	}
+	for _, budget := range debugging.Budgets {
+		if budget.IsDeleted() {
+			records = append(records, trashRecord{TrashBudget, budget.GetIdBudget(), budget.GetIdAccaunt(),
+				true, budget.GetDeletedAt(), budget.GetDeletedBy(), budget})
+		}
+	}
+	for _, account := range debugging.Accounts {
+		if account.IsDeleted() {
+			records = append(records, trashRecord{TrashAccount, account.GetIdAccaunt(), account.GetIdAccaunt(),
+				true, account.GetDeletedAt(), account.GetDeletedBy(), account})
+		}
+	}
+	for _, category := range debugging.Categories {
+		if category.IsDeleted() {
+			records = append(records, trashRecord{TrashCategory, category.GetIdCategory(), category.GetIdAccaunt(),
+				true, category.GetDeletedAt(), category.GetDeletedBy(), category})
+		}
+	}
+	for _, tag := range debugging.Tags {
+		if tag.IsDeleted() {
+			records = append(records, trashRecord{TrashTag, tag.GetIdTag(), tag.GetIdAccaunt(),
+				true, tag.GetDeletedAt(), tag.GetDeletedBy(), tag})
+		}
+	}
+	for _, rule := range debugging.Rules {
+		if rule.IsDeleted() {
+			records = append(records, trashRecord{TrashRule, rule.GetIdRule(), rule.GetIdAccaunt(),
+				true, rule.GetDeletedAt(), rule.GetDeletedBy(), rule})
+		}
+	}
+	for _, profile := range debugging.ImportProfiles {
+		if profile.IsDeleted() {
+			records = append(records, trashRecord{TrashImportProfile, profile.GetIdProfile(), profile.GetIdAccaunt(),
+				true, profile.GetDeletedAt(), profile.GetDeletedBy(), profile})
+		}
+	}
+
+	return records
+}
+
+func newTrashItem(record trashRecord) (*models.TrashItemJSON, error) {
+	var data interface{}
+	var err error
+	switch r := record.record.(type) {
+	case *models.Expence:
+		data, err = r.ToJSON()
+	case *models.Goal:
+		data, err = r.ToJSON()
+	case *models.Income:
+		data, err = r.ToJSON()
+	case *models.IncomeExpected:
+		data, err = r.ToJSON()
+	case *models.Cashback:
+		data, err = r.ToJSON()
+	case *models.Remain:
+		data, err = r.ToJSON()
+	case *models.Transfer:
+		data, err = r.ToJSON()
+	case *models.Budget:
+		data, err = r.ToJSON()
+	case *models.Account:
+		data, err = r.ToJSON()
+	case *models.Category:
+		data, err = r.ToJSON()
+	case *models.Tag:
+		data, err = r.ToJSON()
+	case *models.Rule:
+		data, err = r.ToJSON()
+	case *models.ImportProfile:
+		data, err = r.ToJSON()
+	default:
+		return nil, ErrUnknownEntity
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &models.TrashItemJSON{
+		Entity:    record.entity,
+		Id:        record.id,
+		IdAccaunt: record.idAccaunt,
+		DeletedBy: record.deletedBy,
+		Data:      raw,
+	}
+	if !record.deletedAt.IsZero() {
+		item.DeletedAt = record.deletedAt.Format("2006-01-02 15:04:05")
+	}
+	return item, nil
+}
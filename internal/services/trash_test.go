@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/helltale/api-finances/internal/debugging"
+	"github.com/helltale/api-finances/internal/models"
+)
+
+func TestDeleteAccountGoesToTrash(t *testing.T) {
+	setupPolicyGroup()
+	setupPolicyAccounts()
+	debugging.GroupInvites = nil
+
+	expence := &models.Expence{}
+	expence.SetIdExpence(1)
+	expence.SetIdAccaunt(2)
+	expence.SetAmount(100)
+	expence.SetDateActualTo(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC))
+	debugging.Expences = []*models.Expence{expence}
+
+	if _, err := NewAccountService().WithScope(NewGroupService().ScopeFor(1)).DeleteAccount(1, "owner"); errorText(err) != "account is the last owner of group 1" {
+		t.Fatalf("delete last owner: error = %q", errorText(err))
+	}
+
+	if _, err := NewAccountService().WithScope(NewGroupService().ScopeFor(2)).DeleteAccount(2, "member"); err != nil {
+		t.Fatalf("delete account: %v", err)
+	}
+	if _, err := NewAccountService().GetAccountById(2); err == nil {
+		t.Error("deleted account is still found")
+	}
+	if got := len(NewExpenceService().WithScope(NewGroupService().ScopeFor(1)).GetAllExpences()); got != 1 {
+		t.Errorf("expences seen by the group = %d, want 1", got)
+	}
+
+	// still referred to by the expence, purge keeps it
+	if _, err := NewTrashService().Purge(time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if len(debugging.Accounts) != 4 {
+		t.Fatalf("accounts after purge = %d, want 4", len(debugging.Accounts))
+	}
+
+	items, err := NewTrashService().WithScope(NewGroupService().ScopeFor(1)).GetTrash(TrashAccount)
+	if err != nil || len(items) != 1 || items[0].Id != 2 || items[0].DeletedBy != "member" {
+		t.Fatalf("trash = %+v, %v, want account 2 deleted by member", items, err)
+	}
+	if _, _, err := NewTrashService().WithScope(NewGroupService().ScopeFor(1)).Restore(TrashAccount, 2); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := NewAccountService().GetAccountById(2); err != nil {
+		t.Errorf("restored account: %v", err)
+	}
+	if !NewGroupService().IsMember(1, 2) {
+		t.Error("restored account lost its membership")
+	}
+}
+
+func TestDeletedTagIsRestoredOnRecords(t *testing.T) {
+	setupPolicyGroup()
+	setupPolicyAccounts()
+
+	expence := &models.Expence{}
+	expence.SetIdExpence(1)
+	expence.SetIdAccaunt(2)
+	expence.SetTags([]string{"trip", "food"})
+	debugging.Expences = []*models.Expence{expence}
+	debugging.Incomes = nil
+	debugging.Rules = nil
+
+	tag := &models.Tag{}
+	tag.SetIdAccaunt(1)
+	tag.SetName("trip")
+	debugging.Tags = nil
+	tagService := NewTagService().WithScope(NewGroupService().ScopeFor(1))
+	if err := tagService.AddNewTag(tag); err != nil {
+		t.Fatalf("add tag: %v", err)
+	}
+
+	if _, err := tagService.DeleteTag(tag.GetIdTag(), "owner"); err != nil {
+		t.Fatalf("delete tag: %v", err)
+	}
+	if got := expence.GetTags(); len(got) != 1 || got[0] != "food" {
+		t.Fatalf("tags after delete = %v, want [food]", got)
+	}
+	if len(tagService.GetAllTags()) != 0 {
+		t.Error("deleted tag is still listed")
+	}
+
+	if _, _, err := NewTrashService().WithScope(NewGroupService().ScopeFor(1)).Restore(TrashTag, tag.GetIdTag()); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !HasTags(expence.GetTags(), []string{"trip", "food"}) {
+		t.Errorf("tags after restore = %v, want food and trip", expence.GetTags())
+	}
+	if tag.IsDeleted() || len(tagService.GetAllTags()) != 1 {
+		t.Error("restored tag is not listed")
+	}
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/helltale/api-finances/config"
+	"github.com/helltale/api-finances/internal/auth"
 	"github.com/helltale/api-finances/internal/handlers"
 	"github.com/helltale/api-finances/internal/logger"
 	"github.com/helltale/api-finances/internal/routers"
+	"github.com/helltale/api-finances/internal/scheduler"
 )
 
 func main() {
@@ -31,8 +39,56 @@ func main() {
 
 	logger.Info("Server starting", "port", conf.AppPort)
 
-	routers.Init(logger, conf)
-	if err := http.ListenAndServe(fmt.Sprintf(":%v", conf.AppPort), nil); err != nil {
-		logger.Error("Server failed to start", "error", err)
+	if conf.AuthSecret == "" {
+		secret, err := auth.GenerateSecret("")
+		if err != nil {
+			log.Fatalf("auth secret error: %v\n", err)
+		}
+		conf.AuthSecret = secret
+		logger.Warn("auth-secret is not set, tokens will not survive restart")
+	}
+
+	jobs := scheduler.Init(logger, conf)
+	if jobs != nil {
+		jobs.Start()
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%v", conf.AppPort),
+		Handler: routers.Init(logger, conf),
+	}
+
+	failed := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	var serveErr error
+	select {
+	case <-stop:
+		logger.Info("Server shutting down")
+	case serveErr = <-failed:
+		logger.Error("Server failed to start", "error", serveErr)
+	}
+
+	// requests are drained before jobs stop, the store lock is free after both
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("Server shutdown failed", "error", err)
+	}
+	cancel()
+
+	if jobs != nil {
+		jobs.Stop()
+	}
+
+	if serveErr != nil {
+		fileLogger.Close()
+		os.Exit(1)
 	}
 }